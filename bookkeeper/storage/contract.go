@@ -23,8 +23,16 @@ type (
 		io.Closer
 		Ping(ctx context.Context) error
 		Insert(ctx context.Context, columns *Columns, input InsertMetadata, usrs []*model.User) error
+		// InsertAt is Insert, but with the history of the users recorded at createdAt instead of now, I.E. to backfill it.
+		InsertAt(ctx context.Context, columns *Columns, input InsertMetadata, createdAt stdlibtime.Time, usrs []*model.User) error
 		SelectBalanceHistory(ctx context.Context, id int64, createdAts []stdlibtime.Time) ([]*BalanceHistory, error)
 		SelectTotalCoins(ctx context.Context, createdAts []stdlibtime.Time) ([]*TotalCoins, error)
+		// SelectHistory returns the history snapshots of the user recorded since then, from the oldest to the newest.
+		SelectHistory(ctx context.Context, id int64, since stdlibtime.Time) ([]*HistorySnapshot, error)
+		// InsertLedger appends the entries to the ledger of the users: what changed their balances and by how much.
+		InsertLedger(ctx context.Context, columns *LedgerColumns, input InsertMetadata, entries []*LedgerEntry) error
+		// SelectLedger returns the ledger entries of the user, from the newest to the oldest.
+		SelectLedger(ctx context.Context, id int64, limit, offset uint64) ([]*LedgerEntry, error)
 		DeleteUserInfo(ctx context.Context, id int64) error
 	}
 	BalanceHistory struct {
@@ -38,6 +46,27 @@ type (
 		BalanceTotalEthereum   float64    `redis:"blockchain"`
 		BalanceTotal           float64    `redis:"total"`
 	}
+	HistorySnapshot struct {
+		CreatedAt *time.Time
+		// It's the user as it was before being mined, at CreatedAt.
+		User *model.User
+	}
+	LedgerReason string
+	LedgerEntry  struct {
+		CreatedAt *time.Time
+		Reason    LedgerReason
+		UserID    string
+		// It's negative for the slashed amounts.
+		Amount float64
+		ID     int64
+	}
+	LedgerColumns struct {
+		createdAt *proto.ColDateTime64
+		reason    *proto.ColStr
+		userID    *proto.ColStr
+		amount    *proto.ColFloat64
+		id        *proto.ColInt64
+	}
 	InsertMetadata = proto.Input
 	Columns        struct {
 		miningSessionSoloLastStartedAt                         *proto.ColDateTime64
@@ -116,10 +145,25 @@ type (
 	}
 )
 
+const (
+	SoloMiningLedgerReason          LedgerReason = "solo_mining"
+	T0ReferralLedgerReason          LedgerReason = "t0_referral"
+	T1ReferralLedgerReason          LedgerReason = "t1_referral"
+	T2ReferralLedgerReason          LedgerReason = "t2_referral"
+	ExtraBonusLedgerReason          LedgerReason = "extra_bonus"
+	ResurrectionLedgerReason        LedgerReason = "resurrection"
+	SlashingLedgerReason            LedgerReason = "slashing"
+	WelcomeBonusLedgerReason        LedgerReason = "welcome_bonus"
+	CompletedTasksPrizeLedgerReason LedgerReason = "completed_tasks_prize"
+	PreStakingBonusLedgerReason     LedgerReason = "pre_staking_bonus"
+	BoostLedgerReason               LedgerReason = "boost"
+)
+
 // Private API.
 
 const (
-	tableName = "freezer_user_history"
+	tableName       = "freezer_user_history"
+	ledgerTableName = "freezer_user_ledger"
 )
 
 // .
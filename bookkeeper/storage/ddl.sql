@@ -398,3 +398,34 @@ ALTER TABLE freezer_user_history
 
 ALTER TABLE freezer_user_history
     ADD COLUMN IF NOT EXISTS balance_last_updated_at DateTime64(9,'UTC') DEFAULT 0 AFTER for_tminus1_last_ethereum_coin_distribution_processed_at;
+
+CREATE TABLE IF NOT EXISTS light.freezer_user_ledger
+(
+      created_at DateTime64(9,'UTC')  DEFAULT 0,
+      reason String  DEFAULT '',
+      user_id String  DEFAULT '',
+      amount Float64  DEFAULT 0,
+      id Int64  DEFAULT 0
+) ENGINE=ReplicatedMergeTree('/clickhouse/tables/{cluster}/{shard_light}/freezer_user_ledger', '{replica_light}')
+  PARTITION BY toYYYYMM(created_at)
+  PRIMARY KEY (id, created_at);
+
+CREATE TABLE IF NOT EXISTS dark.freezer_user_ledger
+(
+      created_at DateTime64(9,'UTC')  DEFAULT 0,
+      reason String  DEFAULT '',
+      user_id String  DEFAULT '',
+      amount Float64  DEFAULT 0,
+      id Int64  DEFAULT 0
+) ENGINE=ReplicatedMergeTree('/clickhouse/tables/{cluster}/{shard_dark}/freezer_user_ledger', '{replica_dark}')
+  PARTITION BY toYYYYMM(created_at)
+  PRIMARY KEY (id, created_at);
+
+CREATE TABLE IF NOT EXISTS freezer_user_ledger
+(
+      created_at DateTime64(9,'UTC')  DEFAULT 0,
+      reason String  DEFAULT '',
+      user_id String  DEFAULT '',
+      amount Float64  DEFAULT 0,
+      id Int64  DEFAULT 0
+) ENGINE = Distributed('{cluster}', '', 'freezer_user_ledger', toUInt64(toDate(created_at)));
@@ -109,6 +109,15 @@ func (db *db) Ping(ctx context.Context) error {
 }
 
 func (db *db) Insert(ctx context.Context, columns *Columns, input InsertMetadata, usrs []*model.User) error {
+	truncateDuration := stdlibtime.Minute
+	if !db.cfg.Development {
+		truncateDuration = stdlibtime.Hour
+	}
+
+	return db.InsertAt(ctx, columns, input, time.Now().Truncate(truncateDuration), usrs)
+}
+
+func (db *db) InsertAt(ctx context.Context, columns *Columns, input InsertMetadata, createdAt stdlibtime.Time, usrs []*model.User) error {
 	if len(usrs) == 0 {
 		return nil
 	}
@@ -116,12 +125,6 @@ func (db *db) Insert(ctx context.Context, columns *Columns, input InsertMetadata
 		column.Data.(proto.Resettable).Reset()
 	}
 
-	now := time.Now()
-	truncateDuration := stdlibtime.Minute
-	if !db.cfg.Development {
-		truncateDuration = stdlibtime.Hour
-	}
-
 	for _, usr := range usrs {
 		if usr.MiningSessionSoloLastStartedAt.IsNil() {
 			columns.miningSessionSoloLastStartedAt.Append(stdlibtime.Time{})
@@ -193,7 +196,7 @@ func (db *db) Insert(ctx context.Context, columns *Columns, input InsertMetadata
 		} else {
 			columns.balanceLastUpdatedAt.Append(*usr.BalanceLastUpdatedAt.Time)
 		}
-		columns.createdAt.Append(now.Truncate(truncateDuration))
+		columns.createdAt.Append(createdAt)
 		columns.country.Append(usr.Country)
 		columns.profilePictureName.Append(usr.ProfilePictureName)
 		columns.username.Append(usr.Username)
@@ -642,26 +645,261 @@ func (db *db) SelectTotalCoins(ctx context.Context, createdAts []stdlibtime.Time
 	return res, nil
 }
 
-func (db *db) DeleteUserInfo(ctx context.Context, id int64) error {
-	errDark := db.pools[atomic.AddUint64(&db.currentIndex, 1)%uint64(len(db.pools))].Do(ctx, ch.Query{
-		Body: fmt.Sprintf(`DELETE FROM dark.%[1]v WHERE id = %[2]v`, tableName, id),
+func (db *db) SelectHistory(ctx context.Context, id int64, since stdlibtime.Time) ([]*HistorySnapshot, error) {
+	columns, input := InsertDDL(24) //nolint:gomnd // A day of them, usually.
+	result := make(proto.Results, 0, len(input))
+	names := make([]string, 0, len(input))
+	for _, column := range input {
+		result = append(result, proto.ResultColumn{Name: column.Name, Data: column.Data.(proto.ColResult)}) //nolint:forcetypeassert // They're all decodable.
+		names = append(names, column.Name)
+	}
+	format := since.UTC().Format(stdlibtime.RFC3339)
+	res := make([]*HistorySnapshot, 0, 24) //nolint:gomnd // A day of them, usually.
+	if err := db.pools[atomic.AddUint64(&db.currentIndex, 1)%uint64(len(db.pools))].Do(ctx, ch.Query{
+		Body: fmt.Sprintf(`SELECT %[1]v
+						   FROM %[2]v
+						   WHERE id = %[3]v
+						     AND created_at >= '%[4]v'
+						   ORDER BY created_at
+						   LIMIT 1 BY created_at`, strings.Join(names, ", "), tableName, id, format[0:len(format)-1]),
+		Result: result,
 		OnResult: func(_ context.Context, block proto.Block) error {
+			for ix := 0; ix < block.Rows; ix++ {
+				res = append(res, &HistorySnapshot{CreatedAt: time.New(columns.createdAt.Row(ix)), User: columns.user(ix)})
+			}
+			for _, column := range input {
+				column.Data.(proto.Resettable).Reset()
+			}
+
 			return nil
 		},
 		Secret:      "",
 		InitialUser: "",
-	})
-	if errDark != nil {
-		return errors.Wrapf(errDark, "failed to delete user %v from clickhouse dark", id)
+	}); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// The inverse of InsertAt.
+//
+//nolint:funlen // It's a long list of columns.
+func (c *Columns) user(ix int) *model.User {
+	usr := new(model.User)
+	usr.MiningSessionSoloLastStartedAt = historyTime(c.miningSessionSoloLastStartedAt.Row(ix))
+	usr.MiningSessionSoloStartedAt = historyTime(c.miningSessionSoloStartedAt.Row(ix))
+	usr.MiningSessionSoloEndedAt = historyTime(c.miningSessionSoloEndedAt.Row(ix))
+	usr.MiningSessionSoloPreviouslyEndedAt = historyTime(c.miningSessionSoloPreviouslyEndedAt.Row(ix))
+	usr.ExtraBonusStartedAt = historyTime(c.extraBonusStartedAt.Row(ix))
+	usr.ResurrectSoloUsedAt = historyTime(c.resurrectSoloUsedAt.Row(ix))
+	usr.ResurrectT0UsedAt = historyTime(c.resurrectT0UsedAt.Row(ix))
+	usr.ResurrectTMinus1UsedAt = historyTime(c.resurrectTminus1UsedAt.Row(ix))
+	usr.MiningSessionSoloDayOffLastAwardedAt = historyTime(c.miningSessionSoloDayOffLastAwardedAt.Row(ix))
+	usr.ExtraBonusLastClaimAvailableAt = historyTime(c.extraBonusLastClaimAvailableAt.Row(ix))
+	usr.SoloLastEthereumCoinDistributionProcessedAt = historyTime(c.soloLastEthereumCoinDistributionProcessedAt.Row(ix))
+	usr.ForT0LastEthereumCoinDistributionProcessedAt = historyTime(c.forT0LastEthereumCoinDistributionProcessedAt.Row(ix))
+	usr.ForTMinus1LastEthereumCoinDistributionProcessedAt = historyTime(c.forTMinus1LastEthereumCoinDistributionProcessedAt.Row(ix))
+	usr.BalanceLastUpdatedAt = historyTime(c.balanceLastUpdatedAt.Row(ix))
+	usr.Country = c.country.Row(ix)
+	usr.ProfilePictureName = c.profilePictureName.Row(ix)
+	usr.Username = c.username.Row(ix)
+	usr.MiningBlockchainAccountAddress = c.miningBlockchainAccountAddress.Row(ix)
+	usr.BlockchainAccountAddress = c.blockchainAccountAddress.Row(ix)
+	usr.UserID = c.userID.Row(ix)
+	usr.ID = c.id.Row(ix)
+	usr.IDT0 = c.idT0.Row(ix)
+	usr.IDTMinus1 = c.idTminus1.Row(ix)
+	usr.BalanceTotalStandard = c.balanceTotalStandard.Row(ix)
+	usr.BalanceTotalPreStaking = c.balanceTotalPreStaking.Row(ix)
+	usr.BalanceTotalMinted = c.balanceTotalMinted.Row(ix)
+	usr.BalanceTotalSlashed = c.balanceTotalSlashed.Row(ix)
+	usr.BalanceSoloPending = c.balanceSoloPending.Row(ix)
+	usr.BalanceT1Pending = c.balanceT1Pending.Row(ix)
+	usr.BalanceT2Pending = c.balanceT2Pending.Row(ix)
+	usr.BalanceSoloPendingApplied = c.balanceSoloPendingApplied.Row(ix)
+	usr.BalanceT1PendingApplied = c.balanceT1PendingApplied.Row(ix)
+	usr.BalanceT2PendingApplied = c.balanceT2PendingApplied.Row(ix)
+	usr.BalanceSolo = c.balanceSolo.Row(ix)
+	usr.BalanceT0 = c.balanceT0.Row(ix)
+	usr.BalanceT1 = c.balanceT1.Row(ix)
+	usr.BalanceT2 = c.balanceT2.Row(ix)
+	usr.BalanceForT0 = c.balanceForT0.Row(ix)
+	usr.BalanceForTMinus1 = c.balanceForTminus1.Row(ix)
+	usr.BalanceSoloEthereum = c.balanceSoloEthereum.Row(ix)
+	usr.BalanceT0Ethereum = c.balanceT0Ethereum.Row(ix)
+	usr.BalanceT1Ethereum = c.balanceT1Ethereum.Row(ix)
+	usr.BalanceT2Ethereum = c.balanceT2Ethereum.Row(ix)
+	usr.BalanceForT0Ethereum = c.balanceForT0Ethereum.Row(ix)
+	usr.BalanceForTMinus1Ethereum = c.balanceForTMinus1Ethereum.Row(ix)
+	usr.BalanceSoloEthereumMainnetRewardPoolContribution = c.balanceSoloEthereumMainnetRewardPoolContribution.Row(ix)
+	usr.BalanceT0EthereumMainnetRewardPoolContribution = c.balanceT0EthereumMainnetRewardPoolContribution.Row(ix)
+	usr.BalanceT1EthereumMainnetRewardPoolContribution = c.balanceT1EthereumMainnetRewardPoolContribution.Row(ix)
+	usr.BalanceT2EthereumMainnetRewardPoolContribution = c.balanceT2EthereumMainnetRewardPoolContribution.Row(ix)
+	usr.BalanceForT0EthereumMainnetRewardPoolContribution = c.balanceForT0EthereumMainnetRewardPoolContribution.Row(ix)
+	usr.BalanceForTMinus1EthereumMainnetRewardPoolContribution = c.balanceForTMinus1EthereumMainnetRewardPoolContribution.Row(ix)
+	usr.SlashingRateSolo = c.slashingRateSolo.Row(ix)
+	usr.SlashingRateT0 = c.slashingRateT0.Row(ix)
+	usr.SlashingRateT1 = c.slashingRateT1.Row(ix)
+	usr.SlashingRateT2 = c.slashingRateT2.Row(ix)
+	usr.SlashingRateForT0 = c.slashingRateForT0.Row(ix)
+	usr.SlashingRateForTMinus1 = c.slashingRateForTminus1.Row(ix)
+	usr.ActiveT1Referrals = c.activeT1Referrals.Row(ix)
+	usr.ActiveT2Referrals = c.activeT2Referrals.Row(ix)
+	usr.PreStakingBonus = float64(c.preStakingBonus.Row(ix))
+	usr.PreStakingAllocation = float64(c.preStakingAllocation.Row(ix))
+	usr.ExtraBonus = float64(c.extraBonus.Row(ix))
+	usr.NewsSeen = c.newsSeen.Row(ix)
+	usr.ExtraBonusDaysClaimNotAvailable = c.extraBonusDaysClaimNotAvailable.Row(ix)
+	usr.UTCOffset = int64(c.utcOffset.Row(ix))
+	usr.KYCStepPassed = users.KYCStep(c.kycStepPassed.Row(ix))
+	usr.KYCStepBlocked = users.KYCStep(c.kycStepBlocked.Row(ix))
+	usr.KYCQuizCompleted = c.kycQuizCompleted.Row(ix)
+	usr.KYCQuizDisabled = c.kycQuizDisabled.Row(ix)
+	usr.HideRanking = c.hideRanking.Row(ix)
+	usr.KYCStepsCreatedAt = historyTimes(c.kycStepsCreatedAt.Row(ix))
+	usr.KYCStepsLastUpdatedAt = historyTimes(c.kycStepsLastUpdatedAt.Row(ix))
+
+	return usr
+}
+
+// The nil times are recorded as the zero ones, which overflow the DateTime64s into the distant past.
+func historyTime(date stdlibtime.Time) *time.Time {
+	if date.UnixNano() <= 0 {
+		return nil
+	}
+
+	return time.New(date)
+}
+
+func historyTimes(dates []stdlibtime.Time) *model.TimeSlice {
+	if len(dates) == 0 {
+		return nil
+	}
+	times := make(model.TimeSlice, 0, len(dates))
+	for _, date := range dates {
+		times = append(times, historyTime(date))
+	}
+
+	return &times
+}
+
+func (db *db) InsertLedger(ctx context.Context, columns *LedgerColumns, input InsertMetadata, entries []*LedgerEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	for _, column := range input {
+		column.Data.(proto.Resettable).Reset()
+	}
+	for _, entry := range entries {
+		columns.createdAt.Append(*entry.CreatedAt.Time)
+		columns.reason.Append(string(entry.Reason))
+		columns.userID.Append(entry.UserID)
+		columns.amount.Append(entry.Amount)
+		columns.id.Append(entry.ID)
 	}
-	return errors.Wrapf(db.pools[atomic.AddUint64(&db.currentIndex, 1)%uint64(len(db.pools))].Do(ctx, ch.Query{
-		Body: fmt.Sprintf(`DELETE FROM light.%[1]v WHERE id = %[2]v`, tableName, id),
+
+	return db.pools[atomic.AddUint64(&db.currentIndex, 1)%uint64(len(db.pools))].Do(ctx, ch.Query{
+		Body:     input.Into(ledgerTableName),
+		Input:    input,
+		Settings: db.settings,
+	})
+}
+
+// ReferralTierLedgerReason is the reason of the amounts minted by the optional referral tiers after T2: `t3_referral`, etc.
+func ReferralTierLedgerReason(tier int) LedgerReason {
+	return LedgerReason(fmt.Sprintf("t%v_referral", tier))
+}
+
+func LedgerInsertDDL(rows int) (*LedgerColumns, proto.Input) {
+	var (
+		createdAt = &proto.ColDateTime64{Data: make([]proto.DateTime64, 0, rows), Location: stdlibtime.UTC, Precision: proto.PrecisionMax, PrecisionSet: true}
+		reason    = &proto.ColStr{Buf: make([]byte, 0, 20*rows), Pos: make([]proto.Position, 0, rows)}
+		userID    = &proto.ColStr{Buf: make([]byte, 0, 40*rows), Pos: make([]proto.Position, 0, rows)}
+		amount    = make(proto.ColFloat64, 0, rows)
+		id        = make(proto.ColInt64, 0, rows)
+	)
+	input := append(make(proto.Input, 0, 5),
+		proto.InputColumn{Name: "created_at", Data: createdAt},
+		proto.InputColumn{Name: "reason", Data: reason},
+		proto.InputColumn{Name: "user_id", Data: userID},
+		proto.InputColumn{Name: "amount", Data: &amount},
+		proto.InputColumn{Name: "id", Data: &id})
+
+	return &LedgerColumns{
+		createdAt: createdAt,
+		reason:    reason,
+		userID:    userID,
+		amount:    &amount,
+		id:        &id,
+	}, input
+}
+
+func (db *db) SelectLedger(ctx context.Context, id int64, limit, offset uint64) ([]*LedgerEntry, error) {
+	var (
+		createdAt = proto.ColDateTime64{Data: make([]proto.DateTime64, 0, limit), Location: stdlibtime.UTC, Precision: proto.PrecisionMax, PrecisionSet: true}
+		reason    = proto.ColStr{Buf: make([]byte, 0, 20*limit), Pos: make([]proto.Position, 0, limit)}
+		userID    = proto.ColStr{Buf: make([]byte, 0, 40*limit), Pos: make([]proto.Position, 0, limit)}
+		amount    = make(proto.ColFloat64, 0, limit)
+		res       = make([]*LedgerEntry, 0, limit)
+	)
+	if err := db.pools[atomic.AddUint64(&db.currentIndex, 1)%uint64(len(db.pools))].Do(ctx, ch.Query{
+		Body: fmt.Sprintf(`SELECT created_at,
+								  reason,
+								  user_id,
+								  amount
+						   FROM %[1]v
+						   WHERE id = %[2]v
+						   ORDER BY created_at DESC, reason
+						   LIMIT %[3]v OFFSET %[4]v`, ledgerTableName, id, limit, offset),
+		Result: append(make(proto.Results, 0, 4),
+			proto.ResultColumn{Name: "created_at", Data: &createdAt},
+			proto.ResultColumn{Name: "reason", Data: &reason},
+			proto.ResultColumn{Name: "user_id", Data: &userID},
+			proto.ResultColumn{Name: "amount", Data: &amount}),
 		OnResult: func(_ context.Context, block proto.Block) error {
+			for ix := 0; ix < block.Rows; ix++ {
+				res = append(res, &LedgerEntry{
+					CreatedAt: time.New((&createdAt).Row(ix)),
+					Reason:    LedgerReason((&reason).Row(ix)),
+					UserID:    (&userID).Row(ix),
+					Amount:    (&amount).Row(ix),
+					ID:        id,
+				})
+			}
+			(&createdAt).Reset()
+			(&reason).Reset()
+			(&userID).Reset()
+			(&amount).Reset()
+
 			return nil
 		},
 		Secret:      "",
 		InitialUser: "",
-	}), "failed to delete user %v from clickhouse light", id)
+	}); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (db *db) DeleteUserInfo(ctx context.Context, id int64) error {
+	for _, table := range []string{tableName, ledgerTableName} {
+		for _, database := range []string{"dark", "light"} {
+			if err := db.pools[atomic.AddUint64(&db.currentIndex, 1)%uint64(len(db.pools))].Do(ctx, ch.Query{
+				Body: fmt.Sprintf(`DELETE FROM %[1]v.%[2]v WHERE id = %[3]v`, database, table, id),
+				OnResult: func(_ context.Context, block proto.Block) error {
+					return nil
+				},
+				Secret:      "",
+				InitialUser: "",
+			}); err != nil {
+				return errors.Wrapf(err, "failed to delete user %v from clickhouse %v.%v", id, database, table)
+			}
+		}
+	}
+
+	return nil
 }
 
 func (t *TotalCoins) Key() string {
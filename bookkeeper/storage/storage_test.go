@@ -115,3 +115,75 @@ func TestStorage(t *testing.T) {
 	sort.SliceStable(h2, func(ii, jj int) bool { return h2[ii].CreatedAt.Before(*h2[jj].CreatedAt.Time) })
 	assert.EqualValues(t, []*BalanceHistory{}, h2)
 }
+
+func TestHistory(t *testing.T) {
+	cl := MustConnect(context.Background(), "self")
+	defer func() {
+		if err := recover(); err != nil {
+			cl.Close()
+			panic(err)
+		}
+		cl.Close()
+	}()
+	t1, t2 := stdlibtime.Now().UTC().Truncate(stdlibtime.Minute), stdlibtime.Now().UTC().Add(stdlibtime.Hour).Truncate(stdlibtime.Minute)
+	id := t1.UnixNano()
+	columns, input := InsertDDL(1)
+	usr := &model.User{
+		MiningSessionSoloStartedAtField: model.MiningSessionSoloStartedAtField{MiningSessionSoloStartedAt: time.New(t1)},
+		UserIDField:                     model.UserIDField{UserID: "UserID"},
+		DeserializedUsersKey:            model.DeserializedUsersKey{ID: id},
+		BalanceSoloField:                model.BalanceSoloField{BalanceSolo: 11},
+		PreStakingBonusField:            model.PreStakingBonusField{PreStakingBonus: 27},
+	}
+	require.NoError(t, cl.InsertAt(context.Background(), columns, input, t1, []*model.User{usr}))
+	usr.BalanceSolo = 12
+	require.NoError(t, cl.InsertAt(context.Background(), columns, input, t2, []*model.User{usr}))
+
+	history, err := cl.SelectHistory(context.Background(), id, t1)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.True(t, history[0].CreatedAt.Equal(t1))
+	assert.EqualValues(t, 11, history[0].User.BalanceSolo)
+	assert.EqualValues(t, 12, history[1].User.BalanceSolo)
+	assert.EqualValues(t, 27, history[1].User.PreStakingBonus)
+	assert.Equal(t, "UserID", history[1].User.UserID)
+	assert.True(t, history[1].User.MiningSessionSoloStartedAt.Equal(t1))
+	assert.Nil(t, history[1].User.MiningSessionSoloEndedAt)
+	history, err = cl.SelectHistory(context.Background(), id, t2)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.NoError(t, cl.DeleteUserInfo(context.Background(), id))
+}
+
+func TestLedger(t *testing.T) {
+	cl := MustConnect(context.Background(), "self")
+	defer func() {
+		if err := recover(); err != nil {
+			cl.Close()
+			panic(err)
+		}
+		cl.Close()
+	}()
+	t1, t2 := stdlibtime.Now().UTC().Truncate(stdlibtime.Minute), stdlibtime.Now().UTC().Add(stdlibtime.Hour).Truncate(stdlibtime.Minute)
+	id := t1.UnixNano()
+	columns, input := LedgerInsertDDL(3)
+	entries := []*LedgerEntry{
+		{CreatedAt: time.New(t1), Reason: SoloMiningLedgerReason, UserID: "UserID", Amount: 16, ID: id},
+		{CreatedAt: time.New(t1), Reason: PreStakingBonusLedgerReason, UserID: "UserID", Amount: 4, ID: id},
+		{CreatedAt: time.New(t2), Reason: SlashingLedgerReason, UserID: "UserID", Amount: -2, ID: id},
+	}
+	require.NoError(t, cl.InsertLedger(context.Background(), columns, input, entries))
+
+	ledger, err := cl.SelectLedger(context.Background(), id, 2, 0)
+	require.NoError(t, err)
+	require.Len(t, ledger, 2)
+	assert.Equal(t, SlashingLedgerReason, ledger[0].Reason)
+	assert.EqualValues(t, -2, ledger[0].Amount)
+	assert.Equal(t, PreStakingBonusLedgerReason, ledger[1].Reason)
+	ledger, err = cl.SelectLedger(context.Background(), id, 2, 2)
+	require.NoError(t, err)
+	require.Len(t, ledger, 1)
+	assert.Equal(t, SoloMiningLedgerReason, ledger[0].Reason)
+	assert.True(t, ledger[0].CreatedAt.Equal(t1))
+	require.NoError(t, cl.DeleteUserInfo(context.Background(), id))
+}
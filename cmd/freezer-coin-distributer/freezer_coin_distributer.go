@@ -4,7 +4,9 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"os"
 
 	"github.com/pkg/errors"
 
@@ -23,17 +25,78 @@ func main() {
 	var cfg struct{ Version string }
 	appCfg.MustLoadFromKey(pkgName, &cfg)
 
+	if len(os.Args) > 1 && os.Args[1] == publishMerkleRootCommand {
+		publishMerkleRoot(ctx, os.Args[2:])
+
+		return
+	}
+
 	log.Info(fmt.Sprintf("starting version `%v`...", cfg.Version))
 
 	server.New(new(service), pkgName, "").ListenAndServe(ctx, cancel)
 }
 
+// publishMerkleRootCommand publishes the merkle root of a network in `merkle-claim` mode to its claim contract, instead of starting the service.
+//
+//	freezer-coin-distributer publish-merkle-root -network=bnb [-root=0x...]
+const publishMerkleRootCommand = "publish-merkle-root"
+
+func publishMerkleRoot(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet(publishMerkleRootCommand, flag.ExitOnError)
+	network := flags.String("network", string(coindistribution.EthereumBlockchainNetworkType), "the network which merkle root to publish")
+	root := flags.String("root", "", "the merkle root to publish, the oldest unpublished one by default")
+	log.Panic(errors.Wrap(flags.Parse(args), "failed to parse flags")) //nolint:revive,nolintlint //.
+
+	txHash, err := coindistribution.PublishMerkleRoot(ctx, coindistribution.BlockchainNetworkType(*network), *root)
+	log.Panic(errors.Wrapf(err, "failed to publish %v merkle root %q", *network, *root)) //nolint:revive,nolintlint //.
+
+	log.Info(fmt.Sprintf("%v merkle root published in transaction %v", *network, txHash))
+}
+
 type (
+	GetStatusArg struct{}
 	// | service implements server.State and is responsible for managing the state and lifecycle of the package.
 	service struct{ coinDistributer coindistribution.Client }
 )
 
-func (s *service) RegisterRoutes(_ *server.Router) {}
+const (
+	adminRole = "admin"
+)
+
+func (s *service) RegisterRoutes(router *server.Router) {
+	router.
+		Group("/v1r").
+		GET("/coin-distributer/status", server.RootHandler(s.GetStatus))
+}
+
+// GetStatus godoc
+//
+//	@Schemes
+//	@Description	Returns the status of the coin distributer for each network: what the worker does, how many distributions are pending by status and the last transaction sent.
+//	@Tags			CoinDistribution
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string	true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Success		200				{object}	map[string]coindistribution.DistributerStatus
+//	@Failure		401				{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403				{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		500				{object}	server.ErrorResponse
+//	@Failure		504				{object}	server.ErrorResponse	"if request times out"
+//	@Router			/v1r/coin-distributer/status [GET].
+func (s *service) GetStatus( //nolint:gocritic // .
+	ctx context.Context,
+	req *server.Request[GetStatusArg, map[coindistribution.BlockchainNetworkType]*coindistribution.DistributerStatus],
+) (*server.Response[map[coindistribution.BlockchainNetworkType]*coindistribution.DistributerStatus], *server.Response[server.ErrorResponse]) {
+	if req.AuthenticatedUser.Role != adminRole {
+		return nil, server.Forbidden(errors.Errorf("insufficient role: %v, admin role required", req.AuthenticatedUser.Role))
+	}
+	status, err := s.coinDistributer.GetStatus(ctx)
+	if err != nil {
+		return nil, server.Unexpected(errors.Wrap(err, "failed to get the coin distributer status"))
+	}
+
+	return server.OK(&status), nil
+}
 
 func (s *service) Init(ctx context.Context, cancel context.CancelFunc) {
 	s.coinDistributer = coindistribution.MustStartCoinDistribution(ctx, cancel)
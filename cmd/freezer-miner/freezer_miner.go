@@ -3,9 +3,15 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"flag"
 	"fmt"
+	"io"
+	"os"
+	stdlibtime "time"
 
+	"github.com/goccy/go-json"
 	"github.com/pkg/errors"
 
 	"github.com/ice-blockchain/freezer/miner"
@@ -23,11 +29,150 @@ func main() {
 	var cfg struct{ Version string }
 	appCfg.MustLoadFromKey(pkgName, &cfg)
 
+	if len(os.Args) > 1 && os.Args[1] == simulateCommand {
+		simulate(os.Args[2:])
+
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == dryRunCommand {
+		dryRun(ctx, os.Args[2:])
+
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == recomputeCommand {
+		recompute(ctx, os.Args[2:])
+
+		return
+	}
+
 	log.Info(fmt.Sprintf("starting version `%v`...", cfg.Version))
 
 	server.New(new(service), pkgName, "").ListenAndServe(ctx, cancel)
 }
 
+// simulateCommand replays a scenario file through the mining logic, with a virtual clock, instead of starting the service.
+// It writes the balances and slashing rates of every user, after every step, as CSV.
+//
+//	freezer-miner simulate -scenario=scenario.json [-out=timeline.csv]
+const simulateCommand = "simulate"
+
+func simulate(args []string) {
+	flags := flag.NewFlagSet(simulateCommand, flag.ExitOnError)
+	scenarioFile := flags.String("scenario", "", "the json file with the scenario to simulate")
+	outFile := flags.String("out", "", "the csv file to write the timelines to, stdout by default")
+	log.Panic(errors.Wrap(flags.Parse(args), "failed to parse flags")) //nolint:revive,nolintlint //.
+
+	content, err := os.ReadFile(*scenarioFile)
+	log.Panic(errors.Wrapf(err, "failed to read scenario %q", *scenarioFile)) //nolint:revive,nolintlint //.
+	var scenario miner.SimulationScenario
+	log.Panic(errors.Wrapf(json.Unmarshal(content, &scenario), "failed to parse scenario %q", *scenarioFile)) //nolint:revive,nolintlint //.
+
+	var out io.Writer = os.Stdout
+	if *outFile != "" {
+		file, fErr := os.Create(*outFile)
+		log.Panic(errors.Wrapf(fErr, "failed to create %q", *outFile)) //nolint:revive,nolintlint //.
+		defer func() {
+			log.Panic(errors.Wrapf(file.Close(), "failed to close %q", *outFile)) //nolint:revive,nolintlint //.
+		}()
+		out = file
+	}
+	buffered := bufio.NewWriter(out)
+	log.Panic(errors.Wrapf(miner.Simulate(&scenario, buffered), "failed to simulate %q", *scenarioFile)) //nolint:revive,nolintlint //.
+	log.Panic(errors.Wrap(buffered.Flush(), "failed to flush the timelines"))                            //nolint:revive,nolintlint //.
+}
+
+// dryRunCommand mines all the users once, with a candidate config, without writing anything, instead of starting the service.
+// The candidate config is an application yaml that overrides the loaded one, the baseline, which the users are mined with too, for comparison.
+// It writes what would have been written to the diff log, as NDJSON, and then the aggregated report, as JSON.
+//
+//	freezer-miner dry-run -candidate=candidate.yaml -out=diff.ndjson [-report=report.json]
+const dryRunCommand = "dry-run"
+
+func dryRun(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet(dryRunCommand, flag.ExitOnError)
+	candidateFile := flags.String("candidate", "", "the yaml file with the config to compare with the loaded one")
+	outFile := flags.String("out", "", "the ndjson file to write the diff log to")
+	reportFile := flags.String("report", "", "the json file to write the report to, stdout by default")
+	log.Panic(errors.Wrap(flags.Parse(args), "failed to parse flags")) //nolint:revive,nolintlint //.
+	if *candidateFile == "" {
+		log.Panic(errors.New("-candidate is required")) //nolint:revive,nolintlint //.
+	}
+	if *outFile == "" {
+		log.Panic(errors.New("-out is required")) //nolint:revive,nolintlint //.
+	}
+
+	candidate, err := os.Open(*candidateFile)
+	log.Panic(errors.Wrapf(err, "failed to open %q", *candidateFile)) //nolint:revive,nolintlint //.
+	defer func() {
+		log.Panic(errors.Wrapf(candidate.Close(), "failed to close %q", *candidateFile)) //nolint:revive,nolintlint //.
+	}()
+	file, err := os.Create(*outFile)
+	log.Panic(errors.Wrapf(err, "failed to create %q", *outFile)) //nolint:revive,nolintlint //.
+	defer func() {
+		log.Panic(errors.Wrapf(file.Close(), "failed to close %q", *outFile)) //nolint:revive,nolintlint //.
+	}()
+	buffered := bufio.NewWriter(file)
+	report, err := miner.DryRun(ctx, candidate, buffered)
+	log.Panic(errors.Wrap(err, "failed to dry run the miner"))               //nolint:revive,nolintlint //.
+	log.Panic(errors.Wrap(buffered.Flush(), "failed to flush the diff log")) //nolint:revive,nolintlint //.
+
+	content, err := json.MarshalIndent(report, "", "  ")
+	log.Panic(errors.Wrap(err, "failed to marshal the report")) //nolint:revive,nolintlint //.
+	if *reportFile == "" {
+		fmt.Println(string(content)) //nolint:forbidigo // It's the output of the command.
+
+		return
+	}
+	log.Panic(errors.Wrapf(os.WriteFile(*reportFile, content, 0o600), "failed to write %q", *reportFile)) //nolint:revive,nolintlint //.
+}
+
+// recomputeCommand rebuilds the balances of a user, or of a range of users, from their history, instead of starting the service.
+// It writes the before/after of the recomputed balances to the diff log, as NDJSON, and then the aggregated report, as JSON;
+// with -apply, it also writes the recomputed balances, but only while mining is paused: the miner replicas have to be stopped first.
+//
+//	freezer-miner recompute (-user=1 | -from=1 -to=1000) -since=2024-01-01T00:00:00Z -out=diff.ndjson [-step=1h] [-apply] [-report=report.json]
+const recomputeCommand = "recompute"
+
+func recompute(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet(recomputeCommand, flag.ExitOnError)
+	userID := flags.Int64("user", 0, "the id of the user to recompute")
+	fromID := flags.Int64("from", 0, "the id of the first user to recompute")
+	toID := flags.Int64("to", 0, "the id of the last user to recompute")
+	since := flags.String("since", "", "when to replay the history from, as RFC3339")
+	step := flags.Duration("step", stdlibtime.Hour, "how often to mine the users in between the history snapshots")
+	apply := flags.Bool("apply", false, "write the recomputed balances, instead of only diffing them; the miner has to be stopped")
+	outFile := flags.String("out", "", "the ndjson file to write the diff log to")
+	reportFile := flags.String("report", "", "the json file to write the report to, stdout by default")
+	log.Panic(errors.Wrap(flags.Parse(args), "failed to parse flags")) //nolint:revive,nolintlint //.
+	if *outFile == "" {
+		log.Panic(errors.New("-out is required")) //nolint:revive,nolintlint //.
+	}
+	if *userID != 0 {
+		*fromID, *toID = *userID, *userID
+	}
+	sinceTime, err := stdlibtime.Parse(stdlibtime.RFC3339, *since)
+	log.Panic(errors.Wrapf(err, "failed to parse -since %q", *since)) //nolint:revive,nolintlint //.
+
+	file, err := os.Create(*outFile)
+	log.Panic(errors.Wrapf(err, "failed to create %q", *outFile)) //nolint:revive,nolintlint //.
+	defer func() {
+		log.Panic(errors.Wrapf(file.Close(), "failed to close %q", *outFile)) //nolint:revive,nolintlint //.
+	}()
+	buffered := bufio.NewWriter(file)
+	report, err := miner.Recompute(ctx, &miner.RecomputeOptions{Since: sinceTime, Step: *step, FromID: *fromID, ToID: *toID, Apply: *apply}, buffered)
+	log.Panic(errors.Wrap(buffered.Flush(), "failed to flush the diff log")) //nolint:revive,nolintlint //.
+	log.Panic(errors.Wrap(err, "failed to recompute the balances"))          //nolint:revive,nolintlint //.
+
+	content, err := json.MarshalIndent(report, "", "  ")
+	log.Panic(errors.Wrap(err, "failed to marshal the report")) //nolint:revive,nolintlint //.
+	if *reportFile == "" {
+		fmt.Println(string(content)) //nolint:forbidigo // It's the output of the command.
+
+		return
+	}
+	log.Panic(errors.Wrapf(os.WriteFile(*reportFile, content, 0o600), "failed to write %q", *reportFile)) //nolint:revive,nolintlint //.
+}
+
 type (
 	// | service implements server.State and is responsible for managing the state and lifecycle of the package.
 	service struct{ miner miner.Client }
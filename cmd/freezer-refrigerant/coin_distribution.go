@@ -4,11 +4,15 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"strings"
 
+	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
 
 	coindistribution "github.com/ice-blockchain/freezer/coin-distribution"
+	"github.com/ice-blockchain/wintr/log"
 	"github.com/ice-blockchain/wintr/server"
 )
 
@@ -16,7 +20,47 @@ func (s *service) setupCoinDistributionRoutes(router *server.Router) {
 	router.
 		Group("/v1w").
 		POST("/getCoinDistributionsForReview", server.RootHandler(s.GetCoinDistributionsForReview)).
-		POST("/reviewDistributions", server.RootHandler(s.ReviewCoinDistributions))
+		POST("/reviewDistributions", server.RootHandler(s.ReviewCoinDistributions)).
+		GET("/coinDistributerSettings", server.RootHandler(s.GetCoinDistributerSettings)).
+		PATCH("/coinDistributerSettings", server.RootHandler(s.UpdateCoinDistributerSettings)).
+		GET("/coinDistributerSettingChanges", server.RootHandler(s.GetCoinDistributerSettingChanges)).
+		GET("/exportCoinDistributions", s.ExportCoinDistributions)
+}
+
+func (s *service) setupCoinDistributionReadRoutes(router *server.Router) {
+	router.
+		Group("/v1r").
+		GET("/tokenomics/:userId/coin-distribution-claims", server.RootHandler(s.GetCoinDistributionClaims))
+}
+
+// GetCoinDistributionClaims godoc
+//
+//	@Schemes
+//	@Description	Returns the merkle proofs the user needs to claim the coins distributed in `merkle-claim` mode, from the newest day to the oldest one.
+//	@Description	The proofs can be used only after the merkle root is published, i.e. if `publishedAt` is set.
+//	@Tags			CoinDistribution
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string	true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			userId			path		string	true	"ID of the user"
+//	@Success		200				{array}		coindistribution.CoinDistributionClaim
+//	@Failure		400				{object}	server.ErrorResponse	"if validations fail"
+//	@Failure		401				{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403				{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		422				{object}	server.ErrorResponse	"if syntax fails"
+//	@Failure		500				{object}	server.ErrorResponse
+//	@Failure		504				{object}	server.ErrorResponse	"if request times out"
+//	@Router			/v1r/tokenomics/{userId}/coin-distribution-claims [GET].
+func (s *service) GetCoinDistributionClaims( //nolint:gocritic // False negative.
+	ctx context.Context,
+	req *server.Request[GetCoinDistributionClaimsArg, []*coindistribution.CoinDistributionClaim],
+) (*server.Response[[]*coindistribution.CoinDistributionClaim], *server.Response[server.ErrorResponse]) {
+	claims, err := s.coinDistributionRepository.GetCoinDistributionClaims(ctx, req.Data.UserID)
+	if err != nil {
+		return nil, server.Unexpected(errors.Wrapf(err, "failed to GetCoinDistributionClaims for userID:%v", req.Data.UserID))
+	}
+
+	return server.OK(&claims), nil
 }
 
 // GetCoinDistributionsForReview godoc
@@ -36,6 +80,8 @@ func (s *service) setupCoinDistributionRoutes(router *server.Router) {
 //	@Param			referredByUsernameOrderBy	query		string	false	"if u want to order by referredByUsername lexicographically"	Enums(asc,desc)
 //	@Param			usernameKeyword				query		string	false	"if u want to find usernames starting with keyword"
 //	@Param			referredByUsernameKeyword	query		string	false	"if u want to find referredByUsernames starting with keyword"
+//	@Param			riskFlag					query		string	false	"if u want to find the ones flagged by a specific risk rule"	Enums(address-daily-cap,above-historical-average,shared-eth-address,denylisted-eth-address)
+//	@Param			flaggedOnly					query		bool	false	"if u want to find only the ones flagged by any risk rule"
 //	@Success		200							{object}	coindistribution.CoinDistributionsForReview
 //	@Failure		401							{object}	server.ErrorResponse	"if not authorized"
 //	@Failure		403							{object}	server.ErrorResponse	"if not allowed"
@@ -53,17 +99,8 @@ func (s *service) GetCoinDistributionsForReview( //nolint:gocritic // .
 	if req.Data.Limit == 0 {
 		req.Data.Limit = defaultDistributionLimit
 	}
-	if req.Data.CreatedAtOrderBy != "" && !strings.EqualFold(req.Data.CreatedAtOrderBy, "desc") && !strings.EqualFold(req.Data.CreatedAtOrderBy, "asc") {
-		return nil, server.UnprocessableEntity(errors.Errorf("`createdAtOrderBy` has to be `asc` or `desc`"), "invalid params")
-	}
-	if req.Data.IceOrderBy != "" && !strings.EqualFold(req.Data.IceOrderBy, "desc") && !strings.EqualFold(req.Data.IceOrderBy, "asc") {
-		return nil, server.UnprocessableEntity(errors.Errorf("`iceOrderBy` has to be `asc` or `desc`"), "invalid params")
-	}
-	if req.Data.UsernameOrderBy != "" && !strings.EqualFold(req.Data.UsernameOrderBy, "desc") && !strings.EqualFold(req.Data.UsernameOrderBy, "asc") {
-		return nil, server.UnprocessableEntity(errors.Errorf("`usernameOrderBy` has to be `asc` or `desc`"), "invalid params")
-	}
-	if req.Data.ReferredByUsernameOrderBy != "" && !strings.EqualFold(req.Data.ReferredByUsernameOrderBy, "desc") && !strings.EqualFold(req.Data.ReferredByUsernameOrderBy, "asc") { //nolint:lll // .
-		return nil, server.UnprocessableEntity(errors.Errorf("`referredByUsernameOrderBy` has to be `asc` or `desc`"), "invalid params")
+	if errResp := validateGetCoinDistributionsForReviewArg(req.Data); errResp != nil {
+		return nil, errResp
 	}
 	resp, err := s.coinDistributionRepository.GetCoinDistributionsForReview(ctx, req.Data)
 	if err != nil {
@@ -73,40 +110,279 @@ func (s *service) GetCoinDistributionsForReview( //nolint:gocritic // .
 	return server.OK(resp), nil
 }
 
+func validateGetCoinDistributionsForReviewArg(arg *coindistribution.GetCoinDistributionsForReviewArg) *server.Response[server.ErrorResponse] {
+	if arg.CreatedAtOrderBy != "" && !strings.EqualFold(arg.CreatedAtOrderBy, "desc") && !strings.EqualFold(arg.CreatedAtOrderBy, "asc") {
+		return server.UnprocessableEntity(errors.Errorf("`createdAtOrderBy` has to be `asc` or `desc`"), "invalid params")
+	}
+	if arg.IceOrderBy != "" && !strings.EqualFold(arg.IceOrderBy, "desc") && !strings.EqualFold(arg.IceOrderBy, "asc") {
+		return server.UnprocessableEntity(errors.Errorf("`iceOrderBy` has to be `asc` or `desc`"), "invalid params")
+	}
+	if arg.UsernameOrderBy != "" && !strings.EqualFold(arg.UsernameOrderBy, "desc") && !strings.EqualFold(arg.UsernameOrderBy, "asc") {
+		return server.UnprocessableEntity(errors.Errorf("`usernameOrderBy` has to be `asc` or `desc`"), "invalid params")
+	}
+	if arg.ReferredByUsernameOrderBy != "" && !strings.EqualFold(arg.ReferredByUsernameOrderBy, "desc") && !strings.EqualFold(arg.ReferredByUsernameOrderBy, "asc") { //nolint:lll // .
+		return server.UnprocessableEntity(errors.Errorf("`referredByUsernameOrderBy` has to be `asc` or `desc`"), "invalid params")
+	}
+	switch arg.RiskFlag {
+	case "", coindistribution.RiskFlagAddressDailyCap, coindistribution.RiskFlagAboveHistoricalAverage,
+		coindistribution.RiskFlagSharedEthAddress, coindistribution.RiskFlagDenylistedEthAddress:
+	default:
+		return server.UnprocessableEntity(errors.Errorf("`riskFlag` `%v` is not supported", arg.RiskFlag), "invalid params")
+	}
+
+	return nil
+}
+
 // ReviewCoinDistributions godoc
 //
 //	@Schemes
-//	@Description	Reviews Coin Distributions.
+//	@Description	Reviews Coin Distributions. If neither `distributions` nor `filter` are provided, all the current coin distributions are reviewed.
+//	@Description	Otherwise, only the specified ones are reviewed and the rest stay pending review.
+//	@Description	Approvals are recorded for the current snapshot of the reviewed coin distributions, which are processed only once the configured number of distinct reviewers approved the same snapshot.
+//	@Description	Any change to the coin distributions pending review invalidates the earlier approvals.
 //	@Tags			CoinDistribution
 //	@Accept			json
 //	@Produce		json
-//	@Param			Authorization	header	string	true	"Insert your access token"	default(Bearer <Add access token here>)
-//	@Param			x_client_type	query	string	false	"the type of the client calling this API. I.E. `web`"
-//	@Param			decision		query	string	true	"the decision for the current coin distributions"	Enums(approve,approve-and-process-immediately,deny)
+//	@Param			Authorization	header	string							true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			x_client_type	query	string							false	"the type of the client calling this API. I.E. `web`"
+//	@Param			decision		query	string							true	"the decision for the current coin distributions"	Enums(approve,approve-and-process-immediately,deny)
+//	@Param			snapshotHash	query	string							false	"the `snapshotHash` of the coin distributions pending review the decision is based on"
+//	@Param			request			body	ReviewCoinDistributionsRequestBody	false	"Request params, if only specific coin distributions are to be reviewed"
 //	@Success		200				"OK"
 //	@Failure		401				{object}	server.ErrorResponse	"if not authorized"
 //	@Failure		403				{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		409				{object}	server.ErrorResponse	"if the coin distributions pending review changed since `snapshotHash`"
 //	@Failure		422				{object}	server.ErrorResponse	"if syntax fails"
 //	@Failure		500				{object}	server.ErrorResponse
 //	@Failure		504				{object}	server.ErrorResponse	"if request times out"
 //	@Router			/v1w/reviewDistributions [POST].
 func (s *service) ReviewCoinDistributions( //nolint:gocritic // .
 	ctx context.Context,
-	req *server.Request[struct {
-		Decision string `form:"decision" required:"true" swaggerignore:"true" enums:"approve,approve-and-process-immediately,deny"`
-	}, any],
+	req *server.Request[ReviewCoinDistributionsRequestBody, any],
 ) (*server.Response[any], *server.Response[server.ErrorResponse]) {
 	if req.AuthenticatedUser.Role != adminRole {
 		return nil, server.Forbidden(errors.Errorf("insufficient role: %v, admin role required", req.AuthenticatedUser.Role))
 	}
-	if !strings.EqualFold(req.Data.Decision, "approve") &&
-		!strings.EqualFold(req.Data.Decision, "approve-and-process-immediately") &&
-		!strings.EqualFold(req.Data.Decision, "deny") {
+	if !strings.EqualFold(req.Data.Decision, coindistribution.ReviewDecisionApprove) &&
+		!strings.EqualFold(req.Data.Decision, coindistribution.ReviewDecisionApproveAndProcessImmediately) &&
+		!strings.EqualFold(req.Data.Decision, coindistribution.ReviewDecisionDeny) {
 		return nil, server.UnprocessableEntity(errors.Errorf("`decision` has to be `approve`, `approve-and-process-immediately` or `deny`"), "invalid params")
 	}
-	if err := s.coinDistributionRepository.ReviewCoinDistributions(ctx, req.AuthenticatedUser.UserID, req.Data.Decision); err != nil {
-		return nil, server.Unexpected(errors.Wrapf(err, "failed to ReviewCoinDistributions for adminUserID:%v,decision:%v", req.AuthenticatedUser.UserID, req.Data.Decision)) //nolint:lll // .
+	if req.Data.Filter == nil && len(req.Data.Distributions) == 0 {
+		if err := s.coinDistributionRepository.ReviewCoinDistributions(ctx, req.AuthenticatedUser.UserID, req.Data.Decision, req.Data.SnapshotHash); err != nil {
+			if errors.Is(err, coindistribution.ErrReviewSnapshotChanged) {
+				return nil, server.Conflict(err, reviewSnapshotChangedErrorCode)
+			}
+
+			return nil, server.Unexpected(errors.Wrapf(err, "failed to ReviewCoinDistributions for adminUserID:%v,decision:%v", req.AuthenticatedUser.UserID, req.Data.Decision)) //nolint:lll // .
+		}
+
+		return server.OK[any](), nil
+	}
+	if req.Data.Filter != nil {
+		if errResp := validateGetCoinDistributionsForReviewArg(req.Data.Filter); errResp != nil {
+			return nil, errResp
+		}
+	}
+	selected := &coindistribution.SelectedCoinDistributions{Filter: req.Data.Filter, Distributions: req.Data.Distributions}
+	if err := s.coinDistributionRepository.ReviewSelectedCoinDistributions(ctx, req.AuthenticatedUser.UserID, req.Data.Decision, req.Data.SnapshotHash, selected); err != nil { //nolint:lll // .
+		if errors.Is(err, coindistribution.ErrInvalidSelection) {
+			return nil, server.UnprocessableEntity(err, "invalid params")
+		}
+		if errors.Is(err, coindistribution.ErrReviewSnapshotChanged) {
+			return nil, server.Conflict(err, reviewSnapshotChangedErrorCode)
+		}
+
+		return nil, server.Unexpected(errors.Wrapf(err, "failed to ReviewSelectedCoinDistributions for adminUserID:%v,decision:%v", req.AuthenticatedUser.UserID, req.Data.Decision)) //nolint:lll // .
 	}
 
 	return server.OK[any](), nil
 }
+
+// GetCoinDistributerSettings godoc
+//
+//	@Schemes
+//	@Description	Returns the coin distributer settings that can be changed at runtime.
+//	@Tags			CoinDistribution
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string	true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			x_client_type	query		string	false	"the type of the client calling this API. I.E. `web`"
+//	@Success		200				{array}		coindistribution.DistributerSetting
+//	@Failure		401				{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403				{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		422				{object}	server.ErrorResponse	"if syntax fails"
+//	@Failure		500				{object}	server.ErrorResponse
+//	@Failure		504				{object}	server.ErrorResponse	"if request times out"
+//	@Router			/v1w/coinDistributerSettings [GET].
+func (s *service) GetCoinDistributerSettings( //nolint:gocritic // .
+	ctx context.Context,
+	req *server.Request[GetCoinDistributerSettingsArg, []*coindistribution.DistributerSetting],
+) (*server.Response[[]*coindistribution.DistributerSetting], *server.Response[server.ErrorResponse]) {
+	if req.AuthenticatedUser.Role != adminRole {
+		return nil, server.Forbidden(errors.Errorf("insufficient role: %v, admin role required", req.AuthenticatedUser.Role))
+	}
+	settings, err := s.coinDistributionRepository.GetDistributerSettings(ctx)
+	if err != nil {
+		return nil, server.Unexpected(errors.Wrap(err, "failed to GetDistributerSettings"))
+	}
+
+	return server.OK(&settings), nil
+}
+
+// UpdateCoinDistributerSettings godoc
+//
+//	@Schemes
+//	@Description	Validates and updates the provided coin distributer settings at once. The changes are recorded with the admin who made them.
+//	@Tags			CoinDistribution
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string										true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			x_client_type	query		string										false	"the type of the client calling this API. I.E. `web`"
+//	@Param			request			body		UpdateCoinDistributerSettingsRequestBody	true	"Request params"
+//	@Success		200				{array}		coindistribution.DistributerSetting
+//	@Failure		401				{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403				{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		422				{object}	server.ErrorResponse	"if syntax fails or any of the settings is invalid"
+//	@Failure		500				{object}	server.ErrorResponse
+//	@Failure		504				{object}	server.ErrorResponse	"if request times out"
+//	@Router			/v1w/coinDistributerSettings [PATCH].
+func (s *service) UpdateCoinDistributerSettings( //nolint:gocritic // .
+	ctx context.Context,
+	req *server.Request[UpdateCoinDistributerSettingsRequestBody, []*coindistribution.DistributerSetting],
+) (*server.Response[[]*coindistribution.DistributerSetting], *server.Response[server.ErrorResponse]) {
+	if req.AuthenticatedUser.Role != adminRole {
+		return nil, server.Forbidden(errors.Errorf("insufficient role: %v, admin role required", req.AuthenticatedUser.Role))
+	}
+	settings, err := s.coinDistributionRepository.UpdateDistributerSettings(ctx, req.AuthenticatedUser.UserID, req.Data.Settings)
+	if err != nil {
+		if errors.Is(err, coindistribution.ErrInvalidSetting) {
+			return nil, server.UnprocessableEntity(err, "invalid params")
+		}
+
+		return nil, server.Unexpected(errors.Wrapf(err, "failed to UpdateDistributerSettings for adminUserID:%v,settings:%#v", req.AuthenticatedUser.UserID, req.Data.Settings))
+	}
+
+	return server.OK(&settings), nil
+}
+
+// GetCoinDistributerSettingChanges godoc
+//
+//	@Schemes
+//	@Description	Returns the audit log of the coin distributer settings, from the newest change to the oldest one.
+//	@Tags			CoinDistribution
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string	true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			x_client_type	query		string	false	"the type of the client calling this API. I.E. `web`"
+//	@Param			key				query		string	false	"if u want to see only the changes of a specific setting"
+//	@Param			limit			query		uint64	false	"max number of elements to return. Default is `100`."
+//	@Param			offset			query		uint64	false	"number of elements to skip before starting to fetch data"
+//	@Success		200				{array}		coindistribution.DistributerSettingChange
+//	@Failure		401				{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403				{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		422				{object}	server.ErrorResponse	"if syntax fails"
+//	@Failure		500				{object}	server.ErrorResponse
+//	@Failure		504				{object}	server.ErrorResponse	"if request times out"
+//	@Router			/v1w/coinDistributerSettingChanges [GET].
+func (s *service) GetCoinDistributerSettingChanges( //nolint:gocritic // .
+	ctx context.Context,
+	req *server.Request[GetCoinDistributerSettingChangesArg, []*coindistribution.DistributerSettingChange],
+) (*server.Response[[]*coindistribution.DistributerSettingChange], *server.Response[server.ErrorResponse]) {
+	const defaultLimit, maxLimit = 100, 1000
+	if req.AuthenticatedUser.Role != adminRole {
+		return nil, server.Forbidden(errors.Errorf("insufficient role: %v, admin role required", req.AuthenticatedUser.Role))
+	}
+	if req.Data.Limit > maxLimit {
+		req.Data.Limit = maxLimit
+	}
+	if req.Data.Limit == 0 {
+		req.Data.Limit = defaultLimit
+	}
+	changes, err := s.coinDistributionRepository.GetDistributerSettingChanges(ctx, req.Data.Key, req.Data.Limit, req.Data.Offset)
+	if err != nil {
+		return nil, server.Unexpected(errors.Wrapf(err, "failed to GetDistributerSettingChanges for %#v", req.Data))
+	}
+
+	return server.OK(&changes), nil
+}
+
+// ExportCoinDistributions godoc
+//
+//	@Schemes
+//	@Description	Exports the approved coin distributions of a review day, or of a range of review days, as CSV or NDJSON, followed by their totals.
+//	@Description	The content is streamed as it's read. The SHA-256 digest of all of it, for it to be archived and checked against the on-chain data,
+//	@Description	is sent afterwards, as the `X-Content-SHA256` trailer: if it's missing, the export failed midway and the content is incomplete.
+//	@Tags			CoinDistribution
+//	@Produce		text/csv,application/x-ndjson
+//	@Param			Authorization	header		string	true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			x_client_type	query		string	false	"the type of the client calling this API. I.E. `web`"
+//	@Param			reviewDay		query		string	false	"the review day of the cycle to export, I.E. `2024-01-02`. Either it or `from` and `to` are required"
+//	@Param			from			query		string	false	"the first review day to export, I.E. `2024-01-01`"
+//	@Param			to				query		string	false	"the last review day to export, I.E. `2024-01-31`"
+//	@Param			format			query		string	false	"the format of the content, `csv` by default"	Enums(csv,ndjson)
+//	@Success		200				{string}	string	"the CSV or NDJSON content"
+//	@Header			200				{string}	X-Content-SHA256	"the SHA-256 digest of the content, sent as a trailer"
+//	@Failure		401				{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403				{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		422				{object}	server.ErrorResponse	"if syntax fails"
+//	@Failure		500				{object}	server.ErrorResponse
+//	@Failure		504				{object}	server.ErrorResponse	"if request times out"
+//	@Router			/v1w/exportCoinDistributions [GET].
+func (s *service) ExportCoinDistributions(ginCtx *gin.Context) {
+	token, err := s.authClient.VerifyToken(ginCtx.Request.Context(), strings.TrimPrefix(ginCtx.GetHeader("Authorization"), "Bearer "))
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, &server.ErrorResponse{Error: errors.Wrap(err, "failed to VerifyToken").Error()})
+
+		return
+	}
+	if token.Role != adminRole {
+		ginCtx.JSON(http.StatusForbidden, &server.ErrorResponse{Error: fmt.Sprintf("insufficient role: %v, admin role required", token.Role)})
+
+		return
+	}
+	arg := new(coindistribution.ExportCoinDistributionsArg)
+	if err = ginCtx.ShouldBindQuery(arg); err != nil {
+		ginCtx.JSON(http.StatusUnprocessableEntity, &server.ErrorResponse{Error: err.Error(), Code: "invalid params"})
+
+		return
+	}
+	w := &coinDistributionsExportWriter{ginCtx: ginCtx, arg: arg}
+	summary, err := s.coinDistributionRepository.ExportCoinDistributions(ginCtx.Request.Context(), arg, w)
+	switch {
+	case err != nil && !w.started && errors.Is(err, coindistribution.ErrInvalidExport):
+		ginCtx.JSON(http.StatusUnprocessableEntity, &server.ErrorResponse{Error: err.Error(), Code: "invalid params"})
+	case err != nil && !w.started:
+		log.Error(errors.Wrapf(err, "failed to ExportCoinDistributions for %#v", arg))
+		ginCtx.JSON(http.StatusInternalServerError, &server.ErrorResponse{Error: "oops, something went wrong"})
+	case err != nil: // Without the trailer, the client knows that the content is incomplete.
+		log.Error(errors.Wrapf(err, "failed to ExportCoinDistributions midway for %#v", arg))
+	default:
+		ginCtx.Writer.Header().Set(contentSHA256Trailer, summary.SHA256)
+	}
+}
+
+// Starts the response the first time the export writes to it, when the arguments are validated already, so that they can still be rejected.
+func (w *coinDistributionsExportWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		fileName := fmt.Sprintf("coin-distributions-%v.%v", w.arg.From, w.arg.Format)
+		if w.arg.From != w.arg.To {
+			fileName = fmt.Sprintf("coin-distributions-%v-%v.%v", w.arg.From, w.arg.To, w.arg.Format)
+		}
+		contentType := "application/x-ndjson"
+		if w.arg.Format == coindistribution.CoinDistributionsExportFormatCSV {
+			contentType = "text/csv"
+		}
+		w.ginCtx.Header("Content-Type", contentType)
+		w.ginCtx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+		w.ginCtx.Header("Trailer", contentSHA256Trailer)
+		w.ginCtx.Status(http.StatusOK)
+	}
+	n, err := w.ginCtx.Writer.Write(p)
+	if err == nil {
+		w.ginCtx.Writer.Flush()
+	}
+
+	return n, errors.Wrap(err, "failed to write the response")
+}
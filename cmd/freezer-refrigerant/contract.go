@@ -3,9 +3,12 @@
 package main
 
 import (
+	"github.com/gin-gonic/gin"
+
 	"github.com/ice-blockchain/eskimo/users"
 	coindistribution "github.com/ice-blockchain/freezer/coin-distribution"
 	"github.com/ice-blockchain/freezer/tokenomics"
+	"github.com/ice-blockchain/wintr/auth"
 )
 
 // Public API.
@@ -40,6 +43,32 @@ type (
 		Network tokenomics.BlockchainNetworkType `json:"network" required:"true" example:"ethereum" enums:"arbitrum,bnb,ethereum"`
 		TXHash  string                           `json:"txHash" required:"true" example:"0xf75c78ab01ee4641be46794756f46137dea03a4980126dce4f2df933cccb34ea"`
 	}
+	SetMiningBlockchainNetworkRequestBody struct {
+		UserID  string                           `uri:"userId" swaggerignore:"true" required:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		Network tokenomics.BlockchainNetworkType `json:"network" required:"true" example:"arbitrum" enums:"arbitrum,bnb,ethereum"`
+	}
+	GetCoinDistributionClaimsArg struct {
+		UserID string `uri:"userId" required:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+	}
+	ReviewCoinDistributionsRequestBody struct {
+		// Specify this if you want to review only the page/subset of coin distributions matching it.
+		// If `limit` is not provided, all the coin distributions matching the keywords are reviewed.
+		Filter *coindistribution.GetCoinDistributionsForReviewArg `json:"filter"`
+		// Specify this if you want to review only these specific coin distributions.
+		Distributions []*coindistribution.CoinDistributionKey `json:"distributions"`
+		Decision      string                                  `form:"decision" required:"true" swaggerignore:"true" enums:"approve,approve-and-process-immediately,deny"`
+		SnapshotHash  string                                  `form:"snapshotHash" swaggerignore:"true"`
+	}
+	GetCoinDistributerSettingsArg            struct{}
+	UpdateCoinDistributerSettingsRequestBody struct {
+		// The settings to update, by key. They're all validated and updated at once.
+		Settings map[string]string `json:"settings" required:"true" example:"coin_distributer_gas_limit_units_bnb:30000000"`
+	}
+	GetCoinDistributerSettingChangesArg struct {
+		Key    string `form:"key" example:"coin_distributer_gas_limit_units_bnb"`
+		Limit  uint64 `form:"limit" maximum:"1000" example:"10"`
+		Offset uint64 `form:"offset" example:"5"`
+	}
 )
 
 // Private API.
@@ -49,6 +78,8 @@ const (
 	swaggerRoot        = "/tokenomics/w"
 
 	adminRole = "admin"
+
+	contentSHA256Trailer = "X-Content-SHA256"
 )
 
 // Values for server.ErrorResponse#Code.
@@ -65,6 +96,8 @@ const (
 	noPendingMiningBoostUpgradeFoundErrorCode     = "NO_PENDING_MINING_BOOST_UPGRADE_FOUND"
 	invalidMiningBoostUpgradeTransactionErrorCode = "INVALID_MINING_BOOST_UPGRADE_TRANSACTION"
 	transactionAlreadyUsed                        = "TRANSACTION_ALREADY_USED"
+	reviewSnapshotChangedErrorCode                = "REVIEW_SNAPSHOT_CHANGED"
+	invalidBlockchainNetworkErrorCode             = "INVALID_BLOCKCHAIN_NETWORK"
 
 	defaultDistributionLimit = 5000
 )
@@ -74,6 +107,13 @@ type (
 	service struct {
 		tokenomicsProcessor        tokenomics.Processor
 		coinDistributionRepository coindistribution.Repository
+		authClient                 auth.Client
+	}
+	// Streams the export straight to the response, see ExportCoinDistributions.
+	coinDistributionsExportWriter struct {
+		ginCtx  *gin.Context
+		arg     *coindistribution.ExportCoinDistributionsArg
+		started bool
 	}
 	config struct {
 		Host    string `yaml:"host"`
@@ -71,6 +71,7 @@ const (
 func (s *service) registerReadRoutes(router *server.Router) {
 	s.setupTokenomicsReadRoutes(router)
 	s.setupStatisticsRoutes(router)
+	s.setupCoinDistributionReadRoutes(router)
 }
 
 func (s *service) setupStatisticsRoutes(router *server.Router) {
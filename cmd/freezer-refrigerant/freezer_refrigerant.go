@@ -12,6 +12,7 @@ import (
 	"github.com/ice-blockchain/freezer/cmd/freezer-refrigerant/api"
 	coindistribution "github.com/ice-blockchain/freezer/coin-distribution"
 	"github.com/ice-blockchain/freezer/tokenomics"
+	"github.com/ice-blockchain/wintr/auth"
 	appCfg "github.com/ice-blockchain/wintr/config"
 	"github.com/ice-blockchain/wintr/log"
 	"github.com/ice-blockchain/wintr/server"
@@ -44,6 +45,7 @@ func (s *service) RegisterRoutes(router *server.Router) {
 func (s *service) Init(ctx context.Context, cancel context.CancelFunc) {
 	s.tokenomicsProcessor = tokenomics.StartProcessor(ctx, cancel)
 	s.coinDistributionRepository = coindistribution.NewRepository(ctx, cancel)
+	s.authClient = auth.New(ctx, applicationYamlKey)
 }
 
 func (s *service) Close(ctx context.Context) error {
@@ -19,7 +19,8 @@ func (s *service) setupTokenomicsRoutes(router *server.Router) {
 		PATCH("/tokenomics/:userId/mining-boosts", server.RootHandler(s.FinalizeMiningBoostUpgrade)).
 		POST("/tokenomics/:userId/mining-sessions", server.RootHandler(s.StartNewMiningSession)).
 		POST("/tokenomics/:userId/extra-bonus-claims", server.RootHandler(s.ClaimExtraBonus)).
-		PUT("/tokenomics/:userId/pre-staking", server.RootHandler(s.StartOrUpdatePreStaking))
+		PUT("/tokenomics/:userId/pre-staking", server.RootHandler(s.StartOrUpdatePreStaking)).
+		PUT("/tokenomics/:userId/mining-blockchain-network", server.RootHandler(s.SetMiningBlockchainNetwork))
 }
 
 // InitializeMiningBoostUpgrade godoc
@@ -102,6 +103,44 @@ func (s *service) FinalizeMiningBoostUpgrade( //nolint:gocritic // False negativ
 	return server.OK(resp), nil
 }
 
+// SetMiningBlockchainNetwork godoc
+//
+//	@Schemes
+//	@Description	Sets the blockchain network the user wants to receive their coin distributions on.
+//	@Tags			Tokenomics
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header	string									true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			userId			path	string									true	"ID of the user"
+//	@Param			request			body	SetMiningBlockchainNetworkRequestBody	true	"Request params"
+//	@Success		200				"OK"
+//	@Failure		400				{object}	server.ErrorResponse	"if validations fail"
+//	@Failure		401				{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403				{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		404				{object}	server.ErrorResponse	"user not found"
+//	@Failure		422				{object}	server.ErrorResponse	"if syntax fails"
+//	@Failure		500				{object}	server.ErrorResponse
+//	@Failure		504				{object}	server.ErrorResponse	"if request times out"
+//	@Router			/v1w/tokenomics/{userId}/mining-blockchain-network [PUT].
+func (s *service) SetMiningBlockchainNetwork( //nolint:gocritic // False negative.
+	ctx context.Context,
+	req *server.Request[SetMiningBlockchainNetworkRequestBody, any],
+) (*server.Response[any], *server.Response[server.ErrorResponse]) {
+	if err := s.tokenomicsProcessor.SetMiningBlockchainNetwork(ctx, req.Data.Network, req.Data.UserID); err != nil {
+		err = errors.Wrapf(err, "failed to SetMiningBlockchainNetwork for data:%#v", req.Data)
+		switch {
+		case errors.Is(err, tokenomics.ErrInvalidBlockchainNetwork):
+			return nil, server.BadRequest(err, invalidBlockchainNetworkErrorCode)
+		case errors.Is(err, tokenomics.ErrRelationNotFound):
+			return nil, server.NotFound(err, userNotFoundErrorCode)
+		default:
+			return nil, server.Unexpected(err)
+		}
+	}
+
+	return server.OK[any](), nil
+}
+
 // StartNewMiningSession godoc
 //
 //	@Schemes
@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/freezer/tokenomics"
+	"github.com/ice-blockchain/wintr/server"
+)
+
+func (s *service) setupCoinDistributionRoutes(router *server.Router) {
+	router.
+		Group("/v1r").
+		GET("/tokenomics/:userId/coin-distributions", server.RootHandler(s.GetCoinDistributionHistory))
+}
+
+// GetCoinDistributionHistory godoc
+//
+//	@Schemes
+//	@Description	Returns the history of the user's ethereum coin distributions, from the newest day to the oldest one,
+//	@Description	and the coins mined for the blockchain which are not yet collected to be distributed.
+//	@Tags			CoinDistribution
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string	true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			userId			path		string	true	"ID of the user"
+//	@Param			limit			query		uint64	false	"max number of elements to return. Default is `30`."
+//	@Param			offset			query		uint64	false	"number of elements to skip before starting to fetch data"
+//	@Success		200				{object}	CoinDistributionHistory
+//	@Failure		400				{object}	server.ErrorResponse	"if validations fail"
+//	@Failure		401				{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403				{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		404				{object}	server.ErrorResponse	"if not found"
+//	@Failure		422				{object}	server.ErrorResponse	"if syntax fails"
+//	@Failure		500				{object}	server.ErrorResponse
+//	@Failure		504				{object}	server.ErrorResponse	"if request times out"
+//	@Router			/tokenomics/{userId}/coin-distributions [GET].
+func (s *service) GetCoinDistributionHistory( //nolint:gocritic // False negative.
+	ctx context.Context,
+	req *server.Request[GetCoinDistributionHistoryArg, CoinDistributionHistory],
+) (*server.Response[CoinDistributionHistory], *server.Response[server.ErrorResponse]) {
+	const defaultLimit, maxLimit = 30, 1000
+	if req.Data.Limit > maxLimit {
+		req.Data.Limit = maxLimit
+	}
+	if req.Data.Limit == 0 {
+		req.Data.Limit = defaultLimit
+	}
+	pending, err := s.tokenomicsRepository.GetPendingCoinDistributionBalance(ctx, req.Data.UserID)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to get user's pending coin distribution balance for userID:%v", req.Data.UserID)
+		if errors.Is(err, tokenomics.ErrRelationNotFound) {
+			return nil, server.NotFound(err, userNotFoundErrorCode)
+		}
+
+		return nil, server.Unexpected(err)
+	}
+	distributions, err := s.coinDistributionRepository.GetCoinDistributionHistory(ctx, req.Data.UserID, req.Data.Limit, req.Data.Offset)
+	if err != nil {
+		return nil, server.Unexpected(errors.Wrapf(err, "failed to get user's coin distribution history for userID:%v, data:%#v", req.Data.UserID, req.Data))
+	}
+
+	return server.OK(&CoinDistributionHistory{
+		PendingIce:    fmt.Sprintf("%.2f", pending),
+		Distributions: distributions,
+	}), nil
+}
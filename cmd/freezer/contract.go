@@ -5,6 +5,7 @@ package main
 import (
 	stdlibtime "time"
 
+	coindistribution "github.com/ice-blockchain/freezer/coin-distribution"
 	"github.com/ice-blockchain/freezer/tokenomics"
 )
 
@@ -34,9 +35,34 @@ type (
 		Limit  uint64 `form:"limit" maximum:"1000" example:"24"`
 		Offset uint64 `form:"offset" example:"0"`
 	}
+	GetLedgerArg struct {
+		UserID string `uri:"userId" required:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		// Default is 50.
+		Limit  uint64 `form:"limit" maximum:"1000" example:"50"`
+		Offset uint64 `form:"offset" example:"0"`
+	}
+	GetTeamEarningsArg struct {
+		UserID string `uri:"userId" required:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		// Default is `lifetimeContribution`. They're all descending.
+		OrderBy string `form:"orderBy" enums:"lifetimeContribution,t2LifetimeContribution,mining" example:"lifetimeContribution"`
+		// Default is 50.
+		Limit  uint64 `form:"limit" maximum:"1000" example:"50"`
+		Offset uint64 `form:"offset" example:"0"`
+	}
 	GetRankingSummaryArg struct {
 		UserID string `uri:"userId" allowForbiddenGet:"true" required:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
 	}
+	GetCoinDistributionHistoryArg struct {
+		UserID string `uri:"userId" required:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		// Default is 30.
+		Limit  uint64 `form:"limit" maximum:"1000" example:"30"`
+		Offset uint64 `form:"offset" example:"0"`
+	}
+	CoinDistributionHistory struct {
+		// The coins mined for the blockchain, which are not yet collected to be distributed.
+		PendingIce    string                               `json:"pendingIce" example:"1243.02"`
+		Distributions []*coindistribution.CoinDistribution `json:"distributions"`
+	}
 	GetTopMinersArg struct {
 		Keyword string `form:"keyword" example:"jdoe"`
 		// Default is 10.
@@ -68,7 +94,8 @@ const (
 type (
 	// | service implements server.State and is responsible for managing the state and lifecycle of the package.
 	service struct {
-		tokenomicsRepository tokenomics.Repository
+		tokenomicsRepository       tokenomics.Repository
+		coinDistributionRepository coindistribution.ReadRepository
 	}
 	config struct {
 		Host    string `yaml:"host"`
@@ -23,6 +23,8 @@ func (s *service) setupTokenomicsRoutes(router *server.Router) {
 		GET("/tokenomics/:userId/pre-staking-summary", server.RootHandler(s.GetPreStakingSummary)).
 		GET("/tokenomics/:userId/balance-summary", server.RootHandler(s.GetBalanceSummary)).
 		GET("/tokenomics/:userId/balance-history", server.RootHandler(s.GetBalanceHistory)).
+		GET("/tokenomics/:userId/ledger", server.RootHandler(s.GetLedger)).
+		GET("/tokenomics/:userId/team-earnings", server.RootHandler(s.GetTeamEarnings)).
 		GET("/tokenomics/:userId/ranking-summary", server.RootHandler(s.GetRankingSummary))
 }
 
@@ -225,6 +227,98 @@ func (s *service) GetBalanceHistory( //nolint:gocritic,funlen // False negative.
 	return server.OK(&hist), nil
 }
 
+// GetLedger godoc
+//
+//	@Schemes
+//	@Description	Returns what changed the user's balance and by how much, from the newest change to the oldest one.
+//	@Tags			Tokenomics
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string	true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			userId			path		string	true	"ID of the user"
+//	@Param			limit			query		uint64	false	"max number of elements to return. Default is `50`."
+//	@Param			offset			query		uint64	false	"number of elements to skip before starting to fetch data"
+//	@Success		200				{array}		tokenomics.LedgerEntry
+//	@Failure		400				{object}	server.ErrorResponse	"if validations fail"
+//	@Failure		401				{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403				{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		422				{object}	server.ErrorResponse	"if syntax fails"
+//	@Failure		500				{object}	server.ErrorResponse
+//	@Failure		504				{object}	server.ErrorResponse	"if request times out"
+//	@Router			/tokenomics/{userId}/ledger [GET].
+func (s *service) GetLedger( //nolint:gocritic // False negative.
+	ctx context.Context,
+	req *server.Request[GetLedgerArg, []*tokenomics.LedgerEntry],
+) (*server.Response[[]*tokenomics.LedgerEntry], *server.Response[server.ErrorResponse]) {
+	const defaultLimit, maxLimit = 50, 1000
+	if req.Data.Limit > maxLimit {
+		req.Data.Limit = maxLimit
+	}
+	if req.Data.Limit == 0 {
+		req.Data.Limit = defaultLimit
+	}
+	ledger, err := s.tokenomicsRepository.GetLedger(ctx, req.Data.UserID, req.Data.Limit, req.Data.Offset)
+	if err != nil {
+		return nil, server.Unexpected(errors.Wrapf(err, "failed to get user's ledger for userID:%v, data:%#v", req.Data.UserID, req.Data))
+	}
+
+	return server.OK(&ledger), nil
+}
+
+// GetTeamEarnings godoc
+//
+//	@Schemes
+//	@Description	Returns the T1 referrals of the user, with what each of them, and the T2 referrals they invited, contribute to the user.
+//	@Tags			Tokenomics
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string	true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			userId			path		string	true	"ID of the user"
+//	@Param			orderBy			query		string	false	"how to sort them, descending. Default is `lifetimeContribution`."	Enums(lifetimeContribution,t2LifetimeContribution,mining)
+//	@Param			limit			query		uint64	false	"max number of elements to return. Default is `50`."
+//	@Param			offset			query		uint64	false	"number of elements to skip before starting to fetch data"
+//	@Success		200				{array}		tokenomics.TeamEarnings
+//	@Failure		400				{object}	server.ErrorResponse	"if validations fail"
+//	@Failure		401				{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403				{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		404				{object}	server.ErrorResponse	"if not found"
+//	@Failure		422				{object}	server.ErrorResponse	"if syntax fails"
+//	@Failure		500				{object}	server.ErrorResponse
+//	@Failure		504				{object}	server.ErrorResponse	"if request times out"
+//	@Router			/tokenomics/{userId}/team-earnings [GET].
+func (s *service) GetTeamEarnings( //nolint:gocritic // False negative.
+	ctx context.Context,
+	req *server.Request[GetTeamEarningsArg, []*tokenomics.TeamEarnings],
+) (*server.Response[[]*tokenomics.TeamEarnings], *server.Response[server.ErrorResponse]) {
+	const defaultLimit, maxLimit = 50, 1000
+	if req.Data.Limit > maxLimit {
+		req.Data.Limit = maxLimit
+	}
+	if req.Data.Limit == 0 {
+		req.Data.Limit = defaultLimit
+	}
+	orderBy := tokenomics.LifetimeContributionTeamOrderBy
+	if req.Data.OrderBy != "" {
+		orderBy = tokenomics.TeamOrderBy(req.Data.OrderBy)
+	}
+	switch orderBy {
+	case tokenomics.LifetimeContributionTeamOrderBy, tokenomics.T2LifetimeContributionTeamOrderBy, tokenomics.MiningTeamOrderBy:
+	default:
+		return nil, server.UnprocessableEntity(errors.Errorf("invalid orderBy:`%v`", req.Data.OrderBy), invalidPropertiesErrorCode)
+	}
+	earnings, err := s.tokenomicsRepository.GetTeamEarnings(ctx, req.Data.UserID, orderBy, req.Data.Limit, req.Data.Offset)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to get user's team earnings for userID:%v, data:%#v", req.Data.UserID, req.Data)
+		if errors.Is(err, tokenomics.ErrRelationNotFound) {
+			return nil, server.NotFound(err, userNotFoundErrorCode)
+		}
+
+		return nil, server.Unexpected(err)
+	}
+
+	return server.OK(&earnings), nil
+}
+
 // GetRankingSummary godoc
 //
 //	@Schemes
@@ -25,12 +25,64 @@ CREATE TABLE IF NOT EXISTS pending_coin_distributions  (
                     eth_address               text      NOT NULL,
                     eth_status                pending_coin_distributions_status NOT NULL DEFAULT 'NEW',
                     eth_tx                    text,
+                    network                   text      NOT NULL DEFAULT 'ethereum',
+                    eth_tx_nonce              bigint,
+                    eth_tx_replaced           text[]    NOT NULL DEFAULT '{}',
                     PRIMARY KEY(day, user_id))
                     WITH (FILLFACTOR = 70);
+ALTER TABLE pending_coin_distributions ADD COLUMN IF NOT EXISTS network text NOT NULL DEFAULT 'ethereum';
+ALTER TABLE pending_coin_distributions ADD COLUMN IF NOT EXISTS eth_tx_nonce bigint;
+ALTER TABLE pending_coin_distributions ADD COLUMN IF NOT EXISTS eth_tx_replaced text[] NOT NULL DEFAULT '{}';
 
 CREATE INDEX IF NOT EXISTS pending_coin_distributions_worker_number_ix ON pending_coin_distributions (eth_status, (internal_id % 10), created_at ASC);
 CREATE INDEX IF NOT EXISTS pending_coin_distributions_eth_status_tx_ix ON pending_coin_distributions (eth_status, eth_tx);
 CREATE INDEX IF NOT EXISTS pending_coin_distributions_eth_status_ix ON pending_coin_distributions (eth_status);
+CREATE INDEX IF NOT EXISTS pending_coin_distributions_network_eth_status_ix ON pending_coin_distributions (network, eth_status, created_at ASC);
+
+CREATE TABLE IF NOT EXISTS pending_coin_distribution_reconciliations  (
+                    created_at                timestamp NOT NULL,
+                    day                       date      NOT NULL,
+                    iceflakes                 uint256   NOT NULL,
+                    user_id                   text      NOT NULL,
+                    eth_address               text      NOT NULL,
+                    eth_tx                    text      NOT NULL,
+                    network                   text      NOT NULL,
+                    PRIMARY KEY(day, user_id));
+CREATE INDEX IF NOT EXISTS pending_coin_distribution_reconciliations_network_created_at_ix ON pending_coin_distribution_reconciliations (network, created_at ASC);
+CREATE INDEX IF NOT EXISTS pending_coin_distribution_reconciliations_eth_tx_ix ON pending_coin_distribution_reconciliations (eth_tx);
+
+CREATE TABLE IF NOT EXISTS coin_distribution_reconciliation_mismatches  (
+                    created_at                timestamp NOT NULL,
+                    expected_iceflakes        uint256   NOT NULL,
+                    actual_iceflakes          uint256   NOT NULL,
+                    eth_tx                    text      NOT NULL,
+                    eth_address               text      NOT NULL,
+                    network                   text      NOT NULL,
+                    user_ids                  text[]    NOT NULL DEFAULT '{}',
+                    PRIMARY KEY(eth_tx, eth_address));
+
+CREATE TABLE IF NOT EXISTS coin_distribution_merkle_trees  (
+                    created_at                timestamp NOT NULL,
+                    published_at              timestamp,
+                    total_iceflakes           uint256   NOT NULL,
+                    leaves                    bigint    NOT NULL,
+                    root                      text      NOT NULL PRIMARY KEY,
+                    network                   text      NOT NULL,
+                    publish_tx                text);
+CREATE INDEX IF NOT EXISTS coin_distribution_merkle_trees_network_created_at_ix ON coin_distribution_merkle_trees (network, created_at ASC);
+
+CREATE TABLE IF NOT EXISTS coin_distribution_merkle_proofs  (
+                    created_at                timestamp NOT NULL,
+                    day                       date      NOT NULL,
+                    iceflakes                 uint256   NOT NULL,
+                    leaf_iceflakes            uint256   NOT NULL,
+                    user_id                   text      NOT NULL,
+                    eth_address               text      NOT NULL,
+                    root                      text      NOT NULL REFERENCES coin_distribution_merkle_trees(root) ON DELETE CASCADE,
+                    leaf                      text      NOT NULL,
+                    proof                     text[]    NOT NULL DEFAULT '{}',
+                    PRIMARY KEY(root, day, user_id));
+CREATE INDEX IF NOT EXISTS coin_distribution_merkle_proofs_user_id_ix ON coin_distribution_merkle_proofs (user_id);
 
 CREATE TABLE IF NOT EXISTS global (
                     key       text NOT NULL primary key,
@@ -51,9 +103,55 @@ INSERT INTO global (key,value)
                    ('coin_distributer_gas_price_override','3000000000'),
                    ('coin_distributer_msg_sent_online_date', '2023-01-01T00:00:00Z'),
                    ('coin_distributer_msg_sent_offline_date', '2023-01-01T00:00:00Z'),
-                   ('coin_distributer_msg_sent_finished_date', '2023-01-01T00:00:00Z')
+                   ('coin_distributer_msg_sent_finished_date', '2023-01-01T00:00:00Z'),
+                   ('coin_distributer_forced_execution_arbitrum','false'),
+                   ('coin_distributer_gas_limit_units_arbitrum','30000000'),
+                   ('coin_distributer_gas_price_override_arbitrum','0'),
+                   ('coin_distributer_msg_sent_online_date_arbitrum', '2023-01-01T00:00:00Z'),
+                   ('coin_distributer_msg_sent_offline_date_arbitrum', '2023-01-01T00:00:00Z'),
+                   ('coin_distributer_msg_sent_finished_date_arbitrum', '2023-01-01T00:00:00Z'),
+                   ('coin_distributer_forced_execution_bnb','false'),
+                   ('coin_distributer_gas_limit_units_bnb','30000000'),
+                   ('coin_distributer_gas_price_override_bnb','3000000000'),
+                   ('coin_distributer_msg_sent_online_date_bnb', '2023-01-01T00:00:00Z'),
+                   ('coin_distributer_msg_sent_offline_date_bnb', '2023-01-01T00:00:00Z'),
+                   ('coin_distributer_msg_sent_finished_date_bnb', '2023-01-01T00:00:00Z'),
+                   ('coin_distributer_dynamic_fees_enabled','false'),
+                   ('coin_distributer_gas_tip_cap_override','0'),
+                   ('coin_distributer_gas_fee_cap_limit','0'),
+                   ('coin_distributer_base_fee_ceiling','0'),
+                   ('coin_distributer_dynamic_fees_enabled_arbitrum','false'),
+                   ('coin_distributer_gas_tip_cap_override_arbitrum','0'),
+                   ('coin_distributer_gas_fee_cap_limit_arbitrum','0'),
+                   ('coin_distributer_base_fee_ceiling_arbitrum','0'),
+                   ('coin_distributer_dynamic_fees_enabled_bnb','false'),
+                   ('coin_distributer_gas_tip_cap_override_bnb','0'),
+                   ('coin_distributer_gas_fee_cap_limit_bnb','0'),
+                   ('coin_distributer_base_fee_ceiling_bnb','0'),
+                   ('coin_review_risk_address_daily_cap','0'),
+                   ('coin_review_risk_historical_average_multiplier','0'),
+                   ('coin_review_risk_historical_average_min_days','3'),
+                   ('coin_review_risk_max_users_per_eth_address','0'),
+                   ('coin_review_risk_auto_deny_flags','denylisted-eth-address'),
+                   ('coin_review_approval_quorum','1'),
+                   ('coin_distributer_schedule',''),
+                   ('coin_distributer_schedule_arbitrum',''),
+                   ('coin_distributer_schedule_bnb','')
          ON CONFLICT(key) DO NOTHING;
 
+CREATE TABLE IF NOT EXISTS coin_distributer_setting_changes  (
+                    changed_at                timestamp NOT NULL,
+                    key                       text      NOT NULL,
+                    old_value                 text,
+                    new_value                 text      NOT NULL,
+                    admin_user_id             text      NOT NULL);
+CREATE INDEX IF NOT EXISTS coin_distributer_setting_changes_changed_at_ix ON coin_distributer_setting_changes (changed_at DESC);
+
+CREATE TABLE IF NOT EXISTS coin_distribution_denied_eth_addresses  (
+                    created_at                timestamp NOT NULL DEFAULT current_timestamp,
+                    eth_address               text      NOT NULL PRIMARY KEY CHECK (eth_address = lower(eth_address)),
+                    reason                    text      NOT NULL DEFAULT '');
+
 CREATE TABLE IF NOT EXISTS coin_distributions_by_earner (
                     created_at                timestamp NOT NULL,
                     internal_id               bigint    NOT NULL,
@@ -64,8 +162,10 @@ CREATE TABLE IF NOT EXISTS coin_distributions_by_earner (
                     user_id                   text      NOT NULL,
                     earner_user_id            text      NOT NULL,
                     eth_address               text      NOT NULL,
+                    network                   text      NOT NULL DEFAULT 'ethereum',
                     PRIMARY KEY(day, user_id, earner_user_id))
                     WITH (FILLFACTOR = 70);
+ALTER TABLE coin_distributions_by_earner ADD COLUMN IF NOT EXISTS network text NOT NULL DEFAULT 'ethereum';
 
 CREATE TABLE IF NOT EXISTS coin_distributions_pending_review  (
                     created_at                timestamp ,
@@ -77,7 +177,11 @@ CREATE TABLE IF NOT EXISTS coin_distributions_pending_review  (
                     referred_by_username      text      NOT NULL,
                     user_id                   text      NOT NULL,
                     eth_address               text      NOT NULL,
+                    network                   text      NOT NULL DEFAULT 'ethereum',
+                    risk_flags                text[]    NOT NULL DEFAULT '{}',
                     PRIMARY KEY(day, user_id));
+ALTER TABLE coin_distributions_pending_review ADD COLUMN IF NOT EXISTS network text NOT NULL DEFAULT 'ethereum';
+ALTER TABLE coin_distributions_pending_review ADD COLUMN IF NOT EXISTS risk_flags text[] NOT NULL DEFAULT '{}';
 
 CREATE INDEX IF NOT EXISTS coin_distributions_pending_review_internal_id_ix ON coin_distributions_pending_review (internal_id NULLS FIRST);
 CREATE INDEX IF NOT EXISTS coin_distributions_pending_review_created_at_ix ON coin_distributions_pending_review (created_at);
@@ -90,6 +194,7 @@ CREATE INDEX IF NOT EXISTS coin_distributions_pending_review_lookup3_ix ON coin_
 CREATE INDEX IF NOT EXISTS coin_distributions_pending_review_lookup4_ix ON coin_distributions_pending_review (ice,username,internal_id);
 CREATE INDEX IF NOT EXISTS coin_distributions_pending_review_lookup5_ix ON coin_distributions_pending_review (referred_by_username,internal_id);
 CREATE INDEX IF NOT EXISTS coin_distributions_pending_review_lookup6_ix ON coin_distributions_pending_review (ice,referred_by_username,internal_id);
+CREATE INDEX IF NOT EXISTS coin_distributions_pending_review_risk_flags_ix ON coin_distributions_pending_review USING GIN (risk_flags);
 
 CREATE TABLE IF NOT EXISTS reviewed_coin_distributions  (
                     reviewed_at               timestamp NOT NULL,
@@ -105,7 +210,41 @@ CREATE TABLE IF NOT EXISTS reviewed_coin_distributions  (
                     eth_address               text      NOT NULL,
                     reviewer_user_id          text      NOT NULL,
                     decision                  text      NOT NULL,
+                    network                   text      NOT NULL DEFAULT 'ethereum',
+                    risk_flags                text[]    NOT NULL DEFAULT '{}',
+                    eth_tx                    text,
                     PRIMARY KEY(user_id, day, review_day));
+ALTER TABLE reviewed_coin_distributions ADD COLUMN IF NOT EXISTS network text NOT NULL DEFAULT 'ethereum';
+ALTER TABLE reviewed_coin_distributions ADD COLUMN IF NOT EXISTS risk_flags text[] NOT NULL DEFAULT '{}';
+ALTER TABLE reviewed_coin_distributions ADD COLUMN IF NOT EXISTS eth_tx text;
+CREATE INDEX IF NOT EXISTS reviewed_coin_distributions_review_day_ix ON reviewed_coin_distributions (review_day, day, user_id);
+
+CREATE TABLE IF NOT EXISTS coin_distribution_review_approvals  (
+                    created_at                timestamp NOT NULL,
+                    snapshot_hash             text      NOT NULL,
+                    reviewer_user_id          text      NOT NULL,
+                    decision                  text      NOT NULL,
+                    PRIMARY KEY(snapshot_hash, reviewer_user_id));
+
+create or replace function invalidate_coin_distribution_review_approvals()
+    returns trigger
+language plpgsql
+    as $$
+BEGIN
+    delete from coin_distribution_review_approvals where 1=1;
+
+    return null;
+end; $$;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'coin_distributions_pending_review_invalidate_approvals') THEN
+        CREATE TRIGGER coin_distributions_pending_review_invalidate_approvals
+            AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON coin_distributions_pending_review
+            FOR EACH STATEMENT EXECUTE FUNCTION invalidate_coin_distribution_review_approvals();
+    END IF;
+END
+$$;
 
 create or replace function approve_coin_distributions(reviewer_user_id text, process_immediately boolean, nested boolean)
     returns RECORD
@@ -115,18 +254,20 @@ declare
          now timestamp := current_timestamp;
          ret RECORD;
 BEGIN
-    insert into pending_coin_distributions(created_at, internal_id, day, iceflakes, user_id, eth_address)
-    select created_at, internal_id, day, iceflakes, user_id, eth_address
+    insert into pending_coin_distributions(created_at, internal_id, day, iceflakes, user_id, eth_address, network)
+    select created_at, internal_id, day, iceflakes, user_id, eth_address, network
     from coin_distributions_pending_review;
 
-    insert into reviewed_coin_distributions(reviewed_at, created_at, internal_id, ice, day, review_day, iceflakes, username, referred_by_username, user_id, eth_address, reviewer_user_id, decision)
-    select now, created_at, internal_id, ice, day, now::date, iceflakes, username, referred_by_username, user_id, eth_address, reviewer_user_id, (case when process_immediately is true then 'approve-and-process-immediately' else 'approve' end) AS reason
+    insert into reviewed_coin_distributions(reviewed_at, created_at, internal_id, ice, day, review_day, iceflakes, username, referred_by_username, user_id, eth_address, reviewer_user_id, decision, network, risk_flags)
+    select now, created_at, internal_id, ice, day, now::date, iceflakes, username, referred_by_username, user_id, eth_address, reviewer_user_id, (case when process_immediately is true then 'approve-and-process-immediately' else 'approve' end) AS reason, network, risk_flags
     from coin_distributions_pending_review;
 
     IF process_immediately is true THEN
         INSERT INTO global (key,value)
                     VALUES ('coin_distributer_enabled','true'),
-                           ('coin_distributer_forced_execution','true')
+                           ('coin_distributer_forced_execution','true'),
+                           ('coin_distributer_forced_execution_arbitrum','true'),
+                           ('coin_distributer_forced_execution_bnb','true')
         ON CONFLICT (key) DO UPDATE
                     SET value = EXCLUDED.value;
      END IF;
@@ -148,8 +289,8 @@ language plpgsql
 declare
          now timestamp := current_timestamp;
 BEGIN
-    insert into reviewed_coin_distributions(reviewed_at, created_at, internal_id, ice, day, review_day, iceflakes, username, referred_by_username, user_id, eth_address, reviewer_user_id, decision)
-    select now, created_at, internal_id, ice, day, now::date, iceflakes, username, referred_by_username, user_id, eth_address, reviewer_user_id, 'deny'
+    insert into reviewed_coin_distributions(reviewed_at, created_at, internal_id, ice, day, review_day, iceflakes, username, referred_by_username, user_id, eth_address, reviewer_user_id, decision, network, risk_flags)
+    select now, created_at, internal_id, ice, day, now::date, iceflakes, username, referred_by_username, user_id, eth_address, reviewer_user_id, 'deny', network, risk_flags
     from coin_distributions_pending_review;
 
     delete from coin_distributions_pending_review where 1=1;
@@ -172,11 +313,16 @@ declare
          zeros text := '0000000000000000';
          now timestamp := current_timestamp;
          reward_pool_internal_id bigint := 999999999;
+         address_daily_cap numeric := coalesce((select nullif(value,'')::numeric from global where key = 'coin_review_risk_address_daily_cap'), 0) * 100;
+         historical_average_multiplier numeric := coalesce((select nullif(value,'')::numeric from global where key = 'coin_review_risk_historical_average_multiplier'), 0);
+         historical_average_min_days bigint := coalesce((select nullif(value,'')::bigint from global where key = 'coin_review_risk_historical_average_min_days'), 1);
+         max_users_per_eth_address bigint := coalesce((select nullif(value,'')::bigint from global where key = 'coin_review_risk_max_users_per_eth_address'), 0);
+         auto_deny_flags text[] := coalesce((select string_to_array(replace(value,' ',''),',') from global where key = 'coin_review_risk_auto_deny_flags'), '{}');
 BEGIN
     delete from coin_distributions_by_earner WHERE balance = 0;
 
-    insert into coin_distributions_pending_review(created_at, internal_id, ice, day, iceflakes, username, referred_by_username, user_id, eth_address)
-        SELECT created_at, internal_id, ice, day, (ice::text||zeros)::uint256 AS iceflakes, username, referred_by_username, user_id, eth_address
+    insert into coin_distributions_pending_review(created_at, internal_id, ice, day, iceflakes, username, referred_by_username, user_id, eth_address, network)
+        SELECT created_at, internal_id, ice, day, (ice::text||zeros)::uint256 AS iceflakes, username, referred_by_username, user_id, eth_address, network
         FROM (select
                    min (created_at) filter ( where user_id=earner_user_id or internal_id = reward_pool_internal_id)  AS created_at,
                    min (internal_id) filter ( where user_id=earner_user_id or internal_id = reward_pool_internal_id)  AS internal_id,
@@ -185,7 +331,8 @@ BEGIN
                    string_agg(distinct username,'') AS username,
                    string_agg(distinct referred_by_username,'') AS referred_by_username,
                    user_id,
-                   string_agg(distinct eth_address,'') AS eth_address
+                   string_agg(distinct eth_address,'') AS eth_address,
+                   COALESCE(min (network) filter ( where user_id=earner_user_id or internal_id = reward_pool_internal_id),'ethereum') AS network
                 from coin_distributions_by_earner
                 group by day,user_id) AS X;
 
@@ -194,8 +341,61 @@ BEGIN
     WITH del as (
        DELETE FROM coin_distributions_pending_review WHERE internal_id IS NULL RETURNING *
     )
-    insert into reviewed_coin_distributions(reviewed_at, created_at, internal_id, ice, day, review_day, iceflakes, username, referred_by_username, user_id, eth_address, reviewer_user_id, decision)
-    select now, COALESCE(created_at,to_timestamp(0)), COALESCE(internal_id,0), ice, day, now::date, iceflakes, username, referred_by_username, user_id, eth_address, 'system', 'deny due to incomplete data'
+    insert into reviewed_coin_distributions(reviewed_at, created_at, internal_id, ice, day, review_day, iceflakes, username, referred_by_username, user_id, eth_address, reviewer_user_id, decision, network, risk_flags)
+    select now, COALESCE(created_at,to_timestamp(0)), COALESCE(internal_id,0), ice, day, now::date, iceflakes, username, referred_by_username, user_id, eth_address, 'system', 'deny due to incomplete data', network, risk_flags
+    from del;
+
+    -- Risk rules: each one tags the rows it matches with its flag; the rows with any of the auto_deny_flags are denied, the rest go to review as usual.
+    IF address_daily_cap > 0 THEN
+        UPDATE coin_distributions_pending_review r
+           SET risk_flags = array_append(r.risk_flags, 'address-daily-cap')
+          FROM (SELECT lower(eth_address) AS eth_address, day
+                FROM coin_distributions_pending_review
+                WHERE eth_address != 'skip'
+                GROUP BY lower(eth_address), day
+                HAVING sum(ice) > address_daily_cap) x
+         WHERE lower(r.eth_address) = x.eth_address
+           AND r.day = x.day
+           AND NOT ('address-daily-cap' = ANY(r.risk_flags));
+    END IF;
+
+    IF historical_average_multiplier > 0 THEN
+        UPDATE coin_distributions_pending_review r
+           SET risk_flags = array_append(r.risk_flags, 'above-historical-average')
+          FROM (SELECT user_id, avg(ice) AS ice
+                FROM reviewed_coin_distributions
+                WHERE decision LIKE 'approve%'
+                  AND user_id IN (SELECT user_id FROM coin_distributions_pending_review)
+                GROUP BY user_id
+                HAVING count(1) >= historical_average_min_days) x
+         WHERE r.user_id = x.user_id
+           AND r.ice > x.ice * historical_average_multiplier
+           AND NOT ('above-historical-average' = ANY(r.risk_flags));
+    END IF;
+
+    IF max_users_per_eth_address > 0 THEN
+        UPDATE coin_distributions_pending_review r
+           SET risk_flags = array_append(r.risk_flags, 'shared-eth-address')
+          FROM (SELECT lower(eth_address) AS eth_address
+                FROM coin_distributions_pending_review
+                WHERE eth_address != 'skip'
+                GROUP BY lower(eth_address)
+                HAVING count(distinct user_id) > max_users_per_eth_address) x
+         WHERE lower(r.eth_address) = x.eth_address
+           AND NOT ('shared-eth-address' = ANY(r.risk_flags));
+    END IF;
+
+    UPDATE coin_distributions_pending_review r
+       SET risk_flags = array_append(r.risk_flags, 'denylisted-eth-address')
+      FROM coin_distribution_denied_eth_addresses x
+     WHERE lower(r.eth_address) = x.eth_address
+       AND NOT ('denylisted-eth-address' = ANY(r.risk_flags));
+
+    WITH del as (
+       DELETE FROM coin_distributions_pending_review WHERE risk_flags && auto_deny_flags RETURNING *
+    )
+    insert into reviewed_coin_distributions(reviewed_at, created_at, internal_id, ice, day, review_day, iceflakes, username, referred_by_username, user_id, eth_address, reviewer_user_id, decision, network, risk_flags)
+    select now, created_at, internal_id, ice, day, now::date, iceflakes, username, referred_by_username, user_id, eth_address, 'system', 'deny due to risk: '||array_to_string(risk_flags,','), network, risk_flags
     from del;
 
     IF nested is false THEN
@@ -0,0 +1,254 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package coindistribution
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	stdlibtime "time"
+
+	"github.com/goccy/go-json"
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/wintr/log"
+)
+
+//nolint:gochecknoglobals // It's a constant.
+var defaultAlertSeverities = map[alertEvent]alertSeverity{
+	alertEventReviewApproved:                alertSeverityInfo,
+	alertEventReviewDenied:                  alertSeverityInfo,
+	alertEventNewDistributionsForReview:     alertSeverityInfo,
+	alertEventCollectionCycleStarted:        alertSeverityInfo,
+	alertEventCollectionCycleEndedEarly:     alertSeverityWarning,
+	alertEventDistributerOnline:             alertSeverityInfo,
+	alertEventDistributerOffline:            alertSeverityInfo,
+	alertEventDistributerHasUnfinishedWork:  alertSeverityWarning,
+	alertEventTransactionStuck:              alertSeverityCritical,
+	alertEventAllDistributionsCommitted:     alertSeverityInfo,
+	alertEventProcessingStarted:             alertSeverityInfo,
+	alertEventGasLimitTooLow:                alertSeverityWarning,
+	alertEventPausedDueToHighBaseFee:        alertSeverityWarning,
+	alertEventReconciliationMismatch:        alertSeverityCritical,
+	alertEventMerkleTreeBuilt:               alertSeverityInfo,
+	alertEventProcessingStoppedDueToFailure: alertSeverityCritical,
+	alertEventReviewApprovalRecorded:        alertSeverityInfo,
+}
+
+// EnsureValid panics if any event would be routed to an unknown or an unconfigured sink.
+// slackWebhook is the global `alert-slack-webhook`, used by the `slack` sinks without their own webhook.
+func (c *alertsConfig) EnsureValid(slackWebhook string) {
+	for name, sink := range c.Sinks {
+		switch sink.Type {
+		case alertSinkTypeLog:
+		case alertSinkTypeSlack:
+			if sink.Webhook == "" && slackWebhook == "" {
+				log.Panic(fmt.Sprintf("alerts.sinks.%v.webhook must not be empty if `alert-slack-webhook` is missing", name))
+			}
+		case alertSinkTypeWebhook:
+			if sink.URL == "" {
+				log.Panic(fmt.Sprintf("alerts.sinks.%v.url must not be empty", name))
+			}
+		case alertSinkTypeSMTP:
+			if sink.Host == "" || sink.Port == 0 || sink.From == "" || len(sink.To) == 0 {
+				log.Panic(fmt.Sprintf("alerts.sinks.%v: host, port, from and to must not be empty", name))
+			}
+		default:
+			log.Panic(fmt.Sprintf("alerts.sinks.%v.type `%v` is not supported", name, sink.Type))
+		}
+	}
+	if len(c.Sinks) == 0 && slackWebhook == "" {
+		log.Panic("`alert-slack-webhook` is missing, it's required if there are no alerts.sinks")
+	}
+	for event, route := range c.Routes {
+		if _, found := defaultAlertSeverities[event]; !found {
+			log.Panic(fmt.Sprintf("alerts.routes: unknown event `%v`", event))
+		}
+		switch route.Severity {
+		case "", alertSeverityInfo, alertSeverityWarning, alertSeverityCritical:
+		default:
+			log.Panic(fmt.Sprintf("alerts.routes.%v.severity `%v` is not supported", event, route.Severity))
+		}
+	}
+	for event := range defaultAlertSeverities {
+		_, sinks, _ := c.route(event)
+		if len(sinks) == 0 {
+			log.Panic(fmt.Sprintf("alerts: event `%v` has no sinks, either alerts.defaultSinks or alerts.routes.%v.sinks must be provided", event, event))
+		}
+		for _, name := range sinks {
+			if _, found := c.Sinks[name]; !found && (len(c.Sinks) != 0 || name != defaultAlertSinkName) {
+				log.Panic(fmt.Sprintf("alerts: unknown sink `%v` for event `%v`", name, event))
+			}
+		}
+	}
+}
+
+// route returns the severity, the sinks and the deduplication window of the event.
+func (c *alertsConfig) route(event alertEvent) (severity alertSeverity, sinks []string, dedupWindow stdlibtime.Duration) {
+	severity, sinks, dedupWindow = defaultAlertSeverities[event], c.DefaultSinks, c.DedupWindow
+	if route := c.Routes[event]; route != nil {
+		if route.Severity != "" {
+			severity = route.Severity
+		}
+		if len(route.Sinks) != 0 {
+			sinks = route.Sinks
+		}
+		if route.DedupWindow != nil {
+			dedupWindow = *route.DedupWindow
+		}
+	}
+	if len(sinks) == 0 && len(c.Sinks) == 0 {
+		sinks = []string{defaultAlertSinkName}
+	}
+
+	return severity, sinks, dedupWindow
+}
+
+func (cfg *config) alertSink(name string) (alertSink, error) {
+	sink, found := cfg.Alerts.Sinks[name]
+	if !found {
+		if len(cfg.Alerts.Sinks) == 0 && name == defaultAlertSinkName {
+			return &slackAlertSink{cfg: cfg}, nil
+		}
+
+		return nil, errors.Errorf("unknown alert sink `%v`", name)
+	}
+	switch sink.Type {
+	case alertSinkTypeSlack:
+		return &slackAlertSink{cfg: cfg, Webhook: sink.Webhook}, nil
+	case alertSinkTypeWebhook:
+		return &webhookAlertSink{URL: sink.URL, Headers: sink.Headers}, nil
+	case alertSinkTypeSMTP:
+		return &smtpAlertSink{alertSinkConfig: sink}, nil
+	case alertSinkTypeLog:
+		return new(logAlertSink), nil
+	default:
+		return nil, errors.Errorf("alert sink `%v` has unsupported type `%v`", name, sink.Type)
+	}
+}
+
+// sendAlert sends the alert to all the sinks the event is routed to, unless the same alert was already sent within its deduplication window.
+func (cfg *config) sendAlert(ctx context.Context, event alertEvent, network BlockchainNetworkType, text string) error {
+	severity, sinks, dedupWindow := cfg.Alerts.route(event)
+	key := fmt.Sprintf("%v|%v|%v", event, network, text)
+	if !sentAlerts.Mute(key, dedupWindow, stdlibtime.Now()) {
+		log.Debug(fmt.Sprintf("alert `%v` is muted, it was already sent within %v", event, dedupWindow))
+
+		return nil
+	}
+
+	msg := &alert{Event: event, Severity: severity, Environment: cfg.Environment, Network: network, Text: text}
+	var mErr *multierror.Error
+	sent := false
+	for _, name := range sinks {
+		sink, err := cfg.alertSink(name)
+		if err == nil {
+			err = sink.Send(ctx, msg)
+		}
+		sent = sent || err == nil
+		mErr = multierror.Append(mErr, errors.Wrapf(err, "failed to send alert `%v` to sink `%v`", event, name))
+	}
+	if !sent {
+		sentAlerts.Unmute(key)
+	}
+
+	return mErr.ErrorOrNil() //nolint:wrapcheck // Already wrapped.
+}
+
+// Mute reports whether the alert should be sent, muting it for window if so.
+func (d *alertDeduplicator) Mute(key string, window stdlibtime.Duration, now stdlibtime.Time) bool {
+	if window <= 0 {
+		return true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if until, found := d.mutedUntil[key]; found && now.Before(until) {
+		return false
+	}
+	if len(d.mutedUntil) >= maxDeduplicatedAlerts {
+		for k, until := range d.mutedUntil {
+			if !now.Before(until) {
+				delete(d.mutedUntil, k)
+			}
+		}
+	}
+	d.mutedUntil[key] = now.Add(window)
+
+	return true
+}
+
+func (d *alertDeduplicator) Unmute(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.mutedUntil, key)
+}
+
+func (s *slackAlertSink) Send(ctx context.Context, msg *alert) error {
+	webhook := s.Webhook
+	if webhook == "" {
+		webhook = s.cfg.alertSlackWebhook(msg.Network)
+	}
+
+	return sendSlackMessage(ctx, msg.Text, webhook)
+}
+
+func (s *webhookAlertSink) Send(ctx context.Context, msg *alert) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return errors.Wrapf(err, "failed to Marshal alert:%#v", msg)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewBuffer(data))
+	if err != nil {
+		return errors.Wrap(err, "newRequestWithContext failed")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range s.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := new(http.Client).Do(req)
+	if err != nil {
+		return errors.Wrap(err, "alert webhook request failed")
+	}
+	defer func() {
+		log.Error(errors.Wrap(resp.Body.Close(), "failed to close body"))
+	}()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return errors.Errorf("unexpected statusCode:%v", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (s *smtpAlertSink) Send(_ context.Context, msg *alert) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	body := fmt.Sprintf("From: %v\r\nTo: %v\r\nSubject: [%v] %v: %v %v\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%v\r\n",
+		s.From, strings.Join(s.To, ", "), strings.ToUpper(string(msg.Severity)), msg.Environment, msg.Network, msg.Event, msg.Text)
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+
+	return errors.Wrapf(smtp.SendMail(addr, auth, s.From, s.To, []byte(body)), "failed to send alert email via %v", addr)
+}
+
+func (*logAlertSink) Send(_ context.Context, msg *alert) error {
+	text := fmt.Sprintf("[alert] %v: %v: %v: %v", msg.Environment, msg.Network, msg.Event, msg.Text)
+	switch msg.Severity {
+	case alertSeverityCritical:
+		log.Error(errors.New(text))
+	case alertSeverityWarning:
+		log.Warn(text)
+	default:
+		log.Info(text)
+	}
+
+	return nil
+}
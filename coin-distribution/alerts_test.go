@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package coindistribution
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	stdlibtime "time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/require"
+)
+
+type alertsRecorder struct {
+	alerts []*alert
+	mu     sync.Mutex
+	status int
+}
+
+func (r *alertsRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	msg := new(alert)
+	if err := json.NewDecoder(req.Body).Decode(msg); err == nil {
+		r.alerts = append(r.alerts, msg)
+	}
+	if r.status != 0 {
+		w.WriteHeader(r.status)
+	}
+}
+
+func (r *alertsRecorder) Alerts() []*alert {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]*alert{}, r.alerts...)
+}
+
+func newAlertsRecorder(t *testing.T) (*alertsRecorder, string) {
+	t.Helper()
+
+	recorder := new(alertsRecorder)
+	srv := httptest.NewServer(recorder)
+	t.Cleanup(srv.Close)
+
+	return recorder, srv.URL
+}
+
+func TestAlertRouting(t *testing.T) {
+	t.Parallel()
+
+	oncall, oncallURL := newAlertsRecorder(t)
+	channel, channelURL := newAlertsRecorder(t)
+	conf := &config{Environment: "test", Alerts: alertsConfig{
+		Sinks: map[string]*alertSinkConfig{
+			"oncall":  {Type: alertSinkTypeWebhook, URL: oncallURL},
+			"channel": {Type: alertSinkTypeWebhook, URL: channelURL},
+			"log":     {Type: alertSinkTypeLog},
+		},
+		Routes: map[alertEvent]*alertRoute{
+			alertEventTransactionStuck:       {Sinks: []string{"oncall", "channel"}},
+			alertEventCollectionCycleStarted: {Severity: alertSeverityWarning, Sinks: []string{"channel", "log"}},
+		},
+		DefaultSinks: []string{"log"},
+	}}
+	conf.Alerts.EnsureValid("")
+
+	ctx := context.Background()
+	require.NoError(t, conf.sendAlert(ctx, alertEventTransactionStuck, BNBBlockchainNetworkType, "TestAlertRouting stuck"))
+	require.NoError(t, conf.sendAlert(ctx, alertEventCollectionCycleStarted, "", "TestAlertRouting started"))
+	require.NoError(t, conf.sendAlert(ctx, alertEventDistributerOnline, BNBBlockchainNetworkType, "TestAlertRouting online"))
+
+	require.Equal(t, []*alert{
+		{Event: alertEventTransactionStuck, Severity: alertSeverityCritical, Environment: "test", Network: BNBBlockchainNetworkType, Text: "TestAlertRouting stuck"},
+	}, oncall.Alerts())
+	require.Equal(t, []*alert{
+		{Event: alertEventTransactionStuck, Severity: alertSeverityCritical, Environment: "test", Network: BNBBlockchainNetworkType, Text: "TestAlertRouting stuck"},
+		{Event: alertEventCollectionCycleStarted, Severity: alertSeverityWarning, Environment: "test", Text: "TestAlertRouting started"},
+	}, channel.Alerts())
+}
+
+func TestAlertRouteDefaults(t *testing.T) {
+	t.Parallel()
+
+	conf := new(alertsConfig)
+	severity, sinks, window := conf.route(alertEventReconciliationMismatch)
+	require.Equal(t, alertSeverityCritical, severity)
+	require.Equal(t, []string{defaultAlertSinkName}, sinks)
+	require.Zero(t, window)
+
+	hour := stdlibtime.Hour
+	conf.Sinks = map[string]*alertSinkConfig{"log": {Type: alertSinkTypeLog}}
+	conf.DedupWindow = stdlibtime.Minute
+	conf.Routes = map[alertEvent]*alertRoute{alertEventDistributerOnline: {DedupWindow: &hour}}
+	severity, sinks, window = conf.route(alertEventDistributerOnline)
+	require.Equal(t, alertSeverityInfo, severity)
+	require.Empty(t, sinks)
+	require.Equal(t, hour, window)
+	conf.DefaultSinks = []string{"log"}
+	_, sinks, _ = conf.route(alertEventDistributerOnline)
+	require.Equal(t, []string{"log"}, sinks)
+	_, _, window = conf.route(alertEventDistributerOffline)
+	require.Equal(t, stdlibtime.Minute, window)
+}
+
+func TestAlertsConfigEnsureValid(t *testing.T) {
+	t.Parallel()
+
+	const webhook = "https://hooks.slack.com/services/x"
+	valid := func(conf *alertsConfig, slackWebhook string) func() {
+		return func() { conf.EnsureValid(slackWebhook) }
+	}
+	logSink := map[string]*alertSinkConfig{"log": {Type: alertSinkTypeLog}}
+	require.NotPanics(t, valid(new(alertsConfig), webhook))
+	require.NotPanics(t, valid(&alertsConfig{DefaultSinks: []string{defaultAlertSinkName}}, webhook))
+	require.NotPanics(t, valid(&alertsConfig{Sinks: logSink, DefaultSinks: []string{"log"}}, ""))
+	require.NotPanics(t, valid(&alertsConfig{Sinks: map[string]*alertSinkConfig{"x": {Type: alertSinkTypeSlack}}, DefaultSinks: []string{"x"}}, webhook))
+	require.NotPanics(t, valid(&alertsConfig{Sinks: map[string]*alertSinkConfig{"x": {Type: alertSinkTypeSlack, Webhook: webhook}}, DefaultSinks: []string{"x"}}, ""))
+	require.Panics(t, valid(new(alertsConfig), ""))
+	require.Panics(t, valid(&alertsConfig{Sinks: map[string]*alertSinkConfig{"x": {Type: alertSinkTypeSlack}}, DefaultSinks: []string{"x"}}, ""))
+	require.Panics(t, valid(&alertsConfig{DefaultSinks: []string{"unknown"}}, webhook))
+	require.Panics(t, valid(&alertsConfig{Sinks: logSink, DefaultSinks: []string{defaultAlertSinkName}}, webhook))
+	require.Panics(t, valid(&alertsConfig{Sinks: logSink}, webhook))
+	require.Panics(t, valid(&alertsConfig{Sinks: logSink, Routes: map[alertEvent]*alertRoute{alertEventTransactionStuck: {Sinks: []string{"log"}}}}, webhook))
+	require.Panics(t, valid(&alertsConfig{Sinks: logSink, DefaultSinks: []string{"log"}, Routes: map[alertEvent]*alertRoute{alertEventTransactionStuck: {Sinks: []string{"pager"}}}}, webhook))
+	require.Panics(t, valid(&alertsConfig{Sinks: map[string]*alertSinkConfig{"x": {Type: "pager"}}, DefaultSinks: []string{"x"}}, webhook))
+	require.Panics(t, valid(&alertsConfig{Sinks: map[string]*alertSinkConfig{"x": {Type: alertSinkTypeWebhook}}, DefaultSinks: []string{"x"}}, webhook))
+	require.Panics(t, valid(&alertsConfig{Sinks: map[string]*alertSinkConfig{"x": {Type: alertSinkTypeSMTP, Host: "localhost"}}, DefaultSinks: []string{"x"}}, webhook))
+	require.Panics(t, valid(&alertsConfig{Routes: map[alertEvent]*alertRoute{"unknown": {}}}, webhook))
+	require.Panics(t, valid(&alertsConfig{Routes: map[alertEvent]*alertRoute{alertEventTransactionStuck: {Severity: "fatal"}}}, webhook))
+}
+
+func TestAlertDeduplication(t *testing.T) {
+	t.Parallel()
+
+	recorder, url := newAlertsRecorder(t)
+	conf := &config{Alerts: alertsConfig{
+		Sinks:        map[string]*alertSinkConfig{"webhook": {Type: alertSinkTypeWebhook, URL: url}},
+		DefaultSinks: []string{"webhook"},
+		DedupWindow:  stdlibtime.Hour,
+	}}
+
+	ctx := context.Background()
+	require.NoError(t, conf.sendAlert(ctx, alertEventTransactionStuck, EthereumBlockchainNetworkType, "TestAlertDeduplication 1"))
+	require.NoError(t, conf.sendAlert(ctx, alertEventTransactionStuck, EthereumBlockchainNetworkType, "TestAlertDeduplication 1"))
+	require.NoError(t, conf.sendAlert(ctx, alertEventTransactionStuck, BNBBlockchainNetworkType, "TestAlertDeduplication 1"))
+	require.NoError(t, conf.sendAlert(ctx, alertEventTransactionStuck, EthereumBlockchainNetworkType, "TestAlertDeduplication 2"))
+	require.Len(t, recorder.Alerts(), 3)
+
+	// Failed alerts are not muted, so that they can be retried.
+	recorder.mu.Lock()
+	recorder.status = http.StatusInternalServerError
+	recorder.mu.Unlock()
+	require.Error(t, conf.sendAlert(ctx, alertEventTransactionStuck, EthereumBlockchainNetworkType, "TestAlertDeduplication 3"))
+	require.Error(t, conf.sendAlert(ctx, alertEventTransactionStuck, EthereumBlockchainNetworkType, "TestAlertDeduplication 3"))
+	require.Len(t, recorder.Alerts(), 5)
+}
+
+func TestAlertDeduplicatorMute(t *testing.T) {
+	t.Parallel()
+
+	dedup := &alertDeduplicator{mutedUntil: make(map[string]stdlibtime.Time), mu: new(sync.Mutex)}
+	now := stdlibtime.Now()
+	require.True(t, dedup.Mute("a", 0, now))
+	require.True(t, dedup.Mute("a", 0, now))
+	require.True(t, dedup.Mute("a", stdlibtime.Minute, now))
+	require.False(t, dedup.Mute("a", stdlibtime.Minute, now.Add(59*stdlibtime.Second)))
+	require.True(t, dedup.Mute("a", stdlibtime.Minute, now.Add(stdlibtime.Minute)))
+	dedup.Unmute("a")
+	require.True(t, dedup.Mute("a", stdlibtime.Minute, now.Add(stdlibtime.Minute)))
+
+	for ix := range maxDeduplicatedAlerts {
+		require.True(t, dedup.Mute(string(rune('b'+ix)), stdlibtime.Second, now))
+	}
+	require.True(t, dedup.Mute("z", stdlibtime.Second, now.Add(stdlibtime.Hour)))
+	require.Len(t, dedup.mutedUntil, 1)
+}
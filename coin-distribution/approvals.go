@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package coindistribution
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/wintr/connectors/storage/v2"
+	"github.com/ice-blockchain/wintr/log"
+)
+
+// lockReviewSnapshot makes sure the coin distributions pending review don't change until the transaction ends,
+// and, if expectedSnapshotHash is provided, that they're still the same as the ones the reviewer saw.
+func lockReviewSnapshot(ctx context.Context, conn storage.QueryExecer, expectedSnapshotHash string) error {
+	if _, err := storage.Exec(ctx, conn, "LOCK TABLE coin_distributions_pending_review IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		return errors.Wrap(err, "failed to lock coin_distributions_pending_review")
+	}
+	if expectedSnapshotHash == "" {
+		return nil
+	}
+	snapshot, err := getReviewSnapshot(ctx, conn, "1=1")
+	if err != nil {
+		return err
+	}
+	if snapshot.SnapshotHash != expectedSnapshotHash {
+		return errors.Wrapf(ErrReviewSnapshotChanged, "expected snapshot %v, actual %v", expectedSnapshotHash, snapshot.SnapshotHash)
+	}
+
+	return nil
+}
+
+// getReviewSnapshot hashes everything that matters for the payouts of the coin distributions pending review matching the condition.
+func getReviewSnapshot(ctx context.Context, conn storage.QueryExecer, condition string, args ...any) (*reviewSnapshot, error) {
+	sql := fmt.Sprintf(`SELECT encode(sha256(convert_to(coalesce(string_agg(concat_ws('|', day, user_id, iceflakes, lower(eth_address), network, array_to_string(risk_flags, ',')), ',' ORDER BY day, user_id), ''), 'UTF8')), 'hex') AS snapshot_hash,
+							   count(1) AS rows,
+							   coalesce(sum(ice),0) AS ice
+						FROM coin_distributions_pending_review
+						WHERE %v`, condition)
+	snapshot, err := storage.ExecOne[reviewSnapshot](ctx, conn, sql, args...)
+
+	return snapshot, errors.Wrapf(err, "failed to get the snapshot of coin_distributions_pending_review for %v", condition)
+}
+
+func getRequiredReviewApprovals(ctx context.Context, conn storage.QueryExecer) (uint64, error) {
+	val, err := storage.ExecOne[struct{ Value string }](ctx, conn, "SELECT value FROM global WHERE key = 'coin_review_approval_quorum'")
+	if err != nil {
+		if storage.IsErr(err, storage.ErrNotFound) {
+			return 1, nil
+		}
+
+		return 0, errors.Wrap(err, "failed to get global.coin_review_approval_quorum")
+	}
+	quorum, err := strconv.ParseUint(val.Value, 10, 64)
+	if err != nil {
+		log.Error(errors.Wrapf(err, "invalid global.coin_review_approval_quorum `%v`, using 1", val.Value))
+	}
+	if quorum == 0 {
+		quorum = 1
+	}
+
+	return quorum, nil
+}
+
+// recordReviewApproval records the reviewer's approval of the snapshot of the coin distributions pending review matching the condition.
+// The coin distributions can be moved to be processed only if QuorumReached. Nothing is recorded if there's nothing to approve (Rows is 0).
+func recordReviewApproval(
+	ctx context.Context, conn storage.QueryExecer, reviewerUserID, decision, expectedSnapshotHash, condition string, args ...any,
+) (*reviewApprovals, error) {
+	if err := lockReviewSnapshot(ctx, conn, expectedSnapshotHash); err != nil {
+		return nil, err
+	}
+	snapshot, err := getReviewSnapshot(ctx, conn, condition, args...)
+	if err != nil || snapshot.Rows == 0 {
+		return &reviewApprovals{reviewSnapshot: snapshot}, err
+	}
+	required, err := getRequiredReviewApprovals(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	approvals := &reviewApprovals{reviewSnapshot: snapshot, RequiredApprovals: required, Approvals: 1}
+	if required == 1 {
+		return approvals, nil
+	}
+	sql := `WITH ins AS (
+				INSERT INTO coin_distribution_review_approvals(created_at, snapshot_hash, reviewer_user_id, decision)
+													   VALUES (current_timestamp, $1, $2, $3)
+				ON CONFLICT (snapshot_hash, reviewer_user_id) DO UPDATE
+						SET created_at = EXCLUDED.created_at,
+							decision = EXCLUDED.decision
+				RETURNING reviewer_user_id
+			)
+			SELECT count(1) + 1 AS approvals
+			FROM coin_distribution_review_approvals
+			WHERE snapshot_hash = $1
+			  AND reviewer_user_id != $2`
+	res, err := storage.ExecOne[struct{ Approvals uint64 }](ctx, conn, sql, snapshot.SnapshotHash, reviewerUserID, decision)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to record the approval of %v for snapshot %v", reviewerUserID, snapshot.SnapshotHash)
+	}
+	approvals.Approvals = res.Approvals
+
+	return approvals, nil
+}
+
+func (a *reviewApprovals) QuorumReached() bool {
+	return a.Approvals >= a.RequiredApprovals
+}
+
+func getReviewApprovers(ctx context.Context, conn storage.QueryExecer, snapshotHash string) ([]string, error) {
+	sql := `SELECT reviewer_user_id
+			FROM coin_distribution_review_approvals
+			WHERE snapshot_hash = $1
+			ORDER BY created_at`
+	res, err := storage.ExecMany[struct {
+		ReviewerUserID string `db:"reviewer_user_id"`
+	}](ctx, conn, sql, snapshotHash)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the approvals of snapshot %v", snapshotHash)
+	}
+	approvers := make([]string, 0, len(res))
+	for _, approval := range res {
+		approvers = append(approvers, approval.ReviewerUserID)
+	}
+
+	return approvers, nil
+}
@@ -5,6 +5,7 @@ package coindistribution
 import (
 	"fmt"
 	"math/big"
+	stdlibtime "time"
 
 	"github.com/ethereum/go-ethereum/common"
 
@@ -56,6 +57,17 @@ func (b *batch) Users() []string {
 	return users
 }
 
+// Keys returns the (day, user_id) primary keys of the records, as separate slices.
+func (b *batch) Keys() (days []stdlibtime.Time, users []string) {
+	days, users = make([]stdlibtime.Time, 0, len(b.Records)), make([]string, 0, len(b.Records))
+	for idx := range b.Records {
+		days = append(days, *b.Records[idx].Day.Time)
+		users = append(users, b.Records[idx].UserID)
+	}
+
+	return days, users
+}
+
 func (b *batch) SetStatus(status ethApiStatus) {
 	for idx := range b.Records {
 		b.Records[idx].EthStatus = status
@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package coindistribution
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
+
+	"github.com/ice-blockchain/wintr/log"
+)
+
+func newBatchMetrics(network BlockchainNetworkType) *batchMetrics {
+	const (
+		decayAlpha    = 0.015
+		reservoirSize = 10_000
+	)
+	m := &batchMetrics{
+		Registry: metrics.NewPrefixedRegistry(fmt.Sprintf("coin-distribution.%v.batch.", network)),
+		Size:     metrics.NewHistogram(metrics.NewExpDecaySample(reservoirSize, decayAlpha)),
+		Gas:      metrics.NewHistogram(metrics.NewExpDecaySample(reservoirSize, decayAlpha)),
+		Shrinks:  metrics.NewCounter(),
+	}
+	log.Panic(m.Registry.Register("size", m.Size))       //nolint:revive,nolintlint //.
+	log.Panic(m.Registry.Register("gas", m.Gas))         //nolint:revive,nolintlint //.
+	log.Panic(m.Registry.Register("shrinks", m.Shrinks)) //nolint:revive,nolintlint //.
+
+	return m
+}
+
+func (m *batchMetrics) Printf(format string, args ...any) {
+	log.Info(strings.TrimSuffix(fmt.Sprintf(format, args...), "\n"))
+}
+
+// isGasLimitError reports whether the batch needs more gas than it's allowed to use, so it has to be smaller.
+func isGasLimitError(err error) bool {
+	if errors.Is(err, errBatchGasLimitExceeded) || errors.Is(err, core.ErrGasLimitReached) {
+		return true
+	}
+	// The nodes return these as plain strings: `gas required exceeds allowance (N)` when estimating, `exceeds block gas limit` from the tx pool.
+	for _, msg := range []string{"gas required exceeds allowance", "exceeds block gas limit", core.ErrGasLimitReached.Error()} {
+		if strings.Contains(err.Error(), msg) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BatchSize is the number of records to fetch for the next batch.
+func (proc *coinProcessor) BatchSize() uint64 {
+	maxSize := proc.NetworkConf.maxBatchSize()
+	if proc.batchSize == 0 || proc.batchSize > maxSize {
+		proc.batchSize = maxSize
+	}
+
+	return proc.batchSize
+}
+
+// ShrinkBatchSize makes the next batches smaller, because the current one needs too much gas.
+func (proc *coinProcessor) ShrinkBatchSize(size uint64) {
+	proc.batchSize = max(size, minBatchSize)
+	proc.BatchMetrics.Shrinks.Inc(1)
+	log.Info(fmt.Sprintf("%v: batch size shrunk to %v", proc.Network, proc.batchSize))
+}
+
+// FitBatchToGasLimit estimates the gas the batch needs and drops the records which don't fit the gas limit, back to NEW.
+// The next batches are sized by the gas per record of this one, so they grow back once the records need less gas.
+func (proc *coinProcessor) FitBatchToGasLimit(ctx context.Context, data *batch) error {
+	limit, err := proc.GetGasLimit(ctx)
+	if err != nil {
+		return err
+	}
+	allowed := limit / 100 * batchGasLimitUsagePercent //nolint:gomnd,mnd // .
+	for {
+		recipients, amounts := data.Prepare()
+		gas, eErr := proc.Client.EstimateAirdropGas(ctx, limit, recipients, amounts)
+		if eErr != nil && !isGasLimitError(eErr) {
+			return errors.Wrapf(eErr, "failed to estimate gas of batch %v", data.ID)
+		}
+		size := uint64(len(data.Records))
+		if eErr == nil && gas <= allowed {
+			proc.batchSize = max(min(size*allowed/max(gas, 1), proc.NetworkConf.maxBatchSize()), minBatchSize)
+			proc.BatchMetrics.Size.Update(int64(size))
+			proc.BatchMetrics.Gas.Update(int64(gas))
+			log.Info(fmt.Sprintf("%v: batch %v: %v records, estimated gas %v, gas limit %v, next batch size %v",
+				proc.Network, data.ID, size, gas, limit, proc.batchSize))
+
+			return nil
+		}
+		if size <= minBatchSize {
+			estimated := fmt.Sprint(gas)
+			if eErr != nil {
+				estimated = eErr.Error()
+			}
+			err = errors.Wrapf(errBatchGasLimitExceeded, "batch %v needs more than %v gas, estimated: %v", data.ID, allowed, estimated)
+			log.Error(errors.Wrap(sendEthereumGasLimitTooLowSlackMessage(ctx, proc.Network, err.Error()), "failed to send slack message"))
+
+			return err
+		}
+
+		// The gas per record is known only if the estimation succeeded, otherwise we just try with half of the records.
+		next := size / 2 //nolint:gomnd,mnd // .
+		if eErr == nil {
+			next = size * allowed / gas
+		}
+		proc.ShrinkBatchSize(min(next, size-1))
+		if err = proc.BatchMarkNew(ctx, data.Records[proc.batchSize:]); err != nil {
+			return err
+		}
+		data.Records = data.Records[:proc.batchSize]
+	}
+}
@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package coindistribution
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ice-blockchain/wintr/connectors/storage/v2"
+)
+
+func TestIsGasLimitError(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, isGasLimitError(errors.Wrap(errBatchGasLimitExceeded, "batch")))
+	require.True(t, isGasLimitError(multierror.Append(errClientUncoverable, errors.New("gas required exceeds allowance (30000000)"))))
+	require.True(t, isGasLimitError(errors.New("exceeds block gas limit")))
+	require.False(t, isGasLimitError(errors.New("intrinsic gas too low")))
+	require.False(t, isGasLimitError(errors.New("execution reverted")))
+}
+
+func TestBatchSize(t *testing.T) {
+	t.Parallel()
+
+	proc := newCoinProcessor(nil, nil, &config{}, EthereumBlockchainNetworkType)
+	require.EqualValues(t, defaultMaxBatchSize, proc.BatchSize())
+	proc.ShrinkBatchSize(0)
+	require.EqualValues(t, minBatchSize, proc.BatchSize())
+	require.EqualValues(t, 1, proc.BatchMetrics.Shrinks.Count())
+
+	proc.NetworkConf = &networkConfig{MaxBatchSize: 10}
+	proc.batchSize = 100
+	require.EqualValues(t, 10, proc.BatchSize())
+}
+
+func TestBatchPrepareFetchFitsGasLimit(t *testing.T) { //nolint:paralleltest //.
+	maybeSkipTest(t)
+	ctx := context.TODO()
+	const recipientGas = 1_000_000
+	proc := newCoinProcessor(&mockedDummyEthClient{recipientGas: recipientGas}, storage.MustConnect(ctx, ddl, applicationYamlKey), &config{}, EthereumBlockchainNetworkType)
+	require.NotNil(t, proc)
+	defer proc.Close()
+
+	helperTruncatePendingTransactions(ctx, t, proc.DB)
+	require.NoError(t, databaseSetValue(ctx, proc.DB, networkConfigKey(configKeyCoinDistributerGasLimit, proc.Network), 30_000_000))
+	helperAddNewPendingTransaction(ctx, t, proc, 100)
+
+	b, err := proc.BatchPrepareFetch(ctx)
+	require.NoError(t, err)
+	recipients, _ := b.Prepare()
+	require.LessOrEqual(t, uint64(len(recipients))*recipientGas, uint64(30_000_000/100*batchGasLimitUsagePercent))
+	require.Less(t, len(b.Records), 100)
+	require.Positive(t, proc.BatchMetrics.Shrinks.Count())
+	require.EqualValues(t, 1, proc.BatchMetrics.Size.Count())
+	require.True(t, proc.HasPendingTransactions(ctx, ethApiStatusNew))
+
+	proc.Client = &mockedDummyEthClient{recipientGas: 40_000_000}
+	_, err = proc.BatchPrepareFetch(ctx)
+	require.ErrorIs(t, err, errBatchGasLimitExceeded)
+	require.True(t, proc.HasPendingTransactions(ctx, ethApiStatusNew))
+}
+
+func TestBatchMarkNewPutsBackOnlyTheTrimmedDays(t *testing.T) { //nolint:paralleltest //.
+	maybeSkipTest(t)
+	ctx := context.TODO()
+	proc := newCoinProcessor(nil, storage.MustConnect(ctx, ddl, applicationYamlKey), &config{}, EthereumBlockchainNetworkType)
+	require.NotNil(t, proc)
+	defer proc.Close()
+
+	helperTruncatePendingTransactions(ctx, t, proc.DB)
+	const stmt = `
+INSERT INTO pending_coin_distributions
+	(created_at, day, internal_id, iceflakes, user_id, eth_address)
+VALUES (now() + $1 * interval '1 second', CURRENT_DATE - $1::int, 1, 1000, $2, $3)`
+	// The same user has records on both sides of the cut: the oldest one stays in the batch, the newest one is trimmed.
+	for idx, userID := range []string{"bothSides", "kept", "trimmed", "bothSides"} {
+		_, err := storage.Exec(ctx, proc.DB, stmt, idx, userID, RandStringBytes(16))
+		require.NoError(t, err)
+	}
+	b, err := proc.BatchPrepareFetch(ctx)
+	require.NoError(t, err)
+	require.Len(t, b.Records, 4)
+	sort.Slice(b.Records, func(i, j int) bool { return b.Records[i].CreatedAt.Before(*b.Records[j].CreatedAt.Time) })
+	require.Equal(t, "bothSides", b.Records[0].UserID)
+	require.Equal(t, "bothSides", b.Records[3].UserID)
+
+	require.NoError(t, proc.BatchMarkNew(ctx, b.Records[2:]))
+
+	statuses, err := storage.Select[struct {
+		UserID    string       `db:"user_id"`
+		EthStatus ethApiStatus `db:"eth_status"`
+	}](ctx, proc.DB, `SELECT user_id, eth_status FROM pending_coin_distributions ORDER BY created_at ASC`)
+	require.NoError(t, err)
+	require.Len(t, statuses, 4)
+	for idx, expected := range []ethApiStatus{ethApiStatusPending, ethApiStatusPending, ethApiStatusNew, ethApiStatusNew} {
+		require.Equal(t, expected, statuses[idx].EthStatus, statuses[idx].UserID)
+	}
+}
@@ -14,11 +14,12 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/hashicorp/go-multierror"
@@ -28,12 +29,10 @@ import (
 	"github.com/ice-blockchain/wintr/log"
 )
 
-func mustNewEthClient(ctx context.Context, endpoint, privateKey, contract string) *ethClientImpl {
-	key, err := crypto.HexToECDSA(privateKey)
-	log.Panic(errors.Wrap(err, "failed to parse private key")) //nolint:revive,nolintlint //.
-
+func mustNewEthClient(ctx context.Context, network BlockchainNetworkType, conf *networkConfig) *ethClientImpl {
+	endpoint, contract := conf.RPC, conf.ContractAddress
 	rpcClient, err := ethclient.DialContext(ctx, endpoint)
-	log.Panic(errors.Wrap(err, "failed to connect to ethereum RPC")) //nolint:revive,nolintlint //.
+	log.Panic(errors.Wrapf(err, "failed to connect to %v RPC", network)) //nolint:revive,nolintlint //.
 
 	distributor, err := coindistribution.NewCoindistribution(common.HexToAddress(contract), rpcClient)
 	log.Panic(errors.Wrap(err, "failed to create contract instance")) //nolint:revive,nolintlint //.
@@ -41,12 +40,21 @@ func mustNewEthClient(ctx context.Context, endpoint, privateKey, contract string
 	return &ethClientImpl{
 		RPC:        rpcClient,
 		AirDropper: distributor,
-		Key:        key,
-		Mutex:      new(sync.Mutex),
+		Nonces:     rpcClient,
+		Transfers: &transferEventsReader{
+			Receipts: rpcClient,
+			Token:    &distributor.CoindistributionFilterer,
+			Balances: &distributor.CoindistributionCaller,
+			Contract: common.HexToAddress(contract),
+		},
+		Signer:   mustNewTransactionSigner(ctx, network, conf),
+		Mutex:    new(sync.Mutex),
+		Contract: common.HexToAddress(contract),
+		Network:  network,
 	}
 }
 
-func handleRPCError(ctx context.Context, target error) (retryAfter time.Duration) {
+func handleRPCError(ctx context.Context, network BlockchainNetworkType, target error) (retryAfter time.Duration) {
 	var sysErr *syscall.Errno
 	if errors.As(target, &sysErr) {
 		return time.Second
@@ -68,17 +76,26 @@ func handleRPCError(ctx context.Context, target error) (retryAfter time.Duration
 		return 0
 	}
 
+	if errors.Is(target, errBaseFeeTooHigh) {
+		return time.Minute * 5
+	}
+
 	// We may have two types of errors here:
 	// 1. Errors from ethereum RPC.
 	// 2. Errors from ethereum module (pre validation).
 	// The first type of errors are wrapped (see core.ErrXXX), the second type of errors are not wrapped. Just strings. As is.
 	// So check the second case with HasPrefix() and the first case with errors.Is().
 	if errors.Is(target, core.ErrIntrinsicGas) || strings.HasPrefix(target.Error(), core.ErrIntrinsicGas.Error()) {
-		log.Error(errors.Wrap(sendEthereumGasLimitTooLowSlackMessage(ctx, target.Error()), "failed to send slack message"))
+		log.Error(errors.Wrap(sendEthereumGasLimitTooLowSlackMessage(ctx, network, target.Error()), "failed to send slack message"))
 
 		return time.Minute * 10
 	}
 
+	// The batch has to be made smaller, see FitBatchToGasLimit.
+	if isGasLimitError(target) || strings.Contains(target.Error(), vm.ErrExecutionReverted.Error()) {
+		return 0
+	}
+
 	for _, ethErr := range []error{
 		core.ErrNonceTooLow,
 		core.ErrNonceMax,
@@ -99,7 +116,7 @@ func handleRPCError(ctx context.Context, target error) (retryAfter time.Duration
 	return time.Minute
 }
 
-func maybeRetryRPCRequest[T any](ctx context.Context, fn func() (T, error)) (val T, err error) {
+func maybeRetryRPCRequest[T any](ctx context.Context, network BlockchainNetworkType, fn func() (T, error)) (val T, err error) {
 main:
 	for attempt := 1; ctx.Err() == nil; attempt++ {
 		val, err = fn()
@@ -107,14 +124,14 @@ main:
 			return val, nil
 		}
 
-		retryAfter := handleRPCError(ctx, err)
+		retryAfter := handleRPCError(ctx, network, err)
 		if retryAfter == 0 {
-			log.Error(errors.Wrapf(err, "failed to call ethereum RPC (attempt %v), unrecoverable error", attempt))
+			log.Error(errors.Wrapf(err, "failed to call %v RPC (attempt %v), unrecoverable error", network, attempt))
 
 			return val, multierror.Append(errClientUncoverable, err)
 		}
 
-		log.Error(errors.Wrapf(err, "failed to call ethereum RPC (attempt %v), retrying after %v", attempt, retryAfter.String()))
+		log.Error(errors.Wrapf(err, "failed to call %v RPC (attempt %v), retrying after %v", network, attempt, retryAfter.String()))
 		retryTimer := time.NewTimer(retryAfter)
 		select {
 		case <-ctx.Done():
@@ -131,22 +148,61 @@ main:
 }
 
 func (ec *ethClientImpl) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
-	return maybeRetryRPCRequest(ctx, func() (*big.Int, error) {
+	return maybeRetryRPCRequest(ctx, ec.Network, func() (*big.Int, error) {
 		return ec.RPC.SuggestGasPrice(ctx) //nolint:wrapcheck //.
 	})
 }
 
+func (ec *ethClientImpl) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return maybeRetryRPCRequest(ctx, ec.Network, func() (*big.Int, error) {
+		return ec.RPC.SuggestGasTipCap(ctx) //nolint:wrapcheck //.
+	})
+}
+
+func (ec *ethClientImpl) BaseFee(ctx context.Context) (*big.Int, error) {
+	header, err := maybeRetryRPCRequest(ctx, ec.Network, func() (*types.Header, error) {
+		return ec.RPC.HeaderByNumber(ctx, nil) //nolint:wrapcheck //.
+	})
+	if err != nil {
+		return nil, err
+	} else if header.BaseFee == nil {
+		return nil, errors.Wrapf(core.ErrTxTypeNotSupported, "%v does not support dynamic fee transactions", ec.Network)
+	}
+
+	return header.BaseFee, nil
+}
+
 func (ec *ethClientImpl) AirdropToWallets(opts *bind.TransactOpts, recipients []common.Address, amounts []*big.Int) (*types.Transaction, error) {
 	// The slow zone, we **must** have `nonce` as a linear sequence, **without** gaps.
 	ec.Mutex.Lock()
 	defer ec.Mutex.Unlock()
 
+	// Replacements come with the nonce of the transaction they replace, they don't affect the sequence.
+	replacement := opts.Nonce != nil
+	if !replacement {
+		nonce, err := ec.NextNonce(opts.Context, opts.From)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get next nonce")
+		}
+		opts.Nonce = new(big.Int).SetUint64(nonce)
+	}
+
 	tx, err := ec.AirDropper.AirdropToWallets(opts, recipients, amounts)
+	if err != nil {
+		// We can't be sure whether the transaction was broadcast or not, so the next nonce is fetched from the node again.
+		ec.nextNonce = nil
+	} else if !replacement {
+		nextNonce := tx.Nonce() + 1
+		ec.nextNonce = &nextNonce
+	}
 	if err == nil && opts.Context.Err() == nil {
-		log.Info(fmt.Sprintf("airdropper: new transaction: %v | nonce %v | gas %v | cost %v | limit %v | recipients %v",
+		log.Info(fmt.Sprintf("%v: airdropper: new transaction: %v | type %v | nonce %v | gas %v | tip %v | cost %v | limit %v | recipients %v",
+			ec.Network,
 			tx.Hash().String(),
+			tx.Type(),
 			tx.Nonce(),
 			tx.GasPrice().String(),
+			tx.GasTipCap().String(),
 			tx.Cost().String(),
 			tx.Gas(),
 			len(recipients),
@@ -156,25 +212,101 @@ func (ec *ethClientImpl) AirdropToWallets(opts *bind.TransactOpts, recipients []
 	return tx, err //nolint:wrapcheck //.
 }
 
-func (ec *ethClientImpl) CreateTransactionOpts(ctx context.Context, gasPrice, chanID *big.Int, gasLimit uint64) *bind.TransactOpts {
-	opts, err := bind.NewKeyedTransactorWithChainID(ec.Key, chanID)
-	log.Panic(errors.Wrap(err, "failed to create transaction options")) //nolint:revive,nolintlint //.
-	opts.Context = ctx
-	opts.Value = big.NewInt(0)
-	opts.GasLimit = gasLimit
-	opts.GasPrice = gasPrice
+// NextNonce must be called with Mutex held.
+func (ec *ethClientImpl) NextNonce(ctx context.Context, account common.Address) (uint64, error) {
+	if ec.nextNonce != nil {
+		return *ec.nextNonce, nil
+	}
+
+	return ec.Nonces.PendingNonceAt(ctx, account) //nolint:wrapcheck //.
+}
+
+func (ec *ethClientImpl) CreateTransactionOpts(ctx context.Context, gas *gasOptions, chanID *big.Int) *bind.TransactOpts {
+	opts := &bind.TransactOpts{
+		From: ec.Signer.Address(),
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != ec.Signer.Address() {
+				return nil, bind.ErrNotAuthorized
+			}
+
+			return ec.Signer.SignTx(ctx, tx, chanID)
+		},
+		Context: ctx,
+		Value:   big.NewInt(0),
+	}
+	opts.GasLimit = gas.Limit
+	// The bound contract sends a legacy transaction if GasPrice is set, and a dynamic fee (type 2) one otherwise.
+	if gas.Price != nil {
+		opts.GasPrice = gas.Price
+	} else {
+		opts.GasFeeCap = gas.FeeCap
+		opts.GasTipCap = gas.TipCap
+	}
 
 	return opts
 }
 
-func (ec *ethClientImpl) Airdrop(ctx context.Context, chanID *big.Int, gas gasGetter, recipients []common.Address, amounts []*big.Int) (string, error) {
+func (ec *ethClientImpl) Airdrop(
+	ctx context.Context, chanID *big.Int, gas gasGetter, recipients []common.Address, amounts []*big.Int,
+) (hash string, nonce uint64, err error) {
+	fn := func() (*types.Transaction, error) {
+		gasOpts, err := gas.GetGasOptions(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get gas options")
+		}
+
+		opts := ec.CreateTransactionOpts(ctx, gasOpts, chanID)
+
+		return ec.AirdropToWallets(opts, recipients, amounts)
+	}
+
+	tx, err := maybeRetryRPCRequest(ctx, ec.Network, fn)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return tx.Hash().String(), tx.Nonce(), nil
+}
+
+// EstimateAirdropGas estimates the gas of `airdropToWallets`, capped by gasLimit: the node fails if it needs more.
+func (ec *ethClientImpl) EstimateAirdropGas(ctx context.Context, gasLimit uint64, recipients []common.Address, amounts []*big.Int) (uint64, error) {
+	parsed, err := coindistribution.CoindistributionMetaData.GetAbi()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to parse contract ABI")
+	}
+	data, err := parsed.Pack("airdropToWallets", recipients, amounts)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to pack airdropToWallets call")
+	}
+	msg := ethereum.CallMsg{From: ec.Signer.Address(), To: &ec.Contract, Gas: gasLimit, Data: data}
+
+	return maybeRetryRPCRequest(ctx, ec.Network, func() (uint64, error) {
+		return ec.RPC.EstimateGas(ctx, msg) //nolint:wrapcheck //.
+	})
+}
+
+func (ec *ethClientImpl) ReplaceAirdrop(
+	ctx context.Context, chanID *big.Int, gas gasGetter, replaced *replacedTransaction, recipients []common.Address, amounts []*big.Int,
+) (string, error) {
 	fn := func() (string, error) {
-		gasPrice, gasLimit, err := gas.GetGasOptions(ctx)
+		gasOpts, err := gas.GetGasOptions(ctx)
 		if err != nil {
 			return "", errors.Wrap(err, "failed to get gas options")
 		}
 
-		opts := ec.CreateTransactionOpts(ctx, gasPrice, chanID, gasLimit)
+		prev, pending, err := ec.RPC.TransactionByHash(ctx, common.HexToHash(replaced.Hash))
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
+			return "", err //nolint:wrapcheck //.
+		} else if err == nil && !pending {
+			return "", errors.Wrapf(core.ErrNonceTooLow, "transaction %v is already mined", replaced.Hash)
+		}
+		// If the node doesn't know about the previous transaction anymore, there's nothing to outbid.
+		if err == nil {
+			bumpGasOptions(gasOpts, prev, replaced.BumpPercent)
+		}
+
+		opts := ec.CreateTransactionOpts(ctx, gasOpts, chanID)
+		opts.Nonce = new(big.Int).SetUint64(replaced.Nonce)
 		tx, err := ec.AirdropToWallets(opts, recipients, amounts)
 		if err != nil {
 			return "", err
@@ -183,11 +315,85 @@ func (ec *ethClientImpl) Airdrop(ctx context.Context, chanID *big.Int, gas gasGe
 		return tx.Hash().String(), nil
 	}
 
-	return maybeRetryRPCRequest(ctx, fn)
+	return maybeRetryRPCRequest(ctx, ec.Network, fn)
+}
+
+// bumpGasOptions makes sure the fees are at least percent higher than the ones of prev, so that nodes accept the replacement.
+func bumpGasOptions(opts *gasOptions, prev *types.Transaction, percent uint64) {
+	percent = max(percent, minTransactionReplacementFeeBumpPercent)
+	bump := func(current, previous *big.Int) *big.Int {
+		const hundred = 100
+		bumped := new(big.Int).Mul(previous, new(big.Int).SetUint64(hundred+percent))
+		bumped.Add(bumped, big.NewInt(hundred-1)).Div(bumped, big.NewInt(hundred))
+		if current == nil || current.Cmp(bumped) < 0 {
+			return bumped
+		}
+
+		return current
+	}
+
+	if opts.Price != nil {
+		opts.Price = bump(opts.Price, prev.GasPrice())
+
+		return
+	}
+	opts.FeeCap = bump(opts.FeeCap, prev.GasFeeCap())
+	opts.TipCap = bump(opts.TipCap, prev.GasTipCap())
+}
+
+// PublishMerkleRoot calls `addMerkleRoot(bytes32)` of the claim contract, sharing the nonce sequence with the airdrops.
+func (ec *ethClientImpl) PublishMerkleRoot(
+	ctx context.Context, chanID *big.Int, gas gasGetter, contract common.Address, root common.Hash,
+) (string, error) {
+	parsed, err := abi.JSON(strings.NewReader(claimContractABI))
+	log.Panic(errors.Wrap(err, "failed to parse claim contract ABI")) //nolint:revive,nolintlint //.
+	claim := bind.NewBoundContract(contract, parsed, ec.RPC, ec.RPC, ec.RPC)
+
+	fn := func() (*types.Transaction, error) {
+		gasOpts, err := gas.GetGasOptions(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get gas options")
+		}
+		opts := ec.CreateTransactionOpts(ctx, gasOpts, chanID)
+
+		ec.Mutex.Lock()
+		defer ec.Mutex.Unlock()
+
+		nonce, err := ec.NextNonce(ctx, opts.From)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get next nonce")
+		}
+		opts.Nonce = new(big.Int).SetUint64(nonce)
+		tx, err := claim.Transact(opts, "addMerkleRoot", root)
+		if err != nil {
+			ec.nextNonce = nil
+
+			return nil, err //nolint:wrapcheck //.
+		}
+		nextNonce := tx.Nonce() + 1
+		ec.nextNonce = &nextNonce
+
+		return tx, nil
+	}
+
+	tx, err := maybeRetryRPCRequest(ctx, ec.Network, fn)
+	if err != nil {
+		return "", err
+	}
+
+	return tx.Hash().String(), nil
+}
+
+func (ec *ethClientImpl) TransferEvents(
+	ctx context.Context, hash string, recipients []common.Address,
+) ([]*coindistribution.CoindistributionTransfer, error) {
+	return maybeRetryRPCRequest(ctx, ec.Network, func() ([]*coindistribution.CoindistributionTransfer, error) {
+		return ec.Transfers.TransferEvents(ctx, hash, recipients)
+	})
 }
 
 func (ec *ethClientImpl) TransactionStatus(ctx context.Context, hash string) (ethTxStatus, error) {
-	return maybeRetryRPCRequest(ctx, func() (ethTxStatus, error) {
+	return maybeRetryRPCRequest(ctx, ec.Network, func() (ethTxStatus, error) {
 		receipt, err := ec.RPC.TransactionReceipt(ctx, common.HexToHash(hash))
 		if err != nil {
 			if errors.Is(err, ethereum.NotFound) {
@@ -216,7 +422,7 @@ func (ec *ethClientImpl) TransactionsStatus(ctx context.Context, hashes []*strin
 		}
 	}
 
-	if _, batchErr := maybeRetryRPCRequest(ctx, func() (bool, error) {
+	if _, batchErr := maybeRetryRPCRequest(ctx, ec.Network, func() (bool, error) {
 		return true, ec.RPC.Client().BatchCallContext(ctx, elements) //nolint:wrapcheck //.
 	}); batchErr != nil {
 		return nil, batchErr
@@ -247,5 +453,5 @@ func (ec *ethClientImpl) TransactionsStatus(ctx context.Context, hashes []*strin
 func (ec *ethClientImpl) Close() error {
 	ec.RPC.Close()
 
-	return nil
+	return errors.Wrapf(ec.Signer.Close(), "failed to close %v signer", ec.Network)
 }
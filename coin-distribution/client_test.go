@@ -15,9 +15,9 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/stretchr/testify/require"
 
+	coindistribution "github.com/ice-blockchain/freezer/coin-distribution/internal"
 	"github.com/ice-blockchain/wintr/log"
 )
 
@@ -26,6 +26,9 @@ type (
 		dropErr error
 		txErr   map[string]error
 		gas     int64
+		nonce   uint64
+		// The estimated gas of each recipient of an airdrop.
+		recipientGas uint64
 	}
 	mockedAirDropper struct {
 		errBefore int
@@ -33,6 +36,9 @@ type (
 	mockedGasGetter struct {
 		val int64
 	}
+	mockedNonceSource struct {
+		calls int
+	}
 )
 
 func (m *mockedDummyEthClient) SuggestGasPrice(context.Context) (*big.Int, error) {
@@ -45,7 +51,45 @@ func (m *mockedDummyEthClient) SuggestGasPrice(context.Context) (*big.Int, error
 	return big.NewInt(m.gas), nil
 }
 
-func (m *mockedDummyEthClient) Airdrop(context.Context, *big.Int, gasGetter, []common.Address, []*big.Int) (string, error) {
+func (*mockedDummyEthClient) SuggestGasTipCap(context.Context) (*big.Int, error) {
+	return big.NewInt(rand.Int63n(1_000) + 1), nil //nolint:gosec //.
+}
+
+func (*mockedDummyEthClient) BaseFee(context.Context) (*big.Int, error) {
+	return big.NewInt(rand.Int63n(10_000) + 1), nil //nolint:gosec //.
+}
+
+func (m *mockedDummyEthClient) Airdrop(context.Context, *big.Int, gasGetter, []common.Address, []*big.Int) (string, uint64, error) {
+	if m.dropErr != nil {
+		return "", 0, m.dropErr
+	}
+	m.nonce++
+
+	return fmt.Sprintf("%10d", rand.Int63n(10_000_000_000)), m.nonce - 1, nil //nolint:gosec //.
+}
+
+func (m *mockedDummyEthClient) EstimateAirdropGas(_ context.Context, gasLimit uint64, recipients []common.Address, _ []*big.Int) (uint64, error) {
+	gas := uint64(len(recipients)) * m.recipientGas
+	if gas > gasLimit {
+		return 0, fmt.Errorf("gas required exceeds allowance (%v)", gasLimit) //nolint:goerr113 //.
+	}
+
+	return gas, nil
+}
+
+func (m *mockedDummyEthClient) ReplaceAirdrop(context.Context, *big.Int, gasGetter, *replacedTransaction, []common.Address, []*big.Int) (string, error) {
+	if m.dropErr != nil {
+		return "", m.dropErr
+	}
+
+	return fmt.Sprintf("%10d", rand.Int63n(10_000_000_000)), nil //nolint:gosec //.
+}
+
+func (*mockedDummyEthClient) TransferEvents(context.Context, string, []common.Address) ([]*coindistribution.CoindistributionTransfer, error) {
+	return nil, nil
+}
+
+func (m *mockedDummyEthClient) PublishMerkleRoot(context.Context, *big.Int, gasGetter, common.Address, common.Hash) (string, error) {
 	if m.dropErr != nil {
 		return "", m.dropErr
 	}
@@ -81,7 +125,7 @@ func (m *mockedAirDropper) AirdropToWallets(opts *bind.TransactOpts, _ []common.
 	log.Info(fmt.Sprintf("airdropper: gas price %v, limit %v", opts.GasPrice.String(), opts.GasLimit))
 
 	return types.NewTransaction(
-			0,
+			opts.Nonce.Uint64(),
 			common.HexToAddress("095e7baea6a6c7c4c2dfeb977efac326af552d87"),
 			big.NewInt(0),
 			0,
@@ -91,12 +135,18 @@ func (m *mockedAirDropper) AirdropToWallets(opts *bind.TransactOpts, _ []common.
 		nil
 }
 
-func (m *mockedGasGetter) GetGasOptions(context.Context) (*big.Int, uint64, error) {
+func (m *mockedNonceSource) PendingNonceAt(context.Context, common.Address) (uint64, error) {
+	m.calls++
+
+	return 0, nil
+}
+
+func (m *mockedGasGetter) GetGasOptions(context.Context) (*gasOptions, error) {
 	m.val++
 
 	log.Info(fmt.Sprintf("gas getter: %v", m.val))
 
-	return big.NewInt(m.val), uint64(m.val), nil
+	return &gasOptions{Price: big.NewInt(m.val), Limit: uint64(m.val)}, nil
 }
 
 func TestGasPriceUpdateDuringRetry(t *testing.T) {
@@ -104,20 +154,98 @@ func TestGasPriceUpdateDuringRetry(t *testing.T) {
 
 	const errCount = 3
 
-	privateKey, err := crypto.GenerateKey()
+	signer, err := newLocalSigner()
 	require.NoError(t, err)
 
 	dropper := &mockedAirDropper{errBefore: errCount}
 
 	impl := new(ethClientImpl)
 	impl.Mutex = new(sync.Mutex)
-	impl.Key = privateKey
+	impl.Signer = signer
 	impl.AirDropper = dropper
+	impl.Nonces = new(mockedNonceSource)
 	gasGetter := new(mockedGasGetter)
 
-	_, err = impl.Airdrop(context.TODO(), big.NewInt(1), gasGetter, []common.Address{{1}}, []*big.Int{big.NewInt(1)})
+	_, _, err = impl.Airdrop(context.TODO(), big.NewInt(1), gasGetter, []common.Address{{1}}, []*big.Int{big.NewInt(1)})
 	require.NoError(t, err)
 
 	require.Zero(t, dropper.errBefore)
 	require.Equal(t, errCount+1, int(gasGetter.val))
 }
+
+func TestCreateTransactionOpts(t *testing.T) {
+	t.Parallel()
+
+	signer, err := newLocalSigner()
+	require.NoError(t, err)
+
+	impl := &ethClientImpl{Signer: signer, Mutex: new(sync.Mutex)}
+
+	legacy := impl.CreateTransactionOpts(context.TODO(), &gasOptions{Price: big.NewInt(10), Limit: 100}, big.NewInt(1))
+	require.Equal(t, big.NewInt(10), legacy.GasPrice)
+	require.Nil(t, legacy.GasFeeCap)
+	require.Nil(t, legacy.GasTipCap)
+	require.Equal(t, uint64(100), legacy.GasLimit)
+
+	dynamic := impl.CreateTransactionOpts(context.TODO(), &gasOptions{FeeCap: big.NewInt(20), TipCap: big.NewInt(2), Limit: 100}, big.NewInt(1))
+	require.Nil(t, dynamic.GasPrice)
+	require.Equal(t, big.NewInt(20), dynamic.GasFeeCap)
+	require.Equal(t, big.NewInt(2), dynamic.GasTipCap)
+	require.Equal(t, uint64(100), dynamic.GasLimit)
+}
+
+func TestNonceTracking(t *testing.T) {
+	t.Parallel()
+
+	signer, err := newLocalSigner()
+	require.NoError(t, err)
+
+	dropper := new(mockedAirDropper)
+	nonces := new(mockedNonceSource)
+	impl := &ethClientImpl{Signer: signer, Mutex: new(sync.Mutex), AirDropper: dropper, Nonces: nonces}
+	gasGetter := new(mockedGasGetter)
+	recipients, amounts := []common.Address{{1}}, []*big.Int{big.NewInt(1)}
+
+	for expected := range uint64(3) {
+		_, nonce, aErr := impl.Airdrop(context.TODO(), big.NewInt(1), gasGetter, recipients, amounts)
+		require.NoError(t, aErr)
+		require.Equal(t, expected, nonce)
+	}
+	require.Equal(t, 1, nonces.calls)
+
+	opts := impl.CreateTransactionOpts(context.TODO(), &gasOptions{Price: big.NewInt(1)}, big.NewInt(1))
+	opts.Nonce = big.NewInt(1)
+	tx, err := impl.AirdropToWallets(opts, recipients, amounts)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), tx.Nonce())
+	require.Equal(t, uint64(3), *impl.nextNonce)
+
+	dropper.errBefore = 1
+	_, err = impl.AirdropToWallets(impl.CreateTransactionOpts(context.TODO(), &gasOptions{Price: big.NewInt(1)}, big.NewInt(1)), recipients, amounts)
+	require.Error(t, err)
+	require.Nil(t, impl.nextNonce)
+
+	_, nonce, err := impl.Airdrop(context.TODO(), big.NewInt(1), gasGetter, recipients, amounts)
+	require.NoError(t, err)
+	require.Zero(t, nonce)
+	require.Equal(t, 2, nonces.calls)
+}
+
+func TestBumpGasOptions(t *testing.T) {
+	t.Parallel()
+
+	prev := types.NewTransaction(0, common.Address{}, big.NewInt(0), 0, big.NewInt(100), nil)
+	opts := &gasOptions{Price: big.NewInt(50)}
+	bumpGasOptions(opts, prev, 0)
+	require.Equal(t, big.NewInt(110), opts.Price)
+
+	opts = &gasOptions{Price: big.NewInt(500)}
+	bumpGasOptions(opts, prev, 20)
+	require.Equal(t, big.NewInt(500), opts.Price)
+
+	prev = types.NewTx(&types.DynamicFeeTx{GasFeeCap: big.NewInt(201), GasTipCap: big.NewInt(3)})
+	opts = &gasOptions{FeeCap: big.NewInt(150), TipCap: big.NewInt(10)}
+	bumpGasOptions(opts, prev, 25)
+	require.Equal(t, big.NewInt(252), opts.FeeCap)
+	require.Equal(t, big.NewInt(10), opts.TipCap)
+}
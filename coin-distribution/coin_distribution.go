@@ -26,7 +26,7 @@ func (d *databaseConfig) MustDisable(reason string) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), requestDeadline)
 	defer cancel()
-	log.Error(sendCoinDistributionsProcessingStoppedDueToUnrecoverableFailureSlackMessage(ctx, reason),
+	log.Error(sendCoinDistributionsProcessingStoppedDueToUnrecoverableFailureSlackMessage(ctx, d.Network, reason),
 		"failed to sendCoinDistributionsProcessingStoppedDueToUnrecoverableFailureSlackMessage")
 }
 
@@ -88,13 +88,37 @@ func databaseGetValue[T bool | constraints.Integer | time.Time](ctx context.Cont
 }
 
 func (d *databaseConfig) GetGasLimit(ctx context.Context) (val uint64, err error) {
-	err = databaseGetValue(ctx, d.DB, configKeyCoinDistributerGasLimit, &val)
+	err = databaseGetValue(ctx, d.DB, networkConfigKey(configKeyCoinDistributerGasLimit, d.Network), &val)
 
 	return val, err
 }
 
 func (d *databaseConfig) GetGasPriceOverride(ctx context.Context) (val uint64, err error) {
-	err = databaseGetValue(ctx, d.DB, configKeyCoinDistributerGasPrice, &val)
+	err = databaseGetValue(ctx, d.DB, networkConfigKey(configKeyCoinDistributerGasPrice, d.Network), &val)
+
+	return val, err
+}
+
+func (d *databaseConfig) IsDynamicFeesEnabled(ctx context.Context) (val bool, err error) {
+	err = databaseGetValue(ctx, d.DB, networkConfigKey(configKeyCoinDistributerDynamicFees, d.Network), &val)
+
+	return val, err
+}
+
+func (d *databaseConfig) GetGasTipCapOverride(ctx context.Context) (val uint64, err error) {
+	err = databaseGetValue(ctx, d.DB, networkConfigKey(configKeyCoinDistributerGasTipCap, d.Network), &val)
+
+	return val, err
+}
+
+func (d *databaseConfig) GetGasFeeCapLimit(ctx context.Context) (val uint64, err error) {
+	err = databaseGetValue(ctx, d.DB, networkConfigKey(configKeyCoinDistributerGasFeeCap, d.Network), &val)
+
+	return val, err
+}
+
+func (d *databaseConfig) GetBaseFeeCeiling(ctx context.Context) (val uint64, err error) {
+	err = databaseGetValue(ctx, d.DB, networkConfigKey(configKeyCoinDistributerBaseFeeMax, d.Network), &val)
 
 	return val, err
 }
@@ -106,13 +130,13 @@ func (d *databaseConfig) IsEnabled(ctx context.Context) (val bool) {
 }
 
 func (d *databaseConfig) IsOnDemandMode(ctx context.Context) (val bool) {
-	log.Error(databaseGetValue(ctx, d.DB, configKeyCoinDistributerOnDemand, &val), "failed to databaseGetValue")
+	log.Error(databaseGetValue(ctx, d.DB, networkConfigKey(configKeyCoinDistributerOnDemand, d.Network), &val), "failed to databaseGetValue")
 
 	return val
 }
 
 func (d *databaseConfig) DisableOnDemand(ctx context.Context) error {
-	return databaseSetValue(ctx, d.DB, configKeyCoinDistributerOnDemand, false)
+	return databaseSetValue(ctx, d.DB, networkConfigKey(configKeyCoinDistributerOnDemand, d.Network), false)
 }
 
 func (d *databaseConfig) Disable(ctx context.Context) error {
@@ -123,7 +147,7 @@ func (d *databaseConfig) HasPendingTransactions(ctx context.Context, status ethA
 	reqCtx, cancel := context.WithTimeout(ctx, requestDeadline)
 	defer cancel()
 
-	val, err := storage.ExecOne[bool](reqCtx, d.DB, `SELECT true FROM pending_coin_distributions where eth_status = $1 limit 1`, status)
+	val, err := storage.ExecOne[bool](reqCtx, d.DB, `SELECT true FROM pending_coin_distributions where eth_status = $1 and network = $2 limit 1`, status, d.Network)
 	if err != nil {
 		if errors.Is(err, storage.ErrNotFound) {
 			err = nil
@@ -142,9 +166,13 @@ func init() {
 
 func MustStartCoinDistribution(ctx context.Context, _ context.CancelFunc) Client {
 	cfg.EnsureValid()
-	eth := mustNewEthClient(ctx, cfg.Ethereum.RPC, cfg.Ethereum.PrivateKey, cfg.Ethereum.ContractAddress)
+	networks := cfg.networks()
+	ethClients := make(map[BlockchainNetworkType]ethClient, len(networks))
+	for network, conf := range networks {
+		ethClients[network] = mustNewEthClient(ctx, network, conf)
+	}
 
-	cd := mustCreateCoinDistributionFromConfig(ctx, &cfg, eth)
+	cd := mustCreateCoinDistributionFromConfig(ctx, &cfg, ethClients)
 	cd.MustStart(ctx, nil)
 
 	go startPrepareCoinDistributionsForReviewMonitor(ctx, cd.DB)
@@ -152,29 +180,56 @@ func MustStartCoinDistribution(ctx context.Context, _ context.CancelFunc) Client
 	return cd
 }
 
-func mustCreateCoinDistributionFromConfig(ctx context.Context, conf *config, ethClient ethClient) *coinDistributer {
+func mustCreateCoinDistributionFromConfig(ctx context.Context, conf *config, ethClients map[BlockchainNetworkType]ethClient) *coinDistributer {
 	db := storage.MustConnect(ctx, ddl, applicationYamlKey)
 	cd := &coinDistributer{
-		Client:    ethClient,
-		Processor: newCoinProcessor(ethClient, db, conf),
-		DB:        db,
+		Processors: make(map[BlockchainNetworkType]*coinProcessor, len(ethClients)),
+		DB:         db,
+	}
+	for network, ethClient := range ethClients {
+		cd.Processors[network] = newCoinProcessor(ethClient, db, conf, network)
 	}
 
 	return cd
 }
 
 func (cd *coinDistributer) MustStart(ctx context.Context, notifyProcessed chan<- *batch) {
-	cd.Processor.Start(ctx, notifyProcessed)
+	for _, proc := range cd.Processors {
+		proc.Start(ctx, notifyProcessed)
+	}
 }
 
 func (cd *coinDistributer) Close() error {
+	var mErr *multierror.Error
+	for network, proc := range cd.Processors {
+		mErr = multierror.Append(mErr,
+			errors.Wrapf(proc.Close(), "failed to close %v processor", network),
+			errors.Wrapf(proc.Client.Close(), "failed to close %v eth client", network),
+		)
+	}
+
 	return multierror.Append( //nolint:wrapcheck //.
-		errors.Wrap(cd.Processor.Close(), "failed to close processor"),
-		errors.Wrap(cd.Client.Close(), "failed to close eth client"),
+		mErr,
 		errors.Wrap(cd.DB.Close(), "failed to close db"),
 	).ErrorOrNil()
 }
 
 func (cd *coinDistributer) CheckHealth(ctx context.Context) error {
-	return errors.Wrap(cd.DB.Ping(ctx), "[health-check] failed to ping DB")
+	if err := cd.DB.Ping(ctx); err != nil {
+		return errors.Wrap(err, "[health-check] failed to ping DB")
+	}
+	windows, err := cd.NextScheduledWindows(ctx)
+	if err != nil {
+		return errors.Wrap(err, "[health-check] failed to get the scheduled windows")
+	}
+	for network, window := range windows {
+		if window == nil {
+			log.Info(fmt.Sprintf("[health-check] %v: no scheduled window", network))
+
+			continue
+		}
+		log.Info(fmt.Sprintf("[health-check] %v: next scheduled window: %v - %v", network, window.Start.Format(stdlibtime.RFC3339), window.End.Format(stdlibtime.RFC3339)))
+	}
+
+	return nil
 }
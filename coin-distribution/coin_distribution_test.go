@@ -37,16 +37,16 @@ func TestFullCoinDistribution(t *testing.T) { //nolint:paralleltest,funlen //.
 		t.Skip("skip full coin distribution test")
 	}
 
-	cl := mustNewEthClient(context.TODO(), rpc, privateKey, contractAddr)
-	require.NotNil(t, cl)
-	defer cl.Close()
-
 	conf := new(config)
 	conf.Ethereum.ContractAddress = contractAddr
 	conf.Ethereum.ChainID = 97
 	conf.Ethereum.RPC = rpc
 	conf.Ethereum.PrivateKey = privateKey
 
+	cl := mustNewEthClient(context.TODO(), EthereumBlockchainNetworkType, &conf.Ethereum)
+	require.NotNil(t, cl)
+	defer cl.Close()
+
 	t.Run("AddPendingEntry", func(t *testing.T) {
 		db := storage.MustConnect(context.TODO(), ddl, applicationYamlKey)
 		defer db.Close()
@@ -64,7 +64,7 @@ func TestFullCoinDistribution(t *testing.T) { //nolint:paralleltest,funlen //.
 		require.NoError(t, err)
 	})
 
-	cd := mustCreateCoinDistributionFromConfig(context.TODO(), conf, cl)
+	cd := mustCreateCoinDistributionFromConfig(context.TODO(), conf, map[BlockchainNetworkType]ethClient{EthereumBlockchainNetworkType: cl})
 	require.NotNil(t, cd)
 	defer cd.Close()
 
@@ -141,7 +141,7 @@ func TestCoinDistributionWaitOK(t *testing.T) { //nolint:paralleltest,funlen //.
 		require.NoError(t, err)
 	})
 
-	cd := mustCreateCoinDistributionFromConfig(context.TODO(), conf, cl)
+	cd := mustCreateCoinDistributionFromConfig(context.TODO(), conf, map[BlockchainNetworkType]ethClient{EthereumBlockchainNetworkType: cl})
 	require.NotNil(t, cd)
 	defer cd.Close()
 
@@ -185,7 +185,7 @@ func TestCoinDistributionWaitFailed(t *testing.T) { //nolint:paralleltest,funlen
 		require.NoError(t, err)
 	})
 
-	cd := mustCreateCoinDistributionFromConfig(context.TODO(), conf, cl)
+	cd := mustCreateCoinDistributionFromConfig(context.TODO(), conf, map[BlockchainNetworkType]ethClient{EthereumBlockchainNetworkType: cl})
 	require.NotNil(t, cd)
 	defer cd.Close()
 
@@ -199,5 +199,5 @@ func TestCoinDistributionWaitFailed(t *testing.T) { //nolint:paralleltest,funlen
 	require.Equal(t, testTxFailed, processedBatch.TX)
 	require.Len(t, processedBatch.Records, 1)
 	require.Equal(t, testTxFailed, *processedBatch.Records[0].EthTX)
-	require.False(t, cd.Processor.IsEnabled(context.TODO()))
+	require.False(t, cd.Processors[EthereumBlockchainNetworkType].IsEnabled(context.TODO()))
 }
@@ -3,26 +3,98 @@
 package coindistribution
 
 import (
-	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/pkg/errors"
+	"fmt"
 
 	"github.com/ice-blockchain/wintr/log"
 )
 
 func (cfg *config) EnsureValid() {
-	if cfg.Ethereum.ChainID == 0 {
-		log.Panic("ethereum.chainID must be > 0")
+	networks := cfg.networks()
+	if len(networks) == 0 {
+		log.Panic("at least one network must be configured")
 	}
-	if cfg.Ethereum.RPC == "" {
-		log.Panic("ethereum.rpc must not be empty")
+	for network, conf := range networks {
+		switch network {
+		case ArbitrumBlockchainNetworkType, BNBBlockchainNetworkType, EthereumBlockchainNetworkType:
+		default:
+			log.Panic(fmt.Sprintf("unsupported network `%v`", network))
+		}
+		conf.EnsureValid(network, cfg.Development)
 	}
-	if cfg.Ethereum.PrivateKey == "" {
-		log.Panic("ethereum.privateKey must not be empty")
+	cfg.Alerts.EnsureValid(cfg.AlertSlackWebhook)
+}
+
+func (n *networkConfig) EnsureValid(network BlockchainNetworkType, development bool) {
+	if n.ChainID == 0 {
+		log.Panic(fmt.Sprintf("%v.chainID must be > 0", network))
+	}
+	if n.RPC == "" {
+		log.Panic(fmt.Sprintf("%v.rpc must not be empty", network))
+	}
+	n.Signer.EnsureValid(network, n.PrivateKey, development)
+
+	if n.ContractAddress == "" {
+		log.Panic(fmt.Sprintf("%v.contractAddress must not be empty", network))
+	}
+	switch n.DistributionMode {
+	case "", airdropDistributionMode:
+	case merkleClaimDistributionMode:
+		if n.ClaimContractAddress == "" {
+			log.Panic(fmt.Sprintf("%v.claimContractAddress must not be empty in %v mode", network, merkleClaimDistributionMode))
+		}
+	default:
+		log.Panic(fmt.Sprintf("%v.distributionMode `%v` is not supported", network, n.DistributionMode))
+	}
+}
+
+// networks returns all the configured networks, including the legacy `ethereum` section, if it's configured and not overridden by `networks.ethereum`.
+func (cfg *config) networks() map[BlockchainNetworkType]*networkConfig {
+	networks := make(map[BlockchainNetworkType]*networkConfig, len(cfg.Networks)+1)
+	for network, conf := range cfg.Networks {
+		if conf != nil {
+			networks[network] = conf
+		}
+	}
+	if _, found := networks[EthereumBlockchainNetworkType]; !found && cfg.Ethereum.RPC != "" {
+		networks[EthereumBlockchainNetworkType] = &cfg.Ethereum
+	}
+
+	return networks
+}
+
+func (cfg *config) network(network BlockchainNetworkType) *networkConfig {
+	if conf := cfg.Networks[network]; conf != nil {
+		return conf
+	}
+	if network == EthereumBlockchainNetworkType {
+		return &cfg.Ethereum
 	}
-	_, err := crypto.HexToECDSA(cfg.Ethereum.PrivateKey)
-	log.Panic(errors.Wrap(err, "ethereum.privateKey is invalid")) //nolint:revive,nolintlint //.
 
-	if cfg.Ethereum.ContractAddress == "" {
-		log.Panic("ethereum.contractAddress must not be empty")
+	return new(networkConfig)
+}
+
+func (cfg *config) alertSlackWebhook(network BlockchainNetworkType) string {
+	if webhook := cfg.network(network).AlertSlackWebhook; webhook != "" {
+		return webhook
 	}
+
+	return cfg.AlertSlackWebhook
+}
+
+// networkConfigKey returns the `global` table key of the network specific setting.
+// The keys for EthereumBlockchainNetworkType are the legacy ones, without any suffix.
+func networkConfigKey(key string, network BlockchainNetworkType) string {
+	if network == "" || network == EthereumBlockchainNetworkType {
+		return key
+	}
+
+	return fmt.Sprintf("%v_%v", key, network)
+}
+
+func (n *networkConfig) maxBatchSize() uint64 {
+	if n.MaxBatchSize == 0 {
+		return defaultMaxBatchSize
+	}
+
+	return n.MaxBatchSize
 }
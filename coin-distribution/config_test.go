@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package coindistribution
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigNetworks(t *testing.T) {
+	t.Parallel()
+
+	cfg := new(config)
+	require.Empty(t, cfg.networks())
+
+	cfg.Ethereum.RPC = "https://eth"
+	require.Len(t, cfg.networks(), 1)
+	require.Same(t, &cfg.Ethereum, cfg.networks()[EthereumBlockchainNetworkType])
+
+	bnb := &networkConfig{RPC: "https://bnb", AlertSlackWebhook: "https://bnb-alerts"}
+	eth := &networkConfig{RPC: "https://eth-override"}
+	cfg.Networks = map[BlockchainNetworkType]*networkConfig{BNBBlockchainNetworkType: bnb, EthereumBlockchainNetworkType: eth}
+	networks := cfg.networks()
+	require.Len(t, networks, 2)
+	require.Same(t, eth, networks[EthereumBlockchainNetworkType])
+	require.Same(t, bnb, networks[BNBBlockchainNetworkType])
+
+	cfg.AlertSlackWebhook = "https://alerts"
+	require.Equal(t, "https://bnb-alerts", cfg.alertSlackWebhook(BNBBlockchainNetworkType))
+	require.Equal(t, "https://alerts", cfg.alertSlackWebhook(EthereumBlockchainNetworkType))
+	require.Equal(t, "https://alerts", cfg.alertSlackWebhook(ArbitrumBlockchainNetworkType))
+}
+
+func TestNetworkConfigKey(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, configKeyCoinDistributerGasLimit, networkConfigKey(configKeyCoinDistributerGasLimit, ""))
+	require.Equal(t, configKeyCoinDistributerGasLimit, networkConfigKey(configKeyCoinDistributerGasLimit, EthereumBlockchainNetworkType))
+	require.Equal(t, configKeyCoinDistributerGasLimit+"_bnb", networkConfigKey(configKeyCoinDistributerGasLimit, BNBBlockchainNetworkType))
+}
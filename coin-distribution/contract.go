@@ -12,11 +12,16 @@ import (
 	"sync"
 	stdlibtime "time"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/rcrowley/go-metrics"
 
+	coindistribution "github.com/ice-blockchain/freezer/coin-distribution/internal"
 	"github.com/ice-blockchain/wintr/connectors/storage/v2"
 	"github.com/ice-blockchain/wintr/time"
 )
@@ -27,16 +32,74 @@ type (
 	Client interface {
 		io.Closer
 		CheckHealth(ctx context.Context) error
+		// NextScheduledWindows returns the current or the next window each network is scheduled to distribute coins in,
+		// nil if there's none in the next year. See DistributionSchedule.
+		NextScheduledWindows(ctx context.Context) (map[BlockchainNetworkType]*ScheduledWindow, error)
+		GetStatus(ctx context.Context) (map[BlockchainNetworkType]*DistributerStatus, error)
+	}
+	DistributerStatus struct {
+		LastTransaction     *DistributerTransaction `json:"lastTransaction,omitempty"`
+		NextScheduledWindow *ScheduledWindow        `json:"nextScheduledWindow,omitempty"`
+		// One of `run`, `blocked`, `disabled`, `on-demand`.
+		WorkerAction string `json:"workerAction" example:"run"`
+		// The number of coin distributions by status (`NEW`, `PENDING`, `ACCEPTED`, `REJECTED`).
+		Distributions map[string]uint64 `json:"distributions"`
+	}
+	// DistributerTransaction is the last transaction sent since the distributer started.
+	DistributerTransaction struct {
+		SentAt  *time.Time `json:"sentAt"`
+		Hash    string     `json:"hash"`
+		Status  string     `json:"status"`
+		Records int        `json:"records"`
+	}
+	// DistributionSchedule is when the coin distributer runs, stored as JSON in the `coin_distributer_schedule` global key (per network)
+	// and editable at runtime. If it's empty, the `startHours`-`endHours` daily window from the config is used.
+	DistributionSchedule struct {
+		// Weekday (`monday` ... `sunday`) => its windows. A window ending at or before its start ends the next day.
+		Windows map[string][]*DistributionScheduleWindow `json:"windows"`
+		// Optional, `UTC` by default. I.E. `Europe/Berlin`.
+		Timezone string `json:"timezone,omitempty"`
+		// Dates (`2006-01-02`, in Timezone) when nothing is distributed at all, I.E. token unlock days.
+		BlackoutDates []string `json:"blackoutDates,omitempty"`
+		location      *stdlibtime.Location
+		blackoutDates map[string]struct{}
+		weekdays      [7][]*scheduleMinutes
+	}
+	DistributionScheduleWindow struct {
+		// `15:04`, `24:00` is allowed as the end of the day.
+		Start string `json:"start" example:"09:00"`
+		End   string `json:"end" example:"17:00"`
+	}
+	ScheduledWindow struct {
+		Start *time.Time `json:"start"`
+		End   *time.Time `json:"end"`
 	}
 
-	Repository interface {
+	// ReadRepository is the part of the Repository that the public API needs, see NewReadRepository.
+	ReadRepository interface {
 		io.Closer
-		GetCoinDistributionsForReview(ctx context.Context, arg *GetCoinDistributionsForReviewArg) (*CoinDistributionsForReview, error)
 		CheckHealth(ctx context.Context) error
-		ReviewCoinDistributions(ctx context.Context, reviewerUserID string, decision string) error
+		GetCoinDistributionHistory(ctx context.Context, userID string, limit, offset uint64) ([]*CoinDistribution, error)
+	}
+	Repository interface {
+		ReadRepository
+		GetCoinDistributionsForReview(ctx context.Context, arg *GetCoinDistributionsForReviewArg) (*CoinDistributionsForReview, error)
+		// ReviewCoinDistributions and ReviewSelectedCoinDistributions fail with ErrReviewSnapshotChanged,
+		// if snapshotHash is provided and it's not the one of the current coin distributions pending review.
+		ReviewCoinDistributions(ctx context.Context, reviewerUserID, decision, snapshotHash string) error
+		ReviewSelectedCoinDistributions(ctx context.Context, reviewerUserID, decision, snapshotHash string, selected *SelectedCoinDistributions) error
 		NotifyCoinDistributionCollectionCycleEnded(ctx context.Context) error
 		GetCollectorSettings(ctx context.Context) (*CollectorSettings, error)
 		CollectCoinDistributionsForReview(ctx context.Context, records []*ByEarnerForReview) error
+		GetCoinDistributionClaims(ctx context.Context, userID string) ([]*CoinDistributionClaim, error)
+		GetDistributerSettings(ctx context.Context) ([]*DistributerSetting, error)
+		// UpdateDistributerSettings validates and updates all the settings (key => value) at once, recording the changes in the audit log.
+		// It fails with ErrInvalidSetting if any of them is not a DistributerSetting or its value is invalid.
+		UpdateDistributerSettings(ctx context.Context, adminUserID string, settings map[string]string) ([]*DistributerSetting, error)
+		GetDistributerSettingChanges(ctx context.Context, key string, limit, offset uint64) ([]*DistributerSettingChange, error)
+		// ExportCoinDistributions writes the approved coin distributions of the cycles matching arg to w, as CSV or NDJSON, followed by their totals.
+		// It fails with ErrInvalidExport if arg is invalid.
+		ExportCoinDistributions(ctx context.Context, arg *ExportCoinDistributionsArg, w io.Writer) (*CoinDistributionsExportSummary, error)
 	}
 	CollectorSettings struct {
 		DeniedCountries          map[string]struct{}
@@ -50,33 +113,55 @@ type (
 		ForcedExecution          bool
 	}
 
+	// CoinDistributionsForReview.SnapshotHash identifies the current state of all the coin distributions pending review.
+	// Approvals are the reviewers who already approved it, they're moved to be processed once RequiredApprovals is reached.
 	CoinDistributionsForReview struct {
-		Distributions []*PendingReview `json:"distributions"`
-		Cursor        uint64           `json:"cursor" example:"5065"`
-		TotalRows     uint64           `json:"totalRows" example:"5065"`
-		TotalIce      float64          `json:"totalIce" example:"5065.3"`
+		Distributions     []*PendingReview `json:"distributions"`
+		Approvals         []string         `json:"approvals" example:"12746386-03de-44d7-91c7-856fa66b6ed6"`
+		SnapshotHash      string           `json:"snapshotHash" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+		Cursor            uint64           `json:"cursor" example:"5065"`
+		TotalRows         uint64           `json:"totalRows" example:"5065"`
+		TotalIce          float64          `json:"totalIce" example:"5065.3"`
+		RequiredApprovals uint64           `json:"requiredApprovals" example:"2"`
 	}
 
 	GetCoinDistributionsForReviewArg struct {
-		CreatedAtOrderBy          string `form:"createdAtOrderBy" example:"asc"`
-		IceOrderBy                string `form:"iceOrderBy" example:"asc"`
-		UsernameOrderBy           string `form:"usernameOrderBy" example:"asc"`
-		ReferredByUsernameOrderBy string `form:"referredByUsernameOrderBy" example:"asc"`
-		UsernameKeyword           string `form:"usernameKeyword" example:"jdoe"`
-		ReferredByUsernameKeyword string `form:"referredByUsernameKeyword" example:"jdoe"`
-		Cursor                    uint64 `form:"cursor" example:"5065"`
-		Limit                     uint64 `form:"limit" example:"5000"`
+		CreatedAtOrderBy          string `form:"createdAtOrderBy" json:"createdAtOrderBy,omitempty" example:"asc"`
+		IceOrderBy                string `form:"iceOrderBy" json:"iceOrderBy,omitempty" example:"asc"`
+		UsernameOrderBy           string `form:"usernameOrderBy" json:"usernameOrderBy,omitempty" example:"asc"`
+		ReferredByUsernameOrderBy string `form:"referredByUsernameOrderBy" json:"referredByUsernameOrderBy,omitempty" example:"asc"`
+		UsernameKeyword           string `form:"usernameKeyword" json:"usernameKeyword,omitempty" example:"jdoe"`
+		ReferredByUsernameKeyword string `form:"referredByUsernameKeyword" json:"referredByUsernameKeyword,omitempty" example:"jdoe"`
+		RiskFlag                  string `form:"riskFlag" json:"riskFlag,omitempty" example:"shared-eth-address"`
+		FlaggedOnly               bool   `form:"flaggedOnly" json:"flaggedOnly,omitempty" example:"true"`
+		Cursor                    uint64 `form:"cursor" json:"cursor,omitempty" example:"5065"`
+		Limit                     uint64 `form:"limit" json:"limit,omitempty" example:"5000"`
+	}
+
+	// SelectedCoinDistributions specifies which of the coin distributions pending review are to be reviewed.
+	// Either `Distributions` or `Filter` has to be provided, not both.
+	// If `Filter.Limit` is 0, all the rows matching the filter are selected, otherwise only the page specified by `Filter.Cursor` and `Filter.Limit`.
+	SelectedCoinDistributions struct {
+		Filter        *GetCoinDistributionsForReviewArg `json:"filter,omitempty"`
+		Distributions []*CoinDistributionKey            `json:"distributions,omitempty"`
+	}
+
+	CoinDistributionKey struct {
+		Day    string `json:"day" example:"2024-01-02"`
+		UserID string `json:"userId" example:"12746386-03de-44d7-91c7-856fa66b6ed6"`
 	}
 
 	PendingReview struct {
-		CreatedAt          *time.Time `json:"time" swaggertype:"string" example:"2022-01-03T16:20:52.156534Z"`
-		Iceflakes          string     `json:"iceflakes" swaggertype:"string" example:"100000000000000"`
-		Username           string     `json:"username" swaggertype:"string" example:"myusername"`
-		ReferredByUsername string     `json:"referredByUsername" swaggertype:"string" example:"myrefusername"`
-		UserID             string     `json:"userId" swaggertype:"string" example:"12746386-03de-44d7-91c7-856fa66b6ed6"`
-		EthAddress         string     `json:"ethAddress" swaggertype:"string" example:"0x43...."`
-		Ice                float64    `json:"ice" db:"-" example:"1000"`
-		IceInternal        int64      `json:"-" db:"ice" swaggerignore:"true"`
+		CreatedAt          *time.Time            `json:"time" swaggertype:"string" example:"2022-01-03T16:20:52.156534Z"`
+		Iceflakes          string                `json:"iceflakes" swaggertype:"string" example:"100000000000000"`
+		Username           string                `json:"username" swaggertype:"string" example:"myusername"`
+		ReferredByUsername string                `json:"referredByUsername" swaggertype:"string" example:"myrefusername"`
+		UserID             string                `json:"userId" swaggertype:"string" example:"12746386-03de-44d7-91c7-856fa66b6ed6"`
+		EthAddress         string                `json:"ethAddress" swaggertype:"string" example:"0x43...."`
+		Network            BlockchainNetworkType `json:"network" swaggertype:"string" example:"ethereum"`
+		RiskFlags          []string              `json:"riskFlags" db:"risk_flags" example:"address-daily-cap,shared-eth-address"`
+		Ice                float64               `json:"ice" db:"-" example:"1000"`
+		IceInternal        int64                 `json:"-" db:"ice" swaggerignore:"true"`
 	}
 
 	ByEarnerForReview struct {
@@ -86,9 +171,152 @@ type (
 		UserID             string
 		EarnerUserID       string
 		EthAddress         string
-		InternalID         int64
-		Balance            float64
+		// Optional, EthereumBlockchainNetworkType by default.
+		Network    BlockchainNetworkType
+		InternalID int64
+		Balance    float64
+	}
+
+	// CoinDistributionClaim is what the user needs to claim the coins of a day from the claim contract of a network in `merkle-claim` mode.
+	// Iceflakes is the amount of the leaf, which includes the coins of all the users with the same EthAddress for that Day.
+	CoinDistributionClaim struct {
+		PublishedAt *time.Time            `json:"publishedAt,omitempty" db:"published_at" swaggertype:"string" example:"2022-01-03T16:20:52.156534Z"`
+		PublishTX   *string               `json:"publishTx,omitempty" db:"publish_tx" example:"0xf1a2...."`
+		Day         string                `json:"day" db:"day" example:"2024-01-02"`
+		Iceflakes   string                `json:"iceflakes" db:"iceflakes" example:"100000000000000"`
+		EthAddress  string                `json:"ethAddress" db:"eth_address" example:"0x43...."`
+		Network     BlockchainNetworkType `json:"network" db:"network" swaggertype:"string" example:"ethereum"`
+		Root        string                `json:"root" db:"root" example:"0x5d1e...."`
+		Leaf        string                `json:"leaf" db:"leaf" example:"0x9a3c...."`
+		Proof       []string              `json:"proof" db:"proof" example:"0x1f0b....,0x77ac...."`
+	}
+
+	// CoinDistribution is what the user got (or is going to get) for a Day of ethereum distribution mining.
+	// TXHash and ExplorerURL are available only once the coins are sent (or, in `merkle-claim` mode, once the root is published).
+	CoinDistribution struct {
+		TXHash      *string                `json:"txHash,omitempty" db:"eth_tx" example:"0xf1a2...."`
+		ExplorerURL *string                `json:"explorerUrl,omitempty" db:"-" example:"https://etherscan.io/tx/0xf1a2...."`
+		Day         string                 `json:"day" db:"day" example:"2024-01-02"`
+		Iceflakes   string                 `json:"iceflakes" db:"iceflakes" example:"100000000000000"`
+		EthAddress  string                 `json:"ethAddress" db:"eth_address" example:"0x43...."`
+		Network     BlockchainNetworkType  `json:"network" db:"network" swaggertype:"string" example:"ethereum"`
+		Status      CoinDistributionStatus `json:"status" db:"status" swaggertype:"string" enums:"pending-review,denied,queued,sent,failed,distributed,claimable" example:"distributed"` //nolint:lll // .
+		Ice         float64                `json:"ice" db:"-" example:"1000"`
+		IceInternal int64                  `json:"-" db:"ice" swaggerignore:"true"`
+	}
+
+	CoinDistributionStatus string
+
+	// DistributerSetting is one of the coin distributer's settings in the `global` table that can be changed at runtime, see UpdateDistributerSettings.
+	DistributerSetting struct {
+		Key   string                 `json:"key" db:"key" example:"coin_distributer_gas_limit_units_bnb"`
+		Value string                 `json:"value" db:"value" example:"30000000"`
+		Type  DistributerSettingType `json:"type" db:"-" swaggertype:"string" enums:"bool,uint,timestamp,countries,schedule" example:"uint"`
+	}
+	DistributerSettingChange struct {
+		ChangedAt   *time.Time `json:"changedAt" db:"changed_at" swaggertype:"string" example:"2022-01-03T16:20:52.156534Z"`
+		OldValue    *string    `json:"oldValue" db:"old_value" example:"20000000"`
+		Key         string     `json:"key" db:"key" example:"coin_distributer_gas_limit_units_bnb"`
+		NewValue    string     `json:"newValue" db:"new_value" example:"30000000"`
+		AdminUserID string     `json:"adminUserId" db:"admin_user_id" example:"12746386-03de-44d7-91c7-856fa66b6ed6"`
+	}
+
+	DistributerSettingType string
+
+	// ExportCoinDistributionsArg selects the cycles to export, either by `ReviewDay` or by the `From`-`To` range of review days, both inclusive.
+	ExportCoinDistributionsArg struct {
+		ReviewDay string                        `form:"reviewDay" json:"reviewDay,omitempty" example:"2024-01-02"`
+		From      string                        `form:"from" json:"from,omitempty" example:"2024-01-01"`
+		To        string                        `form:"to" json:"to,omitempty" example:"2024-01-31"`
+		Format    CoinDistributionsExportFormat `form:"format" json:"format,omitempty" swaggertype:"string" enums:"csv,ndjson" example:"csv"`
+	}
+	ExportedCoinDistribution struct {
+		TXHash      *string               `json:"txHash" db:"eth_tx" example:"0xf1a2...."`
+		ReviewDay   string                `json:"reviewDay" db:"review_day" example:"2024-01-02"`
+		Day         string                `json:"day" db:"day" example:"2024-01-01"`
+		UserID      string                `json:"userId" db:"user_id" example:"12746386-03de-44d7-91c7-856fa66b6ed6"`
+		Username    string                `json:"username" db:"username" example:"jdoe"`
+		EthAddress  string                `json:"ethAddress" db:"eth_address" example:"0x43...."`
+		Network     BlockchainNetworkType `json:"network" db:"network" example:"ethereum"`
+		Ice         string                `json:"ice" db:"-" example:"1000.00"`
+		Iceflakes   string                `json:"iceflakes" db:"iceflakes" example:"1000000000000000000000"`
+		IceInternal int64                 `json:"-" db:"ice"`
 	}
+	CoinDistributionsExportTotals struct {
+		Ice       string `json:"ice" example:"1000.00"`
+		Iceflakes string `json:"iceflakes" example:"1000000000000000000000"`
+		Records   uint64 `json:"records" example:"1"`
+	}
+	// CoinDistributionsExportSummary describes the exported content. SHA256 is the hex digest of all of it, totals included.
+	CoinDistributionsExportSummary struct {
+		Totals *CoinDistributionsExportTotals `json:"totals"`
+		SHA256 string                         `json:"sha256" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	}
+
+	CoinDistributionsExportFormat string
+
+	// BlockchainNetworkType is the network the coins are distributed on. Its values are the same as the ones of tokenomics.BlockchainNetworkType.
+	BlockchainNetworkType string
+)
+
+const (
+	ArbitrumBlockchainNetworkType BlockchainNetworkType = "arbitrum"
+	BNBBlockchainNetworkType      BlockchainNetworkType = "bnb"
+	EthereumBlockchainNetworkType BlockchainNetworkType = "ethereum"
+)
+
+const (
+	CoinDistributionStatusPendingReview CoinDistributionStatus = "pending-review"
+	CoinDistributionStatusDenied        CoinDistributionStatus = "denied"
+	CoinDistributionStatusQueued        CoinDistributionStatus = "queued"
+	CoinDistributionStatusSent          CoinDistributionStatus = "sent"
+	CoinDistributionStatusFailed        CoinDistributionStatus = "failed"
+	CoinDistributionStatusDistributed   CoinDistributionStatus = "distributed"
+	CoinDistributionStatusClaimable     CoinDistributionStatus = "claimable"
+)
+
+const (
+	DistributerSettingTypeBool DistributerSettingType = "bool"
+	DistributerSettingTypeUint DistributerSettingType = "uint"
+	// RFC3339, I.E. `2024-01-02T00:00:00Z`.
+	DistributerSettingTypeTimestamp DistributerSettingType = "timestamp"
+	// Comma separated ISO 3166-1 alpha-2 country codes, I.E. `US,CN`.
+	DistributerSettingTypeCountries DistributerSettingType = "countries"
+	// JSON of a DistributionSchedule, or empty for the daily window from the config.
+	DistributerSettingTypeSchedule DistributerSettingType = "schedule"
+)
+
+const (
+	CoinDistributionsExportFormatCSV    CoinDistributionsExportFormat = "csv"
+	CoinDistributionsExportFormatNDJSON CoinDistributionsExportFormat = "ndjson"
+)
+
+const (
+	ReviewDecisionApprove                      = "approve"
+	ReviewDecisionApproveAndProcessImmediately = "approve-and-process-immediately"
+	ReviewDecisionDeny                         = "deny"
+)
+
+// Risk rules run when the collected coin distributions are prepared for review, they're configured via the `coin_review_risk_*` keys of the `global` table.
+// The distributions flagged by any of the rules in `coin_review_risk_auto_deny_flags` are denied automatically, the rest are just tagged for the reviewers.
+const (
+	// The sum of the coin distributions of an eth address for a day is above `coin_review_risk_address_daily_cap` ICE.
+	RiskFlagAddressDailyCap = "address-daily-cap"
+	// The coin distribution is `coin_review_risk_historical_average_multiplier` times above the average of the user's approved ones,
+	// if the user has at least `coin_review_risk_historical_average_min_days` of them.
+	RiskFlagAboveHistoricalAverage = "above-historical-average"
+	// More than `coin_review_risk_max_users_per_eth_address` users share the eth address.
+	RiskFlagSharedEthAddress = "shared-eth-address"
+	// The eth address is in `coin_distribution_denied_eth_addresses`.
+	RiskFlagDenylistedEthAddress = "denylisted-eth-address"
+)
+
+var (
+	ErrInvalidSelection      = errors.New("invalid selection")
+	ErrReviewSnapshotChanged = errors.New("coin distributions pending review changed")
+	ErrInvalidSchedule       = errors.New("invalid schedule")
+	ErrInvalidSetting        = errors.New("invalid setting")
+	ErrInvalidExport         = errors.New("invalid export")
 )
 
 // Private API.
@@ -97,9 +325,31 @@ const (
 	applicationYamlKey = "coin-distribution"
 	requestDeadline    = 25 * stdlibtime.Second
 
-	batchSize = 700
+	// The batches start with the network's maxBatchSize records and are resized to fit the gas limit, see FitBatchToGasLimit.
+	defaultMaxBatchSize = 700
+	minBatchSize        = 1
+	// The estimated gas of a batch must be within this percentage of the gas limit, to leave room for estimation errors.
+	batchGasLimitUsagePercent = 90
+
+	batchMetricsLogInterval = 15 * stdlibtime.Minute
+
+	// The coin distributions are exported in pages of this many records, so that they're never all in memory.
+	exportPageSize = 10_000
+	// The review days of a single export can't span more than this, see ExportCoinDistributions.
+	maxExportDays = 366
+
+	// How far NextScheduledWindows looks for a window.
+	scheduleLookaheadDays = 366
 
 	gasPriceCacheTTL = stdlibtime.Minute
+	// How long the processors take, at most, to pick up the changes of their DistributionSchedule.
+	scheduleCacheTTL = stdlibtime.Minute
+
+	reconciliationTickInterval = stdlibtime.Minute
+	reconciliationBatchSize    = 10
+
+	// Nodes reject replacements with less than 10% higher fees.
+	minTransactionReplacementFeeBumpPercent = 10
 
 	workerActionRun      workerAction = 0
 	workerActionBlocked  workerAction = 1
@@ -111,10 +361,50 @@ const (
 	ethApiStatusAccepted ethApiStatus = "ACCEPTED"
 	ethApiStatusRejected ethApiStatus = "REJECTED"
 
+	airdropDistributionMode     distributionMode = "airdrop"
+	merkleClaimDistributionMode distributionMode = "merkle-claim"
+
 	ethTxStatusSuccessful ethTxStatus = "SUCCESSFUL"
 	ethTxStatusFailed     ethTxStatus = "FAILED"
 	ethTxStatusPending    ethTxStatus = "PENDING"
 
+	alertSeverityInfo     alertSeverity = "info"
+	alertSeverityWarning  alertSeverity = "warning"
+	alertSeverityCritical alertSeverity = "critical"
+
+	alertSinkTypeSlack   alertSinkType = "slack"
+	alertSinkTypeWebhook alertSinkType = "webhook"
+	alertSinkTypeSMTP    alertSinkType = "smtp"
+	alertSinkTypeLog     alertSinkType = "log"
+
+	// The name of the implicit slack sink, used if no sinks are configured.
+	defaultAlertSinkName = "slack"
+
+	alertEventReviewApproved                = "review-approved"
+	alertEventReviewDenied                  = "review-denied"
+	alertEventNewDistributionsForReview     = "new-distributions-for-review"
+	alertEventCollectionCycleStarted        = "collection-cycle-started"
+	alertEventCollectionCycleEndedEarly     = "collection-cycle-ended-prematurely"
+	alertEventDistributerOnline             = "distributer-online"
+	alertEventDistributerOffline            = "distributer-offline"
+	alertEventDistributerHasUnfinishedWork  = "distributer-has-unfinished-work"
+	alertEventTransactionStuck              = "transaction-stuck"
+	alertEventAllDistributionsCommitted     = "all-distributions-committed"
+	alertEventProcessingStarted             = "processing-started"
+	alertEventGasLimitTooLow                = "gas-limit-too-low"
+	alertEventPausedDueToHighBaseFee        = "paused-due-to-high-base-fee"
+	alertEventReconciliationMismatch        = "reconciliation-mismatch"
+	alertEventMerkleTreeBuilt               = "merkle-tree-built"
+	alertEventProcessingStoppedDueToFailure = "processing-stopped-due-to-failure"
+	alertEventReviewApprovalRecorded        = "review-approval-recorded"
+
+	maxDeduplicatedAlerts = 1024
+
+	signerTypePrivateKey signerType = "private-key"
+	signerTypeKeystore   signerType = "keystore"
+	signerTypeClef       signerType = "clef"
+	signerTypeLocal      signerType = "local"
+
 	configKeyCoinDistributerEnabled     = "coin_distributer_enabled"
 	configKeyCoinDistributerOnDemand    = "coin_distributer_forced_execution"
 	configKeyCoinDistributerGasLimit    = "coin_distributer_gas_limit_units"
@@ -122,93 +412,358 @@ const (
 	configKeyCoinDistributerMsgOnline   = "coin_distributer_msg_sent_online_date"
 	configKeyCoinDistributerMsgOffline  = "coin_distributer_msg_sent_offline_date"
 	configKeyCoinDistributerMsgFinished = "coin_distributer_msg_sent_finished_date"
+	configKeyCoinDistributerDynamicFees = "coin_distributer_dynamic_fees_enabled"
+	configKeyCoinDistributerGasTipCap   = "coin_distributer_gas_tip_cap_override"
+	configKeyCoinDistributerGasFeeCap   = "coin_distributer_gas_fee_cap_limit"
+	configKeyCoinDistributerBaseFeeMax  = "coin_distributer_base_fee_ceiling"
+	configKeyCoinDistributerSchedule    = "coin_distributer_schedule"
 )
 
+// claimContractABI is the part of the claim contract's ABI used in `merkle-claim` mode.
+const claimContractABI = `[{"inputs":[{"internalType":"bytes32","name":"root","type":"bytes32"}],"name":"addMerkleRoot","outputs":[],"stateMutability":"nonpayable","type":"function"}]`
+
 // .
 var (
 	//nolint:gochecknoglobals // Singleton & global config mounted only during bootstrap.
 	cfg config
+	//nolint:gochecknoglobals // It's a constant.
+	scheduleWeekdays = map[string]stdlibtime.Weekday{
+		"sunday":    stdlibtime.Sunday,
+		"monday":    stdlibtime.Monday,
+		"tuesday":   stdlibtime.Tuesday,
+		"wednesday": stdlibtime.Wednesday,
+		"thursday":  stdlibtime.Thursday,
+		"friday":    stdlibtime.Friday,
+		"saturday":  stdlibtime.Saturday,
+	}
+	//nolint:gochecknoglobals // It's a constant.
+	distributerSettingTypes = newDistributerSettingTypes()
+	//nolint:gochecknoglobals // It's a constant.
+	defaultExplorerURLs = map[BlockchainNetworkType]string{
+		ArbitrumBlockchainNetworkType: "https://arbiscan.io",
+		BNBBlockchainNetworkType:      "https://bscscan.com",
+		EthereumBlockchainNetworkType: "https://etherscan.io",
+	}
+	//nolint:gochecknoglobals // Shared by all the alerts of the process.
+	sentAlerts = &alertDeduplicator{mutedUntil: make(map[string]stdlibtime.Time), mu: new(sync.Mutex)}
 	//go:embed DDL.sql
 	ddl                  string
 	errNotEnoughData     = errors.New("not enough data")
 	errClientUncoverable = errors.New("uncoverable error")
+	errBaseFeeTooHigh    = errors.New("base fee is above the ceiling")
+	errUnknownNonce      = errors.New("unknown transaction nonce")
+
+	// The batch needs more gas than the gas limit allows, even with minBatchSize records.
+	errBatchGasLimitExceeded = errors.New("batch gas limit exceeded")
 )
 
 type (
-	ethTxStatus  string
-	ethApiStatus string
-	workerAction uint
-	gasGetter    interface {
-		GetGasOptions(ctx context.Context) (price *big.Int, limit uint64, err error)
+	ethTxStatus      string
+	ethApiStatus     string
+	workerAction     uint
+	distributionMode string
+	alertSeverity    string
+	alertSinkType    string
+	alertEvent       string
+	signerType       string
+	// transactionSigner signs the transactions of the distributer, so that it never has to see the key itself.
+	transactionSigner interface {
+		io.Closer
+		Type() signerType
+		Address() common.Address
+		SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+	}
+	privateKeySigner struct {
+		key     *ecdsa.PrivateKey
+		address common.Address
+	}
+	keystoreSigner struct {
+		store   *keystore.KeyStore
+		account accounts.Account
+	}
+	clefSigner struct {
+		client  *rpc.Client
+		address common.Address
+	}
+	alert struct {
+		Event       alertEvent            `json:"event"`
+		Severity    alertSeverity         `json:"severity"`
+		Environment string                `json:"environment"`
+		Network     BlockchainNetworkType `json:"network,omitempty"`
+		Text        string                `json:"text"`
+	}
+	alertSink interface {
+		Send(ctx context.Context, alert *alert) error
+	}
+	slackAlertSink struct {
+		cfg     *config
+		Webhook string
+	}
+	webhookAlertSink struct {
+		Headers map[string]string
+		URL     string
+	}
+	smtpAlertSink struct {
+		*alertSinkConfig
+	}
+	logAlertSink struct{}
+	// alertDeduplicator remembers until when each alert is muted, it's shared by all the sinks.
+	alertDeduplicator struct {
+		mutedUntil map[string]stdlibtime.Time
+		mu         *sync.Mutex
+	}
+	gasGetter interface {
+		GetGasOptions(ctx context.Context) (*gasOptions, error)
+	}
+	// gasOptions holds either the legacy Price or the EIP-1559 FeeCap + TipCap pair, never both.
+	gasOptions struct {
+		Price  *big.Int
+		FeeCap *big.Int
+		TipCap *big.Int
+		Limit  uint64
 	}
 	ethClient interface {
 		SuggestGasPrice(ctx context.Context) (*big.Int, error)
+		SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+		BaseFee(ctx context.Context) (*big.Int, error)
 		TransactionsStatus(ctx context.Context, hashes []*string) (statuses map[ethTxStatus][]string, err error)
 		TransactionStatus(ctx context.Context, hash string) (status ethTxStatus, err error)
-		Airdrop(ctx context.Context, chanID *big.Int, gas gasGetter, recipients []common.Address, amounts []*big.Int) (string, error)
+		Airdrop(ctx context.Context, chanID *big.Int, gas gasGetter, recipients []common.Address, amounts []*big.Int) (hash string, nonce uint64, err error)
+		EstimateAirdropGas(ctx context.Context, gasLimit uint64, recipients []common.Address, amounts []*big.Int) (uint64, error)
+		ReplaceAirdrop(ctx context.Context, chanID *big.Int, gas gasGetter, replaced *replacedTransaction, recipients []common.Address, amounts []*big.Int) (string, error)
+		TransferEvents(ctx context.Context, hash string, recipients []common.Address) ([]*coindistribution.CoindistributionTransfer, error)
+		PublishMerkleRoot(ctx context.Context, chanID *big.Int, gas gasGetter, contract common.Address, root common.Hash) (string, error)
 		io.Closer
 	}
 	airDropper interface {
 		AirdropToWallets(opts *bind.TransactOpts, recipients []common.Address, amounts []*big.Int) (*types.Transaction, error)
 	}
+	receiptSource interface {
+		TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	}
+	// transferEventsReader reads the ERC-20 Transfer events the token contract emitted in a transaction.
+	transferEventsReader struct {
+		Receipts receiptSource
+		Token    *coindistribution.CoindistributionFilterer
+		Balances *coindistribution.CoindistributionCaller
+		Contract common.Address
+	}
+	reconciliationRecord struct {
+		UserID     string `db:"user_id"`
+		EthAddress string `db:"eth_address"`
+		Iceflakes  string `db:"iceflakes"`
+	}
+	reconciliationMismatch struct {
+		Expected   *big.Int
+		Actual     *big.Int
+		EthAddress common.Address
+		UserIDs    []string
+	}
+	merkleLeaf struct {
+		Day     *time.Time
+		Amount  *big.Int
+		Address common.Address
+	}
+	// merkleTree holds all the levels, from the sorted leaves up to the root.
+	merkleTree struct {
+		Index  map[common.Hash]int
+		Levels [][]common.Hash
+	}
+	merkleTreeRecord struct {
+		PublishedAt *time.Time `db:"published_at"`
+		PublishTX   *string    `db:"publish_tx"`
+		Root        string     `db:"root"`
+	}
+	nonceSource interface {
+		PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	}
+	trackedTransaction struct {
+		Nonce    *uint64
+		Hash     string
+		Replaced []string
+	}
+	replacedTransaction struct {
+		Hash        string
+		Nonce       uint64
+		BumpPercent uint64
+	}
 	batchRecord struct {
-		CreatedAt  *time.Time   `db:"created_at"`
-		Day        *time.Time   `db:"day"`
-		EthTX      *string      `db:"eth_tx"`
-		UserID     string       `db:"user_id"`
-		EthAddress string       `db:"eth_address"`
-		EthStatus  ethApiStatus `db:"eth_status"`
-		Iceflakes  string       `db:"iceflakes"`
-		InternalID int64        `db:"internal_id"`
+		CreatedAt     *time.Time            `db:"created_at"`
+		Day           *time.Time            `db:"day"`
+		EthTX         *string               `db:"eth_tx"`
+		EthTXNonce    *uint64               `db:"eth_tx_nonce"`
+		UserID        string                `db:"user_id"`
+		EthAddress    string                `db:"eth_address"`
+		EthStatus     ethApiStatus          `db:"eth_status"`
+		Network       BlockchainNetworkType `db:"network"`
+		Iceflakes     string                `db:"iceflakes"`
+		EthTXReplaced []string              `db:"eth_tx_replaced"`
+		InternalID    int64                 `db:"internal_id"`
 	}
 	batch struct {
 		ID      string
 		TX      string
 		Status  ethTxStatus
 		Records []*batchRecord
+		Nonce   uint64
+	}
+	// The current or the next window of the schedule of a processor, see nextWindow.
+	scheduleCache struct {
+		window *ScheduledWindow
+		time   *time.Time
+		mu     *sync.RWMutex
+	}
+	// The part of a scheduled window within a single day.
+	scheduledPart struct {
+		start, end stdlibtime.Time
+	}
+	// scheduleMinutes are the minutes since the start of the day, End can be on the next day (> 24h).
+	scheduleMinutes struct {
+		Start int
+		End   int
+	}
+	batchMetrics struct {
+		Registry metrics.Registry
+		// The number of records of the batches sent.
+		Size metrics.Histogram
+		// The estimated gas of the batches sent.
+		Gas metrics.Histogram
+		// How many times a batch had to be shrunk to fit the gas limit.
+		Shrinks metrics.Counter
 	}
 	databaseConfig struct {
-		DB *storage.DB
+		DB      *storage.DB
+		Network BlockchainNetworkType
 	}
 	coinProcessor struct {
 		*databaseConfig
-		Client        ethClient
-		Conf          *config
-		WG            *sync.WaitGroup
-		CancelSignal  chan struct{}
+		Client       ethClient
+		Conf         *config
+		NetworkConf  *networkConfig
+		WG           *sync.WaitGroup
+		CancelSignal chan struct{}
+		BatchMetrics *batchMetrics
+		// Accessed only by the controller goroutine.
+		baseFeePaused bool
+		// Accessed only by the controller goroutine, see FitBatchToGasLimit.
+		batchSize     uint64
 		gasPriceCache struct {
 			price *big.Int
 			time  *time.Time
 			mu    *sync.RWMutex
 		}
+		scheduleCache   scheduleCache
+		lastTransaction struct {
+			tx *DistributerTransaction
+			mu *sync.RWMutex
+		}
 	}
 	ethClientImpl struct {
 		RPC        *ethclient.Client
 		Mutex      *sync.Mutex
-		Key        *ecdsa.PrivateKey
+		Signer     transactionSigner
 		AirDropper airDropper
+		Nonces     nonceSource
+		Transfers  *transferEventsReader
+		// Next nonce to use, guarded by Mutex. Nil until it's fetched from Nonces.
+		nextNonce *uint64
+		Contract  common.Address
+		Network   BlockchainNetworkType
 	}
 	coinDistributer struct {
-		Client    ethClient
-		DB        *storage.DB
-		Processor *coinProcessor
+		DB         *storage.DB
+		Processors map[BlockchainNetworkType]*coinProcessor
 	}
 	repository struct {
 		cfg *config
 		db  *storage.DB
 	}
-	config struct {
+	reviewSnapshot struct {
+		SnapshotHash string `db:"snapshot_hash"`
+		Rows         uint64 `db:"rows"`
+		Ice          uint64 `db:"ice"`
+	}
+	reviewApprovals struct {
+		*reviewSnapshot
+		Approvals         uint64
+		RequiredApprovals uint64
+	}
+	networkConfig struct {
+		// Optional, the global `alert-slack-webhook` is used if not provided.
 		AlertSlackWebhook string `yaml:"alert-slack-webhook" mapstructure:"alert-slack-webhook"`
-		Environment       string `yaml:"environment"         mapstructure:"environment"`
-		ReviewURL         string `yaml:"review-url"          mapstructure:"review-url"`
-		Ethereum          struct {
-			RPC             string `yaml:"rpc"             mapstructure:"rpc"`
-			PrivateKey      string `yaml:"privateKey"      mapstructure:"private-key"`
-			ContractAddress string `yaml:"contractAddress" mapstructure:"contract-address"`
-			ChainID         int64  `yaml:"chainId"         mapstructure:"chain-id"`
-		} `yaml:"ethereum" mapstructure:"ethereum"`
-		StartHours  int  `yaml:"startHours"  mapstructure:"start-hours"`
-		EndHours    int  `yaml:"endHours"    mapstructure:"end-hours"`
-		Development bool `yaml:"development" mapstructure:"development"`
+		RPC               string `yaml:"rpc"                 mapstructure:"rpc"`
+		// Required for the default `private-key` signer only.
+		PrivateKey      string       `yaml:"privateKey"          mapstructure:"private-key"`
+		Signer          signerConfig `yaml:"signer"              mapstructure:"signer"`
+		ContractAddress string       `yaml:"contractAddress"     mapstructure:"contract-address"`
+		// Optional, `airdrop` by default. In `merkle-claim` mode the approved coin distributions are turned into a merkle tree,
+		// which root has to be published to ClaimContractAddress (`addMerkleRoot(bytes32)`), so that the users can claim their coins themselves.
+		DistributionMode     distributionMode `yaml:"distributionMode"     mapstructure:"distribution-mode"`
+		ClaimContractAddress string           `yaml:"claimContractAddress" mapstructure:"claim-contract-address"`
+		// Optional, the block explorer the users are linked to for the transactions, I.E. `https://etherscan.io`.
+		ExplorerURL string `yaml:"explorerURL" mapstructure:"explorer-url"`
+		ChainID     int64  `yaml:"chainId"     mapstructure:"chain-id"`
+		// Optional, 700 by default. The maximum number of records of a batch, the actual size depends on the gas the batch needs.
+		MaxBatchSize uint64 `yaml:"maxBatchSize" mapstructure:"max-batch-size"`
+	}
+	alertSinkConfig struct {
+		// Only for `webhook` sinks.
+		Headers map[string]string `yaml:"headers"  mapstructure:"headers"`
+		Type    alertSinkType     `yaml:"type"     mapstructure:"type"`
+		// Only for `slack` sinks. Optional, the network's `alert-slack-webhook` (or the global one) is used if not provided.
+		Webhook string `yaml:"webhook"  mapstructure:"webhook"`
+		// Only for `webhook` sinks, the alert is POSTed to it as JSON.
+		URL string `yaml:"url"      mapstructure:"url"`
+		// Only for `smtp` sinks.
+		Host     string   `yaml:"host"     mapstructure:"host"`
+		Username string   `yaml:"username" mapstructure:"username"`
+		Password string   `yaml:"password" mapstructure:"password"`
+		From     string   `yaml:"from"     mapstructure:"from"`
+		To       []string `yaml:"to"       mapstructure:"to"`
+		Port     int      `yaml:"port"     mapstructure:"port"`
+	}
+	signerConfig struct {
+		// Optional, `private-key` by default, i.e. `privateKey` is used.
+		// `keystore` uses the encrypted KeystoreFile, `clef` a remote signer at Endpoint with the Address account,
+		// while `local` signs with a new random key and is meant for tests and development only.
+		Type         signerType `yaml:"type"         mapstructure:"type"`
+		KeystoreFile string     `yaml:"keystoreFile" mapstructure:"keystore-file"`
+		Passphrase   string     `yaml:"passphrase"   mapstructure:"passphrase"`
+		Endpoint     string     `yaml:"endpoint"     mapstructure:"endpoint"`
+		Address      string     `yaml:"address"      mapstructure:"address"`
+	}
+	alertRoute struct {
+		// Optional, the default severity of the event is used if not provided.
+		Severity alertSeverity `yaml:"severity"    mapstructure:"severity"`
+		// Optional, `alerts.defaultSinks` are used if not provided.
+		Sinks []string `yaml:"sinks"       mapstructure:"sinks"`
+		// Optional, `alerts.dedupWindow` is used if not provided.
+		DedupWindow *stdlibtime.Duration `yaml:"dedupWindow" mapstructure:"dedup-window"`
+	}
+	alertsConfig struct {
+		// If empty, all the alerts go to the implicit `slack` sink, which uses `alert-slack-webhook`.
+		Sinks  map[string]*alertSinkConfig `yaml:"sinks"        mapstructure:"sinks"`
+		Routes map[alertEvent]*alertRoute  `yaml:"routes"       mapstructure:"routes"`
+		// The sinks of the events without their own route sinks. Required if there are Sinks, unless all the events are routed.
+		DefaultSinks []string `yaml:"defaultSinks" mapstructure:"default-sinks"`
+		// The same alert (event, network and text) is sent at most once per DedupWindow. Deduplication is disabled if it's 0.
+		DedupWindow stdlibtime.Duration `yaml:"dedupWindow"  mapstructure:"dedup-window"`
+	}
+	config struct {
+		Alerts            alertsConfig `yaml:"alerts"              mapstructure:"alerts"`
+		AlertSlackWebhook string       `yaml:"alert-slack-webhook" mapstructure:"alert-slack-webhook"`
+		Environment       string       `yaml:"environment"         mapstructure:"environment"`
+		ReviewURL         string       `yaml:"review-url"          mapstructure:"review-url"`
+		// Kept for backwards compatibility, it's used only if `networks` has no `ethereum` entry.
+		Ethereum networkConfig                            `yaml:"ethereum"    mapstructure:"ethereum"`
+		Networks map[BlockchainNetworkType]*networkConfig `yaml:"networks"    mapstructure:"networks"`
+		// A pending transaction is replaced with the same nonce and bumped fees after StuckTransactionTimeout, at most MaxTransactionReplacements times.
+		// Replacement is disabled if either of them is 0.
+		StuckTransactionTimeout              stdlibtime.Duration `yaml:"stuckTransactionTimeout"              mapstructure:"stuck-transaction-timeout"`
+		MaxTransactionReplacements           int                 `yaml:"maxTransactionReplacements"           mapstructure:"max-transaction-replacements"`
+		TransactionReplacementFeeBumpPercent uint64              `yaml:"transactionReplacementFeeBumpPercent" mapstructure:"transaction-replacement-fee-bump-percent"`
+		StartHours                           int                 `yaml:"startHours"  mapstructure:"start-hours"`
+		EndHours                             int                 `yaml:"endHours"    mapstructure:"end-hours"`
+		Development                          bool                `yaml:"development" mapstructure:"development"`
 	}
 )
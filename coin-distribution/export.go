@@ -0,0 +1,208 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package coindistribution
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+	"strconv"
+	stdlibtime "time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/wintr/connectors/storage/v2"
+)
+
+type (
+	coinDistributionsExporter struct {
+		w         io.Writer
+		csv       *csv.Writer
+		json      *json.Encoder
+		hash      hash.Hash
+		iceflakes *big.Int
+		ice       int64
+		records   uint64
+		started   bool
+	}
+	coinDistributionsExportFooter struct {
+		Totals *CoinDistributionsExportTotals `json:"totals"`
+	}
+)
+
+//nolint:gochecknoglobals // It's a constant.
+var coinDistributionsExportCSVHeader = []string{"review_day", "day", "user_id", "username", "eth_address", "network", "ice", "iceflakes", "tx_hash"}
+
+func (arg *ExportCoinDistributionsArg) validate() (from, to string, err error) {
+	if arg.Format == "" {
+		arg.Format = CoinDistributionsExportFormatCSV
+	}
+	if arg.Format != CoinDistributionsExportFormatCSV && arg.Format != CoinDistributionsExportFormatNDJSON {
+		return "", "", errors.Wrapf(ErrInvalidExport, "`format` has to be `%v` or `%v`", CoinDistributionsExportFormatCSV, CoinDistributionsExportFormatNDJSON)
+	}
+	if (arg.ReviewDay == "") == (arg.From == "" && arg.To == "") {
+		return "", "", errors.Wrap(ErrInvalidExport, "either `reviewDay` or `from` and `to` have to be provided")
+	}
+	if arg.ReviewDay != "" {
+		arg.From, arg.To = arg.ReviewDay, arg.ReviewDay
+	}
+	fromDate, fErr := stdlibtime.Parse(stdlibtime.DateOnly, arg.From)
+	toDate, tErr := stdlibtime.Parse(stdlibtime.DateOnly, arg.To)
+	if fErr != nil || tErr != nil {
+		return "", "", errors.Wrap(ErrInvalidExport, "the days have to be in the `YYYY-MM-DD` format")
+	}
+	if toDate.Before(fromDate) || toDate.Sub(fromDate) >= maxExportDays*24*stdlibtime.Hour {
+		return "", "", errors.Wrapf(ErrInvalidExport, "`to` has to be after `from`, at most %v days apart", maxExportDays)
+	}
+
+	return arg.From, arg.To, nil
+}
+
+func (r *repository) ExportCoinDistributions(
+	ctx context.Context, arg *ExportCoinDistributionsArg, w io.Writer,
+) (*CoinDistributionsExportSummary, error) {
+	from, to, err := arg.validate()
+	if err != nil {
+		return nil, err
+	}
+	const sql = `
+SELECT
+	to_char(r.review_day, 'YYYY-MM-DD') AS review_day,
+	to_char(r.day, 'YYYY-MM-DD') AS day,
+	r.user_id,
+	r.username,
+	r.eth_address,
+	r.network,
+	r.ice,
+	coalesce(r.iceflakes, 0)::text AS iceflakes,
+	coalesce(p.eth_tx, rc.eth_tx, r.eth_tx, (SELECT t.publish_tx
+											  FROM coin_distribution_merkle_proofs m
+												JOIN coin_distribution_merkle_trees t
+												  ON t.root = m.root
+											  WHERE m.day = r.day
+												AND m.user_id = r.user_id
+												AND t.publish_tx IS NOT NULL
+											  LIMIT 1)) AS eth_tx
+FROM reviewed_coin_distributions r
+	LEFT JOIN pending_coin_distributions p
+		ON p.day = r.day
+	   AND p.user_id = r.user_id
+	LEFT JOIN pending_coin_distribution_reconciliations rc
+		ON rc.day = r.day
+	   AND rc.user_id = r.user_id
+WHERE r.review_day BETWEEN $1::date AND $2::date
+  AND r.decision LIKE 'approve%'
+  AND ($3::date IS NULL OR (r.review_day, r.day, r.user_id) > ($3::date, $4::date, $5::text))
+ORDER BY r.review_day, r.day, r.user_id
+LIMIT $6`
+	exporter := newCoinDistributionsExporter(arg.Format, w)
+	var cursor *ExportedCoinDistribution
+	for {
+		var cursorReviewDay, cursorDay, cursorUserID *string
+		if cursor != nil {
+			cursorReviewDay, cursorDay, cursorUserID = &cursor.ReviewDay, &cursor.Day, &cursor.UserID
+		}
+		page, sErr := storage.Select[ExportedCoinDistribution](ctx, r.db, sql, from, to, cursorReviewDay, cursorDay, cursorUserID, exportPageSize)
+		if sErr != nil {
+			return nil, errors.Wrapf(sErr, "failed to select coin distributions to export for %#v", arg)
+		}
+		if err = exporter.write(page); err != nil {
+			return nil, errors.Wrapf(err, "failed to export coin distributions for %#v", arg)
+		}
+		if len(page) < exportPageSize {
+			break
+		}
+		cursor = page[len(page)-1]
+	}
+	summary, err := exporter.close()
+
+	return summary, errors.Wrapf(err, "failed to export coin distributions for %#v", arg)
+}
+
+func newCoinDistributionsExporter(format CoinDistributionsExportFormat, w io.Writer) *coinDistributionsExporter {
+	exporter := &coinDistributionsExporter{hash: sha256.New(), iceflakes: new(big.Int)}
+	exporter.w = io.MultiWriter(w, exporter.hash)
+	if format == CoinDistributionsExportFormatCSV {
+		exporter.csv = csv.NewWriter(exporter.w)
+	} else {
+		exporter.json = json.NewEncoder(exporter.w)
+	}
+
+	return exporter
+}
+
+func (e *coinDistributionsExporter) write(rows []*ExportedCoinDistribution) error {
+	if e.csv != nil && !e.started {
+		if err := e.csv.Write(coinDistributionsExportCSVHeader); err != nil {
+			return errors.Wrap(err, "failed to write csv header")
+		}
+	}
+	e.started = true
+	for _, row := range rows {
+		iceflakes, ok := new(big.Int).SetString(row.Iceflakes, 10) //nolint:gomnd,mnd // .
+		if !ok {
+			return errors.Errorf("invalid iceflakes %q for userID:%v,day:%v", row.Iceflakes, row.UserID, row.Day)
+		}
+		row.Ice = formatExportedIce(row.IceInternal)
+		e.ice += row.IceInternal
+		e.iceflakes.Add(e.iceflakes, iceflakes)
+		e.records++
+		if e.csv == nil {
+			if err := e.json.Encode(row); err != nil {
+				return errors.Wrapf(err, "failed to write %#v", row)
+			}
+
+			continue
+		}
+		var txHash string
+		if row.TXHash != nil {
+			txHash = *row.TXHash
+		}
+		if err := e.csv.Write([]string{
+			row.ReviewDay, row.Day, row.UserID, row.Username, row.EthAddress, string(row.Network), row.Ice, row.Iceflakes, txHash,
+		}); err != nil {
+			return errors.Wrapf(err, "failed to write %#v", row)
+		}
+	}
+	if e.csv != nil {
+		e.csv.Flush()
+
+		return errors.Wrap(e.csv.Error(), "failed to flush csv")
+	}
+
+	return nil
+}
+
+// close writes the totals footer and returns the summary of everything written.
+// The csv footer is the row with `TOTAL` in the first column, the number of records in the second one and the sums in the `ice` and `iceflakes` ones.
+func (e *coinDistributionsExporter) close() (*CoinDistributionsExportSummary, error) {
+	if !e.started {
+		if err := e.write(nil); err != nil {
+			return nil, err
+		}
+	}
+	totals := &CoinDistributionsExportTotals{Ice: formatExportedIce(e.ice), Iceflakes: e.iceflakes.String(), Records: e.records}
+	if e.csv != nil {
+		if err := e.csv.Write([]string{"TOTAL", strconv.FormatUint(totals.Records, 10), "", "", "", "", totals.Ice, totals.Iceflakes, ""}); err != nil {
+			return nil, errors.Wrap(err, "failed to write csv footer")
+		}
+		if e.csv.Flush(); e.csv.Error() != nil {
+			return nil, errors.Wrap(e.csv.Error(), "failed to flush csv")
+		}
+	} else if err := e.json.Encode(&coinDistributionsExportFooter{Totals: totals}); err != nil {
+		return nil, errors.Wrap(err, "failed to write json footer")
+	}
+
+	return &CoinDistributionsExportSummary{Totals: totals, SHA256: hex.EncodeToString(e.hash.Sum(nil))}, nil
+}
+
+// formatExportedIce formats the internal ICE amount, which is in hundredths, exactly.
+func formatExportedIce(ice int64) string {
+	return fmt.Sprintf("%d.%02d", ice/100, ice%100) //nolint:gomnd,mnd // .
+}
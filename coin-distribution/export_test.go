@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package coindistribution
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportCoinDistributionsArgValidate(t *testing.T) {
+	t.Parallel()
+
+	arg := &ExportCoinDistributionsArg{ReviewDay: "2024-01-02"}
+	from, to, err := arg.validate()
+	require.NoError(t, err)
+	require.Equal(t, "2024-01-02", from)
+	require.Equal(t, "2024-01-02", to)
+	require.Equal(t, CoinDistributionsExportFormatCSV, arg.Format)
+
+	for _, invalid := range []*ExportCoinDistributionsArg{
+		{},
+		{ReviewDay: "2024-01-02", From: "2024-01-01", To: "2024-01-03"},
+		{From: "2024-01-01"},
+		{ReviewDay: "02.01.2024"},
+		{From: "2024-01-03", To: "2024-01-01"},
+		{From: "2024-01-01", To: "2025-01-01"},
+		{ReviewDay: "2024-01-02", Format: "xml"},
+	} {
+		_, _, err = invalid.validate()
+		require.ErrorIs(t, err, ErrInvalidExport, invalid)
+	}
+}
+
+func TestCoinDistributionsExporter(t *testing.T) {
+	t.Parallel()
+
+	txHash := "0x1"
+	rows := []*ExportedCoinDistribution{
+		{ReviewDay: "2024-01-02", Day: "2024-01-01", UserID: "a", Username: "jdoe", EthAddress: "0xa", Network: EthereumBlockchainNetworkType, IceInternal: 105, Iceflakes: "1050000000000000000", TXHash: &txHash}, //nolint:lll // .
+		{ReviewDay: "2024-01-02", Day: "2024-01-01", UserID: "b", Username: "jane", EthAddress: "0xb", Network: BNBBlockchainNetworkType, IceInternal: 2000, Iceflakes: "20000000000000000000"},                     //nolint:lll // .
+	}
+
+	var content strings.Builder
+	exporter := newCoinDistributionsExporter(CoinDistributionsExportFormatCSV, &content)
+	require.NoError(t, exporter.write(rows[:1]))
+	require.NoError(t, exporter.write(rows[1:]))
+	summary, err := exporter.close()
+	require.NoError(t, err)
+	require.Equal(t, `review_day,day,user_id,username,eth_address,network,ice,iceflakes,tx_hash
+2024-01-02,2024-01-01,a,jdoe,0xa,ethereum,1.05,1050000000000000000,0x1
+2024-01-02,2024-01-01,b,jane,0xb,bnb,20.00,20000000000000000000,
+TOTAL,2,,,,,21.05,21050000000000000000,
+`, content.String())
+	require.Equal(t, &CoinDistributionsExportTotals{Ice: "21.05", Iceflakes: "21050000000000000000", Records: 2}, summary.Totals)
+	digest := sha256.Sum256([]byte(content.String()))
+	require.Equal(t, hex.EncodeToString(digest[:]), summary.SHA256)
+
+	content.Reset()
+	exporter = newCoinDistributionsExporter(CoinDistributionsExportFormatNDJSON, &content)
+	require.NoError(t, exporter.write(rows[1:]))
+	summary, err = exporter.close()
+	require.NoError(t, err)
+	require.Equal(t, `{"txHash":null,"reviewDay":"2024-01-02","day":"2024-01-01","userId":"b","username":"jane","ethAddress":"0xb","network":"bnb","ice":"20.00","iceflakes":"20000000000000000000"}
+{"totals":{"ice":"20.00","iceflakes":"20000000000000000000","records":1}}
+`, content.String())
+	digest = sha256.Sum256([]byte(content.String()))
+	require.Equal(t, hex.EncodeToString(digest[:]), summary.SHA256)
+
+	content.Reset()
+	summary, err = newCoinDistributionsExporter(CoinDistributionsExportFormatCSV, &content).close()
+	require.NoError(t, err)
+	require.Equal(t, "review_day,day,user_id,username,eth_address,network,ice,iceflakes,tx_hash\nTOTAL,0,,,,,0.00,0,\n", content.String())
+	require.Zero(t, summary.Totals.Records)
+}
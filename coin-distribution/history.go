@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package coindistribution
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/wintr/connectors/storage/v2"
+)
+
+func (r *repository) GetCoinDistributionHistory(ctx context.Context, userID string, limit, offset uint64) ([]*CoinDistribution, error) {
+	const sql = `
+SELECT *
+FROM (
+	SELECT
+		to_char(day, 'YYYY-MM-DD') AS day,
+		ice,
+		coalesce(iceflakes, 0)::text AS iceflakes,
+		eth_address,
+		network,
+		'pending-review' AS status,
+		NULL::text AS eth_tx
+	FROM coin_distributions_pending_review
+	WHERE user_id = $1
+	UNION ALL
+	(SELECT DISTINCT ON (r.day)
+		to_char(r.day, 'YYYY-MM-DD') AS day,
+		r.ice,
+		coalesce(r.iceflakes, 0)::text AS iceflakes,
+		r.eth_address,
+		r.network,
+		(CASE
+			WHEN r.decision LIKE 'deny%' THEN 'denied'
+			WHEN p.eth_status = 'REJECTED' THEN 'failed'
+			WHEN p.eth_status = 'ACCEPTED' THEN 'sent'
+			WHEN p.eth_status IS NOT NULL THEN 'queued'
+			WHEN m.root IS NOT NULL AND t.published_at IS NULL THEN 'queued'
+			WHEN m.root IS NOT NULL THEN 'claimable'
+			ELSE 'distributed'
+		 END) AS status,
+		(CASE
+			WHEN r.decision LIKE 'deny%' THEN NULL
+			ELSE coalesce(p.eth_tx, rc.eth_tx, r.eth_tx, t.publish_tx)
+		 END) AS eth_tx
+	FROM reviewed_coin_distributions r
+		LEFT JOIN pending_coin_distributions p
+			ON p.day = r.day
+		   AND p.user_id = r.user_id
+		LEFT JOIN pending_coin_distribution_reconciliations rc
+			ON rc.day = r.day
+		   AND rc.user_id = r.user_id
+		LEFT JOIN coin_distribution_merkle_proofs m
+			ON m.day = r.day
+		   AND m.user_id = r.user_id
+		LEFT JOIN coin_distribution_merkle_trees t
+			ON t.root = m.root
+	WHERE r.user_id = $1
+	ORDER BY r.day, r.reviewed_at DESC)
+) X
+ORDER BY day DESC
+LIMIT $2 OFFSET $3`
+	res, err := storage.Select[CoinDistribution](ctx, r.db, sql, userID, limit, offset)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to select coin distribution history for userID:%v", userID)
+	}
+	for _, distribution := range res {
+		distribution.Ice = float64(distribution.IceInternal) / 100
+		if distribution.TXHash != nil && *distribution.TXHash != "" {
+			explorerURL := r.cfg.explorerTxURL(distribution.Network, *distribution.TXHash)
+			distribution.ExplorerURL = &explorerURL
+		}
+	}
+
+	return res, nil
+}
+
+func (cfg *config) explorerTxURL(network BlockchainNetworkType, txHash string) string {
+	explorerURL := defaultExplorerURLs[network]
+	if conf := cfg.networks()[network]; conf != nil && conf.ExplorerURL != "" {
+		explorerURL = conf.ExplorerURL
+	}
+
+	return strings.TrimSuffix(explorerURL, "/") + "/tx/" + txHash
+}
@@ -0,0 +1,20 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package coindistribution
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplorerTxURL(t *testing.T) {
+	t.Parallel()
+
+	conf := &config{Networks: map[BlockchainNetworkType]*networkConfig{
+		BNBBlockchainNetworkType: {ExplorerURL: "https://testnet.bscscan.com/"},
+	}}
+	require.Equal(t, "https://etherscan.io/tx/0x1", conf.explorerTxURL(EthereumBlockchainNetworkType, "0x1"))
+	require.Equal(t, "https://arbiscan.io/tx/0x2", conf.explorerTxURL(ArbitrumBlockchainNetworkType, "0x2"))
+	require.Equal(t, "https://testnet.bscscan.com/tx/0x3", conf.explorerTxURL(BNBBlockchainNetworkType, "0x3"))
+}
@@ -0,0 +1,302 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package coindistribution
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/wintr/connectors/storage/v2"
+	"github.com/ice-blockchain/wintr/log"
+)
+
+// Hash returns the leaf the same way OpenZeppelin's StandardMerkleTree does: keccak256(bytes.concat(keccak256(abi.encode(address, amount, day)))),
+// where day is the unix timestamp of the start of the day.
+func (l *merkleLeaf) Hash() common.Hash {
+	encoded := make([]byte, 0, 3*common.HashLength) //nolint:gomnd,mnd // 3 abi encoded words.
+	encoded = append(encoded, common.LeftPadBytes(l.Address.Bytes(), common.HashLength)...)
+	encoded = append(encoded, common.LeftPadBytes(l.Amount.Bytes(), common.HashLength)...)
+	encoded = append(encoded, common.LeftPadBytes(big.NewInt(l.Day.Unix()).Bytes(), common.HashLength)...)
+
+	return crypto.Keccak256Hash(crypto.Keccak256(encoded))
+}
+
+// newMerkleLeaves aggregates the records per address and day, the same address can be used by multiple users.
+func newMerkleLeaves(records []*batchRecord) (leaves []*merkleLeaf, byRecord []*merkleLeaf) {
+	type key struct {
+		address common.Address
+		day     int64
+	}
+	aggregated := make(map[key]*merkleLeaf, len(records))
+	byRecord = make([]*merkleLeaf, 0, len(records))
+	for _, record := range records {
+		k := key{address: record.Address(), day: record.Day.Unix()}
+		leaf, found := aggregated[k]
+		if !found {
+			leaf = &merkleLeaf{Address: k.address, Amount: big.NewInt(0), Day: record.Day}
+			aggregated[k] = leaf
+			leaves = append(leaves, leaf)
+		}
+		leaf.Amount.Add(leaf.Amount, record.Amount())
+		byRecord = append(byRecord, leaf)
+	}
+
+	return leaves, byRecord
+}
+
+// newMerkleTree builds the tree out of the sorted leaves, pairs are hashed sorted as well, so that the proofs can be verified
+// with OpenZeppelin's MerkleProof.verify. The last node of a level with an odd number of nodes is promoted to the next level as is.
+func newMerkleTree(leaves []common.Hash) *merkleTree {
+	if len(leaves) == 0 {
+		log.Panic("merkle tree can't be empty")
+	}
+	level := append(make([]common.Hash, 0, len(leaves)), leaves...)
+	sort.Slice(level, func(i, j int) bool { return bytes.Compare(level[i][:], level[j][:]) < 0 })
+	tree := &merkleTree{Levels: [][]common.Hash{level}, Index: make(map[common.Hash]int, len(level))}
+	for ix, leaf := range level {
+		tree.Index[leaf] = ix
+	}
+	for len(level) > 1 {
+		next := make([]common.Hash, 0, (len(level)+1)/2) //nolint:gomnd,mnd // Pairs.
+		for ix := 0; ix < len(level); ix += 2 {
+			if ix+1 == len(level) {
+				next = append(next, level[ix])
+			} else {
+				next = append(next, hashMerklePair(level[ix], level[ix+1]))
+			}
+		}
+		tree.Levels = append(tree.Levels, next)
+		level = next
+	}
+
+	return tree
+}
+
+func hashMerklePair(a, b common.Hash) common.Hash {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+
+	return crypto.Keccak256Hash(a[:], b[:])
+}
+
+func (t *merkleTree) Root() common.Hash {
+	return t.Levels[len(t.Levels)-1][0]
+}
+
+func (t *merkleTree) Proof(leaf common.Hash) []common.Hash {
+	ix, found := t.Index[leaf]
+	if !found {
+		log.Panic(fmt.Sprintf("leaf %v is not part of the tree", leaf.Hex()))
+	}
+	proof := make([]common.Hash, 0, len(t.Levels)-1)
+	for _, level := range t.Levels[:len(t.Levels)-1] {
+		if sibling := ix ^ 1; sibling < len(level) {
+			proof = append(proof, level[sibling])
+		}
+		ix /= 2
+	}
+
+	return proof
+}
+
+func verifyMerkleProof(root, leaf common.Hash, proof []common.Hash) bool {
+	for _, node := range proof {
+		leaf = hashMerklePair(leaf, node)
+	}
+
+	return leaf == root
+}
+
+func (proc *coinProcessor) isMerkleClaimMode() bool {
+	return proc.NetworkConf.DistributionMode == merkleClaimDistributionMode
+}
+
+// BuildMerkleTree turns all the approved coin distributions of the network into a merkle tree, instead of airdropping them.
+// The records are moved to coin_distribution_merkle_proofs, together with the proof of each of them, in the same transaction.
+func (proc *coinProcessor) BuildMerkleTree(ctx context.Context) error { //nolint:funlen //.
+	var tree *merkleTree
+	var leaves []*merkleLeaf
+	total := big.NewInt(0)
+	if err := storage.DoInTransaction(ctx, proc.DB, func(conn storage.QueryExecer) error {
+		const deleteStmt = `delete from pending_coin_distributions where eth_status = 'NEW' and network = $1 returning *`
+		records, err := storage.ExecMany[batchRecord](ctx, conn, deleteStmt, proc.Network)
+		if err != nil {
+			return errors.Wrap(err, "failed to fetch pending coin distributions")
+		} else if len(records) == 0 {
+			return errNotEnoughData
+		}
+		var byRecord []*merkleLeaf
+		leaves, byRecord = newMerkleLeaves(records)
+		hashes := make([]common.Hash, 0, len(leaves))
+		for _, leaf := range leaves {
+			hashes = append(hashes, leaf.Hash())
+			total.Add(total, leaf.Amount)
+		}
+		tree = newMerkleTree(hashes)
+		root := tree.Root().Hex()
+
+		const insertTreeStmt = `
+INSERT INTO coin_distribution_merkle_trees(created_at, total_iceflakes, leaves, root, network)
+VALUES (current_timestamp, $1::uint256, $2, $3, $4)`
+		if _, err = storage.Exec(ctx, conn, insertTreeStmt, total.String(), len(leaves), root, proc.Network); err != nil {
+			return errors.Wrapf(err, "failed to insert merkle tree %v", root)
+		}
+
+		return errors.Wrapf(insertMerkleProofs(ctx, conn, tree, root, records, byRecord), "failed to insert proofs of merkle tree %v", root)
+	}); err != nil {
+		if errors.Is(err, errNotEnoughData) {
+			return nil
+		}
+
+		return err //nolint:wrapcheck //.
+	}
+
+	log.Info(fmt.Sprintf("%v: merkle tree %v built: leaves %v, iceflakes %v", proc.Network, tree.Root().Hex(), len(leaves), total.String()))
+
+	return errors.Wrap(sendCoinDistributionMerkleTreeBuiltSlackMessage(ctx, proc.Network, tree.Root().Hex(), len(leaves), total),
+		"failed to sendCoinDistributionMerkleTreeBuiltSlackMessage")
+}
+
+func insertMerkleProofs(
+	ctx context.Context, conn storage.Execer, tree *merkleTree, root string, records []*batchRecord, byRecord []*merkleLeaf,
+) error {
+	const (
+		columns   = 9
+		chunkSize = 1000
+	)
+	for start := 0; start < len(records); start += chunkSize {
+		end := min(start+chunkSize, len(records))
+		values := make([]string, 0, end-start)
+		args := make([]any, 0, (end-start)*columns)
+		for ix := start; ix < end; ix++ {
+			leaf := byRecord[ix]
+			hash := leaf.Hash()
+			proof := tree.Proof(hash)
+			hexProof := make([]string, 0, len(proof))
+			for _, node := range proof {
+				hexProof = append(hexProof, node.Hex())
+			}
+			values = append(values, generateValuesSQLParams(ix-start, columns))
+			args = append(args,
+				records[ix].Day.Time, records[ix].Iceflakes, leaf.Amount.String(), records[ix].UserID, leaf.Address.Hex(), root, hash.Hex(), hexProof,
+				records[ix].CreatedAt.Time)
+		}
+		sql := fmt.Sprintf(`
+INSERT INTO coin_distribution_merkle_proofs(day, iceflakes, leaf_iceflakes, user_id, eth_address, root, leaf, proof, created_at)
+VALUES %v`, strings.Join(values, ",\n"))
+		if _, err := storage.Exec(ctx, conn, sql, args...); err != nil {
+			return errors.Wrapf(err, "failed to insert proofs %v-%v", start, end)
+		}
+	}
+
+	return nil
+}
+
+func (r *repository) GetCoinDistributionClaims(ctx context.Context, userID string) ([]*CoinDistributionClaim, error) {
+	const sql = `
+SELECT
+	to_char(p.day, 'YYYY-MM-DD') AS day,
+	p.leaf_iceflakes::text       AS iceflakes,
+	p.eth_address,
+	p.root,
+	p.leaf,
+	p.proof,
+	t.network,
+	t.publish_tx,
+	t.published_at
+FROM coin_distribution_merkle_proofs p
+	JOIN coin_distribution_merkle_trees t
+		ON t.root = p.root
+WHERE p.user_id = $1
+ORDER BY p.day DESC, t.network`
+	res, err := storage.Select[CoinDistributionClaim](ctx, r.db, sql, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to select coin distribution claims for userID:%v", userID)
+	}
+
+	return res, nil
+}
+
+// PublishMerkleRoot publishes the root of the network's merkle tree to its claim contract and waits for the transaction to be mined.
+// If root is empty, the oldest unpublished one is published.
+func PublishMerkleRoot(ctx context.Context, network BlockchainNetworkType, root string) (txHash string, err error) {
+	cfg.EnsureValid()
+	conf, found := cfg.networks()[network]
+	if !found {
+		return "", errors.Errorf("network %v is not configured", network)
+	} else if conf.DistributionMode != merkleClaimDistributionMode {
+		return "", errors.Errorf("network %v is not in %v mode", network, merkleClaimDistributionMode)
+	}
+	client := mustNewEthClient(ctx, network, conf)
+	defer func() {
+		log.Error(errors.Wrapf(client.Close(), "failed to close %v eth client", network))
+	}()
+	db := storage.MustConnect(ctx, ddl, applicationYamlKey)
+	defer func() {
+		log.Error(errors.Wrap(db.Close(), "failed to close db"))
+	}()
+
+	return newCoinProcessor(client, db, &cfg, network).PublishMerkleRoot(ctx, root)
+}
+
+func (proc *coinProcessor) PublishMerkleRoot(ctx context.Context, root string) (string, error) {
+	tree, err := proc.GetMerkleTreeToPublish(ctx, root)
+	if err != nil {
+		return "", err
+	} else if tree.PublishedAt != nil {
+		return "", errors.Errorf("merkle tree %v is already published in %v", tree.Root, *tree.PublishTX)
+	}
+
+	txHash, err := proc.Client.PublishMerkleRoot(ctx, big.NewInt(proc.NetworkConf.ChainID), proc,
+		common.HexToAddress(proc.NetworkConf.ClaimContractAddress), common.HexToHash(tree.Root))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to publish merkle tree %v", tree.Root)
+	}
+	log.Info(fmt.Sprintf("%v: merkle tree %v: publish transaction %v", proc.Network, tree.Root, txHash))
+
+	status, txHash, err := proc.WaitForTransaction(ctx, txHash)
+	if err != nil {
+		return txHash, err
+	} else if status != ethTxStatusSuccessful {
+		return txHash, errors.Errorf("merkle tree %v: publish transaction %v failed", tree.Root, txHash)
+	}
+
+	const stmt = `UPDATE coin_distribution_merkle_trees SET published_at = current_timestamp, publish_tx = $2 WHERE root = $1`
+	if _, err = storage.Exec(ctx, proc.DB, stmt, tree.Root, txHash); err != nil {
+		return txHash, errors.Wrapf(err, "failed to mark merkle tree %v as published in %v", tree.Root, txHash)
+	}
+
+	return txHash, nil
+}
+
+func (proc *coinProcessor) GetMerkleTreeToPublish(ctx context.Context, root string) (*merkleTreeRecord, error) {
+	const sql = `
+SELECT
+	published_at,
+	publish_tx,
+	root
+FROM coin_distribution_merkle_trees
+WHERE network = $1 AND
+	  (root = $2 OR ($2 = '' AND published_at IS NULL))
+ORDER BY created_at ASC
+LIMIT 1`
+	tree, err := storage.Get[merkleTreeRecord](ctx, proc.DB, sql, proc.Network, root)
+	if err != nil {
+		if storage.IsErr(err, storage.ErrNotFound) {
+			err = errors.Wrapf(errNotEnoughData, "no merkle tree %q to publish", root)
+		}
+
+		return nil, errors.Wrapf(err, "failed to get %v merkle tree %q", proc.Network, root)
+	}
+
+	return tree, nil
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package coindistribution
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	stdlibtime "time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ice-blockchain/wintr/time"
+)
+
+func TestMerkleLeafHash(t *testing.T) {
+	t.Parallel()
+
+	leaf := &merkleLeaf{
+		Address: common.HexToAddress("0x43Df5e5b5e5fFBc5A0F40E8c3b1cA0A03C6e5a12"),
+		Amount:  big.NewInt(1_234_567_890),
+		Day:     time.New(stdlibtime.Date(2024, 1, 2, 0, 0, 0, 0, stdlibtime.UTC)),
+	}
+
+	addressType, err := abi.NewType("address", "", nil)
+	require.NoError(t, err)
+	uintType, err := abi.NewType("uint256", "", nil)
+	require.NoError(t, err)
+	encoded, err := abi.Arguments{{Type: addressType}, {Type: uintType}, {Type: uintType}}.Pack(leaf.Address, leaf.Amount, big.NewInt(leaf.Day.Unix()))
+	require.NoError(t, err)
+
+	require.Equal(t, crypto.Keccak256Hash(crypto.Keccak256(encoded)), leaf.Hash())
+}
+
+func TestNewMerkleLeaves(t *testing.T) {
+	t.Parallel()
+
+	day1 := time.New(stdlibtime.Date(2024, 1, 2, 0, 0, 0, 0, stdlibtime.UTC))
+	day2 := time.New(stdlibtime.Date(2024, 1, 3, 0, 0, 0, 0, stdlibtime.UTC))
+	records := []*batchRecord{
+		{UserID: "a", EthAddress: "0x1", Iceflakes: "10", Day: day1},
+		{UserID: "b", EthAddress: "0x1", Iceflakes: "5", Day: day1},
+		{UserID: "c", EthAddress: "0x1", Iceflakes: "7", Day: day2},
+		{UserID: "d", EthAddress: "0x2", Iceflakes: "1", Day: day1},
+	}
+
+	leaves, byRecord := newMerkleLeaves(records)
+	require.Len(t, leaves, 3)
+	require.Len(t, byRecord, len(records))
+	require.Same(t, byRecord[0], byRecord[1])
+	require.Equal(t, "15", byRecord[0].Amount.String())
+	require.Equal(t, "7", byRecord[2].Amount.String())
+	require.Equal(t, "1", byRecord[3].Amount.String())
+	require.NotSame(t, byRecord[0], byRecord[2])
+}
+
+func TestMerkleTreeProofs(t *testing.T) {
+	t.Parallel()
+
+	for size := 1; size <= 17; size++ {
+		t.Run(fmt.Sprintf("%v leaves", size), func(t *testing.T) {
+			t.Parallel()
+
+			leaves := make([]common.Hash, 0, size)
+			for ix := range size {
+				leaves = append(leaves, crypto.Keccak256Hash([]byte(fmt.Sprint(ix))))
+			}
+			tree := newMerkleTree(leaves)
+			for _, leaf := range leaves {
+				require.True(t, verifyMerkleProof(tree.Root(), leaf, tree.Proof(leaf)))
+			}
+			require.False(t, verifyMerkleProof(tree.Root(), crypto.Keccak256Hash([]byte("unknown")), tree.Proof(leaves[0])))
+			if size > 1 {
+				require.False(t, verifyMerkleProof(tree.Root(), leaves[0], tree.Proof(leaves[1])))
+			}
+		})
+	}
+}
+
+func TestMerkleTreeIsOrderIndependent(t *testing.T) {
+	t.Parallel()
+
+	leaves := []common.Hash{
+		crypto.Keccak256Hash([]byte("a")),
+		crypto.Keccak256Hash([]byte("b")),
+		crypto.Keccak256Hash([]byte("c")),
+	}
+	reversed := []common.Hash{leaves[2], leaves[1], leaves[0]}
+
+	require.Equal(t, newMerkleTree(leaves).Root(), newMerkleTree(reversed).Root())
+	require.Equal(t, leaves[0], newMerkleTree(leaves[:1]).Root())
+}
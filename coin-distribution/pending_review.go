@@ -19,9 +19,7 @@ import (
 func NewRepository(ctx context.Context, _ context.CancelFunc) Repository {
 	var localCfg config
 	appcfg.MustLoadFromKey(applicationYamlKey, &localCfg)
-	if localCfg.AlertSlackWebhook == "" {
-		log.Panic("`alert-slack-webhook` is missing")
-	}
+	localCfg.Alerts.EnsureValid(localCfg.AlertSlackWebhook)
 	if localCfg.Environment == "" {
 		log.Panic("`environment` is missing")
 	}
@@ -35,6 +33,17 @@ func NewRepository(ctx context.Context, _ context.CancelFunc) Repository {
 	}
 }
 
+// NewReadRepository connects only to the database, without requiring the alerting and the review settings, that are needed just to write.
+func NewReadRepository(ctx context.Context, _ context.CancelFunc) ReadRepository {
+	var localCfg config
+	appcfg.MustLoadFromKey(applicationYamlKey, &localCfg)
+
+	return &repository{
+		db:  storage.MustConnect(ctx, ddl, applicationYamlKey),
+		cfg: &localCfg,
+	}
+}
+
 func (r *repository) CheckHealth(ctx context.Context) error {
 	return errors.Wrap(r.db.Ping(ctx), "[health-check] failed to ping DB for coindistribution.repository")
 }
@@ -78,16 +87,31 @@ func (r *repository) GetCoinDistributionsForReview(ctx context.Context, arg *Get
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to select coin_distributions_pending_review totals for %#v", arg)
 	}
+	snapshot, err := getReviewSnapshot(ctx, r.db, "1=1")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to getReviewSnapshot")
+	}
+	approvers, err := getReviewApprovers(ctx, r.db, snapshot.SnapshotHash)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to getReviewApprovers")
+	}
+	requiredApprovals, err := getRequiredReviewApprovals(ctx, r.db)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to getRequiredReviewApprovals")
+	}
 	nextCursor := uint64(0)
 	if len(result) == int(arg.Limit) {
 		nextCursor = arg.Cursor + arg.Limit
 	}
 
 	return &CoinDistributionsForReview{
-		Distributions: distributions,
-		Cursor:        nextCursor,
-		TotalRows:     total.Rows,
-		TotalIce:      float64(total.Ice) / 100,
+		Distributions:     distributions,
+		Approvals:         approvers,
+		SnapshotHash:      snapshot.SnapshotHash,
+		Cursor:            nextCursor,
+		TotalRows:         total.Rows,
+		TotalIce:          float64(total.Ice) / 100,
+		RequiredApprovals: requiredApprovals,
 	}, nil
 }
 
@@ -111,107 +135,83 @@ func (a *GetCoinDistributionsForReviewArg) orderBy() []string {
 }
 
 func (a *GetCoinDistributionsForReviewArg) where() ([]string, []any) {
-	conditions := make([]string, 0, 2)
-	args := make([]any, 0, 2)
-
-	i := 3
-	if referredByUsernameKeyword := a.ReferredByUsernameKeyword; referredByUsernameKeyword != "" {
-		referredByUsernameKeyword = strings.ReplaceAll(referredByUsernameKeyword, "!", "!!")
-		referredByUsernameKeyword = strings.ReplaceAll(referredByUsernameKeyword, "%", "!%")
-		referredByUsernameKeyword = strings.ReplaceAll(referredByUsernameKeyword, "_", "!_")
-		referredByUsernameKeyword = strings.ReplaceAll(referredByUsernameKeyword, "[", "![")
-		referredByUsernameKeyword = referredByUsernameKeyword + "%"
-		conditions = append(conditions, fmt.Sprintf("referred_by_username LIKE $%v ESCAPE '!'", i))
-		args = append(args, strings.ToLower(referredByUsernameKeyword))
-		i++
-	}
-	if usernameKeyword := a.UsernameKeyword; usernameKeyword != "" {
-		usernameKeyword = strings.ReplaceAll(usernameKeyword, "!", "!!")
-		usernameKeyword = strings.ReplaceAll(usernameKeyword, "%", "!%")
-		usernameKeyword = strings.ReplaceAll(usernameKeyword, "_", "!_")
-		usernameKeyword = strings.ReplaceAll(usernameKeyword, "[", "![")
-		usernameKeyword = usernameKeyword + "%"
-		conditions = append(conditions, fmt.Sprintf("username LIKE $%v ESCAPE '!'", i))
-		args = append(args, strings.ToLower(usernameKeyword))
-	}
-
-	return conditions, args
+	return a.keywordConditions(3) //nolint:gomnd // $1 and $2 are the offset and the limit.
 }
 
 func (a *GetCoinDistributionsForReviewArg) totalsWhere() ([]string, []any) {
-	conditions := make([]string, 0, 2)
-	args := make([]any, 0, 2)
+	return a.keywordConditions(1)
+}
+
+func (a *GetCoinDistributionsForReviewArg) keywordConditions(firstParamIndex int) ([]string, []any) {
+	conditions := make([]string, 0, 4)
+	args := make([]any, 0, 3)
 
-	i := 1
+	i := firstParamIndex
 	if referredByUsernameKeyword := a.ReferredByUsernameKeyword; referredByUsernameKeyword != "" {
-		referredByUsernameKeyword = strings.ReplaceAll(referredByUsernameKeyword, "!", "!!")
-		referredByUsernameKeyword = strings.ReplaceAll(referredByUsernameKeyword, "%", "!%")
-		referredByUsernameKeyword = strings.ReplaceAll(referredByUsernameKeyword, "_", "!_")
-		referredByUsernameKeyword = strings.ReplaceAll(referredByUsernameKeyword, "[", "![")
-		referredByUsernameKeyword = referredByUsernameKeyword + "%"
 		conditions = append(conditions, fmt.Sprintf("referred_by_username LIKE $%v ESCAPE '!'", i))
-		args = append(args, strings.ToLower(referredByUsernameKeyword))
+		args = append(args, strings.ToLower(escapeLikeKeyword(referredByUsernameKeyword)+"%"))
 		i++
 	}
 	if usernameKeyword := a.UsernameKeyword; usernameKeyword != "" {
-		usernameKeyword = strings.ReplaceAll(usernameKeyword, "!", "!!")
-		usernameKeyword = strings.ReplaceAll(usernameKeyword, "%", "!%")
-		usernameKeyword = strings.ReplaceAll(usernameKeyword, "_", "!_")
-		usernameKeyword = strings.ReplaceAll(usernameKeyword, "[", "![")
-		usernameKeyword = usernameKeyword + "%"
 		conditions = append(conditions, fmt.Sprintf("username LIKE $%v ESCAPE '!'", i))
-		args = append(args, strings.ToLower(usernameKeyword))
+		args = append(args, strings.ToLower(escapeLikeKeyword(usernameKeyword)+"%"))
+		i++
+	}
+	if riskFlag := a.RiskFlag; riskFlag != "" {
+		conditions = append(conditions, fmt.Sprintf("risk_flags @> ARRAY[$%v]::text[]", i))
+		args = append(args, riskFlag)
+	}
+	if a.FlaggedOnly {
+		conditions = append(conditions, "cardinality(risk_flags) > 0")
 	}
 
 	return conditions, args
 }
 
+func escapeLikeKeyword(keyword string) string {
+	keyword = strings.ReplaceAll(keyword, "!", "!!")
+	keyword = strings.ReplaceAll(keyword, "%", "!%")
+	keyword = strings.ReplaceAll(keyword, "_", "!_")
+
+	return strings.ReplaceAll(keyword, "[", "![")
+}
+
 //nolint:funlen // .
-func (r *repository) ReviewCoinDistributions(ctx context.Context, reviewerUserID string, decision string) error {
+func (r *repository) ReviewCoinDistributions(ctx context.Context, reviewerUserID, decision, snapshotHash string) error {
 	const sqlToCheckIfAnythingNeedsApproving = "SELECT true AS bogus WHERE exists (select 1 FROM coin_distributions_pending_review LIMIT 1)"
-	switch strings.ToLower(decision) {
-	case "approve":
+	decision = strings.ToLower(decision)
+	switch decision {
+	case ReviewDecisionApprove, ReviewDecisionApproveAndProcessImmediately:
 		return storage.DoInTransaction(ctx, r.db, func(conn storage.QueryExecer) error {
-			if _, err := storage.ExecOne[struct{ Bogus bool }](ctx, conn, sqlToCheckIfAnythingNeedsApproving); err != nil {
-				if storage.IsErr(err, storage.ErrNotFound) {
-					err = nil
-				}
-
-				return errors.Wrap(err, "failed to check if any rows in coin_distributions_pending_review exist")
+			approvals, err := recordReviewApproval(ctx, conn, reviewerUserID, decision, snapshotHash, "1=1")
+			if err != nil || approvals.Rows == 0 {
+				return errors.Wrap(err, "failed to recordReviewApproval")
+			}
+			if !approvals.QuorumReached() {
+				return errors.Wrap(r.sendCoinDistributionsApprovalRecordedSlackMessage(ctx, reviewerUserID, approvals),
+					"failed to sendCoinDistributionsApprovalRecordedSlackMessage")
 			}
+			processImmediately := decision == ReviewDecisionApproveAndProcessImmediately
 			totals, err := storage.ExecOne[struct {
 				Rows uint64
 				Ice  uint64
-			}](ctx, conn, "SELECT rows, ice FROM approve_coin_distributions($1,false,true) AS (rows bigint, ice numeric);", reviewerUserID)
+			}](ctx, conn, "SELECT rows, ice FROM approve_coin_distributions($1,$2,true) AS (rows bigint, ice numeric);", reviewerUserID, processImmediately)
 			if err != nil {
 				return errors.Wrap(err, "failed to call approve_coin_distributions")
 			}
+			if processImmediately {
+				return errors.Wrap(r.sendCurrentCoinDistributionsAvailableForReviewAreApprovedToBeProcessedImmediatelySlackMessage(ctx, totals.Rows, float64(totals.Ice)/100),
+					"failed to sendCurrentCoinDistributionsAvailableForReviewAreApprovedToBeProcessedImmediatelySlackMessage")
+			}
 
 			return errors.Wrap(r.sendCurrentCoinDistributionsAvailableForReviewAreApprovedSlackMessage(ctx, totals.Rows, float64(totals.Ice)/100),
 				"failed to sendCurrentCoinDistributionsAvailableForReviewAreApprovedSlackMessage")
 		})
-	case "approve-and-process-immediately":
+	case ReviewDecisionDeny:
 		return storage.DoInTransaction(ctx, r.db, func(conn storage.QueryExecer) error {
-			if _, err := storage.ExecOne[struct{ Bogus bool }](ctx, conn, sqlToCheckIfAnythingNeedsApproving); err != nil {
-				if storage.IsErr(err, storage.ErrNotFound) {
-					err = nil
-				}
-
-				return errors.Wrap(err, "failed to check if any rows in coin_distributions_pending_review exist")
-			}
-			totals, err := storage.ExecOne[struct {
-				Rows uint64
-				Ice  uint64
-			}](ctx, conn, "SELECT rows, ice FROM approve_coin_distributions($1,true,true) AS (rows bigint, ice numeric);", reviewerUserID)
-			if err != nil {
-				return errors.Wrap(err, "failed to call approve_coin_distributions")
+			if err := lockReviewSnapshot(ctx, conn, snapshotHash); err != nil {
+				return errors.Wrap(err, "failed to lockReviewSnapshot")
 			}
-
-			return errors.Wrap(r.sendCurrentCoinDistributionsAvailableForReviewAreApprovedToBeProcessedImmediatelySlackMessage(ctx, totals.Rows, float64(totals.Ice)/100),
-				"failed to sendCurrentCoinDistributionsAvailableForReviewAreApprovedToBeProcessedImmediatelySlackMessage")
-		})
-	case "deny":
-		return storage.DoInTransaction(ctx, r.db, func(conn storage.QueryExecer) error {
 			if _, err := storage.ExecOne[struct{ Bogus bool }](ctx, conn, sqlToCheckIfAnythingNeedsApproving); err != nil {
 				if storage.IsErr(err, storage.ErrNotFound) {
 					err = nil
@@ -233,6 +233,124 @@ func (r *repository) ReviewCoinDistributions(ctx context.Context, reviewerUserID
 	return ctx.Err()
 }
 
+//nolint:funlen // .
+func (r *repository) ReviewSelectedCoinDistributions(ctx context.Context, reviewerUserID, decision, snapshotHash string, selected *SelectedCoinDistributions) error { //nolint:lll // .
+	decision = strings.ToLower(decision)
+	switch decision {
+	case ReviewDecisionApprove, ReviewDecisionApproveAndProcessImmediately, ReviewDecisionDeny:
+	default:
+		log.Panic(fmt.Sprintf("unknown decision:`%v`", decision))
+	}
+	condition, conditionArgs, err := selected.where(3) //nolint:gomnd // $1 and $2 are the reviewer and the decision.
+	if err != nil {
+		return err
+	}
+	sql := fmt.Sprintf(`WITH del AS (
+							DELETE FROM coin_distributions_pending_review
+							WHERE %[1]v
+							RETURNING *
+						), approved AS (
+							INSERT INTO pending_coin_distributions(created_at, internal_id, day, iceflakes, user_id, eth_address, network)
+							SELECT created_at, internal_id, day, iceflakes, user_id, eth_address, network
+							FROM del
+							WHERE $2 != '%[2]v'
+						), reviewed AS (
+							INSERT INTO reviewed_coin_distributions(reviewed_at, created_at, internal_id, ice, day, review_day, iceflakes, username, referred_by_username, user_id, eth_address, reviewer_user_id, decision, network, risk_flags)
+							SELECT current_timestamp::timestamp, created_at, internal_id, ice, day, current_date, iceflakes, username, referred_by_username, user_id, eth_address, $1, $2, network, risk_flags
+							FROM del
+						)
+						SELECT count(1) AS rows,
+							   coalesce(sum(ice),0) AS ice
+						FROM del`, condition, ReviewDecisionDeny)
+
+	return storage.DoInTransaction(ctx, r.db, func(conn storage.QueryExecer) error {
+		if decision == ReviewDecisionDeny {
+			if txErr := lockReviewSnapshot(ctx, conn, snapshotHash); txErr != nil {
+				return errors.Wrap(txErr, "failed to lockReviewSnapshot")
+			}
+		} else {
+			snapshotCondition, snapshotConditionArgs, _ := selected.where(1) //nolint:errcheck // It's the same selection as above.
+			approvals, txErr := recordReviewApproval(ctx, conn, reviewerUserID, decision, snapshotHash, snapshotCondition, snapshotConditionArgs...)
+			if txErr != nil || approvals.Rows == 0 {
+				return errors.Wrapf(txErr, "failed to recordReviewApproval for %#v", selected)
+			}
+			if !approvals.QuorumReached() {
+				return errors.Wrap(r.sendCoinDistributionsApprovalRecordedSlackMessage(ctx, reviewerUserID, approvals),
+					"failed to sendCoinDistributionsApprovalRecordedSlackMessage")
+			}
+		}
+		totals, txErr := storage.ExecOne[struct {
+			Rows uint64
+			Ice  uint64
+		}](ctx, conn, sql, append([]any{reviewerUserID, decision}, conditionArgs...)...)
+		if txErr != nil {
+			return errors.Wrapf(txErr, "failed to review selected coin_distributions_pending_review for %#v", selected)
+		}
+		if totals.Rows == 0 {
+			return nil
+		}
+		switch decision {
+		case ReviewDecisionApproveAndProcessImmediately:
+			sql = `INSERT INTO global (key,value)
+						   VALUES ('coin_distributer_enabled','true'),
+								  ('coin_distributer_forced_execution','true'),
+								  ('coin_distributer_forced_execution_arbitrum','true'),
+								  ('coin_distributer_forced_execution_bnb','true')
+				   ON CONFLICT (key) DO UPDATE
+						   SET value = EXCLUDED.value`
+			if _, txErr = storage.Exec(ctx, conn, sql); txErr != nil {
+				return errors.Wrap(txErr, "failed to enable coin_distributer_forced_execution")
+			}
+
+			return errors.Wrap(r.sendSelectedCoinDistributionsAreApprovedToBeProcessedImmediatelySlackMessage(ctx, totals.Rows, float64(totals.Ice)/100),
+				"failed to sendSelectedCoinDistributionsAreApprovedToBeProcessedImmediatelySlackMessage")
+		case ReviewDecisionApprove:
+			return errors.Wrap(r.sendSelectedCoinDistributionsAreApprovedSlackMessage(ctx, totals.Rows, float64(totals.Ice)/100),
+				"failed to sendSelectedCoinDistributionsAreApprovedSlackMessage")
+		default:
+			return errors.Wrap(r.sendSelectedCoinDistributionsAreDeniedSlackMessage(ctx, totals.Rows, float64(totals.Ice)/100),
+				"failed to sendSelectedCoinDistributionsAreDeniedSlackMessage")
+		}
+	})
+}
+
+func (s *SelectedCoinDistributions) where(firstParamIndex int) (string, []any, error) {
+	if s == nil || (s.Filter == nil) == (len(s.Distributions) == 0) {
+		return "", nil, errors.Wrap(ErrInvalidSelection, "either the filter or the distributions have to be provided")
+	}
+	if s.Filter == nil {
+		days, userIDs := make([]string, 0, len(s.Distributions)), make([]string, 0, len(s.Distributions))
+		for _, key := range s.Distributions {
+			if key == nil || key.UserID == "" {
+				return "", nil, errors.Wrapf(ErrInvalidSelection, "userId is missing in %#v", key)
+			}
+			if _, err := stdlibtime.Parse(stdlibtime.DateOnly, key.Day); err != nil {
+				return "", nil, errors.Wrapf(ErrInvalidSelection, "invalid day `%v` for userId %v", key.Day, key.UserID)
+			}
+			days = append(days, key.Day)
+			userIDs = append(userIDs, key.UserID)
+		}
+
+		return fmt.Sprintf("(day, user_id) IN (SELECT * FROM unnest($%v::date[], $%v::text[]))", firstParamIndex, firstParamIndex+1), []any{days, userIDs}, nil
+	}
+	var limit any
+	if s.Filter.Limit > 0 {
+		limit = s.Filter.Limit
+	}
+	conditions, args := s.Filter.keywordConditions(firstParamIndex + 2) //nolint:gomnd // The offset and the limit come first.
+	condition := fmt.Sprintf(`(day, user_id) IN (SELECT day, user_id
+												 FROM coin_distributions_pending_review
+												 WHERE %[1]v
+												 ORDER BY %[2]v
+												 LIMIT $%[4]v OFFSET $%[3]v)`,
+		strings.Join(append(conditions, "1=1"), " AND "),
+		strings.Join(append(s.Filter.orderBy(), "internal_id asc"), ", "),
+		firstParamIndex,
+		firstParamIndex+1)
+
+	return condition, append([]any{s.Filter.Cursor, limit}, args...), nil
+}
+
 func (r *repository) CollectCoinDistributionsForReview(ctx context.Context, records []*ByEarnerForReview) error {
 	if len(records) == 0 {
 		return nil
@@ -246,11 +364,15 @@ func (r *repository) CollectCoinDistributionsForReview(ctx context.Context, reco
 			log.Warn(fmt.Sprintf("(%#v) is a duplicate of (%#v)", record, otherRecord))
 		}
 	}
-	const columns = 9
+	const columns = 10
 	values := make([]string, 0, len(records))
 	args := make([]any, 0, len(records)*columns)
 	ix := 0
 	for _, record := range deduplicated {
+		network := record.Network
+		if network == "" {
+			network = EthereumBlockchainNetworkType
+		}
 		values = append(values, generateValuesSQLParams(ix, columns))
 		args = append(args,
 			record.CreatedAt.Time,
@@ -261,10 +383,11 @@ func (r *repository) CollectCoinDistributionsForReview(ctx context.Context, reco
 			record.ReferredByUsername,
 			record.UserID,
 			record.EarnerUserID,
-			record.EthAddress)
+			record.EthAddress,
+			network)
 		ix++
 	}
-	sql := fmt.Sprintf(`INSERT INTO coin_distributions_by_earner(created_at,day,internal_id,balance,username,referred_by_username,user_id,earner_user_id,eth_address) 
+	sql := fmt.Sprintf(`INSERT INTO coin_distributions_by_earner(created_at,day,internal_id,balance,username,referred_by_username,user_id,earner_user_id,eth_address,network) 
 																 VALUES %v
 						ON CONFLICT (day, user_id, earner_user_id) DO UPDATE
 							SET 
@@ -272,7 +395,8 @@ func (r *repository) CollectCoinDistributionsForReview(ctx context.Context, reco
 								balance = EXCLUDED.balance,
 								username = EXCLUDED.username,
 								referred_by_username = EXCLUDED.referred_by_username,
-								eth_address = EXCLUDED.eth_address`, strings.Join(values, ",\n"))
+								eth_address = EXCLUDED.eth_address,
+								network = EXCLUDED.network`, strings.Join(values, ",\n"))
 	_, err := storage.Exec(ctx, r.db, sql, args...)
 
 	return errors.Wrapf(err, "failed to insert into coin_distributions_by_earner [%v]", len(records))
@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package coindistribution
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectedCoinDistributionsWhere(t *testing.T) {
+	t.Parallel()
+
+	for name, selected := range map[string]*SelectedCoinDistributions{
+		"nil":          nil,
+		"empty":        {},
+		"both":         {Filter: new(GetCoinDistributionsForReviewArg), Distributions: []*CoinDistributionKey{{Day: "2024-01-02", UserID: "a"}}},
+		"nil key":      {Distributions: []*CoinDistributionKey{nil}},
+		"no user id":   {Distributions: []*CoinDistributionKey{{Day: "2024-01-02"}}},
+		"invalid day":  {Distributions: []*CoinDistributionKey{{Day: "02.01.2024", UserID: "a"}}},
+		"missing day":  {Distributions: []*CoinDistributionKey{{UserID: "a"}}},
+		"one bad key":  {Distributions: []*CoinDistributionKey{{Day: "2024-01-02", UserID: "a"}, {Day: "2024-01-02"}}},
+		"bad with day": {Distributions: []*CoinDistributionKey{{Day: "2024-13-02", UserID: "a"}}},
+	} {
+		_, _, err := selected.where(3)
+		require.ErrorIs(t, err, ErrInvalidSelection, name)
+	}
+
+	condition, args, err := (&SelectedCoinDistributions{Distributions: []*CoinDistributionKey{
+		{Day: "2024-01-02", UserID: "a"},
+		{Day: "2024-01-03", UserID: "b"},
+	}}).where(3)
+	require.NoError(t, err)
+	require.Equal(t, "(day, user_id) IN (SELECT * FROM unnest($3::date[], $4::text[]))", condition)
+	require.EqualValues(t, []any{[]string{"2024-01-02", "2024-01-03"}, []string{"a", "b"}}, args)
+
+	condition, args, err = (&SelectedCoinDistributions{Filter: &GetCoinDistributionsForReviewArg{UsernameKeyword: "J_d"}}).where(3)
+	require.NoError(t, err)
+	require.Contains(t, condition, "username LIKE $5 ESCAPE '!'")
+	require.Contains(t, condition, "LIMIT $4 OFFSET $3")
+	require.EqualValues(t, []any{uint64(0), nil, "j!_d%"}, args)
+
+	_, args, err = (&SelectedCoinDistributions{Filter: &GetCoinDistributionsForReviewArg{Cursor: 10, Limit: 5}}).where(3)
+	require.NoError(t, err)
+	require.EqualValues(t, []any{uint64(10), uint64(5)}, args)
+}
+
+func TestGetCoinDistributionsForReviewArgKeywordConditions(t *testing.T) {
+	t.Parallel()
+
+	conditions, args := new(GetCoinDistributionsForReviewArg).where()
+	require.Empty(t, conditions)
+	require.Empty(t, args)
+
+	conditions, args = (&GetCoinDistributionsForReviewArg{
+		UsernameKeyword:           "jd",
+		ReferredByUsernameKeyword: "Ref",
+		RiskFlag:                  RiskFlagSharedEthAddress,
+		FlaggedOnly:               true,
+	}).where()
+	require.Equal(t, []string{
+		"referred_by_username LIKE $3 ESCAPE '!'",
+		"username LIKE $4 ESCAPE '!'",
+		"risk_flags @> ARRAY[$5]::text[]",
+		"cardinality(risk_flags) > 0",
+	}, conditions)
+	require.EqualValues(t, []any{"ref%", "jd%", RiskFlagSharedEthAddress}, args)
+
+	conditions, args = (&GetCoinDistributionsForReviewArg{RiskFlag: RiskFlagDenylistedEthAddress}).totalsWhere()
+	require.Equal(t, []string{"risk_flags @> ARRAY[$1]::text[]"}, conditions)
+	require.EqualValues(t, []any{RiskFlagDenylistedEthAddress}, args)
+}
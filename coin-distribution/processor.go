@@ -12,21 +12,26 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/oklog/ulid/v2"
 	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
 
 	"github.com/ice-blockchain/wintr/connectors/storage/v2"
 	"github.com/ice-blockchain/wintr/log"
 	"github.com/ice-blockchain/wintr/time"
 )
 
-func newCoinProcessor(client ethClient, db *storage.DB, conf *config) *coinProcessor {
+func newCoinProcessor(client ethClient, db *storage.DB, conf *config, network BlockchainNetworkType) *coinProcessor {
 	proc := &coinProcessor{
 		Client:         client,
 		Conf:           conf,
+		NetworkConf:    conf.network(network),
 		WG:             new(sync.WaitGroup),
 		CancelSignal:   make(chan struct{}),
-		databaseConfig: &databaseConfig{DB: db},
+		BatchMetrics:   newBatchMetrics(network),
+		databaseConfig: &databaseConfig{DB: db, Network: network},
 	}
 	proc.gasPriceCache.mu = new(sync.RWMutex)
+	proc.scheduleCache.mu = new(sync.RWMutex)
+	proc.lastTransaction.mu = new(sync.RWMutex)
 	proc.gasPriceCache.time = time.New(stdlibtime.Time{})
 
 	return proc
@@ -38,6 +43,12 @@ func (proc *coinProcessor) Start(ctx context.Context, notify chan<- *batch) {
 		defer proc.WG.Done()
 		proc.Controller(ctx, notify)
 	}()
+	proc.WG.Add(1)
+	go func() {
+		defer proc.WG.Done()
+		proc.Reconciler(ctx)
+	}()
+	go metrics.Log(proc.BatchMetrics.Registry, batchMetricsLogInterval, proc.BatchMetrics)
 }
 
 func (proc *coinProcessor) GetGasPrice(ctx context.Context) (value *big.Int, err error) { //nolint:funlen //.
@@ -72,7 +83,7 @@ func (proc *coinProcessor) GetGasPrice(ctx context.Context) (value *big.Int, err
 	}
 
 	if value != proc.gasPriceCache.price {
-		log.Info(fmt.Sprintf("gas price was updated from %v to %v", proc.gasPriceCache.price, value.String()))
+		log.Info(fmt.Sprintf("%v: gas price was updated from %v to %v", proc.Network, proc.gasPriceCache.price, value.String()))
 	}
 
 	proc.gasPriceCache.price = value
@@ -86,13 +97,15 @@ func (proc *coinProcessor) BatchMarkAccepted(ctx context.Context, data *batch, t
 update pending_coin_distributions
 set
 	eth_status = 'ACCEPTED',
-	eth_tx = $1
+	eth_tx = $1,
+	eth_tx_nonce = $4
 where
 	eth_status = 'PENDING' and
+	network = $3 and
 	user_id = ANY($2)
 `
 
-	_, err := storage.Exec(ctx, proc.DB, stmt, txHash, data.Users())
+	_, err := storage.Exec(ctx, proc.DB, stmt, txHash, data.Users(), proc.Network, data.Nonce)
 	data.SetAccepted(txHash)
 
 	return errors.Wrapf(err, "failed to mark batch %v with TX %v as accepted", data.ID, txHash)
@@ -105,9 +118,10 @@ set
 	eth_status = 'REJECTED'
 where
 	eth_status = 'PENDING' and
+	network = $2 and
 	user_id = ANY($1)
 `
-	_, err := storage.Exec(ctx, proc.DB, stmt, data.Users())
+	_, err := storage.Exec(ctx, proc.DB, stmt, data.Users(), proc.Network)
 	data.SetStatus(ethApiStatusRejected)
 
 	return errors.Wrapf(err, "failed to mark batch %v with as rejected", data.ID)
@@ -117,11 +131,13 @@ func (proc *coinProcessor) BatchPrepareFetch(ctx context.Context) (*batch, error
 	const stmt = `
 with records as (
 	select
+		day,
 		user_id
 	from
 		pending_coin_distributions
 	where
-		eth_status = 'NEW'
+		eth_status = 'NEW' and
+		network = $2
 	order by
 		created_at ASC
 	limit $1
@@ -133,32 +149,86 @@ set
 from
 	records
 where
+	up.day = records.day and
 	up.user_id = records.user_id
 returning up.*
 `
 
-	result, err := storage.ExecMany[batchRecord](ctx, proc.DB, stmt, batchSize)
+	result, err := storage.ExecMany[batchRecord](ctx, proc.DB, stmt, proc.BatchSize(), proc.Network)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to fetch pending coin distributions")
 	} else if len(result) == 0 {
 		return nil, errNotEnoughData
 	}
 
-	return &batch{
+	data := &batch{
 		ID:      ulid.Make().String(),
 		Records: result,
-	}, nil
+	}
+	if err = proc.FitBatchToGasLimit(ctx, data); err != nil {
+		return nil, multierror.Append(err, proc.BatchMarkNew(ctx, data.Records)).ErrorOrNil()
+	}
+
+	return data, nil
+}
+
+// BatchMarkNew puts the records back, to be distributed in the next batches.
+func (proc *coinProcessor) BatchMarkNew(ctx context.Context, records []*batchRecord) error {
+	const stmt = `
+update pending_coin_distributions
+set
+	eth_status = 'NEW'
+where
+	eth_status = 'PENDING' and
+	network = $2 and
+	(day, user_id) IN (SELECT * FROM unnest($1::date[], $3::text[]))
+`
+	// The same user can have records of several days, and only these ones are put back.
+	days, users := (&batch{Records: records}).Keys()
+	_, err := storage.Exec(ctx, proc.DB, stmt, days, proc.Network, users)
+
+	return errors.Wrapf(err, "failed to put %v records back to NEW", len(users))
 }
 
+// DeleteTransactions moves the records of the successful transaction to pending_coin_distribution_reconciliations, see Reconciler.
+// The transaction is also kept in reviewed_coin_distributions, for the users' coin distribution history.
 func (proc *coinProcessor) DeleteTransactions(ctx context.Context, hash string) error {
-	const stmt = `delete from pending_coin_distributions where eth_status = 'ACCEPTED' and eth_tx = $1`
+	const stmt = `
+with del as (
+	delete from pending_coin_distributions
+	where
+		eth_status = 'ACCEPTED' and
+		eth_tx = $1 and
+		network = $2
+	returning *
+), reviewed as (
+	update reviewed_coin_distributions r
+	set
+		eth_tx = del.eth_tx
+	from del
+	where
+		r.user_id = del.user_id and
+		r.day = del.day and
+		r.decision like 'approve%'
+)
+insert into pending_coin_distribution_reconciliations(created_at, day, iceflakes, user_id, eth_address, eth_tx, network)
+select current_timestamp, day, iceflakes, user_id, eth_address, eth_tx, network
+from del
+ON CONFLICT (day, user_id) DO UPDATE
+	SET
+		created_at = EXCLUDED.created_at,
+		iceflakes = EXCLUDED.iceflakes,
+		eth_address = EXCLUDED.eth_address,
+		eth_tx = EXCLUDED.eth_tx,
+		network = EXCLUDED.network
+`
 
-	r, err := storage.Exec(ctx, proc.DB, stmt, hash)
+	r, err := storage.Exec(ctx, proc.DB, stmt, hash, proc.Network)
 	if err != nil {
 		return errors.Wrap(err, "failed to delete transactions")
 	}
 
-	log.Info(fmt.Sprintf("transaction: %v: deleted: %v", hash, r))
+	log.Info(fmt.Sprintf("%v: transaction: %v: deleted: %v", proc.Network, hash, r))
 
 	return nil
 }
@@ -170,46 +240,152 @@ set
 	eth_status = 'REJECTED'
 where
 	eth_status = 'ACCEPTED' and
-	eth_tx = $1
+	eth_tx = $1 and
+	network = $2
 `
 
-	r, err := storage.Exec(ctx, proc.DB, stmt, hash)
+	r, err := storage.Exec(ctx, proc.DB, stmt, hash, proc.Network)
 	if err != nil {
 		return errors.Wrap(err, "failed to update transactions")
 	}
 
-	log.Info(fmt.Sprintf("transaction: %v: rejected: %v", hash, r))
+	log.Info(fmt.Sprintf("%v: transaction: %v: rejected: %v", proc.Network, hash, r))
 
 	return nil
 }
 
-func (proc *coinProcessor) GetGasOptions(ctx context.Context) (price *big.Int, limit uint64, err error) {
+func (proc *coinProcessor) GetGasOptions(ctx context.Context) (*gasOptions, error) {
+	limit, err := proc.GetGasLimit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dynamicFees, err := proc.IsDynamicFeesEnabled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if dynamicFees {
+		opts, dErr := proc.GetDynamicFeeOptions(ctx)
+		if dErr != nil {
+			return nil, dErr
+		}
+		opts.Limit = limit
+
+		return opts, nil
+	}
+
 	gasOverride, err := proc.GetGasPriceOverride(ctx)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
+	opts := &gasOptions{Limit: limit}
 	if gasOverride == 0 {
-		price, err = proc.GetGasPrice(ctx)
+		opts.Price, err = proc.GetGasPrice(ctx)
 		if err != nil {
-			return nil, 0, err
+			return nil, err
 		}
 	} else {
-		price = big.NewInt(0).SetUint64(gasOverride)
+		opts.Price = big.NewInt(0).SetUint64(gasOverride)
 	}
 
-	limit, err = proc.GetGasLimit(ctx)
+	return opts, nil
+}
+
+func (proc *coinProcessor) GetDynamicFeeOptions(ctx context.Context) (*gasOptions, error) {
+	baseFee, ceiling, err := proc.GetBaseFee(ctx)
 	if err != nil {
+		return nil, err
+	} else if ceiling != 0 && baseFee.Cmp(big.NewInt(0).SetUint64(ceiling)) > 0 {
+		return nil, errors.Wrapf(errBaseFeeTooHigh, "base fee %v, ceiling %v", baseFee.String(), ceiling)
+	}
+
+	tipOverride, err := proc.GetGasTipCapOverride(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var tipCap *big.Int
+	if tipOverride == 0 {
+		if tipCap, err = proc.Client.SuggestGasTipCap(ctx); err != nil {
+			return nil, errors.Wrap(err, "failed to get gas tip cap")
+		}
+	} else {
+		tipCap = big.NewInt(0).SetUint64(tipOverride)
+	}
+
+	feeCapLimit, err := proc.GetGasFeeCapLimit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	feeCap, tipCap := dynamicFeeCaps(baseFee, tipCap, feeCapLimit)
+
+	return &gasOptions{FeeCap: feeCap, TipCap: tipCap}, nil
+}
+
+// dynamicFeeCaps allows the base fee to double before the transaction stops being includable, same as go-ethereum does,
+// while never exceeding feeCapLimit (if set). The tip is never higher than the resulting fee cap.
+func dynamicFeeCaps(baseFee, tipCap *big.Int, feeCapLimit uint64) (feeCap, tip *big.Int) {
+	feeCap = big.NewInt(0).Add(tipCap, big.NewInt(0).Mul(baseFee, big.NewInt(2))) //nolint:gomnd,mnd // .
+	if limit := big.NewInt(0).SetUint64(feeCapLimit); feeCapLimit != 0 && feeCap.Cmp(limit) > 0 {
+		feeCap = limit
+	}
+	tip = tipCap
+	if tip.Cmp(feeCap) > 0 {
+		tip = feeCap
+	}
+
+	return feeCap, tip
+}
+
+func (proc *coinProcessor) GetBaseFee(ctx context.Context) (baseFee *big.Int, ceiling uint64, err error) {
+	if ceiling, err = proc.GetBaseFeeCeiling(ctx); err != nil {
 		return nil, 0, err
 	}
+	if baseFee, err = proc.Client.BaseFee(ctx); err != nil {
+		return nil, 0, errors.Wrap(err, "failed to get base fee")
+	}
 
-	return price, limit, nil
+	return baseFee, ceiling, nil
+}
+
+// IsPausedByBaseFee reports whether the latest base fee is above the configured ceiling, in which case distribution should wait.
+// It alerts only once per spike.
+func (proc *coinProcessor) IsPausedByBaseFee(ctx context.Context) bool {
+	if dynamicFees, err := proc.IsDynamicFeesEnabled(ctx); err != nil || !dynamicFees {
+		log.Error(errors.Wrap(err, "failed to check if dynamic fees are enabled"))
+
+		return false
+	}
+	baseFee, ceiling, err := proc.GetBaseFee(ctx)
+	if err != nil {
+		log.Error(errors.Wrapf(err, "%v: failed to check base fee", proc.Network))
+
+		return false
+	}
+	if ceiling == 0 || baseFee.Cmp(big.NewInt(0).SetUint64(ceiling)) <= 0 {
+		if proc.baseFeePaused {
+			log.Info(fmt.Sprintf("%v: base fee %v is back under the ceiling %v, resuming", proc.Network, baseFee.String(), ceiling))
+		}
+		proc.baseFeePaused = false
+
+		return false
+	}
+
+	log.Warn(fmt.Sprintf("%v: base fee %v is above the ceiling %v, distribution is paused", proc.Network, baseFee.String(), ceiling))
+	if !proc.baseFeePaused {
+		log.Error(errors.Wrap(sendCoinDistributerPausedDueToHighBaseFeeSlackMessage(ctx, proc.Network, baseFee, ceiling),
+			"failed to sendCoinDistributerPausedDueToHighBaseFeeSlackMessage"))
+	}
+	proc.baseFeePaused = true
+
+	return true
 }
 
 func (proc *coinProcessor) Distribute(ctx context.Context, data *batch) (string, error) {
 	recipients, amounts := data.Prepare()
 	for recordNum := range data.Records {
-		log.Info(fmt.Sprintf("batch %v: distributing %v iceflakes to address %v for user %q",
+		log.Info(fmt.Sprintf("%v: batch %v: distributing %v iceflakes to address %v for user %q",
+			proc.Network,
 			data.ID,
 			data.Records[recordNum].Iceflakes,
 			data.Records[recordNum].EthAddress,
@@ -217,14 +393,15 @@ func (proc *coinProcessor) Distribute(ctx context.Context, data *batch) (string,
 		))
 	}
 
-	txHash, err := proc.Client.Airdrop(ctx, big.NewInt(proc.Conf.Ethereum.ChainID), proc, recipients, amounts)
+	txHash, nonce, err := proc.Client.Airdrop(ctx, big.NewInt(proc.NetworkConf.ChainID), proc, recipients, amounts)
 	if err != nil {
 		log.Error(errors.Wrapf(err, "batch %v: failed to run contract", data.ID))
 
 		return "", errors.Wrapf(err, "failed to run contract on batch %v", data.ID)
 	}
+	data.Nonce = nonce
 
-	log.Info(fmt.Sprintf("batch %v: transaction hash: %v", data.ID, txHash))
+	log.Info(fmt.Sprintf("batch %v: transaction hash: %v, nonce: %v", data.ID, txHash, nonce))
 
 	return txHash, nil
 }
@@ -236,6 +413,16 @@ func (proc *coinProcessor) Do(ctx context.Context) (*batch, error) {
 	}
 
 	txHash, err := proc.Distribute(ctx, data)
+	// The estimation can be off, if the node still says the batch needs too much gas, it's retried with half of the records.
+	if err != nil && isGasLimitError(err) && len(data.Records) > minBatchSize {
+		log.Error(errors.Wrapf(err, "batch %v needs too much gas, retrying with a smaller one", data.ID))
+		proc.ShrinkBatchSize(uint64(len(data.Records)) / 2) //nolint:gomnd,mnd // .
+		if err = proc.BatchMarkNew(ctx, data.Records); err != nil {
+			return data, err
+		}
+
+		return proc.Do(ctx)
+	}
 	if err != nil {
 		err = errors.Wrapf(err, "failed to distribute batch")
 		log.Error(err)
@@ -275,16 +462,17 @@ func (proc *coinProcessor) GetAction(ctx context.Context) workerAction {
 	case proc.IsOnDemandMode(ctx):
 		return workerActionOnDemand
 
-	case proc.isBlocked():
+	case proc.isBlocked(ctx):
 		return workerActionBlocked
 	}
 
 	return workerActionRun
 }
 
-func (proc *coinProcessor) maybeSendMessage(ctx context.Context, key string, f func(context.Context) error) {
+func (proc *coinProcessor) maybeSendMessage(ctx context.Context, key string, f func(context.Context, BlockchainNetworkType) error) {
 	var lastMessageSentAt time.Time
 
+	key = networkConfigKey(key, proc.Network)
 	err := databaseGetValue(ctx, proc.DB, key, &lastMessageSentAt)
 	if err != nil {
 		log.Error(errors.Wrapf(err, "failed to get %v", key))
@@ -301,7 +489,7 @@ func (proc *coinProcessor) maybeSendMessage(ctx context.Context, key string, f f
 		return
 	}
 
-	err = f(ctx)
+	err = f(ctx, proc.Network)
 	if err != nil {
 		log.Error(errors.Wrapf(err, "failed to send message"))
 
@@ -317,11 +505,11 @@ func (proc *coinProcessor) maybeSendMessage(ctx context.Context, key string, f f
 func (proc *coinProcessor) Controller(ctx context.Context, notify chan<- *batch) {
 	const tickInternal = stdlibtime.Minute
 
-	log.Info("controller started")
-	defer log.Info("controller stopped")
+	log.Info(fmt.Sprintf("%v: controller started", proc.Network))
+	defer log.Info(fmt.Sprintf("%v: controller stopped", proc.Network))
 
 	if proc.HasPendingTransactions(ctx, ethApiStatusAccepted) {
-		log.Info("controller: waiting for all accepted transactions to finish")
+		log.Info(fmt.Sprintf("%v: controller: waiting for all accepted transactions to finish", proc.Network))
 		err := proc.WaitForAllAcceptedTransactions(ctx, notify)
 		if err != nil {
 			log.Error(errors.Wrapf(err, "failed to WaitForAllAcceptedTransactions"))
@@ -348,27 +536,27 @@ func (proc *coinProcessor) Controller(ctx context.Context, notify chan<- *batch)
 	for {
 		select {
 		case <-ctx.Done():
-			log.Info(fmt.Sprintf("controller: context: %v", ctx.Err()))
+			log.Info(fmt.Sprintf("%v: controller: context: %v", proc.Network, ctx.Err()))
 
 			return
 
 		case <-proc.CancelSignal:
-			log.Info("controller: exit signal")
+			log.Info(fmt.Sprintf("%v: controller: exit signal", proc.Network))
 
 			return
 
 		case <-signals:
 			action := proc.GetAction(ctx)
 			if action == workerActionDisabled || action == workerActionBlocked {
-				log.Info(fmt.Sprintf("controller: disabled or blocked (%v)", action))
+				log.Info(fmt.Sprintf("%v: controller: disabled or blocked (%v)", proc.Network, action))
 				if prevAction == workerActionRun {
-					proc.maybeSendMessage(ctx, configKeyCoinDistributerMsgOffline, func(ctx context.Context) (err error) {
+					proc.maybeSendMessage(ctx, configKeyCoinDistributerMsgOffline, func(ctx context.Context, network BlockchainNetworkType) (err error) {
 						if proc.HasPendingTransactions(ctx, ethApiStatusNew) {
-							err = errors.Wrap(sendCoinDistributerHasUnfinishedWork(ctx), "failed to sendCoinDistributerHasUnfinishedWork")
+							err = errors.Wrap(sendCoinDistributerHasUnfinishedWork(ctx, network), "failed to sendCoinDistributerHasUnfinishedWork")
 						}
 
 						return multierror.Append(err,
-							errors.Wrap(sendCoinDistributerIsNowOfflineSlackMessage(ctx),
+							errors.Wrap(sendCoinDistributerIsNowOfflineSlackMessage(ctx, network),
 								"failed to sendCoinDistributerIsNowOfflineSlackMessage"),
 						)
 					})
@@ -379,22 +567,26 @@ func (proc *coinProcessor) Controller(ctx context.Context, notify chan<- *batch)
 			}
 
 			if action == workerActionRun {
-				log.Info("controller: unblocked")
+				log.Info(fmt.Sprintf("%v: controller: unblocked", proc.Network))
 				proc.maybeSendMessage(ctx, configKeyCoinDistributerMsgOnline, sendCoinDistributerIsNowOnlineSlackMessage)
 			} else if action == workerActionOnDemand {
-				log.Info("controller: on demand mode trigger")
+				log.Info(fmt.Sprintf("%v: controller: on demand mode trigger", proc.Network))
 				log.Error(errors.Wrapf(proc.DisableOnDemand(ctx), "failed to DisableOnDemand"))
 			}
 			prevAction = action
 
 			if !proc.HasPendingTransactions(ctx, ethApiStatusNew) {
-				log.Info("controller: no pending transactions")
+				log.Info(fmt.Sprintf("%v: controller: no pending transactions", proc.Network))
+
+				continue
+			}
 
+			if !proc.isMerkleClaimMode() && proc.IsPausedByBaseFee(ctx) {
 				continue
 			}
 
-			log.Info(fmt.Sprintf("controller: running action %v", action))
-			log.Error(errors.Wrap(sendCoinDistributerStartedProcessingSlackMessage(ctx),
+			log.Info(fmt.Sprintf("%v: controller: running action %v", proc.Network, action))
+			log.Error(errors.Wrap(sendCoinDistributerStartedProcessingSlackMessage(ctx, proc.Network),
 				"failed to send DistributerStartedProcessingSlackMessage"))
 			err := proc.RunDistribution(ctx, action == workerActionOnDemand, notify)
 			if err != nil {
@@ -411,7 +603,7 @@ func (proc *coinProcessor) Controller(ctx context.Context, notify chan<- *batch)
 					sendAllCurrentCoinDistributionsWereCommittedInEthereumSlackMessage)
 			}
 
-			log.Info(fmt.Sprintf("controller: action %v finished", action))
+			log.Info(fmt.Sprintf("%v: controller: action %v finished", proc.Network, action))
 		}
 	}
 }
@@ -423,13 +615,14 @@ select
 from
 	pending_coin_distributions
 where
-	eth_status = 'ACCEPTED'
+	eth_status = 'ACCEPTED' and
+	network = $1
 order by
 	created_at ASC
 limit 1
 `
 
-	val, err := storage.Get[string](ctx, proc.DB, stmt)
+	val, err := storage.Get[string](ctx, proc.DB, stmt, proc.Network)
 	if err != nil {
 		if errors.Is(err, storage.ErrNotFound) {
 			err = nil
@@ -452,7 +645,7 @@ func (proc *coinProcessor) WaitForAllAcceptedTransactions(ctx context.Context, n
 			return nil
 		}
 
-		status, err := proc.WaitForTransaction(ctx, txHash)
+		status, txHash, err := proc.WaitForTransaction(ctx, txHash)
 		if err != nil {
 			return err
 		}
@@ -490,23 +683,40 @@ func sleepWithContext(ctx context.Context, d stdlibtime.Duration) {
 	}
 }
 
-func (proc *coinProcessor) WaitForTransaction(ctx context.Context, hash string) (ethTxStatus, error) {
+func (proc *coinProcessor) WaitForTransaction(ctx context.Context, hash string) (ethTxStatus, string, error) { //nolint:funlen,revive //.
 	start := time.Now()
+	tracked, err := proc.GetTrackedTransaction(ctx, hash)
+	if err != nil {
+		return "", "", err
+	}
+	lastBroadcast := start
 
 	for ctx.Err() == nil {
-		status, err := proc.Client.TransactionStatus(ctx, hash)
+		status, minedHash, err := proc.TransactionStatus(ctx, tracked)
 		if err != nil {
-			return "", errors.Wrapf(err, "failed to get transaction status: %v", hash)
+			return "", "", errors.Wrapf(err, "failed to get transaction status: %v", hash)
 		}
 
 		if status == ethTxStatusPending {
+			if proc.shouldReplaceTransaction(tracked, lastBroadcast) {
+				lastBroadcast = time.Now()
+				if rErr := proc.ReplaceTransaction(ctx, tracked); rErr != nil {
+					log.Error(errors.Wrapf(rErr, "%v: failed to replace transaction %v", proc.Network, tracked.Hash))
+				}
+
+				continue
+			}
+
 			sleepDuration := stdlibtime.Second * 3
 
 			if d := stdlibtime.Since(*start.Time); d > stdlibtime.Hour {
 				sleepDuration = stdlibtime.Hour
-				log.Warn(fmt.Sprintf("transaction %v is in PENDING state since %v (%v)", hash, start, d))
+				if proc.canReplaceTransaction(tracked) {
+					sleepDuration = min(sleepDuration, proc.Conf.StuckTransactionTimeout)
+				}
+				log.Warn(fmt.Sprintf("%v: transaction %v is in PENDING state since %v (%v)", proc.Network, tracked.Hash, start, d))
 				if d > stdlibtime.Hour*24 {
-					sendCoinDistributerTransactionStuck(ctx, hash, start)
+					log.Error(errors.Wrap(sendCoinDistributerTransactionStuck(ctx, proc.Network, tracked.Hash, start), "failed to sendCoinDistributerTransactionStuck"))
 				}
 			}
 
@@ -515,27 +725,151 @@ func (proc *coinProcessor) WaitForTransaction(ctx context.Context, hash string)
 			continue
 		}
 
-		log.Info(fmt.Sprintf("transaction %v: status: %v, duration: %v", hash, status, stdlibtime.Since(*start.Time)))
+		if minedHash != tracked.Hash {
+			log.Info(fmt.Sprintf("%v: transaction %v was replaced by %v, but %v got mined", proc.Network, hash, tracked.Hash, minedHash))
+			if err = proc.MarkTransactionMined(ctx, tracked.Hash, minedHash); err != nil {
+				return "", "", err
+			}
+		}
+
+		log.Info(fmt.Sprintf("%v: transaction %v: status: %v, duration: %v", proc.Network, minedHash, status, stdlibtime.Since(*start.Time)))
 
-		return status, nil
+		return status, minedHash, nil
 	}
 
-	return "", ctx.Err()
+	return "", "", ctx.Err()
+}
+
+// GetTrackedTransaction returns the nonce and the replacements history of the given transaction.
+func (proc *coinProcessor) GetTrackedTransaction(ctx context.Context, hash string) (*trackedTransaction, error) {
+	const stmt = `
+select
+	eth_tx_nonce,
+	eth_tx_replaced
+from
+	pending_coin_distributions
+where
+	eth_status = 'ACCEPTED' and
+	eth_tx = $1 and
+	network = $2
+limit 1
+`
+	tracked := &trackedTransaction{Hash: hash}
+	res, err := storage.Get[struct {
+		Nonce    *uint64  `db:"eth_tx_nonce"`
+		Replaced []string `db:"eth_tx_replaced"`
+	}](ctx, proc.DB, stmt, hash, proc.Network)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return tracked, nil
+		}
+
+		return nil, errors.Wrapf(err, "failed to get tracked transaction %v", hash)
+	}
+	tracked.Nonce, tracked.Replaced = res.Nonce, res.Replaced
+
+	return tracked, nil
+}
+
+// TransactionStatus checks the current transaction and all the ones it replaced, any of them could be mined.
+func (proc *coinProcessor) TransactionStatus(ctx context.Context, tracked *trackedTransaction) (status ethTxStatus, minedHash string, err error) {
+	for _, hash := range append([]string{tracked.Hash}, tracked.Replaced...) {
+		if status, err = proc.Client.TransactionStatus(ctx, hash); err != nil || status != ethTxStatusPending {
+			return status, hash, err //nolint:wrapcheck //.
+		}
+	}
+
+	return ethTxStatusPending, tracked.Hash, nil
+}
+
+func (proc *coinProcessor) canReplaceTransaction(tracked *trackedTransaction) bool {
+	return tracked.Nonce != nil &&
+		proc.Conf.StuckTransactionTimeout > 0 &&
+		len(tracked.Replaced) < proc.Conf.MaxTransactionReplacements
+}
+
+func (proc *coinProcessor) shouldReplaceTransaction(tracked *trackedTransaction, lastBroadcast *time.Time) bool {
+	return proc.canReplaceTransaction(tracked) && stdlibtime.Since(*lastBroadcast.Time) > proc.Conf.StuckTransactionTimeout
+}
+
+// ReplaceTransaction re-broadcasts the airdrop of the stuck transaction with the same nonce and bumped fees.
+func (proc *coinProcessor) ReplaceTransaction(ctx context.Context, tracked *trackedTransaction) error {
+	const selectStmt = `select * from pending_coin_distributions where eth_status = 'ACCEPTED' and eth_tx = $1 and network = $2`
+	records, err := storage.Select[batchRecord](ctx, proc.DB, selectStmt, tracked.Hash, proc.Network)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get records of transaction %v", tracked.Hash)
+	} else if len(records) == 0 {
+		return errors.Wrapf(errNotEnoughData, "no records for transaction %v", tracked.Hash)
+	} else if tracked.Nonce == nil {
+		return errors.Wrapf(errUnknownNonce, "transaction %v", tracked.Hash)
+	}
+
+	recipients, amounts := (&batch{Records: records}).Prepare()
+	replaced := &replacedTransaction{Hash: tracked.Hash, Nonce: *tracked.Nonce, BumpPercent: proc.Conf.TransactionReplacementFeeBumpPercent}
+	txHash, err := proc.Client.ReplaceAirdrop(ctx, big.NewInt(proc.NetworkConf.ChainID), proc, replaced, recipients, amounts)
+	if err != nil {
+		return errors.Wrapf(err, "failed to replace transaction %v", tracked.Hash)
+	}
+
+	const updateStmt = `
+update pending_coin_distributions
+set
+	eth_tx = $1,
+	eth_tx_replaced = array_append(eth_tx_replaced, $2)
+where
+	eth_status = 'ACCEPTED' and
+	eth_tx = $2 and
+	network = $3
+`
+	if _, err = storage.Exec(ctx, proc.DB, updateStmt, txHash, tracked.Hash, proc.Network); err != nil {
+		return errors.Wrapf(err, "failed to save replacement %v of transaction %v", txHash, tracked.Hash)
+	}
+	log.Info(fmt.Sprintf("%v: transaction %v (nonce %v) was replaced by %v (%v of %v)",
+		proc.Network, tracked.Hash, *tracked.Nonce, txHash, len(tracked.Replaced)+1, proc.Conf.MaxTransactionReplacements))
+	tracked.Replaced = append(tracked.Replaced, tracked.Hash)
+	tracked.Hash = txHash
+
+	return nil
+}
+
+// MarkTransactionMined points the records back to the transaction that actually got mined, when it's not the latest replacement.
+func (proc *coinProcessor) MarkTransactionMined(ctx context.Context, currentHash, minedHash string) error {
+	const stmt = `
+update pending_coin_distributions
+set
+	eth_tx = $1,
+	eth_tx_replaced = array_append(array_remove(eth_tx_replaced, $1), $2)
+where
+	eth_status = 'ACCEPTED' and
+	eth_tx = $2 and
+	network = $3
+`
+	_, err := storage.Exec(ctx, proc.DB, stmt, minedHash, currentHash, proc.Network)
+
+	return errors.Wrapf(err, "failed to mark transaction %v as mined instead of %v", minedHash, currentHash)
 }
 
 func (proc *coinProcessor) RunDistribution(ctx context.Context, ondemand bool, notify chan<- *batch) error {
+	if proc.isMerkleClaimMode() {
+		return proc.BuildMerkleTree(ctx)
+	}
+
 	for it := 1; ctx.Err() == nil; it++ {
 		if !proc.IsEnabled(ctx) {
-			log.Info(fmt.Sprintf("distribution: iteration %v: disabled", it))
+			log.Info(fmt.Sprintf("%v: distribution: iteration %v: disabled", proc.Network, it))
 
 			return nil
-		} else if proc.isBlocked() && !ondemand {
-			log.Info(fmt.Sprintf("distribution: iteration %v: blocked", it))
+		} else if proc.isBlocked(ctx) && !ondemand {
+			log.Info(fmt.Sprintf("%v: distribution: iteration %v: blocked", proc.Network, it))
+
+			return nil
+		} else if proc.IsPausedByBaseFee(ctx) {
+			log.Info(fmt.Sprintf("%v: distribution: iteration %v: paused due to base fee", proc.Network, it))
 
 			return nil
 		}
 
-		log.Info(fmt.Sprintf("distribution: iteration %v", it))
+		log.Info(fmt.Sprintf("%v: distribution: iteration %v", proc.Network, it))
 		b, err := proc.Do(context.WithoutCancel(ctx))
 		if err != nil {
 			if errors.Is(err, errNotEnoughData) {
@@ -545,12 +879,16 @@ func (proc *coinProcessor) RunDistribution(ctx context.Context, ondemand bool, n
 			return err
 		}
 
-		status, err := proc.WaitForTransaction(ctx, b.TX)
+		proc.setLastTransaction(b)
+
+		status, txHash, err := proc.WaitForTransaction(ctx, b.TX)
 		if err != nil {
 			return err
 		}
+		b.TX = txHash
 
 		b.Status = status
+		proc.setLastTransaction(b)
 		sendNotify(notify, b)
 
 		switch status {
@@ -574,27 +912,6 @@ func (proc *coinProcessor) RunDistribution(ctx context.Context, ondemand bool, n
 	return ctx.Err()
 }
 
-// isInTimeWindow checks if current hour is in time window [startHour, endHour].
-func isInTimeWindow(now *time.Time, startHour, endHour int) bool {
-	for _, v := range []int{startHour, endHour} {
-		if v < 0 || v > 23 {
-			log.Panic(fmt.Sprintf("invalid hour: %v", v))
-		}
-	}
-
-	currentHour := now.Hour()
-	currentMinute := now.Minute()
-	if startHour < endHour {
-		return (currentHour >= startHour && currentHour < endHour) || (currentHour == endHour && currentMinute == 0)
-	}
-
-	return currentHour >= startHour || (currentHour < endHour || (currentHour == endHour && currentMinute == 0))
-}
-
-func (proc *coinProcessor) isBlocked() bool {
-	return !isInTimeWindow(time.Now(), proc.Conf.StartHours, proc.Conf.EndHours)
-}
-
 func (proc *coinProcessor) Close() error {
 	close(proc.CancelSignal)
 
@@ -5,6 +5,7 @@ package coindistribution
 import (
 	"context"
 	"errors"
+	"math/big"
 	"math/rand"
 	"os"
 	"testing"
@@ -68,7 +69,7 @@ func helperTruncatePendingTransactions(ctx context.Context, t *testing.T, db *st
 func TestBatchPrepareFetch(t *testing.T) { //nolint:paralleltest //.
 	maybeSkipTest(t)
 	ctx := context.TODO()
-	proc := newCoinProcessor(nil, storage.MustConnect(ctx, ddl, applicationYamlKey), &config{})
+	proc := newCoinProcessor(nil, storage.MustConnect(ctx, ddl, applicationYamlKey), &config{}, EthereumBlockchainNetworkType)
 	require.NotNil(t, proc)
 	defer proc.Close()
 
@@ -93,7 +94,7 @@ func TestGetGasPrice(t *testing.T) { //nolint:tparallel //.
 	t.Parallel()
 
 	ctx := context.TODO()
-	proc := newCoinProcessor(new(mockedDummyEthClient), nil, &config{})
+	proc := newCoinProcessor(new(mockedDummyEthClient), nil, &config{}, EthereumBlockchainNetworkType)
 	require.NotNil(t, proc)
 	defer proc.Close()
 
@@ -124,12 +125,12 @@ func TestGetGasPrice(t *testing.T) { //nolint:tparallel //.
 func TestProcessorDistributeAccepted(t *testing.T) { //nolint:paralleltest //.
 	maybeSkipTest(t)
 	ctx := context.TODO()
-	proc := newCoinProcessor(new(mockedDummyEthClient), storage.MustConnect(ctx, ddl, applicationYamlKey), &config{})
+	proc := newCoinProcessor(new(mockedDummyEthClient), storage.MustConnect(ctx, ddl, applicationYamlKey), &config{}, EthereumBlockchainNetworkType)
 	require.NotNil(t, proc)
 	defer proc.Close()
 
 	helperTruncatePendingTransactions(ctx, t, proc.DB)
-	helperAddNewPendingTransaction(ctx, t, proc, batchSize*3)
+	helperAddNewPendingTransaction(ctx, t, proc, defaultMaxBatchSize*3)
 
 	ch := make(chan *batch, 3)
 	proc.Start(ctx, ch)
@@ -148,6 +149,7 @@ func TestProcessorDistributeRejected(t *testing.T) { //nolint:paralleltest //.
 	proc := newCoinProcessor(&mockedDummyEthClient{dropErr: errors.New("drop error")}, //nolint:goerr113 //.
 		storage.MustConnect(ctx, ddl, applicationYamlKey),
 		&config{},
+		EthereumBlockchainNetworkType,
 	)
 	require.NotNil(t, proc)
 	defer proc.Close()
@@ -171,38 +173,6 @@ func TestProcessorDistributeRejected(t *testing.T) { //nolint:paralleltest //.
 	}
 }
 
-func TestIsInTimeWindow(t *testing.T) {
-	t.Parallel()
-
-	clock := func(h, m int) *time.Time {
-		return time.New(stdlibtime.Date(2038, 1, 1, h, m, 0, 0, stdlibtime.UTC))
-	}
-
-	require.True(t, isInTimeWindow(clock(10, 0), 10, 22))
-	require.True(t, isInTimeWindow(clock(23, 0), 22, 6))
-	require.True(t, isInTimeWindow(clock(6, 0), 22, 6))
-	require.True(t, isInTimeWindow(clock(0, 0), 22, 6))
-	require.False(t, isInTimeWindow(clock(17, 0), 22, 6))
-	require.False(t, isInTimeWindow(clock(23, 0), 10, 22))
-	require.False(t, isInTimeWindow(clock(9, 0), 10, 22))
-	require.True(t, isInTimeWindow(clock(2, 0), 0, 23))
-	require.True(t, isInTimeWindow(clock(0, 0), 0, 0))
-	require.True(t, isInTimeWindow(clock(1, 0), 0, 0))
-
-	require.True(t, isInTimeWindow(clock(16, 0), 16, 18))
-	require.True(t, isInTimeWindow(clock(16, 1), 16, 18))
-	require.True(t, isInTimeWindow(clock(17, 22), 16, 18))
-	require.True(t, isInTimeWindow(clock(18, 0), 16, 18))
-	require.False(t, isInTimeWindow(clock(18, 1), 16, 18))
-
-	require.Panics(t, func() {
-		isInTimeWindow(nil, -1, 0)
-	})
-	require.Panics(t, func() {
-		isInTimeWindow(nil, 0, 24)
-	})
-}
-
 func TestProcessorTriggerOnDemand(t *testing.T) { //nolint:paralleltest //.
 	maybeSkipTest(t)
 	ctx := context.TODO()
@@ -213,16 +183,17 @@ func TestProcessorTriggerOnDemand(t *testing.T) { //nolint:paralleltest //.
 			StartHours: now.Hour() - 2,
 			EndHours:   now.Hour() - 1,
 		},
+		EthereumBlockchainNetworkType,
 	)
 	require.NotNil(t, proc)
 	defer proc.Close()
 
 	helperTruncatePendingTransactions(ctx, t, proc.DB)
-	helperAddNewPendingTransaction(ctx, t, proc, batchSize*4)
+	helperAddNewPendingTransaction(ctx, t, proc, defaultMaxBatchSize*4)
 
 	ch := make(chan *batch, 4)
 	proc.Start(ctx, ch)
-	require.True(t, proc.isBlocked())
+	require.True(t, proc.isBlocked(ctx))
 
 	select {
 	case <-ch:
@@ -247,3 +218,19 @@ func TestProcessorTriggerOnDemand(t *testing.T) { //nolint:paralleltest //.
 
 	require.False(t, proc.IsOnDemandMode(ctx))
 }
+
+func TestDynamicFeeCaps(t *testing.T) {
+	t.Parallel()
+
+	feeCap, tip := dynamicFeeCaps(big.NewInt(100), big.NewInt(2), 0)
+	require.Equal(t, big.NewInt(202), feeCap)
+	require.Equal(t, big.NewInt(2), tip)
+
+	feeCap, tip = dynamicFeeCaps(big.NewInt(100), big.NewInt(2), 150)
+	require.Equal(t, big.NewInt(150), feeCap)
+	require.Equal(t, big.NewInt(2), tip)
+
+	feeCap, tip = dynamicFeeCaps(big.NewInt(100), big.NewInt(50), 20)
+	require.Equal(t, big.NewInt(20), feeCap)
+	require.Equal(t, big.NewInt(20), tip)
+}
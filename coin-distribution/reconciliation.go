@@ -0,0 +1,225 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package coindistribution
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	stdlibtime "time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+
+	coindistribution "github.com/ice-blockchain/freezer/coin-distribution/internal"
+	"github.com/ice-blockchain/wintr/connectors/storage/v2"
+	"github.com/ice-blockchain/wintr/log"
+)
+
+//nolint:gochecknoglobals // It's a constant.
+var transferEventTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// TransferEvents returns the Transfer events of the transaction.
+// `airdropToWallets` mints without emitting any events though, so if there are none, they are derived
+// from the recipients' balance changes in the transaction's block instead.
+func (r *transferEventsReader) TransferEvents(
+	ctx context.Context, hash string, recipients []common.Address,
+) ([]*coindistribution.CoindistributionTransfer, error) {
+	receipt, err := r.Receipts.TransactionReceipt(ctx, common.HexToHash(hash))
+	if err != nil {
+		return nil, err //nolint:wrapcheck //.
+	}
+
+	events := make([]*coindistribution.CoindistributionTransfer, 0, len(receipt.Logs))
+	for _, entry := range receipt.Logs {
+		if entry.Address != r.Contract || len(entry.Topics) == 0 || entry.Topics[0] != transferEventTopic {
+			continue
+		}
+		event, pErr := r.Token.ParseTransfer(*entry)
+		if pErr != nil {
+			return nil, errors.Wrapf(pErr, "failed to parse Transfer event #%v of %v", entry.Index, hash)
+		}
+		events = append(events, event)
+	}
+	if len(events) != 0 || receipt.Status != types.ReceiptStatusSuccessful {
+		return events, nil
+	}
+
+	return r.balanceChanges(ctx, receipt, recipients)
+}
+
+func (r *transferEventsReader) balanceChanges(
+	ctx context.Context, receipt *types.Receipt, recipients []common.Address,
+) ([]*coindistribution.CoindistributionTransfer, error) {
+	before, after := new(big.Int).Sub(receipt.BlockNumber, big.NewInt(1)), receipt.BlockNumber
+	events := make([]*coindistribution.CoindistributionTransfer, 0, len(recipients))
+	seen := make(map[common.Address]struct{}, len(recipients))
+	for _, recipient := range recipients {
+		if _, found := seen[recipient]; found {
+			continue
+		}
+		seen[recipient] = struct{}{}
+		balanceBefore, err := r.Balances.BalanceOf(&bind.CallOpts{Context: ctx, BlockNumber: before}, recipient)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get balance of %v at block %v", recipient.Hex(), before)
+		}
+		balanceAfter, err := r.Balances.BalanceOf(&bind.CallOpts{Context: ctx, BlockNumber: after}, recipient)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get balance of %v at block %v", recipient.Hex(), after)
+		}
+		if diff := new(big.Int).Sub(balanceAfter, balanceBefore); diff.Sign() != 0 {
+			events = append(events, &coindistribution.CoindistributionTransfer{To: recipient, Value: diff})
+		}
+	}
+
+	return events, nil
+}
+
+func (r *reconciliationRecord) Amount() *big.Int {
+	return (&batchRecord{UserID: r.UserID, Iceflakes: r.Iceflakes}).Amount()
+}
+
+// reconcileTransfers matches the expected iceflakes per address against the ones actually transferred.
+// Mismatches are returned sorted by address.
+func reconcileTransfers(records []*reconciliationRecord, transfers []*coindistribution.CoindistributionTransfer) []*reconciliationMismatch {
+	byAddress := make(map[common.Address]*reconciliationMismatch, len(records))
+	get := func(address common.Address) *reconciliationMismatch {
+		if _, found := byAddress[address]; !found {
+			byAddress[address] = &reconciliationMismatch{EthAddress: address, Expected: big.NewInt(0), Actual: big.NewInt(0)}
+		}
+
+		return byAddress[address]
+	}
+	for _, record := range records {
+		expected := get(common.HexToAddress(record.EthAddress))
+		expected.Expected.Add(expected.Expected, record.Amount())
+		expected.UserIDs = append(expected.UserIDs, record.UserID)
+	}
+	for _, transfer := range transfers {
+		actual := get(transfer.To)
+		actual.Actual.Add(actual.Actual, transfer.Value)
+	}
+
+	mismatches := make([]*reconciliationMismatch, 0)
+	for _, res := range byAddress {
+		if res.Expected.Cmp(res.Actual) != 0 {
+			mismatches = append(mismatches, res)
+		}
+	}
+	sort.Slice(mismatches, func(i, j int) bool {
+		return mismatches[i].EthAddress.Cmp(mismatches[j].EthAddress) < 0
+	})
+
+	return mismatches
+}
+
+func (proc *coinProcessor) Reconciler(ctx context.Context) {
+	log.Info(fmt.Sprintf("%v: reconciler started", proc.Network))
+	defer log.Info(fmt.Sprintf("%v: reconciler stopped", proc.Network))
+
+	ticker := stdlibtime.NewTicker(reconciliationTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-proc.CancelSignal:
+			return
+
+		case <-ticker.C:
+			hashes, err := proc.GetTransactionsToReconcile(ctx)
+			if err != nil {
+				log.Error(errors.Wrapf(err, "%v: failed to get transactions to reconcile", proc.Network))
+
+				continue
+			}
+			for _, hash := range hashes {
+				log.Error(errors.Wrapf(proc.ReconcileTransaction(ctx, hash), "%v: failed to reconcile transaction %v", proc.Network, hash))
+			}
+		}
+	}
+}
+
+func (proc *coinProcessor) GetTransactionsToReconcile(ctx context.Context) ([]string, error) {
+	const stmt = `
+select
+	eth_tx
+from
+	pending_coin_distribution_reconciliations
+where
+	network = $1
+group by
+	eth_tx
+order by
+	min(created_at) ASC
+limit $2
+`
+	hashes, err := storage.Select[string](ctx, proc.DB, stmt, proc.Network, reconciliationBatchSize)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to select transactions to reconcile")
+	}
+	res := make([]string, 0, len(hashes))
+	for _, hash := range hashes {
+		res = append(res, *hash)
+	}
+
+	return res, nil
+}
+
+func (proc *coinProcessor) ReconcileTransaction(ctx context.Context, hash string) error {
+	const selectStmt = `select user_id, eth_address, iceflakes from pending_coin_distribution_reconciliations where eth_tx = $1 and network = $2`
+	records, err := storage.Select[reconciliationRecord](ctx, proc.DB, selectStmt, hash, proc.Network)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get records of transaction %v", hash)
+	} else if len(records) == 0 {
+		return nil
+	}
+
+	recipients := make([]common.Address, 0, len(records))
+	for _, record := range records {
+		recipients = append(recipients, common.HexToAddress(record.EthAddress))
+	}
+	transfers, err := proc.Client.TransferEvents(ctx, hash, recipients)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get Transfer events of transaction %v", hash)
+	}
+	mismatches := reconcileTransfers(records, transfers)
+
+	if err = storage.DoInTransaction(ctx, proc.DB, func(conn storage.QueryExecer) error {
+		const insertStmt = `
+INSERT INTO coin_distribution_reconciliation_mismatches(created_at, expected_iceflakes, actual_iceflakes, eth_tx, eth_address, network, user_ids)
+VALUES (current_timestamp, $1::uint256, $2::uint256, $3, $4, $5, $6)
+ON CONFLICT (eth_tx, eth_address) DO NOTHING`
+		for _, mismatch := range mismatches {
+			if _, iErr := storage.Exec(ctx, conn, insertStmt,
+				mismatch.Expected.String(), mismatch.Actual.String(), hash, mismatch.EthAddress.Hex(), proc.Network, mismatch.UserIDs); iErr != nil {
+				return errors.Wrapf(iErr, "failed to insert mismatch for %v", mismatch.EthAddress.Hex())
+			}
+		}
+		const deleteStmt = `delete from pending_coin_distribution_reconciliations where eth_tx = $1 and network = $2`
+		_, dErr := storage.Exec(ctx, conn, deleteStmt, hash, proc.Network)
+
+		return errors.Wrapf(dErr, "failed to delete reconciled records of transaction %v", hash)
+	}); err != nil {
+		return err //nolint:wrapcheck //.
+	}
+
+	if len(mismatches) == 0 {
+		log.Info(fmt.Sprintf("%v: transaction %v: reconciled %v records, no mismatches", proc.Network, hash, len(records)))
+
+		return nil
+	}
+	for _, mismatch := range mismatches {
+		log.Warn(fmt.Sprintf("%v: transaction %v: address %v expected %v iceflakes, got %v (users %v)",
+			proc.Network, hash, mismatch.EthAddress.Hex(), mismatch.Expected.String(), mismatch.Actual.String(), mismatch.UserIDs))
+	}
+
+	return errors.Wrap(sendCoinDistributionReconciliationMismatchSlackMessage(ctx, proc.Network, hash, mismatches),
+		"failed to sendCoinDistributionReconciliationMismatchSlackMessage")
+}
@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package coindistribution
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/stretchr/testify/require"
+
+	coindistribution "github.com/ice-blockchain/freezer/coin-distribution/internal"
+)
+
+func TestReconcileTransfers(t *testing.T) {
+	t.Parallel()
+
+	alice, bob, eve := common.Address{1}, common.Address{2}, common.Address{3}
+	records := []*reconciliationRecord{
+		{UserID: "a1", EthAddress: alice.Hex(), Iceflakes: "100"},
+		{UserID: "a2", EthAddress: alice.Hex(), Iceflakes: "50"},
+		{UserID: "b", EthAddress: bob.Hex(), Iceflakes: "10"},
+	}
+
+	require.Empty(t, reconcileTransfers(records, []*coindistribution.CoindistributionTransfer{
+		{To: alice, Value: big.NewInt(150)},
+		{To: bob, Value: big.NewInt(10)},
+	}))
+
+	mismatches := reconcileTransfers(records, []*coindistribution.CoindistributionTransfer{
+		{To: alice, Value: big.NewInt(100)},
+		{To: eve, Value: big.NewInt(10)},
+	})
+	require.Len(t, mismatches, 3)
+	require.Equal(t, &reconciliationMismatch{EthAddress: alice, Expected: big.NewInt(150), Actual: big.NewInt(100), UserIDs: []string{"a1", "a2"}}, mismatches[0])
+	require.Equal(t, &reconciliationMismatch{EthAddress: bob, Expected: big.NewInt(10), Actual: big.NewInt(0), UserIDs: []string{"b"}}, mismatches[1])
+	require.Equal(t, &reconciliationMismatch{EthAddress: eve, Expected: big.NewInt(0), Actual: big.NewInt(10)}, mismatches[2])
+}
+
+func TestTransferEventsWithSimulatedBackend(t *testing.T) {
+	t.Parallel()
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	owner := crypto.PubkeyToAddress(key.PublicKey)
+	backend := simulated.NewBackend(types.GenesisAlloc{owner: {Balance: big.NewInt(0).Mul(big.NewInt(1_000), big.NewInt(1e18))}})
+	defer backend.Close()
+	client := backend.Client()
+	// The genesis block is pre-merge, so Shanghai opcodes (used by the contract) are available only from the next one.
+	backend.Commit()
+
+	chainID, err := client.ChainID(context.TODO())
+	require.NoError(t, err)
+	auth, err := bind.NewKeyedTransactorWithChainID(key, chainID)
+	require.NoError(t, err)
+
+	contract, _, token, err := coindistribution.DeployCoindistribution(auth, client)
+	require.NoError(t, err)
+	backend.Commit()
+	_, err = token.SetAirDropper(auth, owner)
+	require.NoError(t, err)
+	backend.Commit()
+
+	alice, bob := common.Address{1}, common.Address{2}
+	tx, err := token.AirdropToWallets(auth, []common.Address{alice, bob, owner}, []*big.Int{big.NewInt(150), big.NewInt(10), big.NewInt(1_000)})
+	require.NoError(t, err)
+	backend.Commit()
+
+	reader := &transferEventsReader{Receipts: client, Token: &token.CoindistributionFilterer, Balances: &token.CoindistributionCaller, Contract: contract}
+	transfers, err := reader.TransferEvents(context.TODO(), tx.Hash().Hex(), []common.Address{alice, bob, alice})
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+
+	records := []*reconciliationRecord{
+		{UserID: "a", EthAddress: alice.Hex(), Iceflakes: "150"},
+		{UserID: "b", EthAddress: bob.Hex(), Iceflakes: "10"},
+	}
+	require.Empty(t, reconcileTransfers(records, transfers))
+
+	records[1].Iceflakes = "11"
+	mismatches := reconcileTransfers(records, transfers)
+	require.Len(t, mismatches, 1)
+	require.Equal(t, bob, mismatches[0].EthAddress)
+	require.Equal(t, big.NewInt(10), mismatches[0].Actual)
+
+	tx, err = token.Transfer(auth, alice, big.NewInt(100))
+	require.NoError(t, err)
+	backend.Commit()
+
+	transfers, err = reader.TransferEvents(context.TODO(), tx.Hash().Hex(), nil)
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+	require.Equal(t, owner, transfers[0].From)
+	require.Empty(t, reconcileTransfers([]*reconciliationRecord{{UserID: "a", EthAddress: alice.Hex(), Iceflakes: "100"}}, transfers))
+}
@@ -0,0 +1,266 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package coindistribution
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	stdlibtime "time"
+
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/wintr/connectors/storage/v2"
+	"github.com/ice-blockchain/wintr/log"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+// ParseDistributionSchedule parses and validates the JSON of a DistributionSchedule. An empty value means there's no schedule (nil).
+func ParseDistributionSchedule(val string) (*DistributionSchedule, error) {
+	if strings.TrimSpace(val) == "" {
+		return nil, nil //nolint:nilnil // Nil schedule means the config's daily window.
+	}
+	schedule := new(DistributionSchedule)
+	if err := json.Unmarshal([]byte(val), schedule); err != nil {
+		return nil, errors.Wrapf(ErrInvalidSchedule, "failed to parse %q: %v", val, err)
+	}
+
+	return schedule, schedule.compile()
+}
+
+// legacyDistributionSchedule is the daily `startHours`-`endHours` UTC window from the config, `endHours`:00 included.
+// It's open all day if they're equal.
+func legacyDistributionSchedule(startHours, endHours int) *DistributionSchedule {
+	window := &DistributionScheduleWindow{Start: fmt.Sprintf("%02d:00", startHours), End: fmt.Sprintf("%02d:01", endHours)}
+	if startHours == endHours {
+		window = &DistributionScheduleWindow{Start: "00:00", End: "24:00"}
+	}
+	schedule := &DistributionSchedule{Windows: make(map[string][]*DistributionScheduleWindow, len(scheduleWeekdays))}
+	for day := range scheduleWeekdays {
+		schedule.Windows[day] = []*DistributionScheduleWindow{window}
+	}
+	log.Panic(errors.Wrapf(schedule.compile(), "invalid startHours %v or endHours %v", startHours, endHours)) //nolint:revive,nolintlint //.
+
+	return schedule
+}
+
+func (s *DistributionSchedule) compile() error {
+	location, err := stdlibtime.LoadLocation(s.Timezone)
+	if err != nil {
+		return errors.Wrapf(ErrInvalidSchedule, "invalid timezone %q: %v", s.Timezone, err)
+	}
+	s.location = location
+	s.weekdays = [7][]*scheduleMinutes{}
+	for day, windows := range s.Windows {
+		weekday, found := scheduleWeekdays[strings.ToLower(day)]
+		if !found {
+			return errors.Wrapf(ErrInvalidSchedule, "invalid weekday %q", day)
+		}
+		for _, window := range windows {
+			minutes, wErr := window.minutes()
+			if wErr != nil {
+				return errors.Wrapf(wErr, "invalid %v window", day)
+			}
+			s.weekdays[weekday] = append(s.weekdays[weekday], minutes)
+		}
+	}
+	s.blackoutDates = make(map[string]struct{}, len(s.BlackoutDates))
+	for _, date := range s.BlackoutDates {
+		if _, err = stdlibtime.ParseInLocation(stdlibtime.DateOnly, date, location); err != nil {
+			return errors.Wrapf(ErrInvalidSchedule, "invalid blackout date %q: %v", date, err)
+		}
+		s.blackoutDates[date] = struct{}{}
+	}
+
+	return nil
+}
+
+func (w *DistributionScheduleWindow) minutes() (*scheduleMinutes, error) {
+	const minutesPerDay = 24 * 60
+	parse := func(val string) (int, error) {
+		if val == "24:00" {
+			return minutesPerDay, nil
+		}
+		parsed, err := stdlibtime.Parse("15:04", val)
+		if err != nil {
+			return 0, errors.Wrapf(ErrInvalidSchedule, "invalid time %q, `15:04` expected", val)
+		}
+
+		return parsed.Hour()*60 + parsed.Minute(), nil //nolint:gomnd,mnd // .
+	}
+	start, err := parse(w.Start)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parse(w.End)
+	if err != nil {
+		return nil, err
+	}
+	if start == minutesPerDay {
+		return nil, errors.Wrapf(ErrInvalidSchedule, "window can't start at %v", w.Start)
+	}
+	if end <= start {
+		end += minutesPerDay
+	}
+
+	return &scheduleMinutes{Start: start, End: end}, nil
+}
+
+// NextWindow returns the window open at now, or the next one, nil if there's none in the next scheduleLookaheadDays.
+// The days are scanned one by one, only until that window, merged with the ones that overlap or touch it, is over.
+func (s *DistributionSchedule) NextWindow(now stdlibtime.Time) *ScheduledWindow {
+	local := now.In(s.location)
+	year, month, day := local.Date()
+	var next *ScheduledWindow
+	// Overnight windows of the previous day might still be open.
+	for offset := -1; offset <= scheduleLookaheadDays; offset++ {
+		for _, part := range s.windowsStartingOn(year, month, day+offset, now) {
+			switch {
+			case next == nil:
+				next = &ScheduledWindow{Start: time.New(part.start), End: time.New(part.end)}
+			case !part.start.After(*next.End.Time):
+				if part.end.After(*next.End.Time) {
+					next.End = time.New(part.end)
+				}
+			default:
+				return next
+			}
+		}
+		// The windows of the next days start after it ends, so they can't be merged with it anymore.
+		if next != nil && next.End.Before(stdlibtime.Date(year, month, day+offset+1, 0, 0, 0, 0, s.location)) {
+			return next
+		}
+	}
+
+	return next
+}
+
+// windowsStartingOn returns the parts of the windows, that are not over at now, which start on the provided day, sorted:
+// the ones of that day, up to its end, and the rest of the overnight ones of the previous day.
+// The part of a window in each day is dropped separately, if that day is a blackout date.
+func (s *DistributionSchedule) windowsStartingOn(year int, month stdlibtime.Month, day int, now stdlibtime.Time) []*scheduledPart {
+	date := stdlibtime.Date(year, month, day, 0, 0, 0, 0, s.location)
+	if _, blackout := s.blackoutDates[date.Format(stdlibtime.DateOnly)]; blackout {
+		return nil
+	}
+	nextDate, previousDate := stdlibtime.Date(year, month, day+1, 0, 0, 0, 0, s.location), stdlibtime.Date(year, month, day-1, 0, 0, 0, 0, s.location)
+	var parts []*scheduledPart
+	for _, minutes := range s.weekdays[previousDate.Weekday()] {
+		if end := stdlibtime.Date(year, month, day-1, 0, minutes.End, 0, 0, s.location); end.After(date) && end.After(now) {
+			parts = append(parts, &scheduledPart{start: date, end: end})
+		}
+	}
+	for _, minutes := range s.weekdays[date.Weekday()] {
+		start := stdlibtime.Date(year, month, day, 0, minutes.Start, 0, 0, s.location)
+		if end := minTime(stdlibtime.Date(year, month, day, 0, minutes.End, 0, 0, s.location), nextDate); end.After(start) && end.After(now) {
+			parts = append(parts, &scheduledPart{start: start, end: end})
+		}
+	}
+	sort.SliceStable(parts, func(i, j int) bool { return parts[i].start.Before(parts[j].start) })
+
+	return parts
+}
+
+func minTime(a, b stdlibtime.Time) stdlibtime.Time {
+	if a.Before(b) {
+		return a
+	}
+
+	return b
+}
+
+func (s *DistributionSchedule) IsOpen(now stdlibtime.Time) bool {
+	window := s.NextWindow(now)
+
+	return window != nil && !window.Start.After(now)
+}
+
+func (d *databaseConfig) GetSchedule(ctx context.Context) (*DistributionSchedule, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, requestDeadline)
+	defer cancel()
+
+	key := networkConfigKey(configKeyCoinDistributerSchedule, d.Network)
+	val, err := storage.ExecOne[string](reqCtx, d.DB, "SELECT value FROM global WHERE key = $1", key)
+	if err != nil {
+		if storage.IsErr(err, storage.ErrNotFound) {
+			return nil, nil //nolint:nilnil // Nil schedule means the config's daily window.
+		}
+
+		return nil, errors.Wrapf(err, "failed to get %v", key)
+	}
+	schedule, err := ParseDistributionSchedule(*val)
+
+	return schedule, errors.Wrapf(err, "invalid %v", key)
+}
+
+// schedule is the DistributionSchedule from the database, if any, or the daily window from the config otherwise.
+// Use nextWindow instead, which caches it.
+func (proc *coinProcessor) schedule(ctx context.Context) (*DistributionSchedule, error) {
+	schedule, err := proc.GetSchedule(ctx)
+	if err != nil || schedule != nil {
+		return schedule, err
+	}
+
+	return legacyDistributionSchedule(proc.Conf.StartHours, proc.Conf.EndHours), nil
+}
+
+// isBlocked reports whether the processor is outside of its schedule. An invalid schedule blocks it, until it's fixed.
+func (proc *coinProcessor) isBlocked(ctx context.Context) bool {
+	now := time.Now()
+	window, err := proc.nextWindow(ctx, now)
+	if err != nil {
+		log.Error(errors.Wrapf(err, "%v: distribution is blocked until the schedule is fixed", proc.Network))
+
+		return true
+	}
+
+	return window == nil || window.Start.After(*now.Time)
+}
+
+// nextWindow returns the current or the next window of the schedule, see NextWindow.
+// It's cached until it's over, but for scheduleCacheTTL at most, so that the schedule can still be changed at runtime.
+func (proc *coinProcessor) nextWindow(ctx context.Context, now *time.Time) (*ScheduledWindow, error) {
+	proc.scheduleCache.mu.RLock()
+	if proc.scheduleCache.isValid(now) {
+		defer proc.scheduleCache.mu.RUnlock()
+
+		return proc.scheduleCache.window, nil
+	}
+	proc.scheduleCache.mu.RUnlock()
+
+	proc.scheduleCache.mu.Lock()
+	defer proc.scheduleCache.mu.Unlock()
+	if proc.scheduleCache.isValid(now) {
+		return proc.scheduleCache.window, nil
+	}
+	schedule, err := proc.schedule(ctx)
+	if err != nil {
+		return nil, err
+	}
+	proc.scheduleCache.window = schedule.NextWindow(*now.Time)
+	proc.scheduleCache.time = now
+
+	return proc.scheduleCache.window, nil
+}
+
+func (c *scheduleCache) isValid(now *time.Time) bool {
+	return c.time != nil && now.Sub(*c.time.Time) < scheduleCacheTTL && (c.window == nil || c.window.End.After(*now.Time))
+}
+
+func (proc *coinProcessor) NextScheduledWindow(ctx context.Context) (*ScheduledWindow, error) {
+	return proc.nextWindow(ctx, time.Now())
+}
+func (cd *coinDistributer) NextScheduledWindows(ctx context.Context) (map[BlockchainNetworkType]*ScheduledWindow, error) {
+	windows := make(map[BlockchainNetworkType]*ScheduledWindow, len(cd.Processors))
+	for network, proc := range cd.Processors {
+		window, err := proc.NextScheduledWindow(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the next %v window", network)
+		}
+		windows[network] = window
+	}
+
+	return windows, nil
+}
@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package coindistribution
+
+import (
+	"testing"
+	stdlibtime "time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDistributionSchedule(t *testing.T) {
+	t.Parallel()
+
+	schedule, err := ParseDistributionSchedule(" ")
+	require.NoError(t, err)
+	require.Nil(t, schedule)
+
+	schedule, err = ParseDistributionSchedule(`{"timezone":"Europe/Berlin","windows":{"Monday":[{"start":"09:00","end":"17:00"},{"start":"22:00","end":"02:00"}]},"blackoutDates":["2038-01-04"]}`)
+	require.NoError(t, err)
+	require.Equal(t, "Europe/Berlin", schedule.location.String())
+	require.Equal(t, []*scheduleMinutes{{Start: 9 * 60, End: 17 * 60}, {Start: 22 * 60, End: 26 * 60}}, schedule.weekdays[stdlibtime.Monday])
+
+	for _, invalid := range []string{
+		`{`,
+		`{"timezone":"Mars/Olympus"}`,
+		`{"windows":{"someday":[{"start":"09:00","end":"17:00"}]}}`,
+		`{"windows":{"monday":[{"start":"9","end":"17:00"}]}}`,
+		`{"windows":{"monday":[{"start":"24:00","end":"17:00"}]}}`,
+		`{"blackoutDates":["04.01.2038"]}`,
+	} {
+		_, err = ParseDistributionSchedule(invalid)
+		require.ErrorIs(t, err, ErrInvalidSchedule, invalid)
+	}
+}
+
+func TestDistributionScheduleWindows(t *testing.T) {
+	t.Parallel()
+
+	// 2038-01-04 is a Monday.
+	schedule, err := ParseDistributionSchedule(`{"timezone":"Europe/Berlin","windows":{
+		"monday":[{"start":"09:00","end":"12:00"},{"start":"11:00","end":"13:00"}],
+		"tuesday":[{"start":"22:00","end":"02:00"}],
+		"wednesday":[{"start":"00:00","end":"24:00"}]
+	},"blackoutDates":["2038-01-11"]}`)
+	require.NoError(t, err)
+	berlin := schedule.location
+	at := func(day, hour, minute int) stdlibtime.Time {
+		return stdlibtime.Date(2038, 1, day, hour, minute, 0, 0, berlin)
+	}
+
+	require.False(t, schedule.IsOpen(at(4, 8, 59)))
+	require.True(t, schedule.IsOpen(at(4, 9, 0)))
+	require.True(t, schedule.IsOpen(at(4, 12, 30)))
+	require.False(t, schedule.IsOpen(at(4, 13, 0)))
+	require.True(t, schedule.IsOpen(at(5, 23, 0)))
+	require.True(t, schedule.IsOpen(at(6, 1, 0)))
+	require.True(t, schedule.IsOpen(at(6, 12, 0)))
+	require.False(t, schedule.IsOpen(at(7, 0, 0)))
+	require.True(t, schedule.IsOpen(at(4, 9, 0).UTC()))
+
+	window := schedule.NextWindow(at(4, 10, 0))
+	require.True(t, window.Start.Equal(at(4, 9, 0)))
+	require.True(t, window.End.Equal(at(4, 13, 0)))
+
+	// The overnight window of Tuesday is merged with the whole Wednesday.
+	window = schedule.NextWindow(at(4, 14, 0))
+	require.True(t, window.Start.Equal(at(5, 22, 0)))
+	require.True(t, window.End.Equal(at(7, 0, 0)))
+
+	// Monday 2038-01-11 is a blackout date.
+	window = schedule.NextWindow(at(7, 0, 0))
+	require.False(t, schedule.IsOpen(at(11, 10, 0)))
+	require.True(t, window.Start.Equal(at(12, 22, 0)))
+
+	// An always open schedule is merged only up to the lookahead.
+	always, err := ParseDistributionSchedule(`{"windows":{
+		"monday":[{"start":"00:00","end":"24:00"}],"tuesday":[{"start":"00:00","end":"24:00"}],
+		"wednesday":[{"start":"00:00","end":"24:00"}],"thursday":[{"start":"00:00","end":"24:00"}],
+		"friday":[{"start":"00:00","end":"24:00"}],"saturday":[{"start":"00:00","end":"24:00"}],
+		"sunday":[{"start":"00:00","end":"24:00"}]
+	}}`)
+	require.NoError(t, err)
+	window = always.NextWindow(at(4, 10, 0).UTC())
+	require.True(t, window.Start.Equal(stdlibtime.Date(2038, 1, 4, 0, 0, 0, 0, stdlibtime.UTC)))
+	require.True(t, window.End.Equal(stdlibtime.Date(2038, 1, 4+scheduleLookaheadDays+1, 0, 0, 0, 0, stdlibtime.UTC)))
+
+	empty, err := ParseDistributionSchedule(`{"windows":{}}`)
+	require.NoError(t, err)
+	require.Nil(t, empty.NextWindow(at(4, 0, 0)))
+	require.False(t, empty.IsOpen(at(4, 0, 0)))
+}
+
+func TestLegacyDistributionSchedule(t *testing.T) {
+	t.Parallel()
+
+	isOpen := func(startHours, endHours, hour, minute int) bool {
+		return legacyDistributionSchedule(startHours, endHours).IsOpen(stdlibtime.Date(2038, 1, 1, hour, minute, 0, 0, stdlibtime.UTC))
+	}
+	require.True(t, isOpen(10, 22, 10, 0))
+	require.True(t, isOpen(22, 6, 23, 0))
+	require.True(t, isOpen(22, 6, 6, 0))
+	require.True(t, isOpen(22, 6, 0, 0))
+	require.False(t, isOpen(22, 6, 6, 1))
+	require.False(t, isOpen(22, 6, 17, 0))
+	require.False(t, isOpen(22, 6, 21, 59))
+	require.False(t, isOpen(10, 22, 23, 0))
+	require.False(t, isOpen(10, 22, 9, 59))
+	require.True(t, isOpen(0, 23, 2, 0))
+	require.False(t, isOpen(0, 23, 23, 1))
+	require.True(t, isOpen(0, 0, 0, 0))
+	require.True(t, isOpen(0, 0, 1, 0))
+	require.True(t, isOpen(0, 0, 23, 59))
+	require.True(t, isOpen(16, 18, 16, 0))
+	require.True(t, isOpen(16, 18, 16, 1))
+	require.True(t, isOpen(16, 18, 17, 22))
+	require.True(t, isOpen(16, 18, 18, 0))
+	require.False(t, isOpen(16, 18, 18, 1))
+	require.False(t, isOpen(16, 18, 15, 59))
+
+	require.Panics(t, func() { legacyDistributionSchedule(-1, 0) })
+	require.Panics(t, func() { legacyDistributionSchedule(0, 24) })
+}
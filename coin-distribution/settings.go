@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package coindistribution
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	stdlibtime "time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/wintr/connectors/storage/v2"
+	"github.com/ice-blockchain/wintr/log"
+)
+
+func newDistributerSettingTypes() map[string]DistributerSettingType {
+	settings := map[string]DistributerSettingType{
+		configKeyCoinDistributerEnabled:       DistributerSettingTypeBool,
+		"coin_collector_start_date":           DistributerSettingTypeTimestamp,
+		"coin_collector_end_date":             DistributerSettingTypeTimestamp,
+		"coin_collector_min_balance_required": DistributerSettingTypeUint,
+		"coin_collector_denied_countries":     DistributerSettingTypeCountries,
+	}
+	for _, network := range []BlockchainNetworkType{ArbitrumBlockchainNetworkType, BNBBlockchainNetworkType, EthereumBlockchainNetworkType} {
+		settings[networkConfigKey(configKeyCoinDistributerOnDemand, network)] = DistributerSettingTypeBool
+		settings[networkConfigKey(configKeyCoinDistributerGasLimit, network)] = DistributerSettingTypeUint
+		settings[networkConfigKey(configKeyCoinDistributerGasPrice, network)] = DistributerSettingTypeUint
+		settings[networkConfigKey(configKeyCoinDistributerSchedule, network)] = DistributerSettingTypeSchedule
+	}
+
+	return settings
+}
+
+//nolint:gochecknoglobals // It's a constant.
+var countryCodeRegex = regexp.MustCompile(`^[a-zA-Z]{2}$`)
+
+// normalizeDistributerSetting validates the value of the setting and returns it the way it's stored.
+func normalizeDistributerSetting(key, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch distributerSettingTypes[key] {
+	case DistributerSettingTypeBool:
+		val, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", errors.Wrapf(ErrInvalidSetting, "`%v` has to be `true` or `false`", key)
+		}
+
+		return strconv.FormatBool(val), nil
+	case DistributerSettingTypeUint:
+		val, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return "", errors.Wrapf(ErrInvalidSetting, "`%v` has to be a non negative integer", key)
+		}
+
+		return strconv.FormatUint(val, 10), nil
+	case DistributerSettingTypeTimestamp:
+		val, err := stdlibtime.Parse(stdlibtime.RFC3339, value)
+		if err != nil {
+			return "", errors.Wrapf(ErrInvalidSetting, "`%v` has to be a RFC3339 timestamp, I.E. `2024-01-02T00:00:00Z`", key)
+		}
+
+		return val.UTC().Format(stdlibtime.RFC3339), nil
+	case DistributerSettingTypeCountries:
+		countries := make([]string, 0, strings.Count(value, ",")+1)
+		for _, country := range strings.Split(value, ",") {
+			if country = strings.TrimSpace(country); country == "" {
+				continue
+			}
+			if !countryCodeRegex.MatchString(country) {
+				return "", errors.Wrapf(ErrInvalidSetting, "`%v`: `%v` is not a 2 letter country code", key, country)
+			}
+			countries = append(countries, strings.ToUpper(country))
+		}
+
+		return strings.Join(countries, ","), nil
+	case DistributerSettingTypeSchedule:
+		if _, err := ParseDistributionSchedule(value); err != nil {
+			return "", errors.Wrapf(ErrInvalidSetting, "`%v`: %v", key, err)
+		}
+
+		return value, nil
+	default:
+		return "", errors.Wrapf(ErrInvalidSetting, "`%v` is not a coin distributer setting", key)
+	}
+}
+
+func (r *repository) GetDistributerSettings(ctx context.Context) ([]*DistributerSetting, error) {
+	keys := make([]string, 0, len(distributerSettingTypes))
+	for key := range distributerSettingTypes {
+		keys = append(keys, key)
+	}
+	settings, err := storage.Select[DistributerSetting](ctx, r.db, "SELECT key, value FROM global WHERE key = ANY($1) ORDER BY key", keys)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to select the coin distributer settings")
+	}
+	for _, setting := range settings {
+		setting.Type = distributerSettingTypes[setting.Key]
+	}
+
+	return settings, nil
+}
+
+func (r *repository) UpdateDistributerSettings(ctx context.Context, adminUserID string, settings map[string]string) ([]*DistributerSetting, error) {
+	if len(settings) == 0 {
+		return nil, errors.Wrap(ErrInvalidSetting, "no settings to update")
+	}
+	keys := make([]string, 0, len(settings))
+	normalized := make(map[string]string, len(settings))
+	for key, value := range settings {
+		val, err := normalizeDistributerSetting(key, value)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		normalized[key] = val
+	}
+	sort.Strings(keys)
+	sql := `WITH old AS (
+				SELECT value FROM global WHERE key = $1 FOR UPDATE
+			), upsert AS (
+				INSERT INTO global(key, value) VALUES ($1, $2)
+				ON CONFLICT (key) DO UPDATE
+					SET value = EXCLUDED.value
+			)
+			INSERT INTO coin_distributer_setting_changes(changed_at, key, old_value, new_value, admin_user_id)
+			SELECT current_timestamp, $1, (SELECT value FROM old), $2, $3
+			WHERE (SELECT value FROM old) IS DISTINCT FROM $2::text`
+	err := storage.DoInTransaction(ctx, r.db, func(conn storage.QueryExecer) error {
+		for _, key := range keys {
+			changed, err := storage.Exec(ctx, conn, sql, key, normalized[key], adminUserID)
+			if err != nil {
+				return errors.Wrapf(err, "failed to update global.%v to %q", key, normalized[key])
+			}
+			if changed != 0 {
+				log.Info(fmt.Sprintf("coin distributer setting `%v` was changed to %q by %v", key, normalized[key], adminUserID))
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to update the coin distributer settings %#v", normalized)
+	}
+
+	return r.GetDistributerSettings(ctx)
+}
+
+func (r *repository) GetDistributerSettingChanges(ctx context.Context, key string, limit, offset uint64) ([]*DistributerSettingChange, error) {
+	sql := `SELECT *
+			FROM coin_distributer_setting_changes
+			WHERE ($1 = '' OR key = $1)
+			ORDER BY changed_at DESC
+			LIMIT $2 OFFSET $3`
+	changes, err := storage.Select[DistributerSettingChange](ctx, r.db, sql, key, limit, offset)
+
+	return changes, errors.Wrapf(err, "failed to select the coin distributer setting changes for key %q", key)
+}
@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package coindistribution
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeDistributerSetting(t *testing.T) {
+	t.Parallel()
+
+	for key, values := range map[string][2]string{
+		"coin_distributer_enabled":             {" TRUE ", "true"},
+		"coin_distributer_gas_limit_units_bnb": {"30000000", "30000000"},
+		"coin_collector_start_date":            {"2024-01-02T03:00:00+03:00", "2024-01-02T00:00:00Z"},
+		"coin_collector_denied_countries":      {"ru, by,,ir", "RU,BY,IR"},
+		"coin_distributer_schedule_arbitrum":   {"", ""},
+		"coin_distributer_gas_price_override":  {"0", "0"},
+	} {
+		normalized, err := normalizeDistributerSetting(key, values[0])
+		require.NoError(t, err, key)
+		require.Equal(t, values[1], normalized, key)
+	}
+
+	for key, value := range map[string]string{
+		"coin_distributer_enabled":             "yes",
+		"coin_distributer_gas_limit_units":     "-1",
+		"coin_collector_end_date":              "2024-01-02",
+		"coin_collector_denied_countries":      "RUS",
+		"coin_distributer_schedule_bnb":        "{",
+		"coin_distributer_some_unknown_switch": "true",
+	} {
+		_, err := normalizeDistributerSetting(key, value)
+		require.ErrorIs(t, err, ErrInvalidSetting, key)
+	}
+}
@@ -0,0 +1,214 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package coindistribution
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/wintr/log"
+)
+
+func (s *signerConfig) EnsureValid(network BlockchainNetworkType, privateKey string, development bool) {
+	switch s.Type {
+	case "", signerTypePrivateKey:
+		if privateKey == "" {
+			log.Panic(fmt.Sprintf("%v.privateKey must not be empty", network))
+		}
+		_, err := crypto.HexToECDSA(privateKey)
+		log.Panic(errors.Wrapf(err, "%v.privateKey is invalid", network)) //nolint:revive,nolintlint //.
+	case signerTypeKeystore:
+		if s.KeystoreFile == "" {
+			log.Panic(fmt.Sprintf("%v.signer.keystoreFile must not be empty", network))
+		}
+	case signerTypeClef:
+		if s.Endpoint == "" || !common.IsHexAddress(s.Address) {
+			log.Panic(fmt.Sprintf("%v.signer: endpoint and a valid address are required", network))
+		}
+	case signerTypeLocal:
+		if !development {
+			log.Panic(fmt.Sprintf("%v.signer: `%v` signer is allowed only in development", network, signerTypeLocal))
+		}
+	default:
+		log.Panic(fmt.Sprintf("%v.signer.type `%v` is not supported", network, s.Type))
+	}
+}
+
+func mustNewTransactionSigner(ctx context.Context, network BlockchainNetworkType, conf *networkConfig) transactionSigner {
+	var (
+		signer transactionSigner
+		err    error
+	)
+	switch conf.Signer.Type {
+	case "", signerTypePrivateKey:
+		var key *ecdsa.PrivateKey
+		if key, err = crypto.HexToECDSA(conf.PrivateKey); err == nil {
+			signer = newPrivateKeySigner(key)
+		}
+	case signerTypeKeystore:
+		signer, err = newKeystoreSigner(conf.Signer.KeystoreFile, conf.Signer.Passphrase)
+	case signerTypeClef:
+		signer, err = newClefSigner(ctx, conf.Signer.Endpoint, common.HexToAddress(conf.Signer.Address))
+	case signerTypeLocal:
+		signer, err = newLocalSigner()
+	default:
+		err = errors.Errorf("unsupported signer type `%v`", conf.Signer.Type)
+	}
+	log.Panic(errors.Wrapf(err, "failed to create %v signer", network)) //nolint:revive,nolintlint //.
+	log.Info(fmt.Sprintf("%v: using %v signer for %v", network, signer.Type(), signer.Address().Hex()))
+
+	return signer
+}
+
+func newPrivateKeySigner(key *ecdsa.PrivateKey) *privateKeySigner {
+	return &privateKeySigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+// newLocalSigner is a stand-in for the real signers, for tests and development: it signs with a new random key.
+func newLocalSigner() (*privateKeySigner, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate key")
+	}
+
+	return newPrivateKeySigner(key), nil
+}
+
+func (s *privateKeySigner) Type() signerType {
+	return signerTypePrivateKey
+}
+
+func (s *privateKeySigner) Address() common.Address {
+	return s.address
+}
+
+func (s *privateKeySigner) SignTx(_ context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key) //nolint:wrapcheck //.
+}
+
+func (*privateKeySigner) Close() error {
+	return nil
+}
+
+// newKeystoreSigner unlocks the account of the given encrypted keystore file, the decrypted key is kept by the keystore only.
+func newKeystoreSigner(file, passphrase string) (*keystoreSigner, error) {
+	path, err := filepath.Abs(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid keystore file %v", file)
+	}
+	store := keystore.NewKeyStore(filepath.Dir(path), keystore.StandardScryptN, keystore.StandardScryptP)
+	account, err := store.Find(accounts.Account{URL: accounts.URL{Scheme: keystore.KeyStoreScheme, Path: path}})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find the account of keystore file %v", path)
+	}
+	if err = store.Unlock(account, passphrase); err != nil {
+		return nil, errors.Wrapf(err, "failed to unlock the account of keystore file %v", path)
+	}
+
+	return &keystoreSigner{store: store, account: account}, nil
+}
+
+func (*keystoreSigner) Type() signerType {
+	return signerTypeKeystore
+}
+
+func (s *keystoreSigner) Address() common.Address {
+	return s.account.Address
+}
+
+func (s *keystoreSigner) SignTx(_ context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return s.store.SignTx(s.account, tx, chainID) //nolint:wrapcheck //.
+}
+
+func (s *keystoreSigner) Close() error {
+	return errors.Wrapf(s.store.Lock(s.account.Address), "failed to lock %v", s.account.Address.Hex())
+}
+
+// newClefSigner connects to a remote signer speaking Clef's external API, i.e. `account_signTransaction`.
+func newClefSigner(ctx context.Context, endpoint string, address common.Address) (*clefSigner, error) {
+	client, err := rpc.DialContext(ctx, endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to remote signer %v", endpoint)
+	}
+	var version string
+	if err = client.CallContext(ctx, &version, "account_version"); err != nil {
+		client.Close()
+
+		return nil, errors.Wrapf(err, "remote signer %v is not reachable", endpoint)
+	}
+	log.Info(fmt.Sprintf("remote signer %v version: %v", endpoint, version))
+
+	return &clefSigner{client: client, address: address}, nil
+}
+
+func (*clefSigner) Type() signerType {
+	return signerTypeClef
+}
+
+func (s *clefSigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx makes sure the remote signer signed exactly the given transaction, with the expected account.
+func (s *clefSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	data := hexutil.Bytes(tx.Data())
+	args := &apitypes.SendTxArgs{
+		From:    common.NewMixedcaseAddress(s.address),
+		Data:    &data,
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Value:   hexutil.Big(*tx.Value()),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	if tx.To() != nil {
+		to := common.NewMixedcaseAddress(*tx.To())
+		args.To = &to
+	}
+	switch tx.Type() {
+	case types.LegacyTxType:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	case types.DynamicFeeTxType:
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	default:
+		return nil, errors.Wrapf(types.ErrTxTypeNotSupported, "transaction type %v", tx.Type())
+	}
+
+	var res struct {
+		Tx *types.Transaction `json:"tx"`
+	}
+	if err := s.client.CallContext(ctx, &res, "account_signTransaction", args); err != nil {
+		return nil, errors.Wrapf(err, "remote signer failed to sign %v", tx.Hash().Hex())
+	} else if res.Tx == nil {
+		return nil, errors.Errorf("remote signer returned no transaction for %v", tx.Hash().Hex())
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), res.Tx)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid signature from remote signer")
+	} else if sender != s.address {
+		return nil, errors.Errorf("remote signer signed with %v instead of %v", sender.Hex(), s.address.Hex())
+	} else if types.LatestSignerForChainID(chainID).Hash(res.Tx) != types.LatestSignerForChainID(chainID).Hash(tx) {
+		return nil, errors.Errorf("remote signer returned a different transaction than %v", tx.Hash().Hex())
+	}
+
+	return res.Tx, nil
+}
+
+func (s *clefSigner) Close() error {
+	s.client.Close()
+
+	return nil
+}
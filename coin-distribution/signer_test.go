@@ -0,0 +1,221 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package coindistribution
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	// fakeClef implements the part of Clef's external API used by clefSigner.
+	fakeClef struct {
+		key *ecdsa.PrivateKey
+		// Tamper, if set, is applied to the transaction before it's signed.
+		tamper func(tx *types.DynamicFeeTx)
+	}
+	fakeClefSignTransactionResult struct {
+		Raw hexutil.Bytes      `json:"raw"`
+		Tx  *types.Transaction `json:"tx"`
+	}
+)
+
+func (*fakeClef) Version() string {
+	return "6.0.0"
+}
+
+func (c *fakeClef) SignTransaction(args apitypes.SendTxArgs) (*fakeClefSignTransactionResult, error) {
+	inner := &types.DynamicFeeTx{
+		ChainID:   (*big.Int)(args.ChainID),
+		Nonce:     uint64(args.Nonce),
+		GasTipCap: (*big.Int)(args.MaxPriorityFeePerGas),
+		GasFeeCap: (*big.Int)(args.MaxFeePerGas),
+		Gas:       uint64(args.Gas),
+		Value:     (*big.Int)(&args.Value),
+		Data:      *args.Data,
+	}
+	if args.To != nil {
+		to := args.To.Address()
+		inner.To = &to
+	}
+	if c.tamper != nil {
+		c.tamper(inner)
+	}
+	signed, err := types.SignTx(types.NewTx(inner), types.LatestSignerForChainID(inner.ChainID), c.key)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // .
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err //nolint:wrapcheck // .
+	}
+
+	return &fakeClefSignTransactionResult{Raw: raw, Tx: signed}, nil
+}
+
+func newFakeClef(t *testing.T, clef *fakeClef) string {
+	t.Helper()
+
+	srv := rpc.NewServer()
+	require.NoError(t, srv.RegisterName("account", clef))
+	httpSrv := httptest.NewServer(srv)
+	t.Cleanup(func() {
+		httpSrv.Close()
+		srv.Stop()
+	})
+
+	return httpSrv.URL
+}
+
+func newTestDynamicFeeTx(chainID *big.Int) *types.Transaction {
+	to := common.HexToAddress("0x095e7baea6a6c7c4c2dfeb977efac326af552d87")
+
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     7,
+		GasTipCap: big.NewInt(2),
+		GasFeeCap: big.NewInt(20),
+		Gas:       100_000,
+		To:        &to,
+		Value:     big.NewInt(0),
+		Data:      []byte{1, 2, 3},
+	})
+}
+
+func requireSignedBy(t *testing.T, signer transactionSigner, chainID *big.Int) {
+	t.Helper()
+
+	tx := newTestDynamicFeeTx(chainID)
+	signed, err := signer.SignTx(context.Background(), tx, chainID)
+	require.NoError(t, err)
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	require.NoError(t, err)
+	require.Equal(t, signer.Address(), sender)
+	require.Equal(t, types.LatestSignerForChainID(chainID).Hash(tx), types.LatestSignerForChainID(chainID).Hash(signed))
+}
+
+func TestLocalSigner(t *testing.T) {
+	t.Parallel()
+
+	signer, err := newLocalSigner()
+	require.NoError(t, err)
+	requireSignedBy(t, signer, big.NewInt(1))
+
+	impl := &ethClientImpl{Signer: signer, Mutex: new(sync.Mutex)}
+	opts := impl.CreateTransactionOpts(context.Background(), &gasOptions{Price: big.NewInt(1)}, big.NewInt(1))
+	require.Equal(t, signer.Address(), opts.From)
+	_, err = opts.Signer(common.Address{1}, newTestDynamicFeeTx(big.NewInt(1)))
+	require.ErrorIs(t, err, bind.ErrNotAuthorized)
+	signed, err := opts.Signer(opts.From, newTestDynamicFeeTx(big.NewInt(1)))
+	require.NoError(t, err)
+	sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1)), signed)
+	require.NoError(t, err)
+	require.Equal(t, signer.Address(), sender)
+}
+
+func TestKeystoreSigner(t *testing.T) {
+	t.Parallel()
+
+	const passphrase = "secret"
+
+	store := keystore.NewKeyStore(t.TempDir(), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := store.NewAccount(passphrase)
+	require.NoError(t, err)
+
+	_, err = newKeystoreSigner(account.URL.Path, "wrong")
+	require.ErrorIs(t, err, keystore.ErrDecrypt)
+
+	signer, err := newKeystoreSigner(account.URL.Path, passphrase)
+	require.NoError(t, err)
+	require.Equal(t, account.Address, signer.Address())
+	requireSignedBy(t, signer, big.NewInt(56))
+	require.NoError(t, signer.Close())
+}
+
+func TestClefSigner(t *testing.T) {
+	t.Parallel()
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	chainID := big.NewInt(97)
+
+	t.Run("signs", func(t *testing.T) {
+		t.Parallel()
+
+		signer, sErr := newClefSigner(context.Background(), newFakeClef(t, &fakeClef{key: key}), crypto.PubkeyToAddress(key.PublicKey))
+		require.NoError(t, sErr)
+		defer func() { require.NoError(t, signer.Close()) }()
+		requireSignedBy(t, signer, chainID)
+	})
+
+	t.Run("wrong account", func(t *testing.T) {
+		t.Parallel()
+
+		signer, sErr := newClefSigner(context.Background(), newFakeClef(t, &fakeClef{key: key}), common.Address{1})
+		require.NoError(t, sErr)
+		defer func() { require.NoError(t, signer.Close()) }()
+		_, sErr = signer.SignTx(context.Background(), newTestDynamicFeeTx(chainID), chainID)
+		require.ErrorContains(t, sErr, "instead of")
+	})
+
+	t.Run("tampered transaction", func(t *testing.T) {
+		t.Parallel()
+
+		clef := &fakeClef{key: key, tamper: func(tx *types.DynamicFeeTx) { tx.Value = big.NewInt(1) }}
+		signer, sErr := newClefSigner(context.Background(), newFakeClef(t, clef), crypto.PubkeyToAddress(key.PublicKey))
+		require.NoError(t, sErr)
+		defer func() { require.NoError(t, signer.Close()) }()
+		_, sErr = signer.SignTx(context.Background(), newTestDynamicFeeTx(chainID), chainID)
+		require.ErrorContains(t, sErr, "different transaction")
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		t.Parallel()
+
+		srv := httptest.NewServer(rpc.NewServer())
+		srv.Close()
+		_, sErr := newClefSigner(context.Background(), srv.URL, common.Address{1})
+		require.Error(t, sErr)
+	})
+}
+
+func TestSignerConfigEnsureValid(t *testing.T) {
+	t.Parallel()
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	privateKey := hexutil.Encode(crypto.FromECDSA(key))[2:]
+
+	require.NotPanics(t, func() { new(signerConfig).EnsureValid(EthereumBlockchainNetworkType, privateKey, false) })
+	require.Panics(t, func() { new(signerConfig).EnsureValid(EthereumBlockchainNetworkType, "", false) })
+	require.Panics(t, func() { new(signerConfig).EnsureValid(EthereumBlockchainNetworkType, "xyz", false) })
+	require.NotPanics(t, func() {
+		(&signerConfig{Type: signerTypeKeystore, KeystoreFile: "key.json"}).EnsureValid(EthereumBlockchainNetworkType, "", false)
+	})
+	require.Panics(t, func() {
+		(&signerConfig{Type: signerTypeKeystore}).EnsureValid(EthereumBlockchainNetworkType, "", false)
+	})
+	require.NotPanics(t, func() {
+		(&signerConfig{Type: signerTypeClef, Endpoint: "http://localhost:8550", Address: common.Address{1}.Hex()}).EnsureValid(BNBBlockchainNetworkType, "", false)
+	})
+	require.Panics(t, func() {
+		(&signerConfig{Type: signerTypeClef, Endpoint: "http://localhost:8550"}).EnsureValid(BNBBlockchainNetworkType, "", false)
+	})
+	require.NotPanics(t, func() { (&signerConfig{Type: signerTypeLocal}).EnsureValid(BNBBlockchainNetworkType, "", true) })
+	require.Panics(t, func() { (&signerConfig{Type: signerTypeLocal}).EnsureValid(BNBBlockchainNetworkType, "", false) })
+	require.Panics(t, func() { (&signerConfig{Type: "hsm"}).EnsureValid(BNBBlockchainNetworkType, "", false) })
+}
@@ -6,7 +6,9 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"math/big"
 	"net/http"
+	"strings"
 	stdlibtime "time"
 
 	"github.com/goccy/go-json"
@@ -19,89 +21,142 @@ import (
 func (r *repository) sendCurrentCoinDistributionsAvailableForReviewAreApprovedSlackMessage(ctx context.Context, recipients uint64, iceCoins float64) error {
 	text := fmt.Sprintf(":white_check_mark:`%v` current pending coin distributions are approved and are going to be processed as soon as the coin-distributer comes online :white_check_mark:\n`users`: `%v`\n`coins`: `%v`", r.cfg.Environment, recipients, fmt.Sprintf("%.2f", iceCoins)) //nolint:lll // .
 
-	return errors.Wrap(sendSlackMessage(ctx, text, r.cfg.AlertSlackWebhook), "failed to sendSlackMessage")
+	return errors.Wrap(r.cfg.sendAlert(ctx, alertEventReviewApproved, "", text), "failed to sendAlert")
 }
 
 func (r *repository) sendCurrentCoinDistributionsAvailableForReviewAreApprovedToBeProcessedImmediatelySlackMessage(ctx context.Context, recipients uint64, iceCoins float64) error {
 	text := fmt.Sprintf(":white_check_mark::zap:`%v` current pending coin distributions are approved and are going to be processed immediately :zap::white_check_mark:\n`users`: `%v`\n`coins`: `%v`", r.cfg.Environment, recipients, fmt.Sprintf("%.2f", iceCoins)) //nolint:lll // .
 
-	return errors.Wrap(sendSlackMessage(ctx, text, r.cfg.AlertSlackWebhook), "failed to sendSlackMessage")
+	return errors.Wrap(r.cfg.sendAlert(ctx, alertEventReviewApproved, "", text), "failed to sendAlert")
 }
 
 func (r *repository) sendCurrentCoinDistributionsAvailableForReviewAreDeniedSlackMessage(ctx context.Context) error {
 	text := fmt.Sprintf(":no_entry:`%v` current pending coin distributions are denied and will not be processed :no_entry:", r.cfg.Environment)
 
-	return errors.Wrap(sendSlackMessage(ctx, text, r.cfg.AlertSlackWebhook), "failed to sendSlackMessage")
+	return errors.Wrap(r.cfg.sendAlert(ctx, alertEventReviewDenied, "", text), "failed to sendAlert")
+}
+
+func (r *repository) sendCoinDistributionsApprovalRecordedSlackMessage(ctx context.Context, reviewerUserID string, approvals *reviewApprovals) error {
+	text := fmt.Sprintf(":hourglass_flowing_sand:`%v` `%v` approved <%v|coin distributions pending review>, they're going to be processed once approved by `%v` more reviewer(s) :hourglass_flowing_sand:\n`approvals`: `%v/%v`\n`users`: `%v`\n`coins`: `%v`\n`snapshot`: `%v`", r.cfg.Environment, reviewerUserID, r.cfg.ReviewURL, approvals.RequiredApprovals-approvals.Approvals, approvals.Approvals, approvals.RequiredApprovals, approvals.Rows, fmt.Sprintf("%.2f", float64(approvals.Ice)/100), approvals.SnapshotHash) //nolint:lll // .
+
+	return errors.Wrap(r.cfg.sendAlert(ctx, alertEventReviewApprovalRecorded, "", text), "failed to sendAlert")
+}
+
+func (r *repository) sendSelectedCoinDistributionsAreApprovedSlackMessage(ctx context.Context, recipients uint64, iceCoins float64) error {
+	text := fmt.Sprintf(":ballot_box_with_check:`%v` some of the current pending coin distributions are approved and are going to be processed as soon as the coin-distributer comes online, the rest are still pending review :ballot_box_with_check:\n`users`: `%v`\n`coins`: `%v`", r.cfg.Environment, recipients, fmt.Sprintf("%.2f", iceCoins)) //nolint:lll // .
+
+	return errors.Wrap(r.cfg.sendAlert(ctx, alertEventReviewApproved, "", text), "failed to sendAlert")
+}
+
+func (r *repository) sendSelectedCoinDistributionsAreApprovedToBeProcessedImmediatelySlackMessage(ctx context.Context, recipients uint64, iceCoins float64) error {
+	text := fmt.Sprintf(":ballot_box_with_check::zap:`%v` some of the current pending coin distributions are approved and are going to be processed immediately, the rest are still pending review :zap::ballot_box_with_check:\n`users`: `%v`\n`coins`: `%v`", r.cfg.Environment, recipients, fmt.Sprintf("%.2f", iceCoins)) //nolint:lll // .
+
+	return errors.Wrap(r.cfg.sendAlert(ctx, alertEventReviewApproved, "", text), "failed to sendAlert")
+}
+
+func (r *repository) sendSelectedCoinDistributionsAreDeniedSlackMessage(ctx context.Context, recipients uint64, iceCoins float64) error {
+	text := fmt.Sprintf(":no_entry_sign:`%v` some of the current pending coin distributions are denied and will not be processed, the rest are still pending review :no_entry_sign:\n`users`: `%v`\n`coins`: `%v`", r.cfg.Environment, recipients, fmt.Sprintf("%.2f", iceCoins)) //nolint:lll // .
+
+	return errors.Wrap(r.cfg.sendAlert(ctx, alertEventReviewDenied, "", text), "failed to sendAlert")
 }
 
 func sendNewCoinDistributionsAvailableForReviewSlackMessage(ctx context.Context) error {
 	text := fmt.Sprintf(":eyes:`%v` <%v|new coin distributions are available for review> :eyes:", cfg.Environment, cfg.ReviewURL)
 
-	return errors.Wrap(sendSlackMessage(ctx, text, cfg.AlertSlackWebhook), "failed to sendSlackMessage")
+	return errors.Wrap(cfg.sendAlert(ctx, alertEventNewDistributionsForReview, "", text), "failed to sendAlert")
 }
 
 func SendNewCoinDistributionCollectionCycleStartedSlackMessage(ctx context.Context) error {
 	text := fmt.Sprintf(":money_mouth_face:`%v` started to collect coins for ethereum distribution :money_mouth_face:", cfg.Environment)
 
-	return errors.Wrap(sendSlackMessage(ctx, text, cfg.AlertSlackWebhook), "failed to sendSlackMessage")
+	return errors.Wrap(cfg.sendAlert(ctx, alertEventCollectionCycleStarted, "", text), "failed to sendAlert")
 }
 
 func SendNewCoinDistributionCollectionCycleEndedPrematurelySlackMessage(ctx context.Context) error {
 	text := fmt.Sprintf(":recycle:`%v` collecting coins for ethereum distribution stopped prematurely :recycle:", cfg.Environment)
 
-	return errors.Wrap(sendSlackMessage(ctx, text, cfg.AlertSlackWebhook), "failed to sendSlackMessage")
+	return errors.Wrap(cfg.sendAlert(ctx, alertEventCollectionCycleEndedEarly, "", text), "failed to sendAlert")
 }
 
-func sendCoinDistributerIsNowOnlineSlackMessage(ctx context.Context) error {
-	text := fmt.Sprintf(":sun_with_face:`%v` coin distributer is now online :sun_with_face:", cfg.Environment)
+func sendCoinDistributerIsNowOnlineSlackMessage(ctx context.Context, network BlockchainNetworkType) error {
+	text := fmt.Sprintf(":sun_with_face:`%v` `%v` coin distributer is now online :sun_with_face:", cfg.Environment, network)
 
-	return errors.Wrap(sendSlackMessage(ctx, text, cfg.AlertSlackWebhook), "failed to sendSlackMessage")
+	return errors.Wrap(cfg.sendAlert(ctx, alertEventDistributerOnline, network, text), "failed to sendAlert")
 }
 
-func sendCoinDistributerIsNowOfflineSlackMessage(ctx context.Context) error {
-	text := fmt.Sprintf(":sleeping:`%v` coin distributer is now offline :sleeping:", cfg.Environment)
+func sendCoinDistributerIsNowOfflineSlackMessage(ctx context.Context, network BlockchainNetworkType) error {
+	text := fmt.Sprintf(":sleeping:`%v` `%v` coin distributer is now offline :sleeping:", cfg.Environment, network)
 
-	return errors.Wrap(sendSlackMessage(ctx, text, cfg.AlertSlackWebhook), "failed to sendSlackMessage")
+	return errors.Wrap(cfg.sendAlert(ctx, alertEventDistributerOffline, network, text), "failed to sendAlert")
 }
 
-func sendCoinDistributerHasUnfinishedWork(ctx context.Context) error {
-	text := fmt.Sprintf(":octagonal_sign:`%v` coin distributer has unfinished work :octagonal_sign:", cfg.Environment)
+func sendCoinDistributerHasUnfinishedWork(ctx context.Context, network BlockchainNetworkType) error {
+	text := fmt.Sprintf(":octagonal_sign:`%v` `%v` coin distributer has unfinished work :octagonal_sign:", cfg.Environment, network)
 
-	return errors.Wrap(sendSlackMessage(ctx, text, cfg.AlertSlackWebhook), "failed to sendSlackMessage")
+	return errors.Wrap(cfg.sendAlert(ctx, alertEventDistributerHasUnfinishedWork, network, text), "failed to sendAlert")
 }
 
-func sendCoinDistributerTransactionStuck(ctx context.Context, hash string, start *time.Time) error {
-	text := fmt.Sprintf(":octagonal_sign:`%v` transaction `%v` stuck in PENDING state since `%v` :octagonal_sign:",
+func sendCoinDistributerTransactionStuck(ctx context.Context, network BlockchainNetworkType, hash string, start *time.Time) error {
+	text := fmt.Sprintf(":octagonal_sign:`%v` `%v` transaction `%v` stuck in PENDING state since `%v` :octagonal_sign:",
 		cfg.Environment,
+		network,
 		hash,
 		start.Format(stdlibtime.RFC3339),
 	)
 
-	return errors.Wrap(sendSlackMessage(ctx, text, cfg.AlertSlackWebhook), "failed to sendSlackMessage")
+	return errors.Wrap(cfg.sendAlert(ctx, alertEventTransactionStuck, network, text), "failed to sendAlert")
 }
 
-func sendAllCurrentCoinDistributionsWereCommittedInEthereumSlackMessage(ctx context.Context) error {
-	text := fmt.Sprintf(":tada:`%v` all coin distributions have been committed successfully in ethereum :tada:", cfg.Environment)
+func sendAllCurrentCoinDistributionsWereCommittedInEthereumSlackMessage(ctx context.Context, network BlockchainNetworkType) error {
+	text := fmt.Sprintf(":tada:`%v` all coin distributions have been committed successfully in `%v` :tada:", cfg.Environment, network)
 
-	return errors.Wrap(sendSlackMessage(ctx, text, cfg.AlertSlackWebhook), "failed to sendSlackMessage")
+	return errors.Wrap(cfg.sendAlert(ctx, alertEventAllDistributionsCommitted, network, text), "failed to sendAlert")
 }
 
-func sendCoinDistributerStartedProcessingSlackMessage(ctx context.Context) error {
-	text := fmt.Sprintf("🏁`%v` started processing pending ethereum distributions 🏁", cfg.Environment)
+func sendCoinDistributerStartedProcessingSlackMessage(ctx context.Context, network BlockchainNetworkType) error {
+	text := fmt.Sprintf("🏁`%v` started processing pending `%v` distributions 🏁", cfg.Environment, network)
+
+	return errors.Wrap(cfg.sendAlert(ctx, alertEventProcessingStarted, network, text), "failed to sendAlert")
+}
+
+func sendEthereumGasLimitTooLowSlackMessage(ctx context.Context, network BlockchainNetworkType, errMsg string) error {
+	text := fmt.Sprintf(":warning:`%v` %v %v. We can wait for gas prices to go down, but it could take days, or we could change the gas limit :warning:", cfg.Environment, network, errMsg) //nolint:lll // .
+
+	return errors.Wrap(cfg.sendAlert(ctx, alertEventGasLimitTooLow, network, text), "failed to sendAlert")
+}
+
+func sendCoinDistributerPausedDueToHighBaseFeeSlackMessage(ctx context.Context, network BlockchainNetworkType, baseFee *big.Int, ceiling uint64) error {
+	text := fmt.Sprintf(":hourglass:`%v` `%v` coin distribution is paused until the base fee goes down :hourglass:\n`base fee`: `%v`\n`ceiling`: `%v`", cfg.Environment, network, baseFee.String(), ceiling) //nolint:lll // .
+
+	return errors.Wrap(cfg.sendAlert(ctx, alertEventPausedDueToHighBaseFee, network, text), "failed to sendAlert")
+}
+
+func sendCoinDistributionReconciliationMismatchSlackMessage(ctx context.Context, network BlockchainNetworkType, hash string, mismatches []*reconciliationMismatch) error {
+	const maxListed = 10
+	var details strings.Builder
+	for ix, mismatch := range mismatches {
+		if ix == maxListed {
+			details.WriteString(fmt.Sprintf("\n... and %v more", len(mismatches)-maxListed))
+
+			break
+		}
+		details.WriteString(fmt.Sprintf("\n`%v`: expected `%v`, got `%v`", mismatch.EthAddress.Hex(), mismatch.Expected.String(), mismatch.Actual.String()))
+	}
+	text := fmt.Sprintf(":mag:`%v` `%v` transaction `%v` doesn't match the coin distributions it was supposed to make (%v mismatches) :mag:%v", cfg.Environment, network, hash, len(mismatches), details.String()) //nolint:lll // .
 
-	return errors.Wrap(sendSlackMessage(ctx, text, cfg.AlertSlackWebhook), "failed to sendSlackMessage")
+	return errors.Wrap(cfg.sendAlert(ctx, alertEventReconciliationMismatch, network, text), "failed to sendAlert")
 }
 
-func sendEthereumGasLimitTooLowSlackMessage(ctx context.Context, errMsg string) error {
-	text := fmt.Sprintf(":warning:`%v` ethereum %v. We can wait for gas prices to go down, but it could take days, or we could change the gas limit :warning:", cfg.Environment, errMsg) //nolint:lll // .
+func sendCoinDistributionMerkleTreeBuiltSlackMessage(ctx context.Context, network BlockchainNetworkType, root string, leaves int, iceflakes *big.Int) error {
+	text := fmt.Sprintf(":deciduous_tree:`%v` `%v` coin distributions are ready to be claimed as soon as the merkle root is published :deciduous_tree:\n`root`: `%v`\n`leaves`: `%v`\n`iceflakes`: `%v`", cfg.Environment, network, root, leaves, iceflakes.String()) //nolint:lll // .
 
-	return errors.Wrap(sendSlackMessage(ctx, text, cfg.AlertSlackWebhook), "failed to sendSlackMessage")
+	return errors.Wrap(cfg.sendAlert(ctx, alertEventMerkleTreeBuilt, network, text), "failed to sendAlert")
 }
 
-func sendCoinDistributionsProcessingStoppedDueToUnrecoverableFailureSlackMessage(ctx context.Context, reason string) error {
-	text := fmt.Sprintf(":bangbang:`%v` coin distribution processing stopped due to failure :bangbang:\n:rotating_light: reason: `%v` :rotating_light:", cfg.Environment, reason) //nolint:lll // .
+func sendCoinDistributionsProcessingStoppedDueToUnrecoverableFailureSlackMessage(ctx context.Context, network BlockchainNetworkType, reason string) error {
+	text := fmt.Sprintf(":bangbang:`%v` coin distribution processing stopped due to failure :bangbang:\n:rotating_light: network: `%v`, reason: `%v` :rotating_light:", cfg.Environment, network, reason) //nolint:lll // .
 
-	return errors.Wrap(sendSlackMessage(ctx, text, cfg.AlertSlackWebhook), "failed to sendSlackMessage")
+	return errors.Wrap(cfg.sendAlert(ctx, alertEventProcessingStoppedDueToFailure, network, text), "failed to sendAlert")
 }
 
 func sendSlackMessage(ctx context.Context, text, alertSlackWebhook string) error {
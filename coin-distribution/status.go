@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package coindistribution
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/wintr/connectors/storage/v2"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+func (a workerAction) String() string {
+	switch a {
+	case workerActionRun:
+		return "run"
+	case workerActionBlocked:
+		return "blocked"
+	case workerActionDisabled:
+		return "disabled"
+	case workerActionOnDemand:
+		return "on-demand"
+	default:
+		return "unknown"
+	}
+}
+
+// setLastTransaction records the transaction of the batch, every time its status changes.
+func (proc *coinProcessor) setLastTransaction(data *batch) {
+	status := data.Status
+	if status == "" {
+		status = ethTxStatusPending
+	}
+	proc.lastTransaction.mu.Lock()
+	defer proc.lastTransaction.mu.Unlock()
+
+	sentAt := time.Now()
+	if prev := proc.lastTransaction.tx; prev != nil && prev.Status == string(ethTxStatusPending) {
+		// The transaction might have been replaced while pending, but it was still sent at the same time.
+		sentAt = prev.SentAt
+	}
+	proc.lastTransaction.tx = &DistributerTransaction{SentAt: sentAt, Hash: data.TX, Status: string(status), Records: len(data.Records)}
+}
+
+func (proc *coinProcessor) getLastTransaction() *DistributerTransaction {
+	proc.lastTransaction.mu.RLock()
+	defer proc.lastTransaction.mu.RUnlock()
+
+	if proc.lastTransaction.tx == nil {
+		return nil
+	}
+	tx := *proc.lastTransaction.tx
+
+	return &tx
+}
+
+func (proc *coinProcessor) GetStatus(ctx context.Context) (*DistributerStatus, error) {
+	const stmt = `
+select
+	eth_status,
+	count(1) as count
+from
+	pending_coin_distributions
+where
+	network = $1
+group by
+	eth_status
+`
+	counts, err := storage.Select[struct {
+		EthStatus ethApiStatus `db:"eth_status"`
+		Count     uint64       `db:"count"`
+	}](ctx, proc.DB, stmt, proc.Network)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to count %v coin distributions by status", proc.Network)
+	}
+	window, err := proc.NextScheduledWindow(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status := &DistributerStatus{
+		LastTransaction:     proc.getLastTransaction(),
+		NextScheduledWindow: window,
+		WorkerAction:        proc.GetAction(ctx).String(),
+		Distributions: map[string]uint64{
+			string(ethApiStatusNew):      0,
+			string(ethApiStatusPending):  0,
+			string(ethApiStatusAccepted): 0,
+			string(ethApiStatusRejected): 0,
+		},
+	}
+	for _, count := range counts {
+		status.Distributions[string(count.EthStatus)] = count.Count
+	}
+
+	return status, nil
+}
+
+func (cd *coinDistributer) GetStatus(ctx context.Context) (map[BlockchainNetworkType]*DistributerStatus, error) {
+	statuses := make(map[BlockchainNetworkType]*DistributerStatus, len(cd.Processors))
+	for network, proc := range cd.Processors {
+		status, err := proc.GetStatus(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get %v status", network)
+		}
+		statuses[network] = status
+	}
+
+	return statuses, nil
+}
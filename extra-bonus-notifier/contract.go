@@ -65,6 +65,7 @@ type (
 	messagebrokerConfig = messagebroker.Config
 	extraBonusNotifier  struct {
 		mb                            messagebroker.Client
+		clock                         model.Clock
 		extraBonusStartDate           *time.Time
 		extraBonusIndicesDistribution map[uint16]map[uint16]uint16
 	}
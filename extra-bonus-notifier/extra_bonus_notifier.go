@@ -32,7 +32,8 @@ func init() {
 
 func MustStartNotifyingExtraBonusAvailability(ctx context.Context) {
 	ebs := &extraBonusNotifier{
-		mb: messagebroker.MustConnect(context.Background(), parentApplicationYamlKey),
+		mb:    messagebroker.MustConnect(context.Background(), parentApplicationYamlKey),
+		clock: model.ClockFromContext(ctx),
 	}
 	tmpDb := storage.MustConnect(context.Background(), parentApplicationYamlKey, 1)
 	ebs.extraBonusStartDate = MustGetExtraBonusStartDate(ctx, tmpDb)
@@ -153,7 +154,7 @@ func (ebn *extraBonusNotifier) notifyingExtraBonusAvailability(ctx context.Conte
 	}()
 	var (
 		batchNumber  int64
-		now          = time.Now()
+		now          = ebn.clock.Now()
 		workers      = cfg.Workers
 		batchSize    = cfg.BatchSize
 		userKeys     = make([]string, 0, batchSize)
@@ -164,7 +165,7 @@ func (ebn *extraBonusNotifier) notifyingExtraBonusAvailability(ctx context.Conte
 		updatedUsers = make([]interface{ Key() string }, 0, batchSize)
 	)
 	resetVars := func(success bool) {
-		now = time.Now()
+		now = ebn.clock.Now()
 		if success && len(userResults) < int(batchSize) {
 			batchNumber = 0
 		}
@@ -186,7 +187,7 @@ func (ebn *extraBonusNotifier) notifyingExtraBonusAvailability(ctx context.Conte
 		if err := storage.Bind[User](reqCtx, db, userKeys, &userResults); err != nil {
 			log.Error(errors.Wrapf(err, "[extraBonusNotifier] failed to get users for batchNumber:%v,workerNumber:%v", batchNumber, workerNumber))
 			reqCancel()
-			now = time.Now()
+			now = ebn.clock.Now()
 
 			continue
 		}
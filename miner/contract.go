@@ -5,10 +5,17 @@ package miner
 import (
 	"context"
 	"io"
+	"net/http"
 	"sync"
 	"sync/atomic"
 	stdlibtime "time"
 
+	"github.com/bsm/redislock"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+
 	"github.com/ice-blockchain/eskimo/kyc/quiz"
 	dwh "github.com/ice-blockchain/freezer/bookkeeper/storage"
 	coindistribution "github.com/ice-blockchain/freezer/coin-distribution"
@@ -34,6 +41,132 @@ type (
 		RemainingFreeMiningSessions uint64     `json:"remainingFreeMiningSessions,omitempty"`
 		MiningStreak                uint64     `json:"miningStreak,omitempty"`
 	}
+	SimulationEventType string
+	DryRunDiffKind      string
+	// DryRunReport sums up what a dry run would have changed, on top of the live state, with the candidate config,
+	// and how that compares with what the live config, the baseline, would have changed, over the same snapshot of the users.
+	DryRunReport struct {
+		StartedAt *time.Time `json:"startedAt,omitempty" example:"2024-01-01T00:00:00Z"`
+		EndedAt   *time.Time `json:"endedAt,omitempty" example:"2024-01-01T00:10:00Z"`
+		// Deltas are the sums of the changes of the numeric fields of the updated users, by field.
+		Deltas map[string]float64 `json:"deltas,omitempty"`
+		// Increments are the sums of the amounts that would be added to the fields of the T0/T-1 referrals, by field.
+		Increments map[string]float64 `json:"increments,omitempty"`
+		// Comparison is, by numeric field of the mined users, how much the baseline and the candidate config would have changed it.
+		Comparison           map[string]*DryRunDeltaComparison `json:"comparison,omitempty"`
+		Users                uint64                            `json:"users" example:"1000"`
+		UpdatedUsers         uint64                            `json:"updatedUsers" example:"900"`
+		Histories            uint64                            `json:"histories" example:"100"`
+		LedgerEntries        uint64                            `json:"ledgerEntries" example:"3000"`
+		Messages             uint64                            `json:"messages" example:"50"`
+		CoinDistributions    uint64                            `json:"coinDistributions" example:"10"`
+		TotalMinted          float64                           `json:"totalMinted" example:"1234.5"`
+		TotalSlashed         float64                           `json:"totalSlashed" example:"12.3"`
+		TotalCoinDistributed float64                           `json:"totalCoinDistributed" example:"100.1"`
+	}
+	DryRunDeltaComparison struct {
+		Baseline   float64 `json:"baseline" example:"100.5"`
+		Candidate  float64 `json:"candidate" example:"120.5"`
+		Difference float64 `json:"difference" example:"20"`
+	}
+	// DryRunDiff is a line of the diff log of a dry run: what would have been written instead.
+	DryRunDiff struct {
+		Fields map[string]*DryRunFieldChange `json:"fields,omitempty"`
+		// Comparison has the fields of the user that the baseline config would have written differently than the candidate one.
+		Comparison map[string]*DryRunFieldComparison `json:"comparison,omitempty"`
+		Increments map[string]float64                `json:"increments,omitempty"`
+		Value      any                               `json:"value,omitempty"`
+		Kind       DryRunDiffKind                    `json:"kind" example:"user"`
+		Key        string                            `json:"key,omitempty" example:"users:1"`
+		Topic      string                            `json:"topic,omitempty" example:"mining-sessions-table"`
+	}
+	DryRunFieldChange struct {
+		Before any `json:"before"`
+		After  any `json:"after"`
+	}
+	DryRunFieldComparison struct {
+		Baseline  any `json:"baseline"`
+		Candidate any `json:"candidate"`
+	}
+	// RecomputeOptions select the users that Recompute rebuilds, from FromID to ToID, both included, and how.
+	RecomputeOptions struct {
+		// Since is when the replay starts from, with the first history snapshot recorded since then.
+		Since stdlibtime.Time
+		// Step is how often the users are mined in between the snapshots and the mining session events, like the miner would.
+		Step   stdlibtime.Duration
+		FromID int64
+		ToID   int64
+		// Apply writes the recomputed balances; otherwise they're only diffed.
+		Apply bool
+	}
+	// RecomputeReport sums up what Recompute changed, or would have changed without RecomputeOptions.Apply.
+	RecomputeReport struct {
+		StartedAt *time.Time `json:"startedAt,omitempty" example:"2024-01-01T00:00:00Z"`
+		EndedAt   *time.Time `json:"endedAt,omitempty" example:"2024-01-01T00:10:00Z"`
+		// Deltas are the sums of the changes of the recomputed fields, by field.
+		Deltas       map[string]float64 `json:"deltas,omitempty"`
+		Users        uint64             `json:"users" example:"1000"`
+		UpdatedUsers uint64             `json:"updatedUsers" example:"10"`
+		AppliedUsers uint64             `json:"appliedUsers" example:"10"`
+		// SkippedUsers are the ones without any history snapshot since then, or that don't exist anymore.
+		SkippedUsers uint64 `json:"skippedUsers" example:"5"`
+	}
+	// SimulationScenario is what Simulate replays: the users, their referral tree and what they do over time.
+	SimulationScenario struct {
+		Start  stdlibtime.Time    `json:"start" example:"2024-01-01T00:00:00Z"`
+		End    stdlibtime.Time    `json:"end" example:"2024-01-31T00:00:00Z"`
+		Step   string             `json:"step" example:"1h"`
+		Users  []*SimulatedUser   `json:"users"`
+		Events []*SimulationEvent `json:"events"`
+	}
+	SimulatedUser struct {
+		CreatedAt             *stdlibtime.Time `json:"createdAt,omitempty" example:"2024-01-01T00:00:00Z"`
+		MiningBoostLevelIndex *uint64          `json:"miningBoostLevelIndex,omitempty" example:"0"`
+		ID                    int64            `json:"id" example:"1"`
+		IDT0                  int64            `json:"t0,omitempty" example:"2"`
+		PreStakingAllocation  float64          `json:"preStakingAllocation,omitempty" example:"50"`
+		PreStakingBonus       float64          `json:"preStakingBonus,omitempty" example:"35"`
+	}
+	SimulationEvent struct {
+		At                    stdlibtime.Time     `json:"at" example:"2024-01-01T00:00:00Z"`
+		MiningBoostLevelIndex *uint64             `json:"miningBoostLevelIndex,omitempty" example:"0"`
+		Type                  SimulationEventType `json:"type" example:"startMiningSession"`
+		UserID                int64               `json:"userId" example:"1"`
+		IDT0                  int64               `json:"t0,omitempty" example:"2"`
+		PreStakingAllocation  float64             `json:"preStakingAllocation,omitempty" example:"50"`
+		PreStakingBonus       float64             `json:"preStakingBonus,omitempty" example:"35"`
+		Resurrect             bool                `json:"resurrect,omitempty" example:"true"`
+	}
+)
+
+const (
+	// StartMiningSessionSimulationEventType starts or extends the mining session of the user; with `resurrect` it also rolls back the slashing.
+	StartMiningSessionSimulationEventType SimulationEventType = "startMiningSession"
+	// StopMiningSessionSimulationEventType ends the mining session of the user right away.
+	StopMiningSessionSimulationEventType SimulationEventType = "stopMiningSession"
+	// MiningBoostSimulationEventType sets the `miningBoostLevelIndex` of the user; without it, it removes the mining boost.
+	MiningBoostSimulationEventType SimulationEventType = "miningBoost"
+	// PreStakingSimulationEventType sets the `preStakingAllocation` and `preStakingBonus` of the user.
+	PreStakingSimulationEventType SimulationEventType = "preStaking"
+	// ChangeT0SimulationEventType changes the T0 of the user to `t0`; 0 removes it.
+	ChangeT0SimulationEventType SimulationEventType = "changeT0"
+
+	// UserDryRunDiffKind is the before/after of the fields of a user that would have been updated, and the increments of its fields.
+	UserDryRunDiffKind DryRunDiffKind = "user"
+	// HistoryDryRunDiffKind is a history/bookkeeping record that would have been inserted.
+	HistoryDryRunDiffKind DryRunDiffKind = "history"
+	// LedgerDryRunDiffKind is a ledger entry that would have been inserted.
+	LedgerDryRunDiffKind DryRunDiffKind = "ledger"
+	// CoinDistributionDryRunDiffKind is a coin distribution that would have been collected for review.
+	CoinDistributionDryRunDiffKind DryRunDiffKind = "coinDistribution"
+	// MessageDryRunDiffKind is a message that would have been sent to the broker.
+	MessageDryRunDiffKind DryRunDiffKind = "message"
+)
+
+var (
+	ErrInvalidSimulationScenario = errors.New("invalid simulation scenario")
+	ErrInvalidRecomputeOptions   = errors.New("invalid recompute options")
+	ErrMiningNotPaused           = errors.New("mining is not paused")
 )
 
 // Private API.
@@ -42,12 +175,30 @@ const (
 	applicationYamlKey       = "miner"
 	parentApplicationYamlKey = "tokenomics"
 	requestDeadline          = 30 * stdlibtime.Second
+
+	recomputeMaxAttempts        = 3
+	recomputeLeaseDuration      = 30 * stdlibtime.Second
+	fencedWriteMaxAttempts      = 3
+	recomputeReferralsCacheSize = 10_000
+	failedLedgerBatchesMaxCount = 10
+	// The mining T1 referrals are listed first in model.SerializedMiningT1ReferralsKey by offsetting their scores with it.
+	// It keeps a precision of ~0.0001 for them, way under the 0.01 that's returned.
+	miningTeamScoreOffset = 1e12
+
+	minerReplicasKey                               = "miner_replicas"
+	minerShardingLeaderLockKey                     = "miner_sharding_leader"
+	minerPartitionAssignmentsKey                   = "miner_partition_assignments"
+	minerPartitionLeaseKeyPrefix                   = "miner_partition_lease"
+	coinDistributionCollectionStartedPartitionsKey = "coin_distribution_collection_started_partitions"
+	coinDistributionCollectionEndedPartitionsKey   = "coin_distribution_collection_ended_partitions"
 )
 
 // .
 var (
 	//nolint:gochecknoglobals // Singleton & global config mounted only during bootstrap.
 	cfg config
+
+	errPartitionLeaseLost = errors.New("partition lease lost")
 )
 
 type (
@@ -62,6 +213,7 @@ type (
 		model.MiningBoostLevelIndexField
 		model.KYCState
 		model.MiningBlockchainAccountAddressField
+		model.MiningBlockchainNetworkField
 		model.CountryField
 		model.UsernameField
 		model.LatestDeviceField
@@ -76,6 +228,8 @@ type (
 		model.VerifiedT1ReferralsField
 		model.ActiveT1ReferralsField
 		model.ActiveT2ReferralsField
+		ledger        map[dwh.LedgerReason]float64
+		referralTiers model.ReferralTiers
 	}
 
 	UpdatedUser struct { // This is public only because we have to embed it, and it has to be if so.
@@ -130,6 +284,39 @@ type (
 		model.SlashingRateForTMinus1Field
 		model.ExtraBonusDaysClaimNotAvailableField
 	}
+	// The balances that Recompute rebuilds, with what they depend on.
+	recomputedUser struct {
+		model.BalanceLastUpdatedAtField
+		model.DeserializedUsersKey
+		model.BalanceTotalStandardField
+		model.BalanceTotalPreStakingField
+		model.BalanceSoloPendingAppliedField
+		model.BalanceT1WelcomeBonusPendingAppliedField
+		model.BalanceT1PendingAppliedField
+		model.BalanceT2PendingAppliedField
+		model.BalanceSoloField
+		model.BalanceT0Field
+		model.BalanceT1Field
+		model.BalanceT2Field
+		model.BalanceForT0Field
+		model.BalanceForTMinus1Field
+		model.BalanceSoloEthereumField
+		model.BalanceT0EthereumField
+		model.BalanceT1EthereumField
+		model.BalanceT2EthereumField
+		model.BalanceForT0EthereumField
+		model.BalanceForTMinus1EthereumField
+		model.BalanceSoloEthereumMainnetRewardPoolContributionField
+		model.BalanceT0EthereumMainnetRewardPoolContributionField
+		model.BalanceT1EthereumMainnetRewardPoolContributionField
+		model.BalanceT2EthereumMainnetRewardPoolContributionField
+		model.SlashingRateSoloField
+		model.SlashingRateT0Field
+		model.SlashingRateT1Field
+		model.SlashingRateT2Field
+		model.SlashingRateForT0Field
+		model.SlashingRateForTMinus1Field
+	}
 	referralUpdated struct {
 		model.DeserializedUsersKey
 		model.IDT0Field
@@ -148,6 +335,7 @@ type (
 		model.CountryField
 		model.UsernameField
 		model.MiningBlockchainAccountAddressField
+		model.MiningBlockchainNetworkField
 		model.IDT0Field
 		model.DeserializedUsersKey
 		model.BalanceTotalStandardField
@@ -168,6 +356,21 @@ type (
 		model.DeserializedUsersKey
 	}
 
+	// The teams of the T0s & T-1s of a batch of users, see model.SerializedT1ReferralsKey and model.SerializedT2ReferralsKey:
+	// the scores of the users in their T0's teams, by T0, and the increments of the scores of their T0s in their T-1's team, by T-1.
+	teamsBatch struct {
+		t1                map[int64][]redis.Z
+		miningT1          map[int64][]redis.Z
+		t2                map[int64][]redis.Z
+		t2ScoreIncrements map[int64]map[string]float64
+	}
+	// The deeper referral tiers of a batch of users: the fetched ancestors, by T-1, and what to write.
+	referralTiersBatch struct {
+		ancestors                 map[int64][]int64
+		updated                   map[int64]model.ReferralTiers
+		activeReferralsIncrements []map[int64]int64
+	}
+
 	referralThatStoppedMining struct {
 		StoppedMiningAt     *time.Time
 		ID, IDT0, IDTMinus1 int64
@@ -179,6 +382,38 @@ type (
 		model.DeserializedUsersKey
 	}
 
+	// A partition is the slice of the users keyspace that one worker mines: users with `id % workers == number`.
+	// Without sharding, every partition is owned by the only replica; with it, they're leased from the leader's assignments.
+	partition struct {
+		lease    *redislock.Lock
+		cancel   context.CancelFunc
+		progress *partitionProgress
+		mx       *sync.RWMutex
+		number   int64
+	}
+	partitionProgress struct {
+		OwnedSince           *time.Time `json:"ownedSince,omitempty"`
+		LastBatchProcessedAt *time.Time `json:"lastBatchProcessedAt,omitempty"`
+		Owner                string     `json:"owner,omitempty"`
+		Partition            int64      `json:"partition"`
+		Iteration            uint64     `json:"iteration"`
+		BatchNumber          int64      `json:"batchNumber"`
+	}
+
+	batchTelemetry struct {
+		usersMined                 uint64
+		usersSlashed               uint64
+		usersResurrected           uint64
+		daysOffStarted             uint64
+		coinDistributionsCollected uint64
+	}
+	partitionsCollector struct {
+		progress      func() []*partitionProgress
+		batchLagDesc  *prometheus.Desc
+		iterationDesc *prometheus.Desc
+		batchDesc     *prometheus.Desc
+	}
+
 	miner struct {
 		coinDistributionStartedSignaler             chan struct{}
 		coinDistributionEndedSignaler               chan struct{}
@@ -189,11 +424,79 @@ type (
 		mb                                          messagebroker.Client
 		db                                          storage.DB
 		dwhClient                                   dwh.Client
+		clock                                       model.Clock
 		cancel                                      context.CancelFunc
 		telemetry                                   *telemetry
+		metricsServer                               *http.Server
 		wg                                          *sync.WaitGroup
+		partitionsMX                                *sync.RWMutex
+		partitions                                  map[int64]*partition
+		leaderLease                                 *redislock.Lock
+		leader                                      *atomic.Bool
+		replicaID                                   string
 		extraBonusStartDate                         *time.Time
 		extraBonusIndicesDistribution               map[uint16]map[uint16]uint16
+		dryRun                                      *dryRun
+	}
+	dryRun struct {
+		report *DryRunReport
+		// The live config, that the candidate one is compared with.
+		baseline *config
+		// The comparisons of the users of the current batch, by key, until it's recorded.
+		comparisons map[string]*dryRunComparison
+		encoder     *json.Encoder
+		err         error
+		mx          *sync.Mutex
+	}
+	dryRunComparison struct {
+		fields                          map[string]*DryRunFieldComparison
+		baselineDeltas, candidateDeltas map[string]float64
+	}
+	recomputation struct {
+		opts      *RecomputeOptions
+		dryRun    *dryRun
+		db        storage.DB
+		dwhClient dwh.Client
+		referrals map[int64]*replayTimeline
+		report    *RecomputeReport
+	}
+	// A replayTimeline is what the history snapshots and the current state of a user tell about it, over time.
+	replayTimeline struct {
+		current      *user
+		snapshots    []*replayedSnapshot
+		sessions     []*replayedSession
+		extraBonuses []*replayedExtraBonus
+		// When the coin distributions were collected for the user, as far as its snapshots tell.
+		ethereumDistributedAt []*time.Time
+	}
+	replayedSnapshot struct {
+		createdAt *time.Time
+		usr       *user
+	}
+	replayedSession struct {
+		startedAt, lastStartedAt, endedAt, previouslyEndedAt *time.Time
+		resurrected                                          bool
+	}
+	replayedExtraBonus struct {
+		startedAt *time.Time
+		bonus     float64
+	}
+	dryRunIncrements struct {
+		values any
+		field  string
+		sign   float64
+	}
+	dryRunMessageBroker struct {
+		messagebroker.Client
+		dryRun *dryRun
+	}
+	dryRunDWHClient struct {
+		dwh.Client
+		dryRun *dryRun
+	}
+	dryRunCoinDistributionRepository struct {
+		coindistribution.Repository
+		dryRun *dryRun
 	}
 	config struct {
 		miningBoostLevels                       *atomic.Pointer[[]*tokenomics.MiningBoostLevel]
@@ -201,11 +504,20 @@ type (
 		coinDistributionCollectorStartedAt      *atomic.Pointer[time.Time]
 		coinDistributionCollectorSettings       *atomic.Pointer[coindistribution.CollectorSettings]
 		MainnetRewardPoolContributionEthAddress string                   `yaml:"mainnetRewardPoolContributionEthAddress" mapstructure:"mainnetRewardPoolContributionEthAddress"`
+		MetricsAddress                          string                   `yaml:"metricsAddress" mapstructure:"metricsAddress"`
 		tokenomics.Config                       `mapstructure:",squash"` //nolint:tagliatelle // Nope.
 		EthereumDistributionFrequency           struct {
 			Min stdlibtime.Duration `yaml:"min"`
 			Max stdlibtime.Duration `yaml:"max"`
 		} `yaml:"ethereumDistributionFrequency" mapstructure:"ethereumDistributionFrequency"`
+		// The network the mainnet reward pool contributions are distributed on, regardless of the network chosen by the users contributing to it.
+		MainnetRewardPoolContributionNetwork coindistribution.BlockchainNetworkType `yaml:"mainnetRewardPoolContributionNetwork" mapstructure:"mainnetRewardPoolContributionNetwork"` //nolint:lll // .
+		Sharding                             struct {
+			LeaseDuration     stdlibtime.Duration `yaml:"leaseDuration"`
+			RebalanceInterval stdlibtime.Duration `yaml:"rebalanceInterval"`
+			StalledAfter      stdlibtime.Duration `yaml:"stalledAfter"`
+			Enabled           bool                `yaml:"enabled"`
+		} `yaml:"sharding" mapstructure:"sharding"`
 		MainnetRewardPoolContributionPercentage float64 `yaml:"mainnetRewardPoolContributionPercentage" mapstructure:"mainnetRewardPoolContributionPercentage"`
 		Workers                                 int64   `yaml:"workers"`
 		BatchSize                               int64   `yaml:"batchSize"`
@@ -0,0 +1,408 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package miner
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/goccy/go-json"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	dwh "github.com/ice-blockchain/freezer/bookkeeper/storage"
+	coindistribution "github.com/ice-blockchain/freezer/coin-distribution"
+	"github.com/ice-blockchain/freezer/model"
+	"github.com/ice-blockchain/freezer/tokenomics"
+	messagebroker "github.com/ice-blockchain/wintr/connectors/message_broker"
+	"github.com/ice-blockchain/wintr/connectors/storage/v3"
+	"github.com/ice-blockchain/wintr/log"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+// DryRun mines all the users once, with the candidate config, without writing anything:
+// the updated users, the histories, the ledger entries, the coin distributions and the broker messages go to diffLog, as NDJSON DryRunDiff lines, instead.
+// The candidate config is an application yaml, with the `tokenomics` and `miner` keys, that overrides the live config, the loaded one.
+// Each user is also mined with the live config, the baseline, over the same snapshot, and the report compares the two.
+func DryRun(ctx context.Context, candidate io.Reader, diffLog io.Writer) (*DryRunReport, error) {
+	baseline := cfg
+	candidateCfg, err := loadCandidateConfig(&baseline, candidate)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to loadCandidateConfig")
+	}
+	cfg = *candidateCfg
+	defer func() { cfg = baseline }()
+	dr := newDryRun(diffLog)
+	dr.baseline, dr.comparisons = &baseline, make(map[string]*dryRunComparison, cfg.BatchSize)
+	dr.report.Comparison = make(map[string]*DryRunDeltaComparison)
+	coinDistributionRepository := coindistribution.NewRepository(ctx, func() {})
+	defer func() {
+		log.Error(errors.Wrap(coinDistributionRepository.Close(), "failed to close coinDistributionRepository"))
+	}()
+	settings, err := coinDistributionRepository.GetCollectorSettings(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to GetCollectorSettings")
+	}
+	cfg.coinDistributionCollectorSettings.Store(settings)
+	mi := &miner{
+		coinDistributionRepository: &dryRunCoinDistributionRepository{Repository: coinDistributionRepository, dryRun: dr},
+		mb:                         &dryRunMessageBroker{dryRun: dr},
+		db:                         storage.MustConnect(ctx, parentApplicationYamlKey, int(cfg.Workers)),
+		wg:                         new(sync.WaitGroup),
+		telemetry:                  new(telemetry).mustInit(cfg),
+		clock:                      model.ClockFromContext(ctx),
+		partitionsMX:               new(sync.RWMutex),
+		partitions:                 make(map[int64]*partition, cfg.Workers),
+		leader:                     new(atomic.Bool),
+		replicaID:                  newReplicaID(),
+		coinDistributionWorkerMX:   new(sync.Mutex),
+		dryRun:                     dr,
+	}
+	defer func() {
+		log.Error(errors.Wrap(mi.db.Close(), "failed to close db"))
+	}()
+	if err = mi.syncDisableAdvancedTeamCfg(ctx); err != nil {
+		return nil, errors.Wrap(err, "failed to syncDisableAdvancedTeamCfg")
+	}
+	// The partitions are mined one after the other, so that the config can be swapped to the baseline one, while comparing with it.
+	for workerNumber := int64(0); workerNumber < cfg.Workers && ctx.Err() == nil; workerNumber++ {
+		mi.startPartition(ctx, workerNumber, nil)
+		mi.wg.Wait()
+	}
+	if err = ctx.Err(); err != nil {
+		return dr.report, errors.Wrap(err, "dry run interrupted")
+	}
+
+	return dr.finish()
+}
+
+// Loads the candidate config from an application yaml, on top of the baseline one, like the baseline one is loaded, from its `tokenomics` & `miner` keys.
+// What the yaml doesn't have stays as it is in the baseline, and so does the state that's synchronized at runtime, like the collector settings.
+func loadCandidateConfig(baseline *config, reader io.Reader) (*config, error) {
+	var content map[string]any
+	if err := yaml.NewDecoder(reader).Decode(&content); err != nil {
+		return nil, errors.Wrap(err, "failed to decode the candidate config")
+	}
+	candidate := *baseline
+	for _, key := range []string{parentApplicationYamlKey, applicationYamlKey} {
+		var result any = &candidate
+		if key == parentApplicationYamlKey {
+			result = &candidate.Config
+		}
+		decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			DecodeHook:       mapstructure.ComposeDecodeHookFunc(mapstructure.StringToTimeDurationHookFunc(), mapstructure.StringToSliceHookFunc(",")),
+			ZeroFields:       true, // So that the maps, slices and pointers of the baseline are replaced, instead of altered.
+			WeaklyTypedInput: true,
+			Result:           result,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create the decoder of `%v`", key)
+		}
+		if err = decoder.Decode(content[key]); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode `%v` of the candidate config", key)
+		}
+	}
+	if candidate.SlashingDaysCount == 0 {
+		return nil, errors.Errorf("slashingDaysCount is zero")
+	}
+	if candidate.MainnetRewardPoolContributionNetwork == "" {
+		candidate.MainnetRewardPoolContributionNetwork = coindistribution.EthereumBlockchainNetworkType
+	}
+	if reflect.ValueOf(candidate.MiningBoost.Levels).Pointer() != reflect.ValueOf(baseline.MiningBoost.Levels).Pointer() {
+		levels := sortMiningBoostLevels(candidate.MiningBoost.Levels)
+		candidate.miningBoostLevels = new(atomic.Pointer[[]*tokenomics.MiningBoostLevel])
+		candidate.miningBoostLevels.Store(&levels)
+	}
+
+	return &candidate, nil
+}
+
+func newDryRun(diffLog io.Writer) *dryRun {
+	return &dryRun{
+		report:  &DryRunReport{StartedAt: time.Now(), Deltas: make(map[string]float64), Increments: make(map[string]float64)},
+		encoder: json.NewEncoder(diffLog),
+		mx:      new(sync.Mutex),
+	}
+}
+
+func (dr *dryRun) finish() (*DryRunReport, error) {
+	dr.mx.Lock()
+	defer dr.mx.Unlock()
+
+	dr.report.EndedAt = time.Now()
+	dr.report.TotalMinted = dr.report.Deltas["balance_total_minted"]
+	dr.report.TotalSlashed = dr.report.Deltas["balance_total_slashed"]
+	for _, comparison := range dr.report.Comparison {
+		comparison.Difference = comparison.Candidate - comparison.Baseline
+	}
+
+	return dr.report, dr.err
+}
+
+// Mines the user with the baseline config too, with the same snapshot of it and of its referrals, and compares that with the candidate's mining of it.
+// It's only called while the partitions are mined one after the other, so the global config can be swapped in the meantime.
+func (dr *dryRun) compareWithBaseline(now *time.Time, usr *user, t0Ref, tMinus1Ref *referral, candidate *user) {
+	if dr.baseline == nil {
+		return
+	}
+	candidateCfg := cfg
+	cfg = *dr.baseline
+	baseline, _, _, _, _ := mine(now, usr, t0Ref, tMinus1Ref) //nolint:dogsled // We only care about the user.
+	cfg = candidateCfg
+	if baseline == nil {
+		baseline = usr
+	}
+	if candidate == nil {
+		candidate = usr
+	}
+	beforeFields, baselineFields, candidateFields := make(map[string]reflect.Value), make(map[string]reflect.Value), make(map[string]reflect.Value)
+	redisFields(reflect.ValueOf(&usr.UpdatedUser).Elem(), false, beforeFields)
+	redisFields(reflect.ValueOf(&baseline.UpdatedUser).Elem(), false, baselineFields)
+	redisFields(reflect.ValueOf(&candidate.UpdatedUser).Elem(), false, candidateFields)
+	comparison := &dryRunComparison{
+		fields:          make(map[string]*DryRunFieldComparison),
+		baselineDeltas:  make(map[string]float64),
+		candidateDeltas: make(map[string]float64),
+	}
+	for field, candidateValue := range candidateFields {
+		baselineValue := baselineFields[field]
+		if !equalFieldValues(baselineValue, candidateValue) {
+			comparison.fields[field] = &DryRunFieldComparison{Baseline: baselineValue.Interface(), Candidate: candidateValue.Interface()}
+		}
+		if candidateValue.Kind() == reflect.Float64 {
+			comparison.baselineDeltas[field] = baselineValue.Float() - beforeFields[field].Float()
+			comparison.candidateDeltas[field] = candidateValue.Float() - beforeFields[field].Float()
+		}
+	}
+	dr.mx.Lock()
+	dr.comparisons[usr.Key()] = comparison
+	dr.mx.Unlock()
+}
+
+func (dr *dryRun) write(diffs ...*DryRunDiff) {
+	for _, diff := range diffs {
+		if dr.err != nil {
+			return
+		}
+		dr.err = errors.Wrapf(dr.encoder.Encode(diff), "failed to write the %v dry run diff of `%v`", diff.Kind, diff.Key)
+	}
+}
+
+// Records, instead of persisting them, the updates of a batch: updates are slices of the partial users the miner would write
+// to the `users:` hashes and increments are the amounts it would add to the fields of the T0/T-1 referrals.
+func (dr *dryRun) recordMiningProgress(usrs []*user, updates []any, increments []*dryRunIncrements) {
+	usersByKey := make(map[string]*user, len(usrs))
+	for _, usr := range usrs {
+		if usr.UserID != "" {
+			usersByKey[usr.Key()] = usr
+		}
+	}
+	diffs, diffsByKey := make([]*DryRunDiff, 0, len(usrs)), make(map[string]*DryRunDiff, len(usrs))
+	diffOf := func(key string) *DryRunDiff {
+		diff, found := diffsByKey[key]
+		if !found {
+			diff = &DryRunDiff{Kind: UserDryRunDiffKind, Key: key}
+			diffsByKey[key] = diff
+			diffs = append(diffs, diff)
+		}
+
+		return diff
+	}
+	dr.mx.Lock()
+	defer dr.mx.Unlock()
+
+	dr.report.Users += uint64(len(usersByKey))
+	for _, values := range updates {
+		slice := reflect.ValueOf(values)
+		for ix := 0; ix < slice.Len(); ix++ {
+			update := slice.Index(ix)
+			key := update.Interface().(interface{ Key() string }).Key() //nolint:errcheck,forcetypeassert // They're all keyed.
+			var before reflect.Value
+			if usr, found := usersByKey[key]; found {
+				before = reflect.ValueOf(usr).Elem()
+			}
+			changes := dr.changedFields(before, update.Elem())
+			if len(changes) == 0 {
+				continue
+			}
+			diff := diffOf(key)
+			if diff.Fields == nil {
+				diff.Fields = make(map[string]*DryRunFieldChange, len(changes))
+			}
+			for field, change := range changes {
+				diff.Fields[field] = change
+			}
+		}
+	}
+	dr.report.UpdatedUsers += uint64(len(diffs))
+	for _, incr := range increments {
+		dr.recordIncrements(incr, diffOf)
+	}
+	dr.recordComparisons(usrs, diffOf)
+	dr.write(diffs...)
+}
+
+// Sums up the comparisons of the users of the batch, and adds the fields that the baseline would have written differently to their diffs.
+// The ones of a batch that failed are overwritten when it's retried, so they're only summed up once.
+func (dr *dryRun) recordComparisons(usrs []*user, diffOf func(key string) *DryRunDiff) {
+	for _, usr := range usrs {
+		key := usr.Key()
+		comparison, found := dr.comparisons[key]
+		if !found {
+			continue
+		}
+		delete(dr.comparisons, key)
+		for field, delta := range comparison.candidateDeltas {
+			total, tFound := dr.report.Comparison[field]
+			if !tFound {
+				total = new(DryRunDeltaComparison)
+				dr.report.Comparison[field] = total
+			}
+			total.Baseline += comparison.baselineDeltas[field]
+			total.Candidate += delta
+		}
+		if len(comparison.fields) > 0 {
+			diffOf(key).Comparison = comparison.fields
+		}
+	}
+}
+
+func (dr *dryRun) recordIncrements(incr *dryRunIncrements, diffOf func(key string) *DryRunDiff) {
+	values := reflect.ValueOf(incr.values)
+	ids := make([]int64, 0, values.Len())
+	for _, id := range values.MapKeys() {
+		ids = append(ids, id.Int())
+	}
+	sort.Slice(ids, func(ii, jj int) bool { return ids[ii] < ids[jj] })
+	for _, id := range ids {
+		amount := incr.sign * values.MapIndex(reflect.ValueOf(id)).Convert(reflect.TypeOf(float64(0))).Float()
+		if amount == 0 {
+			continue
+		}
+		diff := diffOf(model.SerializedUsersKey(id))
+		if diff.Increments == nil {
+			diff.Increments = make(map[string]float64, 1)
+		}
+		diff.Increments[incr.field] += amount
+		dr.report.Increments[incr.field] += amount
+	}
+}
+
+// Compares the fields that the miner would write, just like it writes them: the nil fields and the empty `omitempty` ones aren't.
+func (dr *dryRun) changedFields(before, after reflect.Value) map[string]*DryRunFieldChange {
+	beforeFields := make(map[string]reflect.Value)
+	if before.IsValid() {
+		redisFields(before, false, beforeFields)
+	}
+	afterFields := make(map[string]reflect.Value)
+	redisFields(after, true, afterFields)
+	changes := make(map[string]*DryRunFieldChange, len(afterFields))
+	for field, afterValue := range afterFields {
+		beforeValue, found := beforeFields[field]
+		if found && equalFieldValues(beforeValue, afterValue) {
+			continue
+		}
+		change := &DryRunFieldChange{After: afterValue.Interface()}
+		var beforeFloat float64
+		if found {
+			change.Before = beforeValue.Interface()
+			if beforeValue.Kind() == reflect.Float64 {
+				beforeFloat = beforeValue.Float()
+			}
+		}
+		if afterValue.Kind() == reflect.Float64 {
+			dr.report.Deltas[field] += afterValue.Float() - beforeFloat
+		}
+		changes[field] = change
+	}
+
+	return changes
+}
+
+func redisFields(val reflect.Value, onlyWritten bool, fields map[string]reflect.Value) {
+	for ix := 0; ix < val.NumField(); ix++ {
+		field, fieldType := val.Field(ix), val.Type().Field(ix)
+		if fieldType.Anonymous && field.Kind() == reflect.Struct {
+			redisFields(field, onlyWritten, fields)
+
+			continue
+		}
+		tag := fieldType.Tag.Get("redis")
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" || name == "-" || !fieldType.IsExported() {
+			continue
+		}
+		if onlyWritten && field.IsZero() && (strings.HasSuffix(tag, ",omitempty") || field.Kind() == reflect.Pointer) {
+			continue
+		}
+		fields[name] = field
+	}
+}
+
+func equalFieldValues(before, after reflect.Value) bool {
+	if beforeTime, isTime := before.Interface().(*time.Time); isTime {
+		afterTime := after.Interface().(*time.Time) //nolint:errcheck,forcetypeassert // Same field.
+		if beforeTime.IsNil() || afterTime.IsNil() {
+			return beforeTime.IsNil() == afterTime.IsNil()
+		}
+
+		return beforeTime.Equal(*afterTime.Time)
+	}
+
+	return reflect.DeepEqual(before.Interface(), after.Interface())
+}
+
+func (mb *dryRunMessageBroker) SendMessage(_ context.Context, msg *messagebroker.Message, responder chan<- error) {
+	mb.dryRun.mx.Lock()
+	mb.dryRun.report.Messages++
+	mb.dryRun.write(&DryRunDiff{Kind: MessageDryRunDiffKind, Key: msg.Key, Topic: msg.Topic, Value: json.RawMessage(msg.Value)})
+	mb.dryRun.mx.Unlock()
+	responder <- nil
+}
+
+func (*dryRunDWHClient) Close() error {
+	return nil
+}
+
+func (c *dryRunDWHClient) Insert(_ context.Context, _ *dwh.Columns, _ dwh.InsertMetadata, usrs []*model.User) error {
+	c.dryRun.mx.Lock()
+	defer c.dryRun.mx.Unlock()
+
+	for _, usr := range usrs {
+		c.dryRun.report.Histories++
+		c.dryRun.write(&DryRunDiff{Kind: HistoryDryRunDiffKind, Key: usr.Key(), Value: usr})
+	}
+
+	return nil
+}
+
+func (c *dryRunDWHClient) InsertLedger(_ context.Context, _ *dwh.LedgerColumns, _ dwh.InsertMetadata, entries []*dwh.LedgerEntry) error {
+	c.dryRun.mx.Lock()
+	defer c.dryRun.mx.Unlock()
+
+	for _, entry := range entries {
+		c.dryRun.report.LedgerEntries++
+		c.dryRun.write(&DryRunDiff{Kind: LedgerDryRunDiffKind, Key: model.SerializedUsersKey(entry.ID), Value: entry})
+	}
+
+	return nil
+}
+
+func (r *dryRunCoinDistributionRepository) CollectCoinDistributionsForReview(_ context.Context, records []*coindistribution.ByEarnerForReview) error {
+	r.dryRun.mx.Lock()
+	defer r.dryRun.mx.Unlock()
+
+	for _, record := range records {
+		r.dryRun.report.CoinDistributions++
+		r.dryRun.report.TotalCoinDistributed += record.Balance
+		r.dryRun.write(&DryRunDiff{Kind: CoinDistributionDryRunDiffKind, Key: model.SerializedUsersKey(record.InternalID), Value: record})
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package miner
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	coindistribution "github.com/ice-blockchain/freezer/coin-distribution"
+	"github.com/ice-blockchain/freezer/model"
+	messagebroker "github.com/ice-blockchain/wintr/connectors/message_broker"
+)
+
+func decodeDryRunDiffs(t *testing.T, diffLog *bytes.Buffer) []*DryRunDiff {
+	t.Helper()
+
+	var diffs []*DryRunDiff
+	decoder := json.NewDecoder(diffLog)
+	for decoder.More() {
+		diff := new(DryRunDiff)
+		require.NoError(t, decoder.Decode(diff))
+		diffs = append(diffs, diff)
+	}
+
+	return diffs
+}
+
+func TestDryRunRecordMiningProgress(t *testing.T) {
+	t.Parallel()
+
+	var diffLog bytes.Buffer
+	dr := newDryRun(&diffLog)
+	usr := newUser()
+	usr.ID, usr.BalanceSolo, usr.BalanceTotalMinted = 1, 10, 10
+	clone := *usr
+	updatedUser := &clone.UpdatedUser
+	updatedUser.BalanceSolo, updatedUser.BalanceTotalMinted = 12, 12
+	updatedUser.BalanceLastUpdatedAt = testTime
+	notMined := newUser()
+	notMined.ID, notMined.UserID = 3, "not_mined"
+	guardUpdatedUser := &referralCountGuardUpdatedUser{
+		DeserializedUsersKey:                    model.DeserializedUsersKey{ID: 1},
+		ReferralsCountChangeGuardUpdatedAtField: model.ReferralsCountChangeGuardUpdatedAtField{ReferralsCountChangeGuardUpdatedAt: testTime},
+	}
+
+	dr.recordMiningProgress([]*user{usr, notMined}, []any{[]*UpdatedUser{updatedUser}, []*referralCountGuardUpdatedUser{guardUpdatedUser}}, []*dryRunIncrements{
+		{field: "balance_t1_pending", sign: 1, values: map[int64]float64{2: 1.5}},
+		{field: "active_t1_referrals", sign: -1, values: map[int64]uint32{2: 1}},
+	})
+	report, err := dr.finish()
+	require.NoError(t, err)
+
+	diffs := decodeDryRunDiffs(t, &diffLog)
+	require.Len(t, diffs, 2)
+	assert.Equal(t, UserDryRunDiffKind, diffs[0].Kind)
+	assert.Equal(t, "users:1", diffs[0].Key)
+	assert.Len(t, diffs[0].Fields, 4)
+	assert.EqualValues(t, 10, diffs[0].Fields["balance_solo"].Before)
+	assert.EqualValues(t, 12, diffs[0].Fields["balance_solo"].After)
+	assert.Nil(t, diffs[0].Fields["balance_last_updated_at"].Before)
+	assert.Contains(t, diffs[0].Fields, "referrals_count_change_guard_updated_at")
+	assert.Empty(t, diffs[0].Increments)
+	assert.Equal(t, "users:2", diffs[1].Key)
+	assert.Equal(t, map[string]float64{"balance_t1_pending": 1.5, "active_t1_referrals": -1}, diffs[1].Increments)
+
+	assert.EqualValues(t, 2, report.Users)
+	assert.EqualValues(t, 1, report.UpdatedUsers)
+	assert.InDelta(t, 2, report.TotalMinted, 0.000001)
+	assert.InDelta(t, 2, report.Deltas["balance_solo"], 0.000001)
+	assert.Equal(t, map[string]float64{"balance_t1_pending": 1.5, "active_t1_referrals": -1}, report.Increments)
+}
+
+func TestDryRunStandIns(t *testing.T) {
+	t.Parallel()
+
+	var diffLog bytes.Buffer
+	dr := newDryRun(&diffLog)
+	responder := make(chan error, 1)
+	(&dryRunMessageBroker{dryRun: dr}).SendMessage(context.Background(), &messagebroker.Message{Key: "a", Topic: "b", Value: []byte(`{"c":1}`)}, responder)
+	require.NoError(t, <-responder)
+	coinDistributions := []*coindistribution.ByEarnerForReview{{InternalID: 1, Balance: 5}, {InternalID: 2, Balance: 2.5}}
+	require.NoError(t, (&dryRunCoinDistributionRepository{dryRun: dr}).CollectCoinDistributionsForReview(context.Background(), coinDistributions))
+	history := new(model.User)
+	history.ID = 1
+	dwhClient := &dryRunDWHClient{dryRun: dr}
+	require.NoError(t, dwhClient.Insert(context.Background(), nil, nil, []*model.User{history}))
+	require.NoError(t, dwhClient.Close())
+	report, err := dr.finish()
+	require.NoError(t, err)
+
+	diffs := decodeDryRunDiffs(t, &diffLog)
+	require.Len(t, diffs, 4)
+	assert.Equal(t, MessageDryRunDiffKind, diffs[0].Kind)
+	assert.Equal(t, "b", diffs[0].Topic)
+	assert.Equal(t, map[string]any{"c": float64(1)}, diffs[0].Value)
+	assert.Equal(t, CoinDistributionDryRunDiffKind, diffs[1].Kind)
+	assert.Equal(t, "users:2", diffs[2].Key)
+	assert.Equal(t, HistoryDryRunDiffKind, diffs[3].Kind)
+	assert.EqualValues(t, 1, report.Messages)
+	assert.EqualValues(t, 2, report.CoinDistributions)
+	assert.EqualValues(t, 1, report.Histories)
+	assert.InDelta(t, 7.5, report.TotalCoinDistributed, 0.000001)
+	assert.False(t, report.EndedAt.Before(*report.StartedAt.Time))
+}
+
+func TestLoadCandidateConfig(t *testing.T) {
+	t.Parallel()
+
+	baseline := cfg
+	candidate, err := loadCandidateConfig(&baseline, strings.NewReader(`
+tokenomics:
+  referralBonusMiningRates:
+    t2: 99
+  mining-boost:
+    levels:
+      1.5:
+        maxT1Referrals: 3
+miner:
+  slashingDaysCount: 7
+`))
+	require.NoError(t, err)
+	assert.EqualValues(t, 99, candidate.ReferralBonusMiningRates.T2)
+	assert.Equal(t, baseline.ReferralBonusMiningRates.T1, candidate.ReferralBonusMiningRates.T1)
+	assert.EqualValues(t, 7, candidate.SlashingDaysCount)
+	assert.Equal(t, baseline.Workers, candidate.Workers)
+	require.Len(t, *candidate.miningBoostLevels.Load(), 1)
+	assert.EqualValues(t, 3, (*candidate.miningBoostLevels.Load())[0].MaxT1Referrals)
+	assert.Equal(t, cfg.MiningBoost.Levels, baseline.MiningBoost.Levels)
+	assert.Same(t, baseline.coinDistributionCollectorSettings, candidate.coinDistributionCollectorSettings)
+
+	_, err = loadCandidateConfig(&baseline, strings.NewReader("miner:\n  slashingDaysCount: 0\n"))
+	require.Error(t, err)
+}
+
+//nolint:paralleltest // It swaps the global config.
+func TestDryRunCompareWithBaseline(t *testing.T) {
+	var diffLog bytes.Buffer
+	dr := newDryRun(&diffLog)
+	baseline := cfg
+	baseline.ReferralBonusMiningRates.T2 = 2 * cfg.ReferralBonusMiningRates.T2
+	dr.baseline, dr.comparisons, dr.report.Comparison = &baseline, make(map[string]*dryRunComparison), make(map[string]*DryRunDeltaComparison)
+	usr := newUser()
+	usr.ID, usr.ActiveT2Referrals = 1, 1
+	notChanged := newUser()
+	notChanged.ID, notChanged.UserID = 2, "no_t2_referrals"
+
+	candidate, _, _, _, _ := mine(testTime, usr, nil, nil) //nolint:dogsled // We only care about the user.
+	require.NotNil(t, candidate)
+	dr.compareWithBaseline(testTime, usr, nil, nil, candidate)
+	notChangedCandidate, _, _, _, _ := mine(testTime, notChanged, nil, nil) //nolint:dogsled // We only care about the user.
+	dr.compareWithBaseline(testTime, notChanged, nil, nil, notChangedCandidate)
+	dr.recordMiningProgress([]*user{usr, notChanged}, []any{[]*UpdatedUser{&candidate.UpdatedUser}}, nil)
+	report, err := dr.finish()
+	require.NoError(t, err)
+
+	t2Rate := float64(cfg.ReferralBonusMiningRates.T2) * testMiningBase / 100
+	diffs := decodeDryRunDiffs(t, &diffLog)
+	require.Len(t, diffs, 1)
+	assert.Equal(t, "users:1", diffs[0].Key)
+	require.Contains(t, diffs[0].Comparison, "balance_t2")
+	assert.InDelta(t, 2*t2Rate, diffs[0].Comparison["balance_t2"].Baseline, 0.000001)
+	assert.InDelta(t, t2Rate, diffs[0].Comparison["balance_t2"].Candidate, 0.000001)
+	assert.NotContains(t, diffs[0].Comparison, "balance_solo")
+	require.Contains(t, report.Comparison, "balance_t2")
+	assert.InDelta(t, 2*t2Rate, report.Comparison["balance_t2"].Baseline, 0.000001)
+	assert.InDelta(t, t2Rate, report.Comparison["balance_t2"].Candidate, 0.000001)
+	assert.InDelta(t, -t2Rate, report.Comparison["balance_t2"].Difference, 0.000001)
+	assert.Empty(t, dr.comparisons)
+}
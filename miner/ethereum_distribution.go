@@ -26,6 +26,18 @@ func (ref *referral) username() string {
 	return "icenetwork/bogus"
 }
 
+func (ref *referral) miningBlockchainNetwork() coindistribution.BlockchainNetworkType {
+	return miningBlockchainNetwork(ref.MiningBlockchainNetwork)
+}
+
+func miningBlockchainNetwork(network string) coindistribution.BlockchainNetworkType {
+	if network == "" {
+		return coindistribution.EthereumBlockchainNetworkType
+	}
+
+	return coindistribution.BlockchainNetworkType(network)
+}
+
 func (ref *referral) isEligibleForSelfForEthereumDistribution(now, lastEthereumCoinDistributionProcessedAt *time.Time) bool {
 	coinDistributionCollectorSettings := cfg.coinDistributionCollectorSettings.Load()
 
@@ -218,6 +230,7 @@ func (u *user) processEthereumCoinDistribution(
 			UserID:             u.UserID,
 			EarnerUserID:       u.UserID,
 			EthAddress:         u.MiningBlockchainAccountAddress,
+			Network:            miningBlockchainNetwork(u.MiningBlockchainNetwork),
 			InternalID:         u.ID,
 			Balance:            0,
 		}
@@ -228,6 +241,7 @@ func (u *user) processEthereumCoinDistribution(
 			UserID:             mainnetRewardPoolContributionIdentifier,
 			EarnerUserID:       fmt.Sprintf("(%v,%v)", u.UserID, u.UserID),
 			EthAddress:         cfg.MainnetRewardPoolContributionEthAddress,
+			Network:            cfg.MainnetRewardPoolContributionNetwork,
 			InternalID:         999999999,
 			Balance:            0,
 		}
@@ -239,12 +253,14 @@ func (u *user) processEthereumCoinDistribution(
 				CreatedAt:    now,
 				UserID:       u.UserID,
 				EarnerUserID: t0.UserID,
+				Network:      miningBlockchainNetwork(u.MiningBlockchainNetwork),
 				Balance:      0,
 			}
 			t0MainnetRewardPoolContributionCD = &coindistribution.ByEarnerForReview{
 				CreatedAt:    now,
 				UserID:       mainnetRewardPoolContributionIdentifier,
 				EarnerUserID: fmt.Sprintf("(%v,%v)", u.UserID, t0.UserID),
+				Network:      cfg.MainnetRewardPoolContributionNetwork,
 				Balance:      0,
 			}
 			records = append(records, t0CD, t0MainnetRewardPoolContributionCD)
@@ -254,12 +270,14 @@ func (u *user) processEthereumCoinDistribution(
 				CreatedAt:    now,
 				UserID:       t0.UserID,
 				EarnerUserID: u.UserID,
+				Network:      t0.miningBlockchainNetwork(),
 				Balance:      0,
 			}
 			forT0MainnetRewardPoolContributionCD = &coindistribution.ByEarnerForReview{
 				CreatedAt:    now,
 				UserID:       mainnetRewardPoolContributionIdentifier,
 				EarnerUserID: fmt.Sprintf("(%v,%v)", t0.UserID, u.UserID),
+				Network:      cfg.MainnetRewardPoolContributionNetwork,
 				Balance:      0,
 			}
 			records = append(records, forT0CD, forT0MainnetRewardPoolContributionCD)
@@ -270,12 +288,14 @@ func (u *user) processEthereumCoinDistribution(
 			CreatedAt:    now,
 			UserID:       tMinus1.UserID,
 			EarnerUserID: u.UserID,
+			Network:      tMinus1.miningBlockchainNetwork(),
 			Balance:      0,
 		}
 		forTMinus1MainnetRewardPoolContributionCD = &coindistribution.ByEarnerForReview{
 			CreatedAt:    now,
 			UserID:       mainnetRewardPoolContributionIdentifier,
 			EarnerUserID: fmt.Sprintf("(%v,%v)", tMinus1.UserID, u.UserID),
+			Network:      cfg.MainnetRewardPoolContributionNetwork,
 			Balance:      0,
 		}
 		records = append(records, forTMinus1CD, forTMinus1MainnetRewardPoolContributionCD)
@@ -468,6 +488,9 @@ func (m *miner) startCoinDistributionCollectionWorkerManager(ctx context.Context
 			reqCtx, cancel = context.WithTimeout(context.Background(), requestDeadline)
 			m.notifyCoinDistributionCollectionCycleEnded(reqCtx)
 			cancel()
+			reqCtx, cancel = context.WithTimeout(context.Background(), requestDeadline)
+			log.Error(errors.Wrap(m.resetCoinDistributionCollectionSignals(reqCtx), "failed to resetCoinDistributionCollectionSignals"))
+			cancel()
 			log.Info(fmt.Sprintf("finished collecting coin distributions in %v", after.Sub(*before.Time)))
 			cfg.coinDistributionCollectorStartedAt.Store(new(time.Time))
 			m.coinDistributionWorkerMX.Unlock()
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package miner
+
+import (
+	"testing"
+	stdlibtime "time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ice-blockchain/eskimo/users"
+	coindistribution "github.com/ice-blockchain/freezer/coin-distribution"
+	"github.com/ice-blockchain/freezer/model"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+func TestProcessEthereumCoinDistributionNetworks(t *testing.T) { //nolint:paralleltest // It changes the global collector settings.
+	cfg.coinDistributionCollectorSettings.Store(&coindistribution.CollectorSettings{
+		StartDate: testTime,
+		EndDate:   timeDelta(365 * 24 * stdlibtime.Hour),
+		Enabled:   true,
+	})
+	defer cfg.coinDistributionCollectorSettings.Store(nil)
+	kyc := model.KYCState{
+		KYCStepPassedField:         model.KYCStepPassedField{KYCStepPassed: users.LivenessDetectionKYCStep},
+		KYCStepsLastUpdatedAtField: model.KYCStepsLastUpdatedAtField{KYCStepsLastUpdatedAt: &model.TimeSlice{testTime, testTime}},
+	}
+	u := newUser()
+	u.ID = 1
+	u.KYCState = kyc
+	u.MiningBlockchainAccountAddress = "0x4B73C58370AEfcEf86A6021afCDe5673511376B2"
+	u.MiningBlockchainNetwork = string(coindistribution.ArbitrumBlockchainNetworkType)
+	u.BalanceSolo, u.BalanceT0, u.BalanceForT0, u.BalanceForTMinus1 = 1000, 1000, 1000, 1000
+	u.BalanceTotalStandard = 4000
+	t0 := newRef()
+	t0.ID, t0.UserID = 2, "t0_user_id"
+	t0.KYCState = kyc
+	t0.MiningBlockchainAccountAddress = "0x4B73C58370AEfcEf86A6021afCDe5673511376B3"
+	t0.MiningBlockchainNetwork = string(coindistribution.BNBBlockchainNetworkType)
+	t0.BalanceTotalStandard = 1000
+	tMinus1 := newRef()
+	tMinus1.ID, tMinus1.UserID = 3, "tminus1_user_id"
+	tMinus1.KYCState = kyc
+	tMinus1.MiningBlockchainAccountAddress = "0x4B73C58370AEfcEf86A6021afCDe5673511376B4"
+	tMinus1.BalanceTotalStandard = 1000
+
+	records, _, _ := u.processEthereumCoinDistribution(true, time.New(testTime.Add(stdlibtime.Minute)), t0, tMinus1)
+	require.Len(t, records, 8)
+	networks := make(map[string]coindistribution.BlockchainNetworkType, len(records))
+	for _, record := range records {
+		if record.UserID == "mainnet/reward/pool/contribution" {
+			assert.Equal(t, cfg.MainnetRewardPoolContributionNetwork, record.Network, record.EarnerUserID)
+
+			continue
+		}
+		networks[record.UserID+"<-"+record.EarnerUserID] = record.Network
+	}
+	assert.Equal(t, map[string]coindistribution.BlockchainNetworkType{
+		"test_user_id<-test_user_id":    coindistribution.ArbitrumBlockchainNetworkType,
+		"test_user_id<-t0_user_id":      coindistribution.ArbitrumBlockchainNetworkType,
+		"t0_user_id<-test_user_id":      coindistribution.BNBBlockchainNetworkType,
+		"tminus1_user_id<-test_user_id": coindistribution.EthereumBlockchainNetworkType,
+	}, networks)
+	for _, record := range records {
+		if record.UserID == u.UserID && record.EarnerUserID == u.UserID {
+			assert.Positive(t, record.Balance)
+		}
+	}
+}
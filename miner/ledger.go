@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package miner
+
+import (
+	"sort"
+
+	dwh "github.com/ice-blockchain/freezer/bookkeeper/storage"
+	"github.com/ice-blockchain/freezer/tokenomics"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+func (u *user) recordLedger(reason dwh.LedgerReason, amount float64) {
+	if amount == 0 {
+		return
+	}
+	if u.ledger == nil {
+		u.ledger = make(map[dwh.LedgerReason]float64, 4) //nolint:gomnd // Usually, solo, t0, t1 & t2.
+	}
+	u.ledger[reason] += amount
+}
+
+// Records what the pre-staking bonus adds on top of the mined amount.
+// It's filed under the pre-staking bonus even if the user has a mining boost, because the boost's own uplift, of the T1 referrals, is recorded separately.
+func (u *user) recordLedgerPreStakingBonus(amount float64) {
+	standard, preStaking := tokenomics.ApplyPreStaking(amount, u.PreStakingAllocation, u.PreStakingBonus)
+	u.recordLedger(dwh.PreStakingBonusLedgerReason, standard+preStaking-amount)
+}
+
+func (u *user) ledgerEntries(now *time.Time) []*dwh.LedgerEntry {
+	if len(u.ledger) == 0 {
+		return nil
+	}
+	entries := make([]*dwh.LedgerEntry, 0, len(u.ledger))
+	for reason, amount := range u.ledger {
+		entries = append(entries, &dwh.LedgerEntry{CreatedAt: now, Reason: reason, UserID: u.UserID, Amount: amount, ID: u.ID})
+	}
+	sort.Slice(entries, func(ii, jj int) bool { return entries[ii].Reason < entries[jj].Reason })
+
+	return entries
+}
+
+// Keeps the ledger entries that failed to be inserted, so that they're retried with the next batch.
+// Only the newest maxEntries are kept, so that an outage of the DWH can't exhaust the memory; the rest are lost.
+func retainFailedLedgerEntries(failed []*dwh.LedgerEntry, maxEntries int) (retained []*dwh.LedgerEntry, lost int) {
+	if len(failed) <= maxEntries {
+		return failed, 0
+	}
+	lost = len(failed) - maxEntries
+
+	return append(failed[:0], failed[lost:]...), lost
+}
@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package miner
+
+import (
+	"testing"
+	stdlibtime "time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dwh "github.com/ice-blockchain/freezer/bookkeeper/storage"
+	"github.com/ice-blockchain/freezer/model"
+)
+
+func TestMiningLedger(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Minted amounts, by reason", func(t *testing.T) {
+		m := newUser()
+		m.ID = 1
+		m.PreStakingBonus = 200
+		m.PreStakingAllocation = 50
+		m.ExtraBonus = 100
+		m.ExtraBonusStartedAt = timeDelta(stdlibtime.Hour)
+		m.BalanceSoloPending = 10
+		m.BalanceT2Pending = 2
+
+		m, _, _, _, _ = mine(testTime, m, newRef(), nil) //nolint:dogsled // We only care about the ledger.
+		require.NotNil(t, m)
+		assert.Equal(t, map[dwh.LedgerReason]float64{
+			dwh.SoloMiningLedgerReason:          16,
+			dwh.ExtraBonusLedgerReason:          16,
+			dwh.T0ReferralLedgerReason:          4,
+			dwh.CompletedTasksPrizeLedgerReason: 10,
+			dwh.T2ReferralLedgerReason:          2,
+			dwh.PreStakingBonusLedgerReason:     48,
+		}, m.ledger)
+		var total float64
+		for _, amount := range m.ledger {
+			total += amount
+		}
+		assert.InDelta(t, m.BalanceTotalMinted, total, 0.000001)
+
+		entries := m.ledgerEntries(testTime)
+		require.Len(t, entries, 6)
+		assert.Equal(t, dwh.CompletedTasksPrizeLedgerReason, entries[0].Reason)
+		assert.Equal(t, dwh.T2ReferralLedgerReason, entries[5].Reason)
+		assert.EqualValues(t, 1, entries[0].ID)
+		assert.Equal(t, "test_user_id", entries[0].UserID)
+		assert.Equal(t, testTime, entries[0].CreatedAt)
+	})
+
+	t.Run("Welcome bonuses", func(t *testing.T) {
+		m := newUser()
+		m.WelcomeBonusV2Applied = nil
+		m.BalanceT1WelcomeBonusPending = 10
+
+		m, _, _, _, _ = mine(testTime, m, nil, nil) //nolint:dogsled // We only care about the ledger.
+		require.NotNil(t, m)
+		assert.InDelta(t, 10+m.BalanceSolo-testMiningBase, m.ledger[dwh.WelcomeBonusLedgerReason], 0.000001)
+		assert.NotContains(t, m.ledger, dwh.T1ReferralLedgerReason)
+	})
+
+	t.Run("Slashing", func(t *testing.T) {
+		m := newUser()
+		m.BalanceLastUpdatedAt = timeDelta(-stdlibtime.Hour)
+		m.MiningSessionSoloStartedAt = timeDelta(-25 * stdlibtime.Hour)
+		m.MiningSessionSoloEndedAt = timeDelta(-stdlibtime.Hour)
+		m.BalanceSolo, m.BalanceTotalStandard = 1440, 1440
+		m.BalanceT1Pending = -5
+
+		m, _, _, _, _ = mine(testTime, m, nil, nil) //nolint:dogsled // We only care about the ledger.
+		require.NotNil(t, m)
+		require.Len(t, m.ledger, 1)
+		assert.Less(t, m.ledger[dwh.SlashingLedgerReason], -5.)
+		assert.InDelta(t, -m.BalanceTotalSlashed, m.ledger[dwh.SlashingLedgerReason], 0.000001)
+	})
+
+	t.Run("Resurrection, with a mining boost", func(t *testing.T) {
+		m := newUser()
+		m.SlashingRateSolo = 10
+		m.MiningSessionSoloStartedAt = timeDelta(0)
+		m.MiningSessionSoloPreviouslyEndedAt = timeDelta(-24 * 10 * stdlibtime.Hour)
+		m.ResurrectSoloUsedAt = timeDelta(stdlibtime.Hour)
+		m.PreStakingBonus, m.PreStakingAllocation = 100, 100
+		miningBoostLevelIndex := model.FlexibleUint64(0)
+		m.MiningBoostLevelIndex = &miningBoostLevelIndex
+
+		m, _, _, _, _ = mine(testTime, m, nil, nil) //nolint:dogsled // We only care about the ledger.
+		require.NotNil(t, m)
+		assert.EqualValues(t, 2400, m.ledger[dwh.ResurrectionLedgerReason])
+		assert.EqualValues(t, 2400, m.ledger[dwh.PreStakingBonusLedgerReason])
+		assert.NotContains(t, m.ledger, dwh.BoostLedgerReason)
+	})
+
+	t.Run("Nothing changed", func(t *testing.T) {
+		require.Empty(t, newUser().ledgerEntries(testTime))
+	})
+}
+
+func TestRetainFailedLedgerEntries(t *testing.T) {
+	t.Parallel()
+	entries := func(ids ...int64) []*dwh.LedgerEntry {
+		result := make([]*dwh.LedgerEntry, 0, len(ids))
+		for _, id := range ids {
+			result = append(result, &dwh.LedgerEntry{ID: id})
+		}
+
+		return result
+	}
+
+	retained, lost := retainFailedLedgerEntries(entries(1, 2, 3), 3)
+	assert.Equal(t, entries(1, 2, 3), retained)
+	assert.Zero(t, lost)
+
+	retained, lost = retainFailedLedgerEntries(entries(1, 2, 3, 4, 5), 3)
+	assert.Equal(t, entries(3, 4, 5), retained)
+	assert.Equal(t, 2, lost)
+}
@@ -5,12 +5,18 @@ package miner
 import (
 	"fmt"
 	stdlog "log"
+	"net/http"
+	"strconv"
 	"strings"
 	stdlibtime "time"
 
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rcrowley/go-metrics"
 
 	"github.com/ice-blockchain/wintr/log"
+	"github.com/ice-blockchain/wintr/time"
 )
 
 func init() {
@@ -18,10 +24,20 @@ func init() {
 }
 
 type telemetry struct {
-	registry        metrics.Registry
-	steps           [10]string
-	currentStepName string
-	cfg             config
+	registry                   metrics.Registry
+	prometheus                 *prometheus.Registry
+	stepDurationDesc           *prometheus.Desc
+	usersMined                 prometheus.Counter
+	usersSlashed               prometheus.Counter
+	usersResurrected           prometheus.Counter
+	daysOffStarted             prometheus.Counter
+	coinDistributionsCollected prometheus.Counter
+	ledgerEntriesFailed        prometheus.Counter
+	ledgerEntriesLost          prometheus.Counter
+	steps                      [11]string
+	stepLabels                 [11]string
+	currentStepName            string
+	cfg                        config
 }
 
 func (t *telemetry) mustInit(cfg config) *telemetry {
@@ -31,7 +47,8 @@ func (t *telemetry) mustInit(cfg config) *telemetry {
 	)
 	t.cfg = cfg
 	t.registry = metrics.NewRegistry()
-	t.steps = [10]string{"mine[full iteration]", "mine", "get_users", "get_referrals", "send_messages", "get_history", "sync_quiz_status", "insert_history", "collect_coin_distributions", "update_users"} //nolint:lll // .
+	t.steps = [11]string{"mine[full iteration]", "mine", "get_users", "get_referrals", "send_messages", "get_history", "sync_quiz_status", "insert_history", "collect_coin_distributions", "update_users", "insert_ledger"} //nolint:lll // .
+	t.stepLabels = [11]string{"iteration", "batch", "get_users", "get_referrals", "send_messages", "get_history", "sync_quiz_status", "insert_history", "collect_coin_distributions", "update_users", "insert_ledger"}      //nolint:lll // .
 	for ix := range &t.steps {
 		if ix > 1 {
 			t.steps[ix] = fmt.Sprintf("[%v]mine.%v", ix-1, t.steps[ix])
@@ -40,6 +57,7 @@ func (t *telemetry) mustInit(cfg config) *telemetry {
 	}
 
 	go metrics.LogScaled(t.registry, 15*stdlibtime.Minute, stdlibtime.Millisecond, t) //nolint:gomnd // .
+	t.mustInitPrometheus()
 
 	return t
 }
@@ -122,3 +140,97 @@ func (t *telemetry) Printf(format string, args ...interface{}) {
 	}
 	stdlog.Printf("["+t.currentStepName+"]"+strings.ReplaceAll(format, prefixMarker, ""), args...)
 }
+
+// The step timers are exported as they are, as summaries, and the counters are incremented only for the batches that got persisted.
+func (t *telemetry) mustInitPrometheus() {
+	newCounter := func(name, help string) prometheus.Counter {
+		return prometheus.NewCounter(prometheus.CounterOpts{Namespace: "freezer", Subsystem: "miner", Name: name, Help: help})
+	}
+	t.prometheus = prometheus.NewRegistry()
+	t.stepDurationDesc = prometheus.NewDesc("freezer_miner_step_duration_seconds", "How long the steps of the mining take.", []string{"step"}, nil)
+	t.usersMined = newCounter("users_mined_total", "The users whose balances got updated.")
+	t.usersSlashed = newCounter("users_slashed_total", "The users whose balances got slashed.")
+	t.usersResurrected = newCounter("users_resurrected_total", "The users whose slashed balances got resurrected.")
+	t.daysOffStarted = newCounter("days_off_started_total", "The days off that started for the users.")
+	t.coinDistributionsCollected = newCounter("coin_distributions_collected_total", "The coin distributions collected for review.")
+	t.ledgerEntriesFailed = newCounter("ledger_entries_failed_total", "The ledger entries that failed to be inserted and are retried with the next batch.")
+	// Alert on any increase of this one: the mining progress of those entries got persisted, but it's missing from the ledger.
+	t.ledgerEntriesLost = newCounter("ledger_entries_lost_total", "The ledger entries that failed to be inserted and were dropped.")
+	log.Panic(errors.Wrap(t.prometheus.Register(t), "failed to register the mining steps"))
+	for _, counter := range []prometheus.Counter{t.usersMined, t.usersSlashed, t.usersResurrected, t.daysOffStarted, t.coinDistributionsCollected, t.ledgerEntriesFailed, t.ledgerEntriesLost} { //nolint:lll // .
+		log.Panic(errors.Wrap(t.prometheus.Register(counter), "failed to register counter"))
+	}
+}
+
+func (t *telemetry) Describe(descs chan<- *prometheus.Desc) {
+	descs <- t.stepDurationDesc
+}
+
+func (t *telemetry) Collect(collected chan<- prometheus.Metric) {
+	quantiles := []float64{0.5, 0.75, 0.95, 0.99, 0.999} //nolint:gomnd // The ones LogScaled logs.
+	for ix := range &t.steps {
+		timer := t.registry.Get(t.steps[ix]).(metrics.Timer).Snapshot() //nolint:forcetypeassert // .
+		percentiles := timer.Percentiles(quantiles)
+		values := make(map[float64]float64, len(quantiles))
+		for jx, quantile := range quantiles {
+			values[quantile] = stdlibtime.Duration(percentiles[jx]).Seconds()
+		}
+		collected <- prometheus.MustNewConstSummary(t.stepDurationDesc, uint64(timer.Count()), stdlibtime.Duration(timer.Sum()).Seconds(), values, t.stepLabels[ix])
+	}
+}
+
+func (t *telemetry) collectBatch(stats *batchTelemetry) {
+	t.usersMined.Add(float64(stats.usersMined))
+	t.usersSlashed.Add(float64(stats.usersSlashed))
+	t.usersResurrected.Add(float64(stats.usersResurrected))
+	t.daysOffStarted.Add(float64(stats.daysOffStarted))
+	t.coinDistributionsCollected.Add(float64(stats.coinDistributionsCollected))
+}
+
+// The lag of a worker is how long ago it persisted its last batch, so a slow iteration shows up even before it ends.
+func (t *telemetry) registerPartitionsProgress(progress func() []*partitionProgress) {
+	log.Panic(errors.Wrap(t.prometheus.Register(&partitionsCollector{
+		progress:      progress,
+		batchLagDesc:  prometheus.NewDesc("freezer_miner_batch_lag_seconds", "How long ago the worker persisted its last batch.", []string{"worker"}, nil),
+		iterationDesc: prometheus.NewDesc("freezer_miner_iteration", "The iteration the worker is at.", []string{"worker"}, nil),
+		batchDesc:     prometheus.NewDesc("freezer_miner_batch", "The batch of the current iteration the worker is at.", []string{"worker"}, nil),
+	}), "failed to register the partitions progress"))
+}
+
+func (c *partitionsCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- c.batchLagDesc
+	descs <- c.iterationDesc
+	descs <- c.batchDesc
+}
+
+func (c *partitionsCollector) Collect(collected chan<- prometheus.Metric) {
+	now := time.Now()
+	for _, progress := range c.progress() {
+		worker := strconv.FormatInt(progress.Partition, 10)
+		lastProgressAt := progress.OwnedSince
+		if !progress.LastBatchProcessedAt.IsNil() {
+			lastProgressAt = progress.LastBatchProcessedAt
+		}
+		collected <- prometheus.MustNewConstMetric(c.batchLagDesc, prometheus.GaugeValue, now.Sub(*lastProgressAt.Time).Seconds(), worker)
+		collected <- prometheus.MustNewConstMetric(c.iterationDesc, prometheus.GaugeValue, float64(progress.Iteration), worker)
+		collected <- prometheus.MustNewConstMetric(c.batchDesc, prometheus.GaugeValue, float64(progress.BatchNumber), worker)
+	}
+}
+
+func (t *telemetry) mustServe(address string) *http.Server {
+	srv := &http.Server{Addr: address, Handler: t.handler(), ReadHeaderTimeout: requestDeadline}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Panic(errors.Wrapf(err, "failed to serve the metrics on %v", address))
+		}
+	}()
+
+	return srv
+}
+
+func (t *telemetry) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(t.prometheus, promhttp.HandlerOpts{}))
+
+	return mux
+}
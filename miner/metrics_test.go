@@ -4,10 +4,15 @@ package miner
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	stdlibtime "time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ice-blockchain/wintr/time"
 )
 
 type (
@@ -177,3 +182,29 @@ func slowTelemetry(workers int64) *telemetry {
 
 	return tel
 }
+
+func TestPrometheusMetrics(t *testing.T) {
+	t.Parallel()
+
+	tel := slowTelemetry(2)
+	tel.collectBatch(&batchTelemetry{usersMined: 3, usersSlashed: 2, usersResurrected: 1, daysOffStarted: 1, coinDistributionsCollected: 4})
+	tel.collectBatch(&batchTelemetry{usersMined: 1})
+	tel.registerPartitionsProgress(func() []*partitionProgress {
+		return []*partitionProgress{{OwnedSince: time.New(stdlibtime.Now().Add(-stdlibtime.Hour)), Partition: 1, Iteration: 5, BatchNumber: 7}}
+	})
+
+	recorder := httptest.NewRecorder()
+	tel.handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	body := recorder.Body.String()
+	assert.Contains(t, body, `freezer_miner_step_duration_seconds_count{step="get_users"} 1`)
+	assert.Contains(t, body, `freezer_miner_step_duration_seconds{step="iteration",quantile="0.5"} 60`)
+	assert.Contains(t, body, "freezer_miner_users_mined_total 4")
+	assert.Contains(t, body, "freezer_miner_users_slashed_total 2")
+	assert.Contains(t, body, "freezer_miner_users_resurrected_total 1")
+	assert.Contains(t, body, "freezer_miner_days_off_started_total 1")
+	assert.Contains(t, body, "freezer_miner_coin_distributions_collected_total 4")
+	assert.Contains(t, body, `freezer_miner_iteration{worker="1"} 5`)
+	assert.Contains(t, body, `freezer_miner_batch{worker="1"} 7`)
+	assert.Regexp(t, `freezer_miner_batch_lag_seconds\{worker="1"\} 3[56]\d\d\.\d+`, body)
+}
@@ -38,13 +38,23 @@ func init() {
 	if cfg.SlashingDaysCount == 0 {
 		log.Panic(errors.Errorf("slashingDaysCount is zero"))
 	}
+	if cfg.Sharding.Enabled && (cfg.Sharding.RebalanceInterval <= 0 || cfg.Sharding.LeaseDuration <= cfg.Sharding.RebalanceInterval) {
+		log.Panic(errors.Errorf("sharding.leaseDuration must be greater than sharding.rebalanceInterval, which must be positive"))
+	}
+	if cfg.MainnetRewardPoolContributionNetwork == "" {
+		cfg.MainnetRewardPoolContributionNetwork = coindistribution.EthereumBlockchainNetworkType
+	}
 	cfg.disableAdvancedTeam = new(atomic.Pointer[[]string])
 	cfg.coinDistributionCollectorSettings = new(atomic.Pointer[coindistribution.CollectorSettings])
 	cfg.coinDistributionCollectorStartedAt = new(atomic.Pointer[time.Time])
 	cfg.miningBoostLevels = new(atomic.Pointer[[]*tokenomics.MiningBoostLevel])
+	levels := sortMiningBoostLevels(cfg.MiningBoost.Levels)
+	cfg.miningBoostLevels.Store(&levels)
+}
 
-	levels := make([]*tokenomics.MiningBoostLevel, 0, len(cfg.MiningBoost.Levels))
-	for dollars, level := range cfg.MiningBoost.Levels {
+func sortMiningBoostLevels(levelsByPrice map[float64]*tokenomics.MiningBoostLevel) []*tokenomics.MiningBoostLevel {
+	levels := make([]*tokenomics.MiningBoostLevel, 0, len(levelsByPrice))
+	for dollars, level := range levelsByPrice {
 		level.ICEPrice = strconv.FormatFloat(dollars, 'f', 15, 64)
 		levels = append(levels, level)
 	}
@@ -56,7 +66,8 @@ func init() {
 
 		return iiPrice < jjPrice
 	})
-	cfg.miningBoostLevels.Store(&levels)
+
+	return levels
 }
 
 func MustStartMining(ctx context.Context, cancel context.CancelFunc) Client {
@@ -67,19 +78,33 @@ func MustStartMining(ctx context.Context, cancel context.CancelFunc) Client {
 		dwhClient:                  dwh.MustConnect(context.Background(), applicationYamlKey),
 		wg:                         new(sync.WaitGroup),
 		telemetry:                  new(telemetry).mustInit(cfg),
+		clock:                      model.ClockFromContext(ctx),
+		partitionsMX:               new(sync.RWMutex),
+		partitions:                 make(map[int64]*partition, cfg.Workers),
+		leader:                     new(atomic.Bool),
+		replicaID:                  newReplicaID(),
 		//quizRepository:             quiz.NewReadRepository(context.Background()),
 	}
 	go mi.startDisableAdvancedTeamCfgSyncer(ctx)
-	mi.wg.Add(int(cfg.Workers))
+	mi.telemetry.registerPartitionsProgress(mi.partitionsProgress)
+	if cfg.MetricsAddress != "" {
+		mi.metricsServer = mi.telemetry.mustServe(cfg.MetricsAddress)
+	}
 	mi.cancel = cancel
 	mi.extraBonusStartDate = extrabonusnotifier.MustGetExtraBonusStartDate(ctx, mi.db)
 	mi.mustInitCoinDistributionCollector(ctx)
 
-	for workerNumber := int64(0); workerNumber < cfg.Workers; workerNumber++ {
-		go func(wn int64) {
+	if cfg.Sharding.Enabled {
+		go mi.startRelayingCoinDistributionCollectionSignals(ctx)
+		mi.wg.Add(1)
+		go func() {
 			defer mi.wg.Done()
-			mi.mine(ctx, wn)
-		}(workerNumber)
+			mi.startRebalancing(ctx)
+		}()
+	} else {
+		for workerNumber := int64(0); workerNumber < cfg.Workers; workerNumber++ {
+			mi.startPartition(ctx, workerNumber, nil)
+		}
 	}
 
 	return mi
@@ -89,8 +114,15 @@ func (m *miner) Close() error {
 	m.cancel()
 	m.wg.Wait()
 	<-m.stopCoinDistributionCollectionWorkerManager
+	var metricsServerErr error
+	if m.metricsServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), requestDeadline)
+		metricsServerErr = errors.Wrap(m.metricsServer.Shutdown(ctx), "failed to shutdown the metrics server")
+		cancel()
+	}
 
 	return multierror.Append(
+		metricsServerErr,
 		errors.Wrap(m.mb.Close(), "failed to close mb"),
 		errors.Wrap(m.db.Close(), "failed to close db"),
 		errors.Wrap(m.dwhClient.Close(), "failed to close dwh"),
@@ -112,10 +144,15 @@ func (m *miner) CheckHealth(ctx context.Context) error {
 	//if err := m.quizRepository.CheckHealth(ctx); err != nil {
 	//	return err
 	//}
+	partitions := m.partitionsProgress()
+	if err := m.checkPartitionsHealth(partitions); err != nil {
+		return err
+	}
 	type ts struct {
-		TS *time.Time `json:"ts"`
+		TS         *time.Time           `json:"ts"`
+		Partitions []*partitionProgress `json:"partitions,omitempty"`
 	}
-	now := ts{TS: time.Now()}
+	now := ts{TS: time.Now(), Partitions: partitions}
 	bytes, err := json.MarshalContext(ctx, now)
 	if err != nil {
 		return errors.Wrapf(err, "[health-check] failed to marshal %#v", now)
@@ -146,8 +183,13 @@ func (m *miner) checkDBHealth(ctx context.Context) error {
 	return nil
 }
 
-func (m *miner) mine(ctx context.Context, workerNumber int64) {
-	dwhClient := dwh.MustConnect(context.Background(), applicationYamlKey)
+func (m *miner) mine(ctx context.Context, p *partition) {
+	var dwhClient dwh.Client
+	if m.dryRun != nil {
+		dwhClient = &dryRunDWHClient{dryRun: m.dryRun}
+	} else {
+		dwhClient = dwh.MustConnect(context.Background(), applicationYamlKey)
+	}
 	defer func() {
 		if err := recover(); err != nil {
 			log.Error(dwhClient.Close())
@@ -156,10 +198,11 @@ func (m *miner) mine(ctx context.Context, workerNumber int64) {
 		log.Error(dwhClient.Close())
 	}()
 	var (
+		workerNumber                                                         = p.number
 		batchNumber                                                          int64
 		totalBatches                                                         uint64
 		iteration                                                            uint64
-		now, lastIterationStartedAt                                          = time.Now(), time.Now()
+		now, lastIterationStartedAt                                          = m.clock.Now(), time.Now()
 		workers                                                              = cfg.Workers
 		batchSize                                                            = cfg.BatchSize
 		userKeys, userHistoryKeys, referralKeys, syncQuizUserIDs             = make([]string, 0, batchSize), make([]string, 0, batchSize), make([]string, 0, 2*batchSize), make([]string, 0, batchSize)
@@ -171,6 +214,7 @@ func (m *miner) mine(ctx context.Context, workerNumber int64) {
 		balanceT1WelcomeBonusIncr                                            = make(map[int64]float64, batchSize)
 		pendingBalancesForTMinus1, pendingBalancesForT0                      = make(map[int64]float64, batchSize), make(map[int64]float64, batchSize)
 		referralsThatStoppedMining                                           = make([]*referralThatStoppedMining, 0, batchSize)
+		referralTiers                                                        = newReferralTiersBatch(batchSize)
 		coinDistributions                                                    = make([]*coindistribution.ByEarnerForReview, 0, 4*batchSize)
 		msgResponder                                                         = make(chan error, 3*batchSize)
 		msgs                                                                 = make([]*messagebroker.Message, 0, 3*batchSize)
@@ -182,22 +226,33 @@ func (m *miner) mine(ctx context.Context, workerNumber int64) {
 		histories                                                            = make([]*model.User, 0, batchSize)
 		quizStatuses                                                         = make(map[string]*quiz.QuizStatus, batchSize)
 		userGlobalRanks                                                      = make([]redis.Z, 0, batchSize)
+		teams                                                                = newTeamsBatch(batchSize)
 		historyColumns, historyInsertMetadata                                = dwh.InsertDDL(int(batchSize))
+		ledgerEntries                                                        = make([]*dwh.LedgerEntry, 0, 4*batchSize)
+		ledgerColumns, ledgerInsertMetadata                                  = dwh.LedgerInsertDDL(int(4 * batchSize))
+		failedLedgerEntries                                                  = make([]*dwh.LedgerEntry, 0, 4*batchSize)
 		shouldSynchronizeBalanceFunc                                         = func(batchNumberArg uint64) bool { return false }
+		stats                                                                = new(batchTelemetry)
 		startedCoinDistributionCollecting                                    = isCoinDistributionCollectorEnabled(now)
 	)
+	defer func() {
+		if len(failedLedgerEntries) > 0 {
+			log.Error(errors.Errorf("[miner] lost %v ledger entries that failed to be inserted, for workerNumber:%v", len(failedLedgerEntries), workerNumber))
+			m.telemetry.ledgerEntriesLost.Add(float64(len(failedLedgerEntries)))
+		}
+	}()
 	if startedCoinDistributionCollecting {
-		m.coinDistributionStartedSignaler <- struct{}{}
+		m.signalCoinDistributionCollectionStarted(ctx, workerNumber)
 	}
 	resetVars := func(success bool) {
 		if success && len(userKeys) == int(batchSize) && len(userResults) == 0 {
 			go m.telemetry.collectElapsed(0, *lastIterationStartedAt.Time)
 			if !startedCoinDistributionCollecting && iteration%2 == 1 && isCoinDistributionCollectorEnabled(now) {
-				m.coinDistributionStartedSignaler <- struct{}{}
+				m.signalCoinDistributionCollectionStarted(ctx, workerNumber)
 				startedCoinDistributionCollecting = true
 			}
 			if startedCoinDistributionCollecting && iteration%2 == 0 && isCoinDistributionCollectorEnabled(now) {
-				m.coinDistributionEndedSignaler <- struct{}{}
+				m.signalCoinDistributionCollectionEnded(ctx, workerNumber)
 				m.coinDistributionWorkerMX.Lock()
 				m.coinDistributionWorkerMX.Unlock()
 				startedCoinDistributionCollecting = false
@@ -215,7 +270,7 @@ func (m *miner) mine(ctx context.Context, workerNumber int64) {
 		} else if success {
 			go m.telemetry.collectElapsed(1, *now.Time)
 		}
-		now = time.Now()
+		now = m.clock.Now()
 		userKeys, userHistoryKeys, referralKeys = userKeys[:0], userHistoryKeys[:0], referralKeys[:0]
 		userResults, referralResults = userResults[:0], referralResults[:0]
 		syncQuizUserIDs = syncQuizUserIDs[:0]
@@ -225,9 +280,12 @@ func (m *miner) mine(ctx context.Context, workerNumber int64) {
 		referralsCountGuardOnlyUpdatedUsers = referralsCountGuardOnlyUpdatedUsers[:0]
 		referralsUpdated = referralsUpdated[:0]
 		histories = histories[:0]
+		ledgerEntries = ledgerEntries[:0]
 		userGlobalRanks = userGlobalRanks[:0]
 		referralsThatStoppedMining = referralsThatStoppedMining[:0]
 		coinDistributions = coinDistributions[:0]
+		referralTiers.reset()
+		*stats = batchTelemetry{}
 
 		for k := range t0Referrals {
 			delete(t0Referrals, k)
@@ -265,6 +323,7 @@ func (m *miner) mine(ctx context.Context, workerNumber int64) {
 		for k := range quizStatuses {
 			delete(quizStatuses, k)
 		}
+		teams.reset()
 	}
 	for ctx.Err() == nil {
 		/******************************************************************************************************************************************************
@@ -280,7 +339,7 @@ func (m *miner) mine(ctx context.Context, workerNumber int64) {
 		if err := storage.Bind[user](reqCtx, m.db, userKeys, &userResults); err != nil {
 			log.Error(errors.Wrapf(err, "[miner] failed to get users for batchNumber:%v,workerNumber:%v", batchNumber, workerNumber))
 			reqCancel()
-			now = time.Now()
+			now = m.clock.Now()
 
 			continue
 		}
@@ -330,6 +389,15 @@ func (m *miner) mine(ctx context.Context, workerNumber int64) {
 		if len(referralKeys) > 0 {
 			go m.telemetry.collectElapsed(3, *before.Time)
 		}
+		reqCtx, reqCancel = context.WithTimeout(context.Background(), requestDeadline)
+		if err := referralTiers.load(reqCtx, m.db, userResults, t0Referrals); err != nil {
+			log.Error(errors.Wrapf(err, "[miner] failed to get the deeper referral tiers for batchNumber:%v,workerNumber:%v", batchNumber, workerNumber))
+			reqCancel()
+			resetVars(false)
+
+			continue
+		}
+		reqCancel()
 
 		/******************************************************************************************************************************************************
 			3. Mining for the users.
@@ -368,19 +436,31 @@ func (m *miner) mine(ctx context.Context, workerNumber int64) {
 			}
 			beforeWelcomeBonusV2Applied := usr.WelcomeBonusV2Applied == nil || !*usr.WelcomeBonusV2Applied
 			updatedUser, shouldGenerateHistory, IDT0Changed, pendingAmountForTMinus1, pendingAmountForT0 := mine(now, usr, t0Ref, tMinus1Ref)
+			if m.dryRun != nil {
+				m.dryRun.compareWithBaseline(now, usr, t0Ref, tMinus1Ref, updatedUser)
+			}
 			if shouldGenerateHistory {
 				syncQuizUserIDs = append(syncQuizUserIDs, usr.UserID)
 				userHistoryKeys = append(userHistoryKeys, usr.Key())
 			}
 
 			if updatedUser != nil {
+				stats.usersMined++
+				if updatedUser.BalanceTotalSlashed > usr.BalanceTotalSlashed {
+					stats.usersSlashed++
+				}
+				if !updatedUser.ResurrectSoloUsedAt.IsNil() {
+					stats.usersResurrected++
+				}
 				if userStoppedMining := didUserStoppedMining(now, usr); userStoppedMining != nil {
 					referralsCountGuardOnlyUpdatedUsers = append(referralsCountGuardOnlyUpdatedUsers, userStoppedMining)
 				}
 				if userStoppedMining := didReferralJustStopMining(now, usr, t0Ref, tMinus1Ref); userStoppedMining != nil {
 					referralsThatStoppedMining = append(referralsThatStoppedMining, userStoppedMining)
+					referralTiers.incrementActiveReferrals(usr.IDT0, usr.IDTMinus1, nil, true, -1)
 				}
 				if dayOffStarted := didANewDayOffJustStart(now, usr); dayOffStarted != nil {
+					stats.daysOffStarted++
 					msgs = append(msgs, dayOffStartedMessage(reqCtx, dayOffStarted))
 				}
 				if t0Ref != nil {
@@ -394,6 +474,7 @@ func (m *miner) mine(ctx context.Context, workerNumber int64) {
 						if usr.ActiveT1Referrals > 0 && t0Ref.ID != 0 {
 							t2ReferralsToIncrementActiveValue[t0Ref.ID] += usr.ActiveT1Referrals
 						}
+						referralTiers.incrementActiveReferrals(t0Ref.ID, t0Ref.IDT0, usr.activeReferrals(), !usr.BalanceLastUpdatedAt.IsNil(), 1)
 					}
 					if usr.IDTMinus1 != t0Ref.IDT0 {
 						updatedUser.IDTMinus1 = t0Ref.IDT0
@@ -421,6 +502,8 @@ func (m *miner) mine(ctx context.Context, workerNumber int64) {
 					}
 					balanceT1WelcomeBonusIncr[idT0] += tokenomics.WelcomeBonusV2Amount
 				}
+				referralTiers.recordMined(updatedUser)
+				ledgerEntries = append(ledgerEntries, updatedUser.ledgerEntries(now)...)
 				updatedUsers = append(updatedUsers, &updatedUser.UpdatedUser)
 			} else {
 				if updUsr := updateT0AndTMinus1ReferralsForUserHasNeverMined(usr); updUsr != nil {
@@ -428,8 +511,12 @@ func (m *miner) mine(ctx context.Context, workerNumber int64) {
 					if t0Ref != nil && t0Ref.ID != 0 && usr.ActiveT1Referrals > 0 {
 						t2ReferralsToIncrementActiveValue[t0Ref.ID] += usr.ActiveT1Referrals
 					}
+					if t0Ref != nil {
+						referralTiers.incrementActiveReferrals(t0Ref.ID, t0Ref.IDT0, usr.activeReferrals(), false, 1)
+					}
 				}
 			}
+			teams.record(now, usr, updatedUser, t0Ref, tMinus1Ref, shouldSynchronizeBalance)
 			totalStandardBalance, totalPreStakingBalance := usr.BalanceTotalStandard, usr.BalanceTotalPreStaking
 			if updatedUser != nil {
 				totalStandardBalance, totalPreStakingBalance = updatedUser.BalanceTotalStandard, updatedUser.BalanceTotalPreStaking
@@ -450,6 +537,18 @@ func (m *miner) mine(ctx context.Context, workerNumber int64) {
 
 		before = time.Now()
 		reqCtx, reqCancel = context.WithTimeout(context.Background(), requestDeadline)
+		// The broker messages, the history and the coin distributions are fenced here, the mining progress again when it's persisted.
+		// This replica lets go of the lease only after the batch, so it can lose it in between only if it fails to refresh it until it expires.
+		if err := checkPartitionLease(reqCtx, m.db, p); err != nil {
+			log.Error(errors.Wrapf(err, "[miner] stopped mining batchNumber:%v,workerNumber:%v", batchNumber, workerNumber))
+			reqCancel()
+			if errors.Is(err, errPartitionLeaseLost) {
+				p.cancel()
+			}
+			resetVars(false)
+
+			continue
+		}
 		for _, message := range msgs {
 			m.mb.SendMessage(reqCtx, message, msgResponder)
 		}
@@ -547,6 +646,7 @@ func (m *miner) mine(ctx context.Context, workerNumber int64) {
 		}
 		reqCancel()
 		if len(coinDistributions) > 0 {
+			stats.coinDistributionsCollected = uint64(len(coinDistributions))
 			go m.telemetry.collectElapsed(8, *before.Time)
 		}
 
@@ -563,18 +663,23 @@ func (m *miner) mine(ctx context.Context, workerNumber int64) {
 			}
 		}
 
-		var pipeliner redis.Pipeliner
-		var transactional bool
-		if len(pendingBalancesForTMinus1)+len(pendingBalancesForT0)+len(balanceT1WelcomeBonusIncr)+len(balanceT1EthereumIncr)+len(balanceT2EthereumIncr)+len(t1ReferralsToIncrementActiveValue)+len(t2ReferralsToIncrementActiveValue)+len(referralsCountGuardOnlyUpdatedUsers)+len(t1ReferralsThatStoppedMining)+len(t2ReferralsThatStoppedMining)+len(extraBonusOnlyUpdatedUsers)+len(referralsUpdated)+len(userGlobalRanks) > 0 {
-			pipeliner = m.db.TxPipeline()
-			transactional = true
-		} else {
-			pipeliner = m.db.Pipeline()
-		}
+		transactional := len(pendingBalancesForTMinus1)+len(pendingBalancesForT0)+len(balanceT1WelcomeBonusIncr)+len(balanceT1EthereumIncr)+len(balanceT2EthereumIncr)+len(t1ReferralsToIncrementActiveValue)+len(t2ReferralsToIncrementActiveValue)+len(referralsCountGuardOnlyUpdatedUsers)+len(t1ReferralsThatStoppedMining)+len(t2ReferralsThatStoppedMining)+len(extraBonusOnlyUpdatedUsers)+len(referralsUpdated)+len(userGlobalRanks)+referralTiers.len()+teams.len() > 0
 
 		before = time.Now()
 		reqCtx, reqCancel = context.WithTimeout(context.Background(), requestDeadline)
-		if responses, err := pipeliner.Pipelined(reqCtx, func(pipeliner redis.Pipeliner) error {
+		if m.dryRun != nil {
+			m.dryRun.recordMiningProgress(userResults, []any{updatedUsers, extraBonusOnlyUpdatedUsers, referralsCountGuardOnlyUpdatedUsers, referralsUpdated}, append([]*dryRunIncrements{
+				{field: "active_t1_referrals", sign: 1, values: t1ReferralsToIncrementActiveValue},
+				{field: "active_t2_referrals", sign: 1, values: t2ReferralsToIncrementActiveValue},
+				{field: "active_t1_referrals", sign: -1, values: t1ReferralsThatStoppedMining},
+				{field: "active_t2_referrals", sign: -1, values: t2ReferralsThatStoppedMining},
+				{field: "balance_t1_welcome_bonus_pending", sign: 1, values: balanceT1WelcomeBonusIncr},
+				{field: "balance_t1_ethereum_pending", sign: 1, values: balanceT1EthereumIncr},
+				{field: "balance_t2_ethereum_pending", sign: 1, values: balanceT2EthereumIncr},
+				{field: "balance_t1_pending", sign: 1, values: pendingBalancesForT0},
+				{field: "balance_t2_pending", sign: 1, values: pendingBalancesForTMinus1},
+			}, referralTiers.dryRunIncrements()...))
+		} else if responses, err := m.persistMiningProgress(reqCtx, p, transactional, func(pipeliner redis.Pipeliner) error {
 			for id, value := range t1ReferralsToIncrementActiveValue {
 				if err := pipeliner.HIncrBy(reqCtx, model.SerializedUsersKey(id), "active_t1_referrals", int64(value)).Err(); err != nil {
 					return err
@@ -627,6 +732,9 @@ func (m *miner) mine(ctx context.Context, workerNumber int64) {
 					return err
 				}
 			}
+			if err := teams.persist(reqCtx, pipeliner); err != nil {
+				return err
+			}
 			for idT0, amount := range balanceT1WelcomeBonusIncr {
 				if err := pipeliner.HIncrByFloat(reqCtx, model.SerializedUsersKey(idT0), "balance_t1_welcome_bonus_pending", amount).Err(); err != nil {
 					return err
@@ -659,10 +767,13 @@ func (m *miner) mine(ctx context.Context, workerNumber int64) {
 				}
 			}
 
-			return nil
+			return referralTiers.persist(reqCtx, pipeliner)
 		}); err != nil {
 			log.Error(errors.Wrapf(err, "[miner] [1]failed to persist mining process for batchNumber:%v,workerNumber:%v", batchNumber, workerNumber))
 			reqCancel()
+			if errors.Is(err, errPartitionLeaseLost) {
+				p.cancel()
+			}
 			resetVars(false)
 
 			continue
@@ -689,7 +800,36 @@ func (m *miner) mine(ctx context.Context, workerNumber int64) {
 
 		batchNumber++
 		reqCancel()
+
+		/******************************************************************************************************************************************************
+			10. Inserting the ledger entries of the persisted mining progress.
+		******************************************************************************************************************************************************/
+
+		before = time.Now()
+		reqCtx, reqCancel = context.WithTimeout(context.Background(), requestDeadline)
+		// The mining progress is already persisted, so retrying the batch would mine it twice; the failed entries are retried with the next batch instead.
+		entries := append(failedLedgerEntries, ledgerEntries...) //nolint:gocritic // The failed ones are inserted first.
+		if err := dwhClient.InsertLedger(reqCtx, ledgerColumns, ledgerInsertMetadata, entries); err != nil {
+			log.Error(errors.Wrapf(err, "[miner] failed to insert %v ledger entries for batchNumber:%v,workerNumber:%v", len(entries), batchNumber-1, workerNumber))
+			m.telemetry.ledgerEntriesFailed.Add(float64(len(entries)))
+			var lost int
+			if failedLedgerEntries, lost = retainFailedLedgerEntries(entries, failedLedgerBatchesMaxCount*4*int(batchSize)); lost > 0 {
+				log.Error(errors.Errorf("[miner] lost %v ledger entries that failed to be inserted, for batchNumber:%v,workerNumber:%v", lost, batchNumber-1, workerNumber))
+				m.telemetry.ledgerEntriesLost.Add(float64(lost))
+			}
+		} else {
+			failedLedgerEntries = entries[:0]
+			if len(entries) > 0 {
+				go m.telemetry.collectElapsed(10, *before.Time)
+			}
+		}
+		reqCancel()
+		m.telemetry.collectBatch(stats)
 		resetVars(true)
+		p.reportProgress(iteration, batchNumber)
+		if m.dryRun != nil && iteration > 0 {
+			return
+		}
 	}
 }
 
@@ -766,7 +906,7 @@ func didUserStoppedMining(now *time.Time, before *user) *referralCountGuardUpdat
 		before.MiningSessionSoloEndedAt.Before(*now.Time) {
 		return &referralCountGuardUpdatedUser{
 			DeserializedUsersKey:                    before.DeserializedUsersKey,
-			ReferralsCountChangeGuardUpdatedAtField: model.ReferralsCountChangeGuardUpdatedAtField{ReferralsCountChangeGuardUpdatedAt: time.Now()},
+			ReferralsCountChangeGuardUpdatedAtField: model.ReferralsCountChangeGuardUpdatedAtField{ReferralsCountChangeGuardUpdatedAt: now},
 		}
 	}
 
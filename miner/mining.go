@@ -5,6 +5,7 @@ package miner
 import (
 	"math"
 
+	dwh "github.com/ice-blockchain/freezer/bookkeeper/storage"
 	"github.com/ice-blockchain/freezer/model"
 	"github.com/ice-blockchain/freezer/tokenomics"
 	"github.com/ice-blockchain/wintr/time"
@@ -16,6 +17,8 @@ func mine(now *time.Time, usr *user, t0Ref, tMinus1Ref *referral) (updatedUser *
 	}
 	clonedUser1 := *usr
 	updatedUser = &clonedUser1
+	updatedUser.ledger = nil
+	updatedUser.referralTiers = usr.referralTiers.Clone()
 	pendingResurrectionForTMinus1, pendingResurrectionForT0 := resurrect(now, updatedUser, t0Ref, tMinus1Ref)
 	IDT0Changed, _ = changeT0AndTMinus1Referrals(updatedUser)
 	if updatedUser.MiningSessionSoloEndedAt.Before(*now.Time) && updatedUser.isAbsoluteZero() {
@@ -27,7 +30,7 @@ func mine(now *time.Time, usr *user, t0Ref, tMinus1Ref *referral) (updatedUser *
 
 			return updatedUser, false, IDT0Changed, 0, 0
 		}
-		if updatedUser.BalanceT1 > 0 || updatedUser.BalanceT2 > 0 {
+		if hadReferralTiersBalance := updatedUser.resetReferralTiersBalances(); updatedUser.BalanceT1 > 0 || updatedUser.BalanceT2 > 0 || hadReferralTiersBalance {
 			updatedUser.BalanceTotalStandard, updatedUser.BalanceTotalPreStaking = 0, 0
 			updatedUser.BalanceT1 = 0
 			updatedUser.BalanceT2 = 0
@@ -114,23 +117,27 @@ func mine(now *time.Time, usr *user, t0Ref, tMinus1Ref *referral) (updatedUser *
 			rate := (100 + float64(updatedUser.ExtraBonus)) * baseMiningRate * elapsedTimeFraction / 100.
 			updatedUser.BalanceSolo += rate
 			mintedAmount += rate
+			updatedUser.recordLedger(dwh.SoloMiningLedgerReason, baseMiningRate*elapsedTimeFraction)
+			updatedUser.recordLedger(dwh.ExtraBonusLedgerReason, rate-baseMiningRate*elapsedTimeFraction)
 		} else {
 			rate := baseMiningRate * elapsedTimeFraction
 			updatedUser.BalanceSolo += rate
 			mintedAmount += rate
+			updatedUser.recordLedger(dwh.SoloMiningLedgerReason, rate)
 		}
 		if t0Ref != nil && !t0Ref.MiningSessionSoloEndedAt.IsNil() && t0Ref.MiningSessionSoloEndedAt.After(*now.Time) {
-			rate := 25 * baseMiningRate * elapsedTimeFraction / 100
+			rate := float64(cfg.ReferralBonusMiningRates.T0) * baseMiningRate * elapsedTimeFraction / 100
 			updatedUser.BalanceForT0 += rate
 			updatedUser.BalanceT0 += rate
 			mintedAmount += rate
+			updatedUser.recordLedger(dwh.T0ReferralLedgerReason, rate)
 
 			if updatedUser.SlashingRateForT0 != 0 {
 				updatedUser.SlashingRateForT0 = 0
 			}
 		}
 		if tMinus1Ref != nil && !tMinus1Ref.MiningSessionSoloEndedAt.IsNil() && tMinus1Ref.MiningSessionSoloEndedAt.After(*now.Time) {
-			updatedUser.BalanceForTMinus1 += 5 * baseMiningRate * elapsedTimeFraction / 100
+			updatedUser.BalanceForTMinus1 += float64(cfg.ReferralBonusMiningRates.T2) * baseMiningRate * elapsedTimeFraction / 100
 
 			if updatedUser.SlashingRateForTMinus1 != 0 {
 				updatedUser.SlashingRateForTMinus1 = 0
@@ -150,11 +157,16 @@ func mine(now *time.Time, usr *user, t0Ref, tMinus1Ref *referral) (updatedUser *
 				activeT1Referrals = int32(math.Min(float64((*cfg.miningBoostLevels.Load())[int(*updatedUser.MiningBoostLevelIndex)].MaxT1Referrals), float64(updatedUser.ActiveT1Referrals)))
 			}
 		}
-		t1Rate := (25 * float64(activeT1Referrals)) * baseMiningRate * elapsedTimeFraction / 100
-		t2Rate := (5 * float64(updatedUser.ActiveT2Referrals)) * baseMiningRate * elapsedTimeFraction / 100
+		t1Rate := (float64(cfg.ReferralBonusMiningRates.T1) * float64(activeT1Referrals)) * baseMiningRate * elapsedTimeFraction / 100
+		t2Rate := (float64(cfg.ReferralBonusMiningRates.T2) * float64(updatedUser.ActiveT2Referrals)) * baseMiningRate * elapsedTimeFraction / 100
 		updatedUser.BalanceT1 += t1Rate
 		updatedUser.BalanceT2 += t2Rate
 		mintedAmount += t1Rate + t2Rate
+		boostedT1Rate := (float64(cfg.ReferralBonusMiningRates.T1) * float64(max(0, activeT1Referrals-updatedUser.ActiveT1Referrals))) * baseMiningRate * elapsedTimeFraction / 100
+		updatedUser.recordLedger(dwh.T1ReferralLedgerReason, t1Rate-boostedT1Rate)
+		updatedUser.recordLedger(dwh.BoostLedgerReason, boostedT1Rate)
+		updatedUser.recordLedger(dwh.T2ReferralLedgerReason, t2Rate)
+		mintedAmount += updatedUser.mineReferralTiers(baseMiningRate, elapsedTimeFraction)
 
 	} else {
 		if !updatedUser.slashingDisabled() {
@@ -167,6 +179,9 @@ func mine(now *time.Time, usr *user, t0Ref, tMinus1Ref *referral) (updatedUser *
 			if updatedUser.SlashingRateSolo < 0 {
 				updatedUser.SlashingRateSolo = 0
 			}
+			if !updatedUser.reachedSlashingFloor() {
+				updatedUser.startSlashingReferralTiers(miningSessionRatio)
+			}
 		}
 	}
 
@@ -184,12 +199,14 @@ func mine(now *time.Time, usr *user, t0Ref, tMinus1Ref *referral) (updatedUser *
 		}
 	}
 
+	var unAppliedT1WelcomeBonus float64
 	if updatedUser.BalanceT1WelcomeBonusPendingApplied < 25*tokenomics.WelcomeBonusV2Amount {
 		if unAppliedT1WelcomeBonusPending := updatedUser.BalanceT1WelcomeBonusPending - updatedUser.BalanceT1WelcomeBonusPendingApplied; unAppliedT1WelcomeBonusPending == 0 {
 			updatedUser.BalanceT1WelcomeBonusPending = 0
 			updatedUser.BalanceT1WelcomeBonusPendingApplied = 0
 		} else {
-			unAppliedT1Pending += min(unAppliedT1WelcomeBonusPending, 25*tokenomics.WelcomeBonusV2Amount-updatedUser.BalanceT1WelcomeBonusPendingApplied)
+			unAppliedT1WelcomeBonus = min(unAppliedT1WelcomeBonusPending, 25*tokenomics.WelcomeBonusV2Amount-updatedUser.BalanceT1WelcomeBonusPendingApplied)
+			unAppliedT1Pending += unAppliedT1WelcomeBonus
 			updatedUser.BalanceT1WelcomeBonusPendingApplied = min(updatedUser.BalanceT1WelcomeBonusPending, 25*tokenomics.WelcomeBonusV2Amount)
 		}
 	} else {
@@ -206,6 +223,7 @@ func mine(now *time.Time, usr *user, t0Ref, tMinus1Ref *referral) (updatedUser *
 	updatedUser.BalanceForTMinus1 += pendingAmountForTMinus1
 	updatedUser.BalanceForT0 += pendingAmountForT0
 	updatedUser.BalanceT0 -= updatedUser.SlashingRateT0 * elapsedTimeFraction
+	slashedAmount += updatedUser.slashReferralTiers(elapsedTimeFraction)
 	updatedUser.BalanceSolo += unAppliedSoloPending
 	updatedUser.BalanceT1 += unAppliedT1Pending
 	updatedUser.BalanceT2 += unAppliedT2Pending
@@ -217,16 +235,21 @@ func mine(now *time.Time, usr *user, t0Ref, tMinus1Ref *referral) (updatedUser *
 		slashedAmount += -unAppliedSoloPending
 	} else {
 		mintedAmount += unAppliedSoloPending
+		updatedUser.recordLedger(dwh.CompletedTasksPrizeLedgerReason, unAppliedSoloPending)
 	}
 	if unAppliedT1Pending < 0 {
 		slashedAmount += -unAppliedT1Pending
 	} else {
 		mintedAmount += unAppliedT1Pending
+		welcomeBonus := min(unAppliedT1WelcomeBonus, unAppliedT1Pending)
+		updatedUser.recordLedger(dwh.WelcomeBonusLedgerReason, welcomeBonus)
+		updatedUser.recordLedger(dwh.T1ReferralLedgerReason, unAppliedT1Pending-welcomeBonus)
 	}
 	if unAppliedT2Pending < 0 {
 		slashedAmount += -unAppliedT2Pending
 	} else {
 		mintedAmount += unAppliedT2Pending
+		updatedUser.recordLedger(dwh.T2ReferralLedgerReason, unAppliedT2Pending)
 	}
 	if updatedUser.BalanceSolo < 0 {
 		updatedUser.BalanceSolo = 0
@@ -252,20 +275,25 @@ func mine(now *time.Time, usr *user, t0Ref, tMinus1Ref *referral) (updatedUser *
 	if usr.BalanceTotalPreStaking+usr.BalanceTotalStandard == 0 {
 		slashedAmount = 0
 	}
+	var welcomeBonus float64
 	if updatedUser.WelcomeBonusV2Applied == nil || !*updatedUser.WelcomeBonusV2Applied {
-		updatedUser.BalanceSolo += tokenomics.WelcomeBonusV2Amount - 10
+		welcomeBonus = tokenomics.WelcomeBonusV2Amount - 10
+		updatedUser.BalanceSolo += welcomeBonus
+		updatedUser.recordLedger(dwh.WelcomeBonusLedgerReason, welcomeBonus)
 		trueVal := model.FlexibleBool(true)
 		updatedUser.WelcomeBonusV2Applied = &trueVal
 	} else {
 		updatedUser.WelcomeBonusV2Applied = nil
 	}
 
-	totalAmount := updatedUser.BalanceSolo + updatedUser.BalanceT0 + updatedUser.BalanceT1 + updatedUser.BalanceT2
+	totalAmount := updatedUser.BalanceSolo + updatedUser.BalanceT0 + updatedUser.BalanceT1 + updatedUser.BalanceT2 + updatedUser.referralTiers.Balance()
 	updatedUser.BalanceTotalStandard, updatedUser.BalanceTotalPreStaking = tokenomics.ApplyPreStaking(totalAmount, updatedUser.PreStakingAllocation, updatedUser.PreStakingBonus)
 	mintedStandard, mintedPreStaking := tokenomics.ApplyPreStaking(mintedAmount, updatedUser.PreStakingAllocation, updatedUser.PreStakingBonus)
 	slashedStandard, slashedPreStaking := tokenomics.ApplyPreStaking(slashedAmount, updatedUser.PreStakingAllocation, updatedUser.PreStakingBonus)
 	updatedUser.BalanceTotalMinted += mintedStandard + mintedPreStaking
 	updatedUser.BalanceTotalSlashed += slashedStandard + slashedPreStaking
+	updatedUser.recordLedgerPreStakingBonus(mintedAmount + welcomeBonus)
+	updatedUser.recordLedger(dwh.SlashingLedgerReason, -(slashedStandard + slashedPreStaking))
 	updatedUser.BalanceLastUpdatedAt = now
 
 	return updatedUser, shouldGenerateHistory, IDT0Changed, pendingAmountForTMinus1, pendingAmountForT0
@@ -295,7 +323,7 @@ func (u *user) isAbsoluteZero() bool {
 }
 
 func (u *user) reachedSlashingFloor() bool {
-	return (u.BalanceSolo + u.BalanceT0 + u.BalanceT1 + u.BalanceT2) <= cfg.SlashingFloor
+	return (u.BalanceSolo + u.BalanceT0 + u.BalanceT1 + u.BalanceT2 + u.referralTiers.Balance()) <= cfg.SlashingFloor
 }
 
 func (ref *referral) reachedSlashingFloor() bool {
@@ -3,9 +3,11 @@
 package miner
 
 import (
+	"context"
 	"testing"
 	stdlibtime "time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/ice-blockchain/freezer/model"
@@ -1710,3 +1712,33 @@ func testMinerTier1Limit_1000(t *testing.T) {
 	require.EqualValues(t, 0, m.IDT0)
 	require.EqualValues(t, 0, m.IDTMinus1)
 }
+
+func TestMineFastForwarded(t *testing.T) {
+	t.Parallel()
+
+	clock := model.NewFakeClock(testTime)
+	m := &miner{clock: model.ClockFromContext(model.ContextWithClock(context.Background(), clock))}
+	newSessionUser := func() *user {
+		usr := newUser()
+		usr.MiningSessionSoloStartedAt, usr.MiningSessionSoloEndedAt = testTime, timeDelta(24*stdlibtime.Hour)
+
+		return usr
+	}
+	usr := newSessionUser()
+	for clock.Now().Before(*usr.MiningSessionSoloEndedAt.Time) {
+		updatedUser, _, _, _, _ := mine(m.clock.Now(), usr, nil, nil) //nolint:dogsled // We only care about the user.
+		require.NotNil(t, updatedUser)
+		usr.persist(&updatedUser.UpdatedUser)
+		clock.Advance(stdlibtime.Hour)
+	}
+	minedAtOnce, _, _, _, _ := mine(timeDelta(23*stdlibtime.Hour), newSessionUser(), nil, nil) //nolint:dogsled // We only care about the user.
+	require.NotNil(t, minedAtOnce)
+	assert.Positive(t, usr.BalanceSolo)
+	assert.InDelta(t, minedAtOnce.BalanceSolo, usr.BalanceSolo, 0.000001)
+
+	clock.Advance(48 * stdlibtime.Hour)
+	updatedUser, _, _, _, _ := mine(m.clock.Now(), usr, nil, nil) //nolint:dogsled // We only care about the user.
+	require.NotNil(t, updatedUser)
+	assert.Positive(t, updatedUser.SlashingRateSolo)
+	assert.Less(t, updatedUser.BalanceSolo, usr.BalanceSolo)
+}
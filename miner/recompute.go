@@ -0,0 +1,555 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package miner
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strconv"
+	stdlibtime "time"
+
+	"github.com/bsm/redislock"
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+
+	dwh "github.com/ice-blockchain/freezer/bookkeeper/storage"
+	coindistribution "github.com/ice-blockchain/freezer/coin-distribution"
+	"github.com/ice-blockchain/freezer/model"
+	"github.com/ice-blockchain/freezer/tokenomics"
+	"github.com/ice-blockchain/wintr/connectors/storage/v3"
+	"github.com/ice-blockchain/wintr/log"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+// Recompute rebuilds the balances of the users, the solo, T0, T1, T2, for T0, for T-1 and the Ethereum ones, by replaying,
+// through the same mining functions the miner uses, their history snapshots recorded since opts.Since and the mining sessions they tell about.
+// The before/after of every recomputed user goes to diffLog, as NDJSON DryRunDiff lines;
+// with opts.Apply, the recomputed balances are also written, atomically, unless the user is mined meanwhile for recomputeMaxAttempts times in a row.
+//
+// The miner doesn't watch the users it writes, so opts.Apply refuses to start while mining isn't paused, i.e. while any miner replica heartbeats,
+// and it holds the lease of the partition of every user it writes, so that no replica starts mining it meanwhile.
+// A miner running without sharding neither heartbeats nor leases its partitions, so it can't be detected: it has to be stopped before applying.
+//
+// The replay starts from the balances of the first snapshot, so it can only fix what went wrong after it. Other than that:
+//   - the pending balances, the active referrals, the pre-staking and the T1 & T2 Ethereum balances are replayed as of each snapshot, not as they changed in between;
+//   - the T1 welcome bonuses, the mining boost, the KYC state and the devices aren't recorded in the history, so they're taken as they are now;
+//   - the deeper referral tiers aren't recorded in the history either, so they're taken as they are now and only added to the totals;
+//   - the resurrections of the T0 & T-1 referrals aren't replayed;
+//   - the coin distributions are replayed only when the snapshots tell that they were collected, with the current collector settings.
+func Recompute(ctx context.Context, opts *RecomputeOptions, diffLog io.Writer) (*RecomputeReport, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	coinDistributionRepository := coindistribution.NewRepository(ctx, func() {})
+	defer func() {
+		log.Error(errors.Wrap(coinDistributionRepository.Close(), "failed to close coinDistributionRepository"))
+	}()
+	settings, err := coinDistributionRepository.GetCollectorSettings(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to GetCollectorSettings")
+	}
+	cfg.coinDistributionCollectorSettings.Store(settings)
+	rc := &recomputation{
+		opts:      opts,
+		dryRun:    newDryRun(diffLog),
+		db:        storage.MustConnect(ctx, parentApplicationYamlKey, 1),
+		dwhClient: dwh.MustConnect(ctx, applicationYamlKey),
+		referrals: make(map[int64]*replayTimeline),
+		report:    new(RecomputeReport),
+	}
+	defer func() {
+		log.Error(errors.Wrap(rc.db.Close(), "failed to close db"))
+		log.Error(errors.Wrap(rc.dwhClient.Close(), "failed to close dwh"))
+	}()
+	if opts.Apply {
+		if err = rc.checkMiningPaused(ctx); err != nil {
+			return nil, err
+		}
+	}
+	for id := opts.FromID; id <= opts.ToID && ctx.Err() == nil; id++ {
+		if err = rc.recompute(ctx, id); err != nil {
+			return rc.finish(errors.Wrapf(err, "failed to recompute the user %v", id))
+		}
+	}
+
+	return rc.finish(errors.Wrap(ctx.Err(), "recompute interrupted"))
+}
+
+func (o *RecomputeOptions) validate() error {
+	if o.FromID <= 0 || o.ToID < o.FromID {
+		return errors.Wrapf(ErrInvalidRecomputeOptions, "the ids have to be a positive range, got [%v, %v]", o.FromID, o.ToID)
+	}
+	if o.Since.IsZero() || !o.Since.Before(*time.Now().Time) {
+		return errors.Wrapf(ErrInvalidRecomputeOptions, "`since` has to be in the past, got %v", o.Since)
+	}
+	if o.Step <= 0 {
+		return errors.Wrapf(ErrInvalidRecomputeOptions, "`step` has to be a positive duration, got %v", o.Step)
+	}
+
+	return nil
+}
+
+func (rc *recomputation) finish(err error) (*RecomputeReport, error) {
+	dryRunReport, dErr := rc.dryRun.finish()
+	rc.report.StartedAt, rc.report.EndedAt = dryRunReport.StartedAt, dryRunReport.EndedAt
+	rc.report.Deltas = dryRunReport.Deltas
+	rc.report.Users, rc.report.UpdatedUsers = dryRunReport.Users, dryRunReport.UpdatedUsers
+
+	return rc.report, multierror.Append(err, dErr).ErrorOrNil() //nolint:wrapcheck // They're wrapped already.
+}
+
+func (rc *recomputation) checkMiningPaused(ctx context.Context) error {
+	replicas, err := rc.db.ZCount(ctx, minerReplicasKey, strconv.FormatInt(time.Now().UnixNano(), 10), "+inf").Result()
+	if err != nil {
+		return errors.Wrap(err, "failed to count the live miner replicas")
+	}
+	if replicas != 0 {
+		return errors.Wrapf(ErrMiningNotPaused, "%v miner replicas are running", replicas)
+	}
+
+	return nil
+}
+
+// Leases the partition of the user the way a miner replica would, so that none of them mines it while its recomputed balances are written.
+func (rc *recomputation) leasePartition(ctx context.Context, id int64) (*redislock.Lock, error) {
+	number := id % cfg.Workers
+	lease, err := redislock.Obtain(ctx, rc.db, partitionLeaseKey(number), recomputeLeaseDuration, &redislock.Options{RetryStrategy: redislock.NoRetry()})
+	if err != nil {
+		if errors.Is(err, redislock.ErrNotObtained) {
+			err = errors.Wrapf(ErrMiningNotPaused, "partition %v is being mined", number)
+		}
+
+		return nil, errors.Wrapf(err, "failed to obtain the lease of partition %v", number)
+	}
+
+	return lease, nil
+}
+
+func (rc *recomputation) recompute(ctx context.Context, id int64) error {
+	history, err := rc.dwhClient.SelectHistory(ctx, id, rc.opts.Since)
+	if err != nil {
+		return errors.Wrap(err, "failed to SelectHistory")
+	}
+	if len(history) == 0 {
+		rc.report.SkippedUsers++
+
+		return nil
+	}
+	if rc.opts.Apply {
+		lease, lErr := rc.leasePartition(ctx, id)
+		if lErr != nil {
+			return lErr
+		}
+		defer func() {
+			reqCtx, cancel := context.WithTimeout(context.Background(), requestDeadline)
+			defer cancel()
+			if rErr := lease.Release(reqCtx); rErr != nil && !errors.Is(rErr, redislock.ErrLockNotHeld) {
+				log.Error(errors.Wrapf(rErr, "failed to release the lease of the partition of the user %v", id))
+			}
+		}()
+	}
+	key := model.SerializedUsersKey(id)
+	var current *user
+	var recomputed *recomputedUser
+	for attempt := 1; ; attempt++ {
+		err = rc.db.Watch(ctx, func(tx *redis.Tx) error {
+			usrs, gErr := storage.Get[user](ctx, rc.db, key)
+			if gErr != nil {
+				return errors.Wrap(gErr, "failed to get the user")
+			}
+			current = nil
+			if len(usrs) == 0 || usrs[0] == nil || usrs[0].UserID == "" {
+				return nil
+			}
+			current = usrs[0]
+			referralTiers, gErr := tokenomics.GetReferralTiers(ctx, rc.db, cfg.DeeperReferralTiers(), id)
+			if gErr != nil {
+				return errors.Wrap(gErr, "failed to GetReferralTiers")
+			}
+			current.referralTiers = referralTiers[id]
+			timeline := newReplayTimeline(current, history)
+			if gErr = rc.loadReferrals(ctx, timeline); gErr != nil {
+				return gErr
+			}
+			recomputed = timeline.replay(time.Now(), rc.opts.Step, rc.referrals).recomputed()
+			if !rc.opts.Apply {
+				return nil
+			}
+			_, gErr = tx.TxPipelined(ctx, func(pipeliner redis.Pipeliner) error {
+				return pipeliner.HSet(ctx, key, storage.SerializeValue(recomputed)...).Err()
+			})
+
+			return gErr //nolint:wrapcheck // Wrapped below.
+		}, key)
+		if !errors.Is(err, redis.TxFailedErr) || attempt == recomputeMaxAttempts {
+			break
+		}
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to recompute `%v`", key)
+	}
+	if current == nil {
+		rc.report.SkippedUsers++
+
+		return nil
+	}
+	rc.dryRun.recordMiningProgress([]*user{current}, []any{[]*recomputedUser{recomputed}}, nil)
+	if rc.opts.Apply {
+		rc.report.AppliedUsers++
+	}
+
+	return nil
+}
+
+// Loads the timelines of the T0 & T-1 referrals that the user had since then, if they're not cached already.
+func (rc *recomputation) loadReferrals(ctx context.Context, timeline *replayTimeline) error {
+	ids := make(map[int64]struct{}, 2) //nolint:gomnd // Usually, the T0 & T-1.
+	for _, usr := range timeline.users() {
+		if usr.IDT0 != 0 {
+			ids[abs(usr.IDT0)] = struct{}{}
+		}
+		if usr.IDTMinus1 != 0 && !isAdvancedTeamDisabled(timeline.current.LatestDevice) {
+			ids[abs(usr.IDTMinus1)] = struct{}{}
+		}
+	}
+	if len(rc.referrals)+len(ids) > recomputeReferralsCacheSize {
+		rc.referrals = make(map[int64]*replayTimeline, len(ids))
+	}
+	for id := range ids {
+		if _, found := rc.referrals[id]; found {
+			continue
+		}
+		history, err := rc.dwhClient.SelectHistory(ctx, id, rc.opts.Since)
+		if err != nil {
+			return errors.Wrapf(err, "failed to SelectHistory for the referral %v", id)
+		}
+		refs, err := storage.Get[user](ctx, rc.db, model.SerializedUsersKey(id))
+		if err != nil {
+			return errors.Wrapf(err, "failed to get the referral %v", id)
+		}
+		var ref *user
+		if len(refs) != 0 && refs[0] != nil && refs[0].UserID != "" {
+			ref = refs[0]
+		}
+		if ref == nil && len(history) == 0 {
+			rc.referrals[id] = nil
+
+			continue
+		}
+		rc.referrals[id] = newReplayTimeline(ref, history)
+	}
+
+	return nil
+}
+
+func newReplayTimeline(current *user, history []*dwh.HistorySnapshot) *replayTimeline {
+	tl := &replayTimeline{current: current, snapshots: make([]*replayedSnapshot, 0, len(history))}
+	for _, snapshot := range history {
+		usr := new(user)
+		if current != nil {
+			*usr = *current
+		}
+		usr.restore(snapshot.User)
+		tl.snapshots = append(tl.snapshots, &replayedSnapshot{createdAt: snapshot.CreatedAt, usr: usr})
+	}
+	sessions, extraBonuses, ethereumDistributedAt := make(map[int64]*replayedSession), make(map[int64]*replayedExtraBonus), make(map[int64]*time.Time)
+	for _, usr := range tl.users() {
+		if !usr.MiningSessionSoloStartedAt.IsNil() && !usr.MiningSessionSoloEndedAt.IsNil() {
+			session, found := sessions[usr.MiningSessionSoloStartedAt.UnixNano()]
+			if !found {
+				session = &replayedSession{startedAt: usr.MiningSessionSoloStartedAt, previouslyEndedAt: usr.MiningSessionSoloPreviouslyEndedAt}
+				sessions[usr.MiningSessionSoloStartedAt.UnixNano()] = session
+			}
+			if session.endedAt.IsNil() || usr.MiningSessionSoloEndedAt.After(*session.endedAt.Time) {
+				session.endedAt, session.lastStartedAt = usr.MiningSessionSoloEndedAt, usr.MiningSessionSoloLastStartedAt
+			}
+			session.resurrected = session.resurrected ||
+				(!usr.ResurrectSoloUsedAt.IsNil() && !usr.ResurrectSoloUsedAt.Before(*usr.MiningSessionSoloStartedAt.Time))
+		}
+		if !usr.ExtraBonusStartedAt.IsNil() {
+			extraBonuses[usr.ExtraBonusStartedAt.UnixNano()] = &replayedExtraBonus{startedAt: usr.ExtraBonusStartedAt, bonus: usr.ExtraBonus}
+		}
+		for _, processedAt := range []*time.Time{
+			usr.SoloLastEthereumCoinDistributionProcessedAt,
+			usr.ForT0LastEthereumCoinDistributionProcessedAt,
+			usr.ForTMinus1LastEthereumCoinDistributionProcessedAt,
+		} {
+			if !processedAt.IsNil() {
+				ethereumDistributedAt[processedAt.UnixNano()] = processedAt
+			}
+		}
+	}
+	for _, session := range sessions {
+		tl.sessions = append(tl.sessions, session)
+	}
+	sort.Slice(tl.sessions, func(ii, jj int) bool { return tl.sessions[ii].startedAt.Before(*tl.sessions[jj].startedAt.Time) })
+	for _, extraBonus := range extraBonuses {
+		tl.extraBonuses = append(tl.extraBonuses, extraBonus)
+	}
+	sort.Slice(tl.extraBonuses, func(ii, jj int) bool {
+		return tl.extraBonuses[ii].startedAt.Before(*tl.extraBonuses[jj].startedAt.Time)
+	})
+	for _, processedAt := range ethereumDistributedAt {
+		tl.ethereumDistributedAt = append(tl.ethereumDistributedAt, processedAt)
+	}
+
+	return tl
+}
+
+// The snapshots, from the oldest to the newest, and then the current state, if any.
+func (tl *replayTimeline) users() []*user {
+	usrs := make([]*user, 0, len(tl.snapshots)+1)
+	for _, snapshot := range tl.snapshots {
+		usrs = append(usrs, snapshot.usr)
+	}
+	if tl.current != nil {
+		usrs = append(usrs, tl.current)
+	}
+
+	return usrs
+}
+
+// replay mines the user again, from its first snapshot to now, the way the miner would have:
+// the snapshots are applied when they were recorded, the mining sessions and the extra bonuses when they started
+// and the user is mined at every step in between, right before the sessions and the extra bonuses end and at now, with the current state.
+func (tl *replayTimeline) replay(now *time.Time, step stdlibtime.Duration, referrals map[int64]*replayTimeline) *user {
+	usr := *tl.snapshots[0].usr
+	usr.referralTiers = nil
+	var referralTiers model.ReferralTiers
+	if tl.current != nil {
+		referralTiers = tl.current.referralTiers
+	}
+	previous, next := tl.snapshots[0].usr, 1
+	for _, at := range tl.instants(now, step) {
+		for ; next < len(tl.snapshots) && !tl.snapshots[next].createdAt.After(*at.Time); next++ {
+			usr.replaySnapshot(previous, tl.snapshots[next].usr)
+			previous = tl.snapshots[next].usr
+		}
+		if at.Equal(*now.Time) {
+			usr.replaySnapshot(previous, tl.current)
+		}
+		usr.replaySession(tl.session(at))
+		usr.replayExtraBonus(tl.extraBonus(at))
+		var t0Ref, tMinus1Ref *referral
+		if t0 := referrals[abs(usr.IDT0)]; t0 != nil {
+			t0Ref = t0.referral(at)
+		}
+		if isAdvancedTeamDisabled(usr.LatestDevice) {
+			usr.ActiveT2Referrals = 0
+		} else if tMinus1 := referrals[abs(usr.IDTMinus1)]; tMinus1 != nil {
+			tMinus1Ref = tMinus1.referral(at)
+		}
+		updatedUser, _, _, _, _ := mine(at, &usr, t0Ref, tMinus1Ref) //nolint:dogsled // We only care about the user.
+		if updatedUser == nil {
+			continue
+		}
+		if t0Ref != nil && usr.IDTMinus1 != t0Ref.IDT0 {
+			updatedUser.IDTMinus1 = t0Ref.IDT0
+		}
+		updatedUser.processEthereumCoinDistribution(tl.ethereumDistributed(at), at, t0Ref, tMinus1Ref)
+		updatedUser.addReferralTiersToTotals(referralTiers)
+		usr.persist(&updatedUser.UpdatedUser)
+	}
+
+	return &usr
+}
+
+// The instants the user has to be mined at, from its first snapshot to now.
+func (tl *replayTimeline) instants(now *time.Time, step stdlibtime.Duration) []*time.Time {
+	from := tl.snapshots[0].createdAt
+	instants := make(map[int64]*time.Time)
+	add := func(at *time.Time) {
+		if !at.IsNil() && !at.Before(*from.Time) && !at.After(*now.Time) {
+			instants[at.UnixNano()] = at
+		}
+	}
+	for at := *from.Time; at.Before(*now.Time); at = at.Add(step) {
+		add(time.New(at))
+	}
+	for _, snapshot := range tl.snapshots {
+		add(snapshot.createdAt)
+	}
+	for _, session := range tl.sessions {
+		add(session.startedAt)
+		add(time.New(session.endedAt.Add(-stdlibtime.Nanosecond)))
+	}
+	for _, extraBonus := range tl.extraBonuses {
+		add(extraBonus.startedAt)
+		add(time.New(extraBonus.startedAt.Add(cfg.ExtraBonuses.Duration - stdlibtime.Nanosecond)))
+	}
+	for _, processedAt := range tl.ethereumDistributedAt {
+		add(processedAt)
+	}
+	add(now)
+	sorted := make([]*time.Time, 0, len(instants))
+	for _, at := range instants {
+		sorted = append(sorted, at)
+	}
+	sort.Slice(sorted, func(ii, jj int) bool { return sorted[ii].Before(*sorted[jj].Time) })
+
+	return sorted
+}
+
+// The latest session started before then.
+func (tl *replayTimeline) session(now *time.Time) *replayedSession {
+	var latest *replayedSession
+	for _, session := range tl.sessions {
+		if !session.startedAt.Before(*now.Time) {
+			break
+		}
+		latest = session
+	}
+
+	return latest
+}
+
+// The latest extra bonus started before then.
+func (tl *replayTimeline) extraBonus(now *time.Time) *replayedExtraBonus {
+	var latest *replayedExtraBonus
+	for _, extraBonus := range tl.extraBonuses {
+		if !extraBonus.startedAt.Before(*now.Time) {
+			break
+		}
+		latest = extraBonus
+	}
+
+	return latest
+}
+
+func (tl *replayTimeline) ethereumDistributed(now *time.Time) bool {
+	for _, processedAt := range tl.ethereumDistributedAt {
+		if processedAt.Equal(*now.Time) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// How the referrals of the user saw it then: as its latest snapshot, or the first one, with the sessions it had.
+func (tl *replayTimeline) referral(now *time.Time) *referral {
+	usr := tl.current
+	for ix, snapshot := range tl.snapshots {
+		if ix != 0 && snapshot.createdAt.After(*now.Time) {
+			break
+		}
+		usr = snapshot.usr
+	}
+	clonedUser := *usr
+	clonedUser.replaySession(tl.session(now))
+	ref := clonedUser.referral()
+	ref.ResurrectSoloUsedAt = nil
+
+	return ref
+}
+
+// restore sets everything the history snapshot has about the user.
+//
+//nolint:funlen // It's a long list of fields.
+func (u *user) restore(snapshot *model.User) {
+	u.BalanceLastUpdatedAt = snapshot.BalanceLastUpdatedAt
+	u.MiningSessionSoloLastStartedAt = snapshot.MiningSessionSoloLastStartedAt
+	u.MiningSessionSoloStartedAt = snapshot.MiningSessionSoloStartedAt
+	u.MiningSessionSoloEndedAt = snapshot.MiningSessionSoloEndedAt
+	u.MiningSessionSoloPreviouslyEndedAt = snapshot.MiningSessionSoloPreviouslyEndedAt
+	u.ExtraBonusStartedAt, u.ExtraBonus = snapshot.ExtraBonusStartedAt, snapshot.ExtraBonus
+	u.ResurrectSoloUsedAt = snapshot.ResurrectSoloUsedAt
+	u.ResurrectT0UsedAt = snapshot.ResurrectT0UsedAt
+	u.ResurrectTMinus1UsedAt = snapshot.ResurrectTMinus1UsedAt
+	u.SoloLastEthereumCoinDistributionProcessedAt = snapshot.SoloLastEthereumCoinDistributionProcessedAt
+	u.ForT0LastEthereumCoinDistributionProcessedAt = snapshot.ForT0LastEthereumCoinDistributionProcessedAt
+	u.ForTMinus1LastEthereumCoinDistributionProcessedAt = snapshot.ForTMinus1LastEthereumCoinDistributionProcessedAt
+	u.BalanceSoloEthereumPending, u.BalanceT0EthereumPending = nil, nil
+	u.BalanceT1EthereumPending, u.BalanceT2EthereumPending = nil, nil
+	u.IDT0, u.IDTMinus1 = snapshot.IDT0, snapshot.IDTMinus1
+	u.BalanceTotalStandard, u.BalanceTotalPreStaking = snapshot.BalanceTotalStandard, snapshot.BalanceTotalPreStaking
+	u.BalanceTotalMinted, u.BalanceTotalSlashed = snapshot.BalanceTotalMinted, snapshot.BalanceTotalSlashed
+	u.BalanceSoloPending, u.BalanceSoloPendingApplied = snapshot.BalanceSoloPending, snapshot.BalanceSoloPendingApplied
+	u.BalanceT1Pending, u.BalanceT1PendingApplied = snapshot.BalanceT1Pending, snapshot.BalanceT1PendingApplied
+	u.BalanceT2Pending, u.BalanceT2PendingApplied = snapshot.BalanceT2Pending, snapshot.BalanceT2PendingApplied
+	u.BalanceSolo, u.BalanceT0, u.BalanceT1, u.BalanceT2 = snapshot.BalanceSolo, snapshot.BalanceT0, snapshot.BalanceT1, snapshot.BalanceT2
+	u.BalanceForT0, u.BalanceForTMinus1 = snapshot.BalanceForT0, snapshot.BalanceForTMinus1
+	u.BalanceSoloEthereum, u.BalanceT0Ethereum = snapshot.BalanceSoloEthereum, snapshot.BalanceT0Ethereum
+	u.BalanceT1Ethereum, u.BalanceT2Ethereum = snapshot.BalanceT1Ethereum, snapshot.BalanceT2Ethereum
+	u.BalanceForT0Ethereum, u.BalanceForTMinus1Ethereum = snapshot.BalanceForT0Ethereum, snapshot.BalanceForTMinus1Ethereum
+	u.BalanceSoloEthereumMainnetRewardPoolContribution = snapshot.BalanceSoloEthereumMainnetRewardPoolContribution
+	u.BalanceT0EthereumMainnetRewardPoolContribution = snapshot.BalanceT0EthereumMainnetRewardPoolContribution
+	u.BalanceT1EthereumMainnetRewardPoolContribution = snapshot.BalanceT1EthereumMainnetRewardPoolContribution
+	u.BalanceT2EthereumMainnetRewardPoolContribution = snapshot.BalanceT2EthereumMainnetRewardPoolContribution
+	u.SlashingRateSolo, u.SlashingRateT0, u.SlashingRateT1 = snapshot.SlashingRateSolo, snapshot.SlashingRateT0, snapshot.SlashingRateT1
+	u.SlashingRateT2, u.SlashingRateForT0, u.SlashingRateForTMinus1 = snapshot.SlashingRateT2, snapshot.SlashingRateForT0, snapshot.SlashingRateForTMinus1
+	u.ActiveT1Referrals, u.ActiveT2Referrals = snapshot.ActiveT1Referrals, snapshot.ActiveT2Referrals
+	u.PreStakingAllocation, u.PreStakingBonus = snapshot.PreStakingAllocation, snapshot.PreStakingBonus
+}
+
+// replaySnapshot applies what changed for the user in between the snapshots, but wasn't mined by it:
+// the pending balances only ever add up, so what was added in between is applied at once.
+func (u *user) replaySnapshot(previous, next *user) {
+	u.IDT0, u.IDTMinus1 = next.IDT0, next.IDTMinus1
+	u.ActiveT1Referrals, u.ActiveT2Referrals = next.ActiveT1Referrals, next.ActiveT2Referrals
+	u.PreStakingAllocation, u.PreStakingBonus = next.PreStakingAllocation, next.PreStakingBonus
+	u.BalanceSoloPending += next.BalanceSoloPending - previous.BalanceSoloPending
+	u.BalanceT1Pending += next.BalanceT1Pending - previous.BalanceT1Pending
+	u.BalanceT2Pending += next.BalanceT2Pending - previous.BalanceT2Pending
+	u.BalanceT1Ethereum += next.BalanceT1Ethereum - previous.BalanceT1Ethereum
+	u.BalanceT2Ethereum += next.BalanceT2Ethereum - previous.BalanceT2Ethereum
+	u.BalanceT1EthereumMainnetRewardPoolContribution += next.BalanceT1EthereumMainnetRewardPoolContribution - previous.BalanceT1EthereumMainnetRewardPoolContribution
+	u.BalanceT2EthereumMainnetRewardPoolContribution += next.BalanceT2EthereumMainnetRewardPoolContribution - previous.BalanceT2EthereumMainnetRewardPoolContribution
+}
+
+// replaySession starts the session, or extends it, like tokenomics does it.
+func (u *user) replaySession(session *replayedSession) {
+	if session == nil || (!u.MiningSessionSoloStartedAt.IsNil() && session.startedAt.Before(*u.MiningSessionSoloStartedAt.Time)) {
+		return
+	}
+	if u.MiningSessionSoloStartedAt.IsNil() || !session.startedAt.Equal(*u.MiningSessionSoloStartedAt.Time) {
+		if session.resurrected {
+			u.ResurrectSoloUsedAt = time.New(stdlibtime.Date(3000, 0, 0, 0, 0, 0, 0, stdlibtime.UTC)) //nolint:gomnd,mnd // Like tokenomics does it.
+		}
+		u.MiningSessionSoloStartedAt, u.MiningSessionSoloPreviouslyEndedAt = session.startedAt, session.previouslyEndedAt
+	}
+	u.MiningSessionSoloLastStartedAt, u.MiningSessionSoloEndedAt = session.lastStartedAt, session.endedAt
+}
+
+func (u *user) replayExtraBonus(extraBonus *replayedExtraBonus) {
+	if extraBonus == nil || (!u.ExtraBonusStartedAt.IsNil() && !extraBonus.startedAt.After(*u.ExtraBonusStartedAt.Time)) {
+		return
+	}
+	u.ExtraBonusStartedAt, u.ExtraBonus = extraBonus.startedAt, extraBonus.bonus
+}
+
+// The deeper referral tiers can't be replayed, so their current balances are added to the totals, like the miner adds them.
+func (u *user) addReferralTiersToTotals(referralTiers model.ReferralTiers) {
+	if len(referralTiers) == 0 {
+		return
+	}
+	totalAmount := u.BalanceSolo + u.BalanceT0 + u.BalanceT1 + u.BalanceT2 + referralTiers.Balance()
+	u.BalanceTotalStandard, u.BalanceTotalPreStaking = tokenomics.ApplyPreStaking(totalAmount, u.PreStakingAllocation, u.PreStakingBonus)
+}
+
+func (u *user) recomputed() *recomputedUser {
+	recomputed := new(recomputedUser)
+	recomputed.BalanceLastUpdatedAtField = u.BalanceLastUpdatedAtField
+	recomputed.DeserializedUsersKey = u.DeserializedUsersKey
+	recomputed.BalanceTotalStandardField, recomputed.BalanceTotalPreStakingField = u.BalanceTotalStandardField, u.BalanceTotalPreStakingField
+	recomputed.BalanceSoloPendingAppliedField = u.BalanceSoloPendingAppliedField
+	recomputed.BalanceT1WelcomeBonusPendingAppliedField = u.BalanceT1WelcomeBonusPendingAppliedField
+	recomputed.BalanceT1PendingAppliedField, recomputed.BalanceT2PendingAppliedField = u.BalanceT1PendingAppliedField, u.BalanceT2PendingAppliedField
+	recomputed.BalanceSoloField, recomputed.BalanceT0Field = u.BalanceSoloField, u.BalanceT0Field
+	recomputed.BalanceT1Field, recomputed.BalanceT2Field = u.BalanceT1Field, u.BalanceT2Field
+	recomputed.BalanceForT0Field, recomputed.BalanceForTMinus1Field = u.BalanceForT0Field, u.BalanceForTMinus1Field
+	recomputed.BalanceSoloEthereumField, recomputed.BalanceT0EthereumField = u.BalanceSoloEthereumField, u.BalanceT0EthereumField
+	recomputed.BalanceT1EthereumField, recomputed.BalanceT2EthereumField = u.BalanceT1EthereumField, u.BalanceT2EthereumField
+	recomputed.BalanceForT0EthereumField, recomputed.BalanceForTMinus1EthereumField = u.BalanceForT0EthereumField, u.BalanceForTMinus1EthereumField
+	recomputed.BalanceSoloEthereumMainnetRewardPoolContributionField = u.BalanceSoloEthereumMainnetRewardPoolContributionField
+	recomputed.BalanceT0EthereumMainnetRewardPoolContributionField = u.BalanceT0EthereumMainnetRewardPoolContributionField
+	recomputed.BalanceT1EthereumMainnetRewardPoolContributionField = u.BalanceT1EthereumMainnetRewardPoolContributionField
+	recomputed.BalanceT2EthereumMainnetRewardPoolContributionField = u.BalanceT2EthereumMainnetRewardPoolContributionField
+	recomputed.SlashingRateSoloField, recomputed.SlashingRateT0Field = u.SlashingRateSoloField, u.SlashingRateT0Field
+	recomputed.SlashingRateT1Field, recomputed.SlashingRateT2Field = u.SlashingRateT1Field, u.SlashingRateT2Field
+	recomputed.SlashingRateForT0Field, recomputed.SlashingRateForTMinus1Field = u.SlashingRateForT0Field, u.SlashingRateForTMinus1Field
+
+	return recomputed
+}
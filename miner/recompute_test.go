@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package miner
+
+import (
+	"bytes"
+	"testing"
+	stdlibtime "time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dwh "github.com/ice-blockchain/freezer/bookkeeper/storage"
+	"github.com/ice-blockchain/freezer/model"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+func TestRecomputeReplay(t *testing.T) {
+	t.Parallel()
+
+	snapshot := func(at stdlibtime.Duration, edit func(*model.User)) *dwh.HistorySnapshot {
+		usr := new(model.User)
+		usr.ID, usr.IDT0 = 1, 2
+		usr.MiningSessionSoloStartedAt = timeDelta(-stdlibtime.Hour)
+		usr.MiningSessionSoloEndedAt = timeDelta(71 * stdlibtime.Hour)
+		usr.BalanceLastUpdatedAt = testTime
+		usr.BalanceSolo = 100
+		edit(usr)
+
+		return &dwh.HistorySnapshot{CreatedAt: timeDelta(at), User: usr}
+	}
+	history := []*dwh.HistorySnapshot{
+		snapshot(0, func(*model.User) {}),
+		snapshot(24*stdlibtime.Hour, func(usr *model.User) {
+			usr.BalanceSolo = 1
+			usr.BalanceSoloPending = 10
+		}),
+	}
+	current := newUser()
+	current.CreatedAt = nil // So that the base mining rate doesn't decrease over time.
+	current.ID, current.IDT0 = 1, 2
+	current.MiningSessionSoloStartedAt = timeDelta(-stdlibtime.Hour)
+	current.MiningSessionSoloEndedAt = timeDelta(71 * stdlibtime.Hour)
+	current.BalanceLastUpdatedAt = timeDelta(48 * stdlibtime.Hour)
+	current.BalanceSolo = 5
+	current.BalanceSoloPending, current.BalanceSoloPendingApplied = 10, 10
+	t0 := newUser()
+	t0.ID, t0.UserID = 2, "t0"
+	t0.MiningSessionSoloEndedAt = timeDelta(71 * stdlibtime.Hour)
+
+	recomputed := newReplayTimeline(current, history).
+		replay(timeDelta(48*stdlibtime.Hour), stdlibtime.Hour, map[int64]*replayTimeline{2: newReplayTimeline(t0, nil)}).
+		recomputed()
+	assert.InDelta(t, 100+48*testMiningBase+10, recomputed.BalanceSolo, 0.000001)
+	assert.InDelta(t, 48*testMiningBase/4, recomputed.BalanceT0, 0.000001)
+	assert.InDelta(t, 48*testMiningBase/4, recomputed.BalanceForT0, 0.000001)
+	assert.InDelta(t, recomputed.BalanceSolo+recomputed.BalanceT0, recomputed.BalanceTotalStandard, 0.000001)
+	assert.EqualValues(t, 10, recomputed.BalanceSoloPendingApplied)
+	assert.True(t, recomputed.BalanceLastUpdatedAt.Equal(*timeDelta(48 * stdlibtime.Hour).Time))
+
+	var diffLog bytes.Buffer
+	dr := newDryRun(&diffLog)
+	dr.recordMiningProgress([]*user{current}, []any{[]*recomputedUser{recomputed}}, nil)
+	diffs := decodeDryRunDiffs(t, &diffLog)
+	require.Len(t, diffs, 1)
+	assert.Equal(t, "users:1", diffs[0].Key)
+	assert.EqualValues(t, 5, diffs[0].Fields["balance_solo"].Before)
+	assert.NotContains(t, diffs[0].Fields, "balance_solo_pending_applied")
+}
+
+func TestRecomputeReplayReferralTiers(t *testing.T) {
+	t.Parallel()
+
+	usr := new(model.User)
+	usr.ID = 1
+	usr.MiningSessionSoloStartedAt = timeDelta(-stdlibtime.Hour)
+	usr.MiningSessionSoloEndedAt = timeDelta(71 * stdlibtime.Hour)
+	usr.BalanceLastUpdatedAt = testTime
+	usr.BalanceSolo = 100
+	current := newUser()
+	current.CreatedAt = nil // So that the base mining rate doesn't decrease over time.
+	current.ID = 1
+	current.MiningSessionSoloStartedAt = timeDelta(-stdlibtime.Hour)
+	current.MiningSessionSoloEndedAt = timeDelta(71 * stdlibtime.Hour)
+	current.referralTiers = model.ReferralTiers{{Balance: 50, ActiveReferrals: 10}}
+
+	recomputed := newReplayTimeline(current, []*dwh.HistorySnapshot{{CreatedAt: testTime, User: usr}}).
+		replay(timeDelta(24*stdlibtime.Hour), stdlibtime.Hour, nil).
+		recomputed()
+	assert.InDelta(t, 100+24*testMiningBase, recomputed.BalanceSolo, 0.000001)
+	assert.InDelta(t, recomputed.BalanceSolo+50, recomputed.BalanceTotalStandard, 0.000001)
+	assert.EqualValues(t, 50, current.referralTiers[0].Balance)
+}
+
+func TestRecomputeTimeline(t *testing.T) {
+	t.Parallel()
+
+	observe := func(startedAt, endedAt, resurrectedAt *time.Time) *dwh.HistorySnapshot {
+		usr := new(model.User)
+		usr.MiningSessionSoloStartedAt, usr.MiningSessionSoloEndedAt, usr.ResurrectSoloUsedAt = startedAt, endedAt, resurrectedAt
+
+		return &dwh.HistorySnapshot{CreatedAt: startedAt, User: usr}
+	}
+	tl := newReplayTimeline(nil, []*dwh.HistorySnapshot{
+		observe(timeDelta(0), timeDelta(24*stdlibtime.Hour), nil),
+		observe(timeDelta(0), timeDelta(48*stdlibtime.Hour), nil),
+		observe(timeDelta(72*stdlibtime.Hour), timeDelta(96*stdlibtime.Hour), timeDelta(73*stdlibtime.Hour)),
+	})
+	require.Len(t, tl.sessions, 2)
+	assert.True(t, tl.sessions[0].endedAt.Equal(*timeDelta(48 * stdlibtime.Hour).Time))
+	assert.False(t, tl.sessions[0].resurrected)
+	assert.True(t, tl.sessions[1].resurrected)
+	assert.Nil(t, tl.session(timeDelta(0)))
+	assert.Equal(t, tl.sessions[0], tl.session(timeDelta(72*stdlibtime.Hour)))
+	assert.Equal(t, tl.sessions[1], tl.session(timeDelta(73*stdlibtime.Hour)))
+
+	instants := tl.instants(timeDelta(96*stdlibtime.Hour), 24*stdlibtime.Hour)
+	require.Len(t, instants, 7)
+	assert.True(t, instants[2].Equal(*timeDelta(48*stdlibtime.Hour - stdlibtime.Nanosecond).Time))
+	assert.True(t, instants[6].Equal(*timeDelta(96 * stdlibtime.Hour).Time))
+
+	usr := newUser()
+	usr.MiningSessionSoloStartedAt = timeDelta(0)
+	usr.replaySession(tl.session(timeDelta(73 * stdlibtime.Hour)))
+	assert.True(t, usr.MiningSessionSoloStartedAt.Equal(*timeDelta(72 * stdlibtime.Hour).Time))
+	assert.True(t, usr.ResurrectSoloUsedAt.After(*timeDelta(96 * stdlibtime.Hour).Time))
+	usr.replaySession(tl.session(timeDelta(stdlibtime.Hour)))
+	assert.True(t, usr.MiningSessionSoloStartedAt.Equal(*timeDelta(72 * stdlibtime.Hour).Time))
+}
@@ -0,0 +1,206 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package miner
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+
+	dwh "github.com/ice-blockchain/freezer/bookkeeper/storage"
+	"github.com/ice-blockchain/freezer/model"
+	"github.com/ice-blockchain/freezer/tokenomics"
+	"github.com/ice-blockchain/wintr/connectors/storage/v3"
+)
+
+// The deeper referral tiers, the optional ones after T2, are mined like T1 & T2 are: with their configured rate, for each active referral,
+// while mining. And, like T0, they're slashed while not mining and resurrected with the solo balance, so they have no pending balances.
+
+func (u *user) mineReferralTiers(baseMiningRate, elapsedTimeFraction float64) (mintedAmount float64) {
+	for ix, tier := range u.referralTiers {
+		if tier.ActiveReferrals < 0 {
+			tier.ActiveReferrals = 0
+		}
+		if ix >= len(cfg.ReferralBonusMiningRates.Deeper) {
+			continue
+		}
+		rate := float64(cfg.ReferralBonusMiningRates.Deeper[ix]) * float64(tier.ActiveReferrals) * baseMiningRate * elapsedTimeFraction / 100
+		tier.Balance += rate
+		mintedAmount += rate
+		u.recordLedger(dwh.ReferralTierLedgerReason(model.FirstDeeperReferralTier+ix), rate)
+	}
+
+	return mintedAmount
+}
+
+func (u *user) resetReferralTiersBalances() (hadBalance bool) {
+	for _, tier := range u.referralTiers {
+		hadBalance = hadBalance || tier.Balance > 0
+		tier.Balance = 0
+	}
+
+	return hadBalance
+}
+
+func (u *user) startSlashingReferralTiers(miningSessionRatio float64) {
+	for _, tier := range u.referralTiers {
+		if tier.SlashingRate == 0 {
+			tier.SlashingRate = tier.Balance / float64(cfg.SlashingDaysCount) / miningSessionRatio
+		}
+	}
+}
+
+func (u *user) slashReferralTiers(elapsedTimeFraction float64) (slashedAmount float64) {
+	for _, tier := range u.referralTiers {
+		tier.Balance -= tier.SlashingRate * elapsedTimeFraction
+		slashedAmount += tier.SlashingRate * elapsedTimeFraction
+		if tier.Balance < 0 {
+			tier.Balance = 0
+		}
+	}
+
+	return slashedAmount
+}
+
+func (u *user) resurrectReferralTiers(resurrectDelta float64) (mintedAmount float64) {
+	for _, tier := range u.referralTiers {
+		tier.Balance += tier.SlashingRate * resurrectDelta
+		mintedAmount += tier.SlashingRate * resurrectDelta
+	}
+	u.stopSlashingReferralTiers()
+
+	return mintedAmount
+}
+
+func (u *user) stopSlashingReferralTiers() {
+	for _, tier := range u.referralTiers {
+		tier.SlashingRate = 0
+	}
+}
+
+// The active referrals of the tiers, T1's first, for model.ActiveReferralsIncrements.
+func (u *user) activeReferrals() []int32 {
+	activeReferrals := append(make([]int32, 0, 2+len(u.referralTiers)), u.ActiveT1Referrals, u.ActiveT2Referrals) //nolint:gomnd,mnd // T1 & T2.
+	for _, tier := range u.referralTiers {
+		activeReferrals = append(activeReferrals, tier.ActiveReferrals)
+	}
+
+	return activeReferrals
+}
+
+func newReferralTiersBatch(batchSize int64) *referralTiersBatch {
+	return &referralTiersBatch{
+		ancestors:                 make(map[int64][]int64, batchSize),
+		updated:                   make(map[int64]model.ReferralTiers, batchSize),
+		activeReferralsIncrements: make([]map[int64]int64, cfg.DeeperReferralTiers()),
+	}
+}
+
+// Fetches the deeper referral tiers of the users, and the ancestors, above the T-1s, that the users count for.
+func (t *referralTiersBatch) load(ctx context.Context, db storage.DB, usrs []*user, t0Referrals map[int64]*referral) error {
+	tiers := cfg.DeeperReferralTiers()
+	if tiers == 0 {
+		return nil
+	}
+	ids, tMinus1IDs := make([]int64, 0, len(usrs)), make([]int64, 0, 2*len(usrs)) //nolint:gomnd,mnd // Theirs and their new T0s' ones.
+	for _, usr := range usrs {
+		if usr.UserID == "" {
+			continue
+		}
+		ids = append(ids, usr.ID)
+		if usr.IDTMinus1 != 0 {
+			tMinus1IDs = append(tMinus1IDs, abs(usr.IDTMinus1))
+		}
+	}
+	for _, ref := range t0Referrals {
+		if ref != nil && ref.IDT0 != 0 {
+			tMinus1IDs = append(tMinus1IDs, abs(ref.IDT0))
+		}
+	}
+	referralTiers, err := tokenomics.GetReferralTiers(ctx, db, tiers, ids...)
+	if err != nil {
+		return errors.Wrap(err, "failed to GetReferralTiers")
+	}
+	ancestors, err := tokenomics.GetReferralAncestors(ctx, db, tiers, tMinus1IDs...)
+	if err != nil {
+		return errors.Wrap(err, "failed to GetReferralAncestors")
+	}
+	for id, idAncestors := range ancestors {
+		t.ancestors[id] = idAncestors
+	}
+	for _, usr := range usrs {
+		usr.referralTiers = referralTiers[usr.ID]
+	}
+
+	return nil
+}
+
+// The ancestors of a user, with the provided T0 & T-1, that model.ActiveReferralsIncrements expects.
+func (t *referralTiersBatch) ancestorsOf(idT0, idTMinus1 int64) []int64 {
+	idTMinus1 = abs(idTMinus1)
+
+	return append([]int64{abs(idT0), idTMinus1}, t.ancestors[idTMinus1]...)
+}
+
+// Accumulates the increments of the active referrals of the deeper tiers of the ancestors of a user, with the provided T0 & T-1.
+func (t *referralTiersBatch) incrementActiveReferrals(idT0, idTMinus1 int64, activeReferrals []int32, active bool, sign int64) {
+	if len(t.activeReferralsIncrements) == 0 || idT0 == 0 {
+		return
+	}
+	increments := model.ActiveReferralsIncrements(t.ancestorsOf(idT0, idTMinus1), activeReferrals, active, sign, len(t.activeReferralsIncrements))
+	for ix, values := range increments {
+		for id, value := range values {
+			if t.activeReferralsIncrements[ix] == nil {
+				t.activeReferralsIncrements[ix] = make(map[int64]int64, len(values))
+			}
+			t.activeReferralsIncrements[ix][id] += value
+		}
+	}
+}
+
+func (t *referralTiersBatch) recordMined(updatedUser *user) {
+	if len(updatedUser.referralTiers) > 0 {
+		t.updated[updatedUser.ID] = updatedUser.referralTiers
+	}
+}
+
+func (t *referralTiersBatch) len() (count int) {
+	count = len(t.updated)
+	for _, values := range t.activeReferralsIncrements {
+		count += len(values)
+	}
+
+	return count
+}
+
+func (t *referralTiersBatch) persist(ctx context.Context, pipeliner redis.Pipeliner) error {
+	for id, referralTiers := range t.updated {
+		if err := pipeliner.HSet(ctx, model.SerializedUsersKey(id), referralTiers.SerializeValue()...).Err(); err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrap(tokenomics.IncrementActiveReferrals(ctx, pipeliner, t.activeReferralsIncrements), "failed to IncrementActiveReferrals")
+}
+
+func (t *referralTiersBatch) dryRunIncrements() []*dryRunIncrements {
+	increments := make([]*dryRunIncrements, 0, len(t.activeReferralsIncrements))
+	for ix, values := range t.activeReferralsIncrements {
+		increments = append(increments, &dryRunIncrements{field: model.ActiveReferralsField(model.FirstDeeperReferralTier + ix), sign: 1, values: values})
+	}
+
+	return increments
+}
+
+func (t *referralTiersBatch) reset() {
+	for k := range t.ancestors {
+		delete(t.ancestors, k)
+	}
+	for k := range t.updated {
+		delete(t.updated, k)
+	}
+	for ix := range t.activeReferralsIncrements {
+		t.activeReferralsIncrements[ix] = nil
+	}
+}
@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package miner
+
+import (
+	"testing"
+	stdlibtime "time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dwh "github.com/ice-blockchain/freezer/bookkeeper/storage"
+	"github.com/ice-blockchain/freezer/model"
+)
+
+//nolint:paralleltest // It changes the global config.
+func TestMineReferralTiers(t *testing.T) {
+	deeper := cfg.ReferralBonusMiningRates.Deeper
+	cfg.ReferralBonusMiningRates.Deeper = []uint32{1}
+	t.Cleanup(func() { cfg.ReferralBonusMiningRates.Deeper = deeper })
+
+	t.Run("Mining", func(t *testing.T) {
+		m := newUser()
+		m.CreatedAt = nil
+		m.referralTiers = model.ReferralTiers{{ActiveReferrals: 2}}
+
+		updated, _, _, _, _ := mine(testTime, m, nil, nil) //nolint:dogsled // We only care about the tiers.
+		require.NotNil(t, updated)
+		require.Len(t, updated.referralTiers, 1)
+		assert.InDelta(t, 2*testMiningBase/100., updated.referralTiers[0].Balance, 0.000001)
+		assert.InDelta(t, updated.BalanceSolo+updated.referralTiers[0].Balance, updated.BalanceTotalStandard, 0.000001)
+		assert.InDelta(t, updated.referralTiers[0].Balance, updated.ledger[dwh.ReferralTierLedgerReason(3)], 0.000001)
+		assert.Zero(t, m.referralTiers[0].Balance)
+	})
+
+	t.Run("Slashing", func(t *testing.T) {
+		m := newUser()
+		m.CreatedAt = nil
+		m.BalanceLastUpdatedAt = timeDelta(-stdlibtime.Hour)
+		m.MiningSessionSoloStartedAt = timeDelta(-25 * stdlibtime.Hour)
+		m.MiningSessionSoloEndedAt = timeDelta(-stdlibtime.Hour)
+		m.BalanceSolo, m.BalanceTotalStandard = 1440, 1680
+		m.referralTiers = model.ReferralTiers{{ActiveReferrals: 2, Balance: 240}}
+
+		updated, _, _, _, _ := mine(testTime, m, nil, nil) //nolint:dogsled // We only care about the tiers.
+		require.NotNil(t, updated)
+		assert.EqualValues(t, 1, updated.referralTiers[0].SlashingRate)
+		assert.EqualValues(t, 239, updated.referralTiers[0].Balance)
+	})
+}
+
+func TestReferralTiersBatchIncrementActiveReferrals(t *testing.T) {
+	t.Parallel()
+
+	batch := &referralTiersBatch{
+		ancestors:                 map[int64][]int64{testIDTMinus1: {70, 71}},
+		updated:                   make(map[int64]model.ReferralTiers),
+		activeReferralsIncrements: make([]map[int64]int64, 2),
+	}
+	batch.incrementActiveReferrals(-testIDT0, testIDTMinus1, []int32{5, 1}, true, 1)
+	assert.Equal(t, []map[int64]int64{
+		{testIDT0: 1, testIDTMinus1: 5, 70: 1},
+		{testIDTMinus1: 1, 70: 5, 71: 1},
+	}, batch.activeReferralsIncrements)
+	assert.Equal(t, 6, batch.len())
+
+	batch.incrementActiveReferrals(testIDT0, testIDTMinus1, nil, true, -1)
+	assert.Equal(t, []map[int64]int64{
+		{testIDT0: 1, testIDTMinus1: 5, 70: 0},
+		{testIDTMinus1: 1, 70: 5, 71: 0},
+	}, batch.activeReferralsIncrements)
+
+	batch.reset()
+	assert.Zero(t, batch.len())
+	assert.Empty(t, batch.ancestors)
+}
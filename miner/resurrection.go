@@ -3,6 +3,7 @@
 package miner
 
 import (
+	dwh "github.com/ice-blockchain/freezer/bookkeeper/storage"
 	"github.com/ice-blockchain/freezer/tokenomics"
 	"github.com/ice-blockchain/wintr/time"
 )
@@ -19,8 +20,11 @@ func resurrect(now *time.Time, usr *user, t0Ref, tMinus1Ref *referral) (pendingR
 		usr.BalanceSolo += usr.SlashingRateSolo * resurrectDelta
 		usr.BalanceT0 += usr.SlashingRateT0 * resurrectDelta
 		mintedAmount := (usr.SlashingRateSolo + usr.SlashingRateT0) * resurrectDelta
+		mintedAmount += usr.resurrectReferralTiers(resurrectDelta)
 		mintedStandard, mintedPreStaking := tokenomics.ApplyPreStaking(mintedAmount, usr.PreStakingAllocation, usr.PreStakingBonus)
 		usr.BalanceTotalMinted += mintedStandard + mintedPreStaking
+		usr.recordLedger(dwh.ResurrectionLedgerReason, mintedAmount)
+		usr.recordLedgerPreStakingBonus(mintedAmount)
 
 		usr.SlashingRateSolo, usr.SlashingRateT0 = 0, 0
 		usr.ResurrectSoloUsedAt = now
@@ -66,6 +70,7 @@ func resurrect(now *time.Time, usr *user, t0Ref, tMinus1Ref *referral) (pendingR
 
 	if usr.MiningSessionSoloEndedAt.After(*now.Time) {
 		usr.SlashingRateSolo, usr.SlashingRateT0 = 0, 0
+		usr.stopSlashingReferralTiers()
 	}
 	if usr.SlashingRateForT0 > 0 && (t0Ref == nil || t0Ref.MiningSessionSoloEndedAt.IsNil() || (t0Ref.MiningSessionSoloEndedAt.After(*now.Time) && usr.MiningSessionSoloEndedAt.After(*now.Time))) {
 		usr.SlashingRateForT0 = 0
@@ -0,0 +1,462 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package miner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	stdlibtime "time"
+
+	"github.com/bsm/redislock"
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ice-blockchain/wintr/log"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+func newReplicaID() string {
+	hostname, err := os.Hostname()
+	log.Panic(errors.Wrap(err, "failed to get hostname"))
+
+	return fmt.Sprintf("%v:%v:%v", hostname, os.Getpid(), time.Now().UnixNano())
+}
+
+func (m *miner) startPartition(ctx context.Context, number int64, lease *redislock.Lock) {
+	now := time.Now()
+	partitionCtx, cancel := context.WithCancel(ctx)
+	p := &partition{
+		lease:  lease,
+		cancel: cancel,
+		number: number,
+		mx:     new(sync.RWMutex),
+		progress: &partitionProgress{
+			OwnedSince: now,
+			Owner:      m.replicaID,
+			Partition:  number,
+		},
+	}
+	m.partitionsMX.Lock()
+	m.partitions[number] = p
+	m.partitionsMX.Unlock()
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer cancel()
+		m.mine(partitionCtx, p)
+		m.partitionsMX.Lock()
+		delete(m.partitions, number)
+		m.partitionsMX.Unlock()
+		if p.lease != nil {
+			reqCtx, reqCancel := context.WithTimeout(context.Background(), requestDeadline)
+			if err := p.lease.Release(reqCtx); err != nil && !errors.Is(err, redislock.ErrLockNotHeld) {
+				log.Error(errors.Wrapf(err, "failed to release the lease of partition %v", number))
+			}
+			reqCancel()
+		}
+	}()
+}
+
+func (p *partition) reportProgress(iteration uint64, batchNumber int64) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	p.progress.Iteration = iteration
+	p.progress.BatchNumber = batchNumber
+	p.progress.LastBatchProcessedAt = time.Now()
+}
+
+func (m *miner) partitionsProgress() []*partitionProgress {
+	m.partitionsMX.RLock()
+	defer m.partitionsMX.RUnlock()
+
+	progress := make([]*partitionProgress, 0, len(m.partitions))
+	for _, p := range m.partitions {
+		p.mx.RLock()
+		snapshot := *p.progress
+		p.mx.RUnlock()
+		progress = append(progress, &snapshot)
+	}
+	sort.SliceStable(progress, func(ii, jj int) bool { return progress[ii].Partition < progress[jj].Partition })
+
+	return progress
+}
+
+func (m *miner) checkPartitionsHealth(progress []*partitionProgress) error {
+	if cfg.Sharding.StalledAfter == 0 {
+		return nil
+	}
+	now := time.Now()
+	for _, p := range progress {
+		lastProgressAt := p.OwnedSince
+		if !p.LastBatchProcessedAt.IsNil() {
+			lastProgressAt = p.LastBatchProcessedAt
+		}
+		if now.Sub(*lastProgressAt.Time) > cfg.Sharding.StalledAfter {
+			return errors.Errorf("[health-check] partition %v stalled at iteration %v, batch %v, since %v", p.Partition, p.Iteration, p.BatchNumber, lastProgressAt)
+		}
+	}
+
+	return nil
+}
+
+// Every replica heartbeats and then (re)claims the partitions assigned to it, while the leader keeps the assignments in line with the live replicas.
+func (m *miner) startRebalancing(ctx context.Context) {
+	ticker := stdlibtime.NewTicker(cfg.Sharding.RebalanceInterval)
+	defer ticker.Stop()
+	defer m.leave()
+
+	for {
+		reqCtx, cancel := context.WithTimeout(ctx, requestDeadline)
+		log.Error(errors.Wrap(m.rebalance(reqCtx), "failed to rebalance miner partitions"))
+		cancel()
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *miner) rebalance(ctx context.Context) error {
+	if err := m.heartbeat(ctx); err != nil {
+		return err
+	}
+	if err := m.electLeader(ctx); err != nil {
+		return err
+	}
+	if m.leader.Load() {
+		if err := m.assignPartitions(ctx); err != nil {
+			return err
+		}
+	}
+
+	return m.claimPartitions(ctx)
+}
+
+func (m *miner) heartbeat(ctx context.Context) error {
+	now := time.Now()
+	_, err := m.db.TxPipelined(ctx, func(pipeliner redis.Pipeliner) error {
+		if err := pipeliner.ZAdd(ctx, minerReplicasKey, redis.Z{Score: float64(now.Add(cfg.Sharding.LeaseDuration).UnixNano()), Member: m.replicaID}).Err(); err != nil {
+			return err
+		}
+
+		return pipeliner.ZRemRangeByScore(ctx, minerReplicasKey, "-inf", strconv.FormatInt(now.UnixNano(), 10)).Err()
+	})
+
+	return errors.Wrapf(err, "failed to heartbeat replica %v", m.replicaID)
+}
+
+func (m *miner) electLeader(ctx context.Context) error {
+	if m.leaderLease != nil {
+		err := m.leaderLease.Refresh(ctx, cfg.Sharding.LeaseDuration, nil)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, redislock.ErrNotObtained) {
+			return errors.Wrap(err, "failed to refresh the sharding leader lease")
+		}
+		log.Info(fmt.Sprintf("replica %v is no longer the miner sharding leader", m.replicaID))
+		m.leaderLease = nil
+		m.leader.Store(false)
+	}
+	lease, err := redislock.Obtain(ctx, m.db, minerShardingLeaderLockKey, cfg.Sharding.LeaseDuration, &redislock.Options{RetryStrategy: redislock.NoRetry()})
+	if err != nil {
+		if errors.Is(err, redislock.ErrNotObtained) {
+			return nil
+		}
+
+		return errors.Wrap(err, "failed to obtain the sharding leader lease")
+	}
+	log.Info(fmt.Sprintf("replica %v is the miner sharding leader", m.replicaID))
+	m.leaderLease = lease
+	m.leader.Store(true)
+
+	return nil
+}
+
+func (m *miner) assignPartitions(ctx context.Context) error {
+	replicas, err := m.db.ZRangeByScore(ctx, minerReplicasKey, &redis.ZRangeBy{Min: strconv.FormatInt(time.Now().UnixNano(), 10), Max: "+inf"}).Result()
+	if err != nil {
+		return errors.Wrap(err, "failed to get the live miner replicas")
+	}
+	if len(replicas) == 0 {
+		return nil
+	}
+	current, err := m.db.HGetAll(ctx, minerPartitionAssignmentsKey).Result()
+	if err != nil {
+		return errors.Wrap(err, "failed to get the miner partition assignments")
+	}
+	owners := make([]string, cfg.Workers)
+	for number := range owners {
+		owners[number] = current[strconv.Itoa(number)]
+	}
+	values := make([]any, 0, 2*cfg.Workers)
+	for number, owner := range rebalancePartitions(owners, replicas) {
+		if owner != owners[number] {
+			log.Info(fmt.Sprintf("miner partition %v moved from `%v` to `%v`", number, owners[number], owner))
+			values = append(values, strconv.Itoa(number), owner)
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	return errors.Wrap(m.db.HSet(ctx, minerPartitionAssignmentsKey, values...).Err(), "failed to update the miner partition assignments")
+}
+
+// Spreads the partitions evenly across the replicas, keeping as many of them as possible with their current owner,
+// so that a replica joining or dying only moves the partitions it has to.
+func rebalancePartitions(owners, replicas []string) []string {
+	owned := make(map[string]int, len(replicas))
+	for _, owner := range owners {
+		owned[owner]++
+	}
+	replicas = append(make([]string, 0, len(replicas)), replicas...)
+	sort.SliceStable(replicas, func(ii, jj int) bool { // The remainder goes to the ones owning the most already.
+		if owned[replicas[ii]] != owned[replicas[jj]] {
+			return owned[replicas[ii]] > owned[replicas[jj]]
+		}
+
+		return replicas[ii] < replicas[jj]
+	})
+	limits, counts := make(map[string]int, len(replicas)), make(map[string]int, len(replicas))
+	for ix, replica := range replicas {
+		limits[replica] = len(owners) / len(replicas)
+		if ix < len(owners)%len(replicas) {
+			limits[replica]++
+		}
+	}
+	assignments := make([]string, len(owners))
+	for number, owner := range owners {
+		if limit, alive := limits[owner]; alive && counts[owner] < limit {
+			assignments[number] = owner
+			counts[owner]++
+		}
+	}
+	ix := 0
+	for number := range assignments {
+		if assignments[number] != "" {
+			continue
+		}
+		for counts[replicas[ix]] >= limits[replicas[ix]] {
+			ix++
+		}
+		assignments[number] = replicas[ix]
+		counts[replicas[ix]]++
+	}
+
+	return assignments
+}
+
+func (m *miner) claimPartitions(ctx context.Context) error {
+	assignments, err := m.db.HGetAll(ctx, minerPartitionAssignmentsKey).Result()
+	if err != nil {
+		return errors.Wrap(err, "failed to get the miner partition assignments")
+	}
+	var mErr *multierror.Error
+	for number := int64(0); number < cfg.Workers; number++ {
+		assigned := assignments[strconv.FormatInt(number, 10)] == m.replicaID
+		m.partitionsMX.RLock()
+		p := m.partitions[number]
+		m.partitionsMX.RUnlock()
+		switch {
+		case p != nil && !assigned:
+			p.cancel()
+		case p != nil:
+			if rErr := p.lease.Refresh(ctx, cfg.Sharding.LeaseDuration, nil); rErr != nil {
+				if errors.Is(rErr, redislock.ErrNotObtained) {
+					log.Error(errors.Wrapf(errPartitionLeaseLost, "partition %v", number))
+					p.cancel()
+				} else {
+					mErr = multierror.Append(mErr, errors.Wrapf(rErr, "failed to refresh the lease of partition %v", number))
+				}
+			}
+		case assigned:
+			lease, oErr := redislock.Obtain(ctx, m.db, partitionLeaseKey(number), cfg.Sharding.LeaseDuration, &redislock.Options{RetryStrategy: redislock.NoRetry()}) //nolint:lll // .
+			if oErr != nil {
+				if !errors.Is(oErr, redislock.ErrNotObtained) { // Otherwise, the previous owner didn't let go of it yet.
+					mErr = multierror.Append(mErr, errors.Wrapf(oErr, "failed to obtain the lease of partition %v", number))
+				}
+
+				continue
+			}
+			log.Info(fmt.Sprintf("replica %v started mining partition %v", m.replicaID, number))
+			m.startPartition(ctx, number, lease)
+		}
+	}
+
+	return mErr.ErrorOrNil() //nolint:wrapcheck // Not needed.
+}
+
+func (m *miner) leave() {
+	ctx, cancel := context.WithTimeout(context.Background(), requestDeadline)
+	defer cancel()
+	log.Error(errors.Wrapf(m.db.ZRem(ctx, minerReplicasKey, m.replicaID).Err(), "failed to remove replica %v", m.replicaID))
+	if m.leaderLease != nil {
+		if err := m.leaderLease.Release(ctx); err != nil && !errors.Is(err, redislock.ErrLockNotHeld) {
+			log.Error(errors.Wrap(err, "failed to release the sharding leader lease"))
+		}
+		m.leader.Store(false)
+	}
+}
+
+func partitionLeaseKey(number int64) string {
+	return fmt.Sprintf("%v:%v", minerPartitionLeaseKeyPrefix, number)
+}
+
+// Fences the writes of a batch with the lease of its partition: they're applied only if this replica still holds it,
+// so two replicas never update the same users in the same iteration, even while a partition is moving between them.
+func (m *miner) persistMiningProgress(
+	ctx context.Context, p *partition, transactional bool, persist func(pipeliner redis.Pipeliner) error,
+) ([]redis.Cmder, error) {
+	if p.lease == nil {
+		if transactional {
+			return m.db.TxPipelined(ctx, persist) //nolint:wrapcheck // Wrapped by the caller.
+		}
+
+		return m.db.Pipelined(ctx, persist) //nolint:wrapcheck // Wrapped by the caller.
+	}
+	var responses []redis.Cmder
+	err := retryFencedWrite(func() error {
+		return m.db.Watch(ctx, func(tx *redis.Tx) error { //nolint:wrapcheck // Wrapped by the caller.
+			if err := checkPartitionLease(ctx, tx, p); err != nil {
+				return err
+			}
+			var err error
+			responses, err = tx.TxPipelined(ctx, persist)
+
+			return err //nolint:wrapcheck // Wrapped by the caller.
+		}, p.lease.Key())
+	})
+
+	return responses, err
+}
+
+// The lease key is also touched when this replica refreshes the lease (PEXPIRE), which aborts the watching transaction too,
+// so an aborted transaction only means that the token has to be checked again, not that the lease is lost.
+func retryFencedWrite(write func() error) (err error) {
+	for attempt := 0; attempt < fencedWriteMaxAttempts; attempt++ {
+		if err = write(); !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+
+	return errors.Wrapf(err, "lease key kept changing for %v attempts", fencedWriteMaxAttempts)
+}
+
+// Checks that this replica still holds the lease of the partition, before anything about its batch leaves the miner.
+func checkPartitionLease(ctx context.Context, db redis.Cmdable, p *partition) error {
+	if p.lease == nil {
+		return nil
+	}
+	token, err := db.Get(ctx, p.lease.Key()).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return errors.Wrapf(err, "failed to get the lease of partition %v", p.number)
+	}
+	if token != p.lease.Token() {
+		return errors.Wrapf(errPartitionLeaseLost, "partition %v", p.number)
+	}
+
+	return nil
+}
+
+func (m *miner) signalCoinDistributionCollectionStarted(ctx context.Context, partitionNumber int64) {
+	m.signalCoinDistributionCollection(ctx, m.coinDistributionStartedSignaler, coinDistributionCollectionStartedPartitionsKey, partitionNumber)
+}
+
+func (m *miner) signalCoinDistributionCollectionEnded(ctx context.Context, partitionNumber int64) {
+	m.signalCoinDistributionCollection(ctx, m.coinDistributionEndedSignaler, coinDistributionCollectionEndedPartitionsKey, partitionNumber)
+}
+
+func (m *miner) signalCoinDistributionCollection(ctx context.Context, signaler chan<- struct{}, key string, partitionNumber int64) {
+	if m.dryRun != nil {
+		return
+	}
+	if !cfg.Sharding.Enabled {
+		signaler <- struct{}{}
+
+		return
+	}
+	for ctx.Err() == nil {
+		reqCtx, cancel := context.WithTimeout(ctx, requestDeadline)
+		err := m.db.SAdd(reqCtx, key, partitionNumber).Err()
+		cancel()
+		if err == nil {
+			return
+		}
+		log.Error(errors.Wrapf(err, "failed to signal `%v` for partition %v", key, partitionNumber))
+	}
+}
+
+// With sharding, the partitions signal the coin distribution collection cycle through redis and the leader relays them to its
+// coin distribution collection worker manager, which then sees all the partitions, just like it does when there's a single replica.
+func (m *miner) startRelayingCoinDistributionCollectionSignals(ctx context.Context) {
+	ticker := stdlibtime.NewTicker(stdlibtime.Second)
+	defer ticker.Stop()
+	relayedStarted, relayedEnded := make(map[string]struct{}, cfg.Workers), make(map[string]struct{}, cfg.Workers)
+
+	for {
+		select {
+		case <-ticker.C:
+			if !m.leader.Load() {
+				continue
+			}
+			reqCtx, cancel := context.WithTimeout(ctx, requestDeadline)
+			log.Error(errors.Wrap(m.relayCoinDistributionCollectionSignals(reqCtx, relayedStarted, relayedEnded),
+				"failed to relayCoinDistributionCollectionSignals"))
+			cancel()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *miner) relayCoinDistributionCollectionSignals(ctx context.Context, relayedStarted, relayedEnded map[string]struct{}) error {
+	started, err := m.db.SMembers(ctx, coinDistributionCollectionStartedPartitionsKey).Result()
+	if err != nil {
+		return errors.Wrapf(err, "failed to get `%v`", coinDistributionCollectionStartedPartitionsKey)
+	}
+	if len(started) == 0 {
+		clear(relayedStarted)
+		clear(relayedEnded)
+
+		return nil
+	}
+	ended, err := m.db.SMembers(ctx, coinDistributionCollectionEndedPartitionsKey).Result()
+	if err != nil {
+		return errors.Wrapf(err, "failed to get `%v`", coinDistributionCollectionEndedPartitionsKey)
+	}
+	relay := func(partitions []string, relayed map[string]struct{}, signaler chan<- struct{}) {
+		for _, partitionNumber := range partitions {
+			if _, alreadyRelayed := relayed[partitionNumber]; alreadyRelayed {
+				continue
+			}
+			select {
+			case signaler <- struct{}{}:
+				relayed[partitionNumber] = struct{}{}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+	relay(started, relayedStarted, m.coinDistributionStartedSignaler)
+	relay(ended, relayedEnded, m.coinDistributionEndedSignaler)
+
+	return errors.Wrap(ctx.Err(), "relaying interrupted")
+}
+
+func (m *miner) resetCoinDistributionCollectionSignals(ctx context.Context) error {
+	if !cfg.Sharding.Enabled {
+		return nil
+	}
+
+	return errors.Wrap(m.db.Del(ctx, coinDistributionCollectionStartedPartitionsKey, coinDistributionCollectionEndedPartitionsKey).Err(),
+		"failed to reset the coin distribution collection signals")
+}
@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package miner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebalancePartitions(t *testing.T) {
+	t.Parallel()
+
+	t.Run("First replica takes everything", func(t *testing.T) {
+		require.Equal(t, []string{"a", "a", "a", "a"}, rebalancePartitions(make([]string, 4), []string{"a"}))
+	})
+
+	t.Run("Joining replica takes only its share", func(t *testing.T) {
+		require.Equal(t, []string{"a", "a", "a", "b", "b"}, rebalancePartitions([]string{"a", "a", "a", "a", "a"}, []string{"b", "a"}))
+	})
+
+	t.Run("Dead replica's partitions are spread across the others", func(t *testing.T) {
+		owners := []string{"a", "b", "c", "a", "b", "c"}
+		require.Equal(t, []string{"a", "b", "a", "a", "b", "b"}, rebalancePartitions(owners, []string{"a", "b"}))
+	})
+
+	t.Run("Balanced assignments are kept", func(t *testing.T) {
+		owners := []string{"c", "b", "a", "c", "b", "a", "c"}
+		require.Equal(t, owners, rebalancePartitions(owners, []string{"a", "b", "c"}))
+	})
+
+	t.Run("More replicas than partitions", func(t *testing.T) {
+		require.Equal(t, []string{"b", "a"}, rebalancePartitions([]string{"b", ""}, []string{"c", "b", "a"}))
+	})
+}
+
+func TestRetryFencedWrite(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Own lease refresh aborts the transaction", func(t *testing.T) {
+		attempts := 0
+		require.NoError(t, retryFencedWrite(func() error {
+			if attempts++; attempts == 1 {
+				return redis.TxFailedErr
+			}
+
+			return nil
+		}))
+		require.Equal(t, 2, attempts)
+	})
+
+	t.Run("Lost lease is not retried", func(t *testing.T) {
+		attempts := 0
+		err := retryFencedWrite(func() error {
+			attempts++
+
+			return errors.Wrap(errPartitionLeaseLost, "partition 1")
+		})
+		require.ErrorIs(t, err, errPartitionLeaseLost)
+		require.Equal(t, 1, attempts)
+	})
+
+	t.Run("Lease key keeps changing", func(t *testing.T) {
+		attempts := 0
+		err := retryFencedWrite(func() error {
+			attempts++
+
+			return redis.TxFailedErr
+		})
+		require.ErrorIs(t, err, redis.TxFailedErr)
+		require.NotErrorIs(t, err, errPartitionLeaseLost)
+		require.Equal(t, fencedWriteMaxAttempts, attempts)
+	})
+
+	t.Run("Without sharding there's no lease to check", func(t *testing.T) {
+		require.NoError(t, checkPartitionLease(context.Background(), nil, &partition{number: 1}))
+	})
+}
@@ -0,0 +1,319 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package miner
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	stdlibtime "time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/freezer/model"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+type (
+	simulation struct {
+		usrs   map[int64]*user
+		csv    *csv.Writer
+		ids    []int64
+		events []*SimulationEvent
+	}
+)
+
+//nolint:gochecknoglobals // It's a constant.
+var simulationCSVHeader = []string{
+	"time", "id", "t0", "t_minus_1", "mining", "day_off_started",
+	"balance_solo", "balance_t0", "balance_t1", "balance_t2", "balance_for_t0", "balance_for_t_minus_1",
+	"balance_total_standard", "balance_total_pre_staking", "balance_total_minted", "balance_total_slashed",
+	"slashing_rate_solo", "slashing_rate_t0", "slashing_rate_for_t0", "slashing_rate_for_t_minus_1",
+	"active_t1_referrals", "active_t2_referrals",
+}
+
+// Simulate replays the scenario through the same mining functions the miner uses, stepping a virtual clock from its start to its end,
+// and writes the state of every user after every step as CSV to w.
+//
+// The scenario is replayed the way the miner and the tokenomics service would process it, except that:
+//   - the active referrals are recounted from the mining sessions at every step, instead of being incremented and decremented by events;
+//   - the free mining sessions (days off) aren't awarded when sessions are extended.
+func Simulate(scenario *SimulationScenario, w io.Writer) error {
+	step, err := scenario.validate()
+	if err != nil {
+		return err
+	}
+	sim := newSimulation(scenario, w)
+	if err = sim.csv.Write(simulationCSVHeader); err != nil {
+		return errors.Wrap(err, "failed to write csv header")
+	}
+	for now := scenario.Start; !now.After(scenario.End); now = now.Add(step) {
+		if err = sim.step(time.New(now)); err != nil {
+			return errors.Wrapf(err, "failed to simulate %v", now)
+		}
+	}
+	sim.csv.Flush()
+
+	return errors.Wrap(sim.csv.Error(), "failed to flush csv")
+}
+
+func (s *SimulationScenario) validate() (stdlibtime.Duration, error) {
+	step, err := stdlibtime.ParseDuration(s.Step)
+	if err != nil || step <= 0 {
+		return 0, errors.Wrapf(ErrInvalidSimulationScenario, "`step` has to be a positive duration, like `1h`, got %q", s.Step)
+	}
+	if s.Start.IsZero() || !s.End.After(s.Start) {
+		return 0, errors.Wrap(ErrInvalidSimulationScenario, "`end` has to be after `start`")
+	}
+	ids := make(map[int64]bool, len(s.Users))
+	for _, usr := range s.Users {
+		if usr.ID <= 0 || ids[usr.ID] {
+			return 0, errors.Wrapf(ErrInvalidSimulationScenario, "the user ids have to be positive and unique, got %v", usr.ID)
+		}
+		ids[usr.ID] = true
+	}
+	for _, usr := range s.Users {
+		if usr.CreatedAt != nil && usr.CreatedAt.After(s.Start) {
+			return 0, errors.Wrapf(ErrInvalidSimulationScenario, "the user %v has to be created before `start`", usr.ID)
+		}
+		if err = validateSimulatedReferral(ids, usr.ID, usr.IDT0); err != nil {
+			return 0, err
+		}
+		if err = validateSimulatedMiningBoostLevelIndex(usr.MiningBoostLevelIndex); err != nil {
+			return 0, err
+		}
+	}
+	for _, event := range s.Events {
+		if !ids[event.UserID] {
+			return 0, errors.Wrapf(ErrInvalidSimulationScenario, "event %v at %v is for the unknown user %v", event.Type, event.At, event.UserID)
+		}
+		switch event.Type {
+		case StartMiningSessionSimulationEventType, StopMiningSessionSimulationEventType, PreStakingSimulationEventType:
+		case MiningBoostSimulationEventType:
+			err = validateSimulatedMiningBoostLevelIndex(event.MiningBoostLevelIndex)
+		case ChangeT0SimulationEventType:
+			err = validateSimulatedReferral(ids, event.UserID, event.IDT0)
+		default:
+			err = errors.Wrapf(ErrInvalidSimulationScenario, "unknown event type %q", event.Type)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return step, nil
+}
+
+func validateSimulatedReferral(ids map[int64]bool, id, idT0 int64) error {
+	if idT0 != 0 && (idT0 == id || !ids[idT0]) {
+		return errors.Wrapf(ErrInvalidSimulationScenario, "the t0 %v of the user %v has to be another user", idT0, id)
+	}
+
+	return nil
+}
+
+func validateSimulatedMiningBoostLevelIndex(levelIndex *uint64) error {
+	if levelIndex != nil && *levelIndex >= uint64(len(*cfg.miningBoostLevels.Load())) {
+		return errors.Wrapf(ErrInvalidSimulationScenario, "there are only %v mining boost levels, got the index %v", len(*cfg.miningBoostLevels.Load()), *levelIndex)
+	}
+
+	return nil
+}
+
+func newSimulation(scenario *SimulationScenario, w io.Writer) *simulation {
+	sim := &simulation{
+		usrs:   make(map[int64]*user, len(scenario.Users)),
+		ids:    make([]int64, 0, len(scenario.Users)),
+		events: append(make([]*SimulationEvent, 0, len(scenario.Events)), scenario.Events...),
+		csv:    csv.NewWriter(w),
+	}
+	sort.SliceStable(sim.events, func(ii, jj int) bool { return sim.events[ii].At.Before(sim.events[jj].At) })
+	welcomeBonusApplied := model.FlexibleBool(true)
+	for _, simulatedUsr := range scenario.Users {
+		usr := new(user)
+		usr.ID = simulatedUsr.ID
+		usr.UserID = strconv.FormatInt(simulatedUsr.ID, 10)
+		usr.CreatedAt = time.New(scenario.Start)
+		if simulatedUsr.CreatedAt != nil {
+			usr.CreatedAt = time.New(*simulatedUsr.CreatedAt)
+		}
+		usr.IDT0 = simulatedUsr.IDT0
+		usr.PreStakingAllocation, usr.PreStakingBonus = simulatedUsr.PreStakingAllocation, simulatedUsr.PreStakingBonus
+		usr.MiningBoostLevelIndex = (*model.FlexibleUint64)(simulatedUsr.MiningBoostLevelIndex)
+		usr.WelcomeBonusV2Applied = &welcomeBonusApplied
+		sim.usrs[usr.ID] = usr
+		sim.ids = append(sim.ids, usr.ID)
+	}
+	for _, usr := range sim.usrs {
+		if t0 := sim.usrs[usr.IDT0]; t0 != nil {
+			usr.IDTMinus1 = t0.IDT0
+		}
+	}
+
+	return sim
+}
+
+// step does what the tokenomics service would have done until now and then what the miner does for each user in one iteration.
+func (s *simulation) step(now *time.Time) error {
+	for len(s.events) != 0 && !s.events[0].At.After(*now.Time) {
+		s.apply(s.events[0])
+		s.events = s.events[1:]
+	}
+	s.recountActiveReferrals(now)
+	refs := make(map[int64]*referral, len(s.usrs))
+	for id, usr := range s.usrs {
+		refs[id] = usr.referral()
+	}
+	pendingBalancesForT0, pendingBalancesForTMinus1 := make(map[int64]float64), make(map[int64]float64)
+	daysOff := make(map[int64]bool)
+	for _, id := range s.ids {
+		usr := s.usrs[id]
+		t0Ref, tMinus1Ref := refs[abs(usr.IDT0)], refs[abs(usr.IDTMinus1)]
+		updatedUser, _, _, pendingAmountForTMinus1, pendingAmountForT0 := mine(now, usr, t0Ref, tMinus1Ref)
+		if updatedUser == nil {
+			clonedUser := *usr
+			if updatedReferral := updateT0AndTMinus1ReferralsForUserHasNeverMined(&clonedUser); updatedReferral != nil {
+				usr.IDT0Field, usr.IDTMinus1Field = updatedReferral.IDT0Field, updatedReferral.IDTMinus1Field
+			}
+
+			continue
+		}
+		daysOff[id] = didANewDayOffJustStart(now, usr) != nil
+		if t0Ref != nil && usr.IDTMinus1 != t0Ref.IDT0 {
+			updatedUser.IDTMinus1 = t0Ref.IDT0
+		}
+		if tMinus1Ref != nil && pendingAmountForTMinus1 != 0 {
+			pendingBalancesForTMinus1[tMinus1Ref.ID] += pendingAmountForTMinus1
+		}
+		if t0Ref != nil && pendingAmountForT0 != 0 {
+			pendingBalancesForT0[t0Ref.ID] += pendingAmountForT0
+		}
+		usr.persist(&updatedUser.UpdatedUser)
+	}
+	for id, amount := range pendingBalancesForT0 {
+		s.usrs[id].BalanceT1Pending += amount
+	}
+	for id, amount := range pendingBalancesForTMinus1 {
+		s.usrs[id].BalanceT2Pending += amount
+	}
+
+	return s.write(now, daysOff)
+}
+
+func (s *simulation) apply(event *SimulationEvent) {
+	usr, now := s.usrs[event.UserID], time.New(event.At)
+	switch event.Type {
+	case StartMiningSessionSimulationEventType:
+		if usr.MiningSessionSoloEndedAt.IsNil() || usr.MiningSessionSoloEndedAt.Before(*now.Time) {
+			if event.Resurrect && !usr.MiningSessionSoloEndedAt.IsNil() && usr.ResurrectSoloUsedAt.IsNil() {
+				usr.ResurrectSoloUsedAt = time.New(stdlibtime.Date(3000, 0, 0, 0, 0, 0, 0, stdlibtime.UTC)) //nolint:gomnd,mnd // Like tokenomics does it.
+			}
+			usr.MiningSessionSoloPreviouslyEndedAt = usr.MiningSessionSoloEndedAt
+			usr.MiningSessionSoloStartedAt = now
+		}
+		usr.MiningSessionSoloLastStartedAt = now
+		usr.MiningSessionSoloEndedAt = time.New(now.Add(usr.maxMiningSessionDuration()))
+	case StopMiningSessionSimulationEventType:
+		if !usr.MiningSessionSoloEndedAt.IsNil() && usr.MiningSessionSoloEndedAt.After(*now.Time) {
+			usr.MiningSessionSoloEndedAt = now
+		}
+	case MiningBoostSimulationEventType:
+		usr.MiningBoostLevelIndex = (*model.FlexibleUint64)(event.MiningBoostLevelIndex)
+	case PreStakingSimulationEventType:
+		usr.PreStakingAllocation, usr.PreStakingBonus = event.PreStakingAllocation, event.PreStakingBonus
+	case ChangeT0SimulationEventType:
+		// Negative, like tokenomics marks them when the referral changes, so that the miner resets what the user had for the old ones.
+		usr.IDT0, usr.IDTMinus1 = -event.IDT0, 0
+		if t0 := s.usrs[event.IDT0]; t0 != nil {
+			usr.IDTMinus1 = -abs(t0.IDT0)
+		}
+	}
+}
+
+// recountActiveReferrals sets the active T1 & T2 referrals of every user based on the current referral tree.
+func (s *simulation) recountActiveReferrals(now *time.Time) {
+	for _, usr := range s.usrs {
+		usr.ActiveT1Referrals, usr.ActiveT2Referrals = 0, 0
+	}
+	for _, usr := range s.usrs {
+		t0 := s.usrs[abs(usr.IDT0)]
+		if t0 == nil || usr.MiningSessionSoloEndedAt.IsNil() || !usr.MiningSessionSoloEndedAt.After(*now.Time) {
+			continue
+		}
+		t0.ActiveT1Referrals++
+		if tMinus1 := s.usrs[abs(t0.IDT0)]; tMinus1 != nil {
+			tMinus1.ActiveT2Referrals++
+		}
+	}
+}
+
+func abs(id int64) int64 {
+	if id < 0 {
+		return -id
+	}
+
+	return id
+}
+
+func (s *simulation) write(now *time.Time, daysOff map[int64]bool) error {
+	formatFloat := func(val float64) string { return strconv.FormatFloat(val, 'f', -1, 64) }
+	for _, id := range s.ids {
+		usr := s.usrs[id]
+		mining := !usr.MiningSessionSoloEndedAt.IsNil() && usr.MiningSessionSoloEndedAt.After(*now.Time)
+		if err := s.csv.Write([]string{
+			now.Format(stdlibtime.RFC3339), strconv.FormatInt(id, 10), strconv.FormatInt(usr.IDT0, 10), strconv.FormatInt(usr.IDTMinus1, 10),
+			strconv.FormatBool(mining), strconv.FormatBool(daysOff[id]),
+			formatFloat(usr.BalanceSolo), formatFloat(usr.BalanceT0), formatFloat(usr.BalanceT1), formatFloat(usr.BalanceT2),
+			formatFloat(usr.BalanceForT0), formatFloat(usr.BalanceForTMinus1),
+			formatFloat(usr.BalanceTotalStandard), formatFloat(usr.BalanceTotalPreStaking), formatFloat(usr.BalanceTotalMinted), formatFloat(usr.BalanceTotalSlashed),
+			formatFloat(usr.SlashingRateSolo), formatFloat(usr.SlashingRateT0), formatFloat(usr.SlashingRateForT0), formatFloat(usr.SlashingRateForTMinus1),
+			fmt.Sprint(usr.ActiveT1Referrals), fmt.Sprint(usr.ActiveT2Referrals),
+		}); err != nil {
+			return errors.Wrapf(err, "failed to write the state of the user %v", id)
+		}
+	}
+
+	return nil
+}
+
+// referral is how the user is seen by its referrals, when the miner reads it as their T0 or T-1.
+func (u *user) referral() *referral {
+	ref := new(referral)
+	ref.ID, ref.UserID, ref.IDT0 = u.ID, u.UserID, u.IDT0
+	ref.KYCState = u.KYCState
+	ref.MiningBoostLevelIndex = u.MiningBoostLevelIndex
+	ref.MiningSessionSoloStartedAt, ref.MiningSessionSoloEndedAt = u.MiningSessionSoloStartedAt, u.MiningSessionSoloEndedAt
+	ref.MiningSessionSoloPreviouslyEndedAt = u.MiningSessionSoloPreviouslyEndedAt
+	ref.ResurrectSoloUsedAt = u.ResurrectSoloUsedAt
+	ref.BalanceTotalStandard = u.BalanceTotalStandard
+	ref.BalanceSolo, ref.BalanceT0, ref.BalanceT1, ref.BalanceT2 = u.BalanceSolo, u.BalanceT0, u.BalanceT1, u.BalanceT2
+	ref.PreStakingAllocation, ref.PreStakingBonus = u.PreStakingAllocation, u.PreStakingBonus
+
+	return ref
+}
+
+// persist updates the user the way the miner writes the updated user to its `users:` hash:
+// the nil fields and the empty `omitempty` ones aren't written, so they stay the same.
+func (u *user) persist(updatedUser *UpdatedUser) {
+	keepUnwrittenFields(reflect.ValueOf(&u.UpdatedUser).Elem(), reflect.ValueOf(updatedUser).Elem())
+	u.UpdatedUser = *updatedUser
+}
+
+func keepUnwrittenFields(before, after reflect.Value) {
+	for ix := 0; ix < after.NumField(); ix++ {
+		field := after.Field(ix)
+		if field.Kind() == reflect.Struct {
+			keepUnwrittenFields(before.Field(ix), field)
+
+			continue
+		}
+		omitEmpty := strings.HasSuffix(after.Type().Field(ix).Tag.Get("redis"), ",omitempty")
+		if field.IsZero() && (omitEmpty || field.Kind() == reflect.Pointer) {
+			field.Set(before.Field(ix))
+		}
+	}
+}
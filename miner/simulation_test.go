@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package miner
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	stdlibtime "time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testScenario() *SimulationScenario {
+	return &SimulationScenario{
+		Start: *testTime.Time,
+		End:   testTime.Add(2 * stdlibtime.Hour),
+		Step:  "1h",
+		Users: []*SimulatedUser{{ID: 1}, {ID: 2, IDT0: 1}},
+		Events: []*SimulationEvent{
+			{At: *testTime.Time, Type: StartMiningSessionSimulationEventType, UserID: 2},
+			{At: *testTime.Time, Type: StartMiningSessionSimulationEventType, UserID: 1},
+		},
+	}
+}
+
+func TestSimulationStep(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	sim := newSimulation(testScenario(), &out)
+	for hours := 0; hours <= 2; hours++ {
+		require.NoError(t, sim.step(timeDelta(stdlibtime.Duration(hours)*stdlibtime.Hour)))
+	}
+	t0, referral := sim.usrs[1], sim.usrs[2]
+	minedIn2Hours := cfg.BaseMiningRate(timeDelta(stdlibtime.Hour), testTime) + cfg.BaseMiningRate(timeDelta(2*stdlibtime.Hour), testTime)
+	require.InDelta(t, minedIn2Hours, t0.BalanceSolo, 0.000001)
+	require.InDelta(t, minedIn2Hours, referral.BalanceSolo, 0.000001)
+	require.InDelta(t, minedIn2Hours*25/100, referral.BalanceT0, 0.000001)
+	require.EqualValues(t, 1, t0.ActiveT1Referrals)
+	require.EqualValues(t, 0, referral.IDTMinus1)
+
+	sim.events = []*SimulationEvent{{At: testTime.Add(3 * stdlibtime.Hour), Type: StopMiningSessionSimulationEventType, UserID: 1}}
+	require.NoError(t, sim.step(timeDelta(3*stdlibtime.Hour)))
+	require.NoError(t, sim.step(timeDelta(4*stdlibtime.Hour)))
+	assert.Positive(t, t0.SlashingRateSolo)
+	assert.Less(t, t0.BalanceSolo, referral.BalanceSolo)
+	assert.EqualValues(t, 1, t0.ActiveT1Referrals)
+}
+
+func TestSimulate(t *testing.T) {
+	t.Parallel()
+
+	var out, again bytes.Buffer
+	require.NoError(t, Simulate(testScenario(), &out))
+	require.NoError(t, Simulate(testScenario(), &again))
+	require.Equal(t, out.String(), again.String())
+
+	rows, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 1+3*2)
+	require.Equal(t, simulationCSVHeader, rows[0])
+	require.Equal(t, []string{testTime.Add(2 * stdlibtime.Hour).Format(stdlibtime.RFC3339), "2", "1", "0", "true", "false"}, rows[6][:6])
+}
+
+func TestSimulationScenarioValidation(t *testing.T) {
+	t.Parallel()
+
+	for name, invalidate := range map[string]func(*SimulationScenario){
+		"invalid step":         func(s *SimulationScenario) { s.Step = "1 hour" },
+		"end before start":     func(s *SimulationScenario) { s.End = s.Start.Add(-stdlibtime.Hour) },
+		"duplicated user":      func(s *SimulationScenario) { s.Users = append(s.Users, &SimulatedUser{ID: 1}) },
+		"unknown t0":           func(s *SimulationScenario) { s.Users[1].IDT0 = 3 },
+		"own t0":               func(s *SimulationScenario) { s.Users[1].IDT0 = 2 },
+		"created after start":  func(s *SimulationScenario) { s.Users[0].CreatedAt = &s.End },
+		"unknown event user":   func(s *SimulationScenario) { s.Events[0].UserID = 3 },
+		"unknown event type":   func(s *SimulationScenario) { s.Events[0].Type = "claimExtraBonus" },
+		"unknown t0 in change": func(s *SimulationScenario) { s.Events[0].Type, s.Events[0].IDT0 = ChangeT0SimulationEventType, 3 },
+	} {
+		scenario := testScenario()
+		invalidate(scenario)
+		_, err := scenario.validate()
+		require.ErrorIs(t, err, ErrInvalidSimulationScenario, name)
+	}
+	_, err := testScenario().validate()
+	require.NoError(t, err)
+}
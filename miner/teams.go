@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package miner
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ice-blockchain/freezer/model"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+// The teams are listed, and sorted, for tokenomics.Repository.GetTeamEarnings: each user is (re)listed in the teams of its T0 every time
+// it's mined, or while its balance is synchronized, see shouldSynchronizeBalanceFunc, so that the ones who didn't mine in a while are listed too.
+
+func newTeamsBatch(batchSize int64) *teamsBatch {
+	return &teamsBatch{
+		t1:                make(map[int64][]redis.Z, batchSize),
+		miningT1:          make(map[int64][]redis.Z, batchSize),
+		t2:                make(map[int64][]redis.Z, batchSize),
+		t2ScoreIncrements: make(map[int64]map[string]float64, batchSize),
+	}
+}
+
+// Lists the user in the teams of its T0, and adds what it contributed to its T-1, since it was last mined, to the score of its T0 in the T-1's team.
+func (t *teamsBatch) record(now *time.Time, usr, updatedUser *user, t0Ref, tMinus1Ref *referral, synchronize bool) {
+	if t0Ref == nil || t0Ref.ID == 0 || (updatedUser == nil && !synchronize) {
+		return
+	}
+	current := updatedUser
+	if current == nil {
+		current = usr
+	}
+	balanceForT0 := current.BalanceForT0
+	if updatedUser == nil && usr.IDT0 < 0 {
+		balanceForT0 = 0 // It's reset when the new T0 is applied.
+	}
+	miningScore := balanceForT0
+	if !current.MiningSessionSoloEndedAt.IsNil() && current.MiningSessionSoloEndedAt.After(*now.Time) {
+		miningScore += miningTeamScoreOffset
+	}
+	member := usr.Key()
+	t.t1[t0Ref.ID] = append(t.t1[t0Ref.ID], redis.Z{Score: balanceForT0, Member: member})
+	t.miningT1[t0Ref.ID] = append(t.miningT1[t0Ref.ID], redis.Z{Score: miningScore, Member: member})
+	t.t2[t0Ref.ID] = append(t.t2[t0Ref.ID], redis.Z{Member: member})
+	if updatedUser == nil || tMinus1Ref == nil || tMinus1Ref.ID == 0 {
+		return
+	}
+	balanceForTMinus1 := usr.BalanceForTMinus1
+	if usr.IDT0 <= 0 || usr.IDTMinus1 <= 0 {
+		balanceForTMinus1 = 0 // It was reset, with the new T0 or T-1.
+	}
+	if increment := updatedUser.BalanceForTMinus1 - balanceForTMinus1; increment != 0 {
+		if t.t2ScoreIncrements[tMinus1Ref.ID] == nil {
+			t.t2ScoreIncrements[tMinus1Ref.ID] = make(map[string]float64)
+		}
+		t.t2ScoreIncrements[tMinus1Ref.ID][model.SerializedUsersKey(t0Ref.ID)] += increment
+	}
+}
+
+func (t *teamsBatch) len() (count int) {
+	for _, values := range t.t1 {
+		count += len(values)
+	}
+	for _, values := range t.t2ScoreIncrements {
+		count += len(values)
+	}
+
+	return count
+}
+
+func (t *teamsBatch) persist(ctx context.Context, pipeliner redis.Pipeliner) error {
+	for idT0, members := range t.t1 {
+		if err := pipeliner.ZAdd(ctx, model.SerializedT1ReferralsKey(idT0), members...).Err(); err != nil {
+			return err
+		}
+		if err := pipeliner.ZAdd(ctx, model.SerializedMiningT1ReferralsKey(idT0), t.miningT1[idT0]...).Err(); err != nil {
+			return err
+		}
+		if err := pipeliner.ZAddNX(ctx, model.SerializedT2ReferralsKey(idT0), t.t2[idT0]...).Err(); err != nil {
+			return err
+		}
+	}
+	for idTMinus1, increments := range t.t2ScoreIncrements {
+		for member, increment := range increments {
+			if err := pipeliner.ZIncrBy(ctx, model.SerializedT2ReferralsKey(idTMinus1), increment, member).Err(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (t *teamsBatch) reset() {
+	for k := range t.t1 {
+		delete(t.t1, k)
+	}
+	for k := range t.miningT1 {
+		delete(t.miningT1, k)
+	}
+	for k := range t.t2 {
+		delete(t.t2, k)
+	}
+	for k := range t.t2ScoreIncrements {
+		delete(t.t2ScoreIncrements, k)
+	}
+}
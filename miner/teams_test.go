@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package miner
+
+import (
+	"testing"
+	stdlibtime "time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ice-blockchain/freezer/model"
+)
+
+func TestTeamsBatchRecord(t *testing.T) {
+	t.Parallel()
+
+	t0Ref, tMinus1Ref := newRef(), newRef()
+	t0Ref.ID, tMinus1Ref.ID = testIDT0, testIDTMinus1
+	batch := newTeamsBatch(2)
+
+	mining := newUser()
+	mining.ID, mining.IDT0, mining.IDTMinus1 = 1, testIDT0, testIDTMinus1
+	mining.BalanceForT0, mining.BalanceForTMinus1 = 3, 1
+	updated := *mining
+	updated.BalanceForT0, updated.BalanceForTMinus1 = 4, 1.5
+	batch.record(testTime, mining, &updated, t0Ref, tMinus1Ref, false)
+
+	// It changed its T0, so what it contributed to its T-1 was reset and it's listed with nothing contributed yet.
+	idle := newUser()
+	idle.ID, idle.IDT0, idle.IDTMinus1 = 2, -testIDT0, -testIDTMinus1
+	idle.MiningSessionSoloEndedAt = timeDelta(-stdlibtime.Hour)
+	idle.BalanceForT0, idle.BalanceForTMinus1 = 5, 5
+	batch.record(testTime, idle, nil, t0Ref, tMinus1Ref, false)
+	assert.Equal(t, 1, len(batch.t1[testIDT0]))
+	batch.record(testTime, idle, nil, t0Ref, tMinus1Ref, true)
+
+	assert.Equal(t, []redis.Z{{Score: 4, Member: mining.Key()}, {Member: idle.Key()}}, batch.t1[testIDT0])
+	assert.Equal(t, []redis.Z{{Score: 4 + miningTeamScoreOffset, Member: mining.Key()}, {Member: idle.Key()}}, batch.miningT1[testIDT0])
+	assert.Equal(t, []redis.Z{{Member: mining.Key()}, {Member: idle.Key()}}, batch.t2[testIDT0])
+	assert.Equal(t, map[int64]map[string]float64{testIDTMinus1: {model.SerializedUsersKey(int64(testIDT0)): 0.5}}, batch.t2ScoreIncrements)
+	assert.Equal(t, 3, batch.len())
+
+	batch.record(testTime, mining, &updated, nil, tMinus1Ref, true)
+	assert.Equal(t, 3, batch.len())
+
+	batch.reset()
+	assert.Zero(t, batch.len())
+	assert.Empty(t, batch.t2)
+}
@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package model
+
+import (
+	"context"
+	"sync"
+	stdlibtime "time"
+
+	"github.com/ice-blockchain/wintr/time"
+)
+
+type (
+	// Clock tells the time to the mining logic.
+	// The services use RealClock, unless they are started with ContextWithClock, and the tests can use a FakeClock, to move the time forward without waiting for it.
+	Clock interface {
+		Now() *time.Time
+	}
+	RealClock struct{}
+	FakeClock struct {
+		now *time.Time
+		mx  sync.RWMutex
+	}
+	clockContextKey struct{}
+)
+
+// ContextWithClock makes the services started with the returned context, like the miner, tell the time with clock, instead of RealClock,
+// so that they can be fast-forwarded, I.E. by a FakeClock.
+func ContextWithClock(ctx context.Context, clock Clock) context.Context {
+	return context.WithValue(ctx, clockContextKey{}, clock)
+}
+
+// ClockFromContext is the clock set with ContextWithClock, if any, or RealClock.
+func ClockFromContext(ctx context.Context) Clock {
+	if clock, ok := ctx.Value(clockContextKey{}).(Clock); ok && clock != nil {
+		return clock
+	}
+
+	return RealClock{}
+}
+
+func (RealClock) Now() *time.Time {
+	return time.Now()
+}
+
+func NewFakeClock(now *time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() *time.Time {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	return time.New(*c.now.Time)
+}
+
+// Set moves the clock to now, forward or backward.
+func (c *FakeClock) Set(now *time.Time) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.now = time.New(*now.Time)
+}
+
+// Advance moves the clock forward by duration and returns the new time.
+func (c *FakeClock) Advance(duration stdlibtime.Duration) *time.Time {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.now = time.New(c.now.Add(duration))
+
+	return time.New(*c.now.Time)
+}
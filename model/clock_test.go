@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package model
+
+import (
+	"context"
+	"testing"
+	stdlibtime "time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ice-blockchain/wintr/time"
+)
+
+func TestFakeClock(t *testing.T) {
+	t.Parallel()
+
+	start := time.New(stdlibtime.Date(2024, 1, 1, 0, 0, 0, 0, stdlibtime.UTC))
+	clock := NewFakeClock(start)
+	now := clock.Now()
+	assert.True(t, start.Equal(*now.Time))
+	*now.Time = now.Add(stdlibtime.Hour)
+	assert.True(t, start.Equal(*clock.Now().Time))
+
+	advanced := clock.Advance(48 * stdlibtime.Hour)
+	assert.True(t, start.Add(48*stdlibtime.Hour).Equal(*advanced.Time))
+	assert.True(t, advanced.Equal(*clock.Now().Time))
+	assert.True(t, start.Equal(stdlibtime.Date(2024, 1, 1, 0, 0, 0, 0, stdlibtime.UTC)))
+
+	clock.Set(start)
+	assert.True(t, start.Equal(*clock.Now().Time))
+}
+
+func TestClockFromContext(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, RealClock{}, ClockFromContext(context.Background()))
+	clock := NewFakeClock(time.New(stdlibtime.Date(2024, 1, 1, 0, 0, 0, 0, stdlibtime.UTC)))
+	assert.Same(t, clock, ClockFromContext(ContextWithClock(context.Background(), clock)))
+}
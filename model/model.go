@@ -151,6 +151,9 @@ type (
 	MiningBlockchainAccountAddressField struct {
 		MiningBlockchainAccountAddress string `redis:"mining_blockchain_account_address" json:"miningBlockchainAccountAddress"`
 	}
+	MiningBlockchainNetworkField struct {
+		MiningBlockchainNetwork string `redis:"mining_blockchain_network,omitempty" json:"miningBlockchainNetwork,omitempty"`
+	}
 	BlockchainAccountAddressField struct {
 		BlockchainAccountAddress string `redis:"blockchain_account_address"`
 	}
@@ -522,6 +525,34 @@ func SerializedUsersKey(val any) string {
 	}
 }
 
+// SerializedT1ReferralsKey is the sorted set of the keys of the users who have the provided user as T0,
+// scored by what they contributed to it so far, i.e. their balance_for_t0.
+func SerializedT1ReferralsKey(idT0 int64) string {
+	return teamKey("t1_referrals:", idT0)
+}
+
+// SerializedMiningT1ReferralsKey is the same sorted set as SerializedT1ReferralsKey, but with the ones that are mining first.
+func SerializedMiningT1ReferralsKey(idT0 int64) string {
+	return teamKey("t1_referrals_mining:", idT0)
+}
+
+// SerializedT2ReferralsKey is the sorted set of the keys of the T1 referrals of the provided user,
+// scored by what their own T1 referrals contributed to it so far, i.e. the sum of their balance_for_tminus1.
+func SerializedT2ReferralsKey(idTMinus1 int64) string {
+	return teamKey("t2_referrals:", idTMinus1)
+}
+
+func teamKey(prefix string, id int64) string {
+	if id < 0 {
+		id *= -1
+	}
+	if id == 0 {
+		return ""
+	}
+
+	return prefix + strconv.FormatInt(id, 10)
+}
+
 func CalculateMiningStreak(now, start, end *time.Time, miningSessionDuration stdlibtime.Duration) uint64 {
 	if start.IsNil() || end.IsNil() || now.After(*end.Time) || now.Before(*start.Time) {
 		return 0
@@ -558,8 +589,8 @@ func (kyc *KYCState) KYCStepAttempted(kycStep users.KYCStep) bool {
 	return kyc.KYCStepsLastUpdatedAt != nil && len(*kyc.KYCStepsLastUpdatedAt) >= int(kycStep) && !(*kyc.KYCStepsLastUpdatedAt)[kycStep-1].IsNil()
 }
 
-func (kyc *KYCState) DelayPassedSinceLastKYCStepAttempt(kycStep users.KYCStep, duration stdlibtime.Duration) bool {
-	return kyc.KYCStepAttempted(kycStep) && time.Now().Sub(*(*kyc.KYCStepsLastUpdatedAt)[kycStep-1].Time) >= duration
+func (kyc *KYCState) DelayPassedSinceLastKYCStepAttempt(now *time.Time, kycStep users.KYCStep, duration stdlibtime.Duration) bool {
+	return kyc.KYCStepAttempted(kycStep) && now.Sub(*(*kyc.KYCStepsLastUpdatedAt)[kycStep-1].Time) >= duration
 }
 
 type (
@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package model
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// FirstDeeperReferralTier is the first of the optional referral tiers that come after T2.
+const FirstDeeperReferralTier = 3
+
+type (
+	// ReferralTier is the state of one of the optional referral tiers after T2.
+	// Since how many of them there are is configurable, they can't have static fields, like T1 & T2 do:
+	// each one has its own `balance_t<N>`, `active_t<N>_referrals`, etc., fields in the users hashes instead.
+	// Unlike T1 & T2, they have no pending balances, because they're slashed and resurrected together with the user that owns them,
+	// instead of with each of the referrals that contributed to them.
+	ReferralTier struct {
+		Balance         float64
+		SlashingRate    float64
+		ActiveReferrals int32
+	}
+	// ReferralTiers are the deeper referral tiers of a user, T3's first.
+	ReferralTiers []*ReferralTier
+)
+
+func ActiveReferralsField(tier int) string {
+	return fmt.Sprintf("active_t%v_referrals", tier)
+}
+
+// ReferralTiersFields are the fields of the first `tiers` deeper referral tiers, in the order ParseReferralTiers expects their values in.
+func ReferralTiersFields(tiers int) []string {
+	fields := make([]string, 0, 3*tiers) //nolint:gomnd,mnd // The fields of a tier.
+	for ix := 0; ix < tiers; ix++ {
+		tier := FirstDeeperReferralTier + ix
+		fields = append(fields,
+			fmt.Sprintf("balance_t%v", tier),
+			fmt.Sprintf("slashing_rate_t%v", tier),
+			ActiveReferralsField(tier),
+		)
+	}
+
+	return fields
+}
+
+// ParseReferralTiers parses the values of the ReferralTiersFields, as returned by HMGET; missing fields are zero.
+func ParseReferralTiers(values []any) (ReferralTiers, error) {
+	const fieldsPerTier = 3
+	if len(values)%fieldsPerTier != 0 {
+		return nil, errors.Errorf("unexpected number of referral tiers values: %v", len(values))
+	}
+	tiers := make(ReferralTiers, 0, len(values)/fieldsPerTier)
+	for ix := 0; ix < len(values); ix += fieldsPerTier {
+		var activeReferrals float64
+		tier := new(ReferralTier)
+		for jx, dst := range []*float64{&tier.Balance, &tier.SlashingRate, &activeReferrals} {
+			if str, ok := values[ix+jx].(string); ok && str != "" {
+				val, err := strconv.ParseFloat(str, 64)
+				if err != nil {
+					return nil, errors.Wrapf(err, "failed to ParseFloat `%v` for the referral tier T%v", str, FirstDeeperReferralTier+ix/fieldsPerTier)
+				}
+				*dst = val
+			}
+		}
+		tier.ActiveReferrals = int32(activeReferrals)
+		tiers = append(tiers, tier)
+	}
+
+	return tiers, nil
+}
+
+// Clone deep copies the tiers, so that they can be mined without altering the original ones.
+func (rt ReferralTiers) Clone() ReferralTiers {
+	if rt == nil {
+		return nil
+	}
+	cloned := make(ReferralTiers, 0, len(rt))
+	for _, tier := range rt {
+		clonedTier := *tier
+		cloned = append(cloned, &clonedTier)
+	}
+
+	return cloned
+}
+
+// Balance is the sum of the balances of all the tiers.
+func (rt ReferralTiers) Balance() (balance float64) {
+	for _, tier := range rt {
+		balance += tier.Balance
+	}
+
+	return balance
+}
+
+// SlashingRate is the sum of the slashing rates of all the tiers.
+func (rt ReferralTiers) SlashingRate() (slashingRate float64) {
+	for _, tier := range rt {
+		slashingRate += tier.SlashingRate
+	}
+
+	return slashingRate
+}
+
+// SerializeValue returns the field/value pairs that the miner owns, for HSET.
+// The active referrals are left out, because they're only ever incremented, by whoever changes them.
+func (rt ReferralTiers) SerializeValue() []any {
+	values := make([]any, 0, 4*len(rt)) //nolint:gomnd,mnd // 2 field/value pairs per tier.
+	for ix, tier := range rt {
+		num := FirstDeeperReferralTier + ix
+		values = append(values,
+			fmt.Sprintf("balance_t%v", num), strconv.FormatFloat(tier.Balance, 'f', -1, 64),
+			fmt.Sprintf("slashing_rate_t%v", num), strconv.FormatFloat(tier.SlashingRate, 'f', -1, 64),
+		)
+	}
+
+	return values
+}
+
+// ActiveReferralsIncrements computes how much the active referrals of the deeper tiers, of the ancestors of a user, change
+// when that user joins their team (sign 1) or leaves it (sign -1).
+// The ancestors are the user's T0 first, then its T0's T0, etc.; activeReferrals are the user's own ones, T1's first,
+// because they all move down to the next tier of each ancestor, together with the user itself, if active.
+// The result has a map of increments, by ancestor id, for each of the `tiers` deeper tiers.
+func ActiveReferralsIncrements(ancestors []int64, activeReferrals []int32, active bool, sign int64, tiers int) []map[int64]int64 {
+	if tiers <= 0 {
+		return nil
+	}
+	increments := make([]map[int64]int64, tiers)
+	add := func(tier int, id, value int64) {
+		ix := tier - FirstDeeperReferralTier
+		if ix < 0 || ix >= tiers || id == 0 || value == 0 {
+			return
+		}
+		if increments[ix] == nil {
+			increments[ix] = make(map[int64]int64, 1)
+		}
+		increments[ix][id] += sign * value
+	}
+	for ix, ancestor := range ancestors {
+		if ancestor < 0 {
+			ancestor *= -1
+		}
+		if ancestor == 0 {
+			break
+		}
+		depth := ix + 1
+		if active {
+			add(depth, ancestor, 1)
+		}
+		for jx, count := range activeReferrals {
+			if count > 0 {
+				add(depth+jx+1, ancestor, int64(count))
+			}
+		}
+	}
+
+	return increments
+}
@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReferralTiers(t *testing.T) {
+	t.Parallel()
+
+	fields := ReferralTiersFields(2)
+	require.Len(t, fields, 6)
+	assert.Equal(t, "balance_t3", fields[0])
+	assert.Equal(t, "active_t4_referrals", fields[5])
+
+	tiers, err := ParseReferralTiers([]any{"1.5", "0.25", "3", nil, nil, "-1"})
+	require.NoError(t, err)
+	assert.Equal(t, ReferralTiers{
+		{Balance: 1.5, SlashingRate: 0.25, ActiveReferrals: 3},
+		{ActiveReferrals: -1},
+	}, tiers)
+	assert.InDelta(t, 1.5, tiers.Balance(), 0.000001)
+	assert.InDelta(t, 0.25, tiers.SlashingRate(), 0.000001)
+	assert.Equal(t, []any{
+		"balance_t3", "1.5", "slashing_rate_t3", "0.25",
+		"balance_t4", "0", "slashing_rate_t4", "0",
+	}, tiers.SerializeValue())
+
+	_, err = ParseReferralTiers([]any{"1"})
+	require.Error(t, err)
+	_, err = ParseReferralTiers([]any{"bogus", nil, nil})
+	require.Error(t, err)
+}
+
+func TestActiveReferralsIncrements(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, ActiveReferralsIncrements([]int64{1, 2, 3}, nil, true, 1, 0))
+	assert.Equal(t, []map[int64]int64{{3: -1}}, ActiveReferralsIncrements([]int64{1, -2, 3, 4}, nil, true, -1, 1))
+	assert.Equal(t, []map[int64]int64{{1: 4, 2: 7}}, ActiveReferralsIncrements([]int64{1, 2, 0, 4}, []int32{7, 4, 1}, false, 1, 1))
+}
@@ -24,7 +24,7 @@ func (r *repository) GetAdoptionSummary(ctx context.Context, userID string) (as
 	if as = new(AdoptionSummary); ctx.Err() != nil {
 		return nil, errors.Wrap(ctx.Err(), "context failed")
 	}
-	if as.TotalActiveUsers, err = r.db.Get(ctx, r.totalActiveUsersKey(*time.Now().Time)).Uint64(); err != nil && !errors.Is(err, redis.Nil) {
+	if as.TotalActiveUsers, err = r.db.Get(ctx, r.totalActiveUsersKey(*r.clock.Now().Time)).Uint64(); err != nil && !errors.Is(err, redis.Nil) {
 		return nil, errors.Wrap(err, "failed to get current totalActiveUsers")
 	}
 	as.Milestones = make([]*Adoption[string], 0, r.cfg.Adoption.Milestones)
@@ -42,7 +42,7 @@ func (r *repository) GetAdoptionSummary(ctx context.Context, userID string) (as
 	}
 	createdAt := res[0].CreatedAt
 	if createdAt.IsNil() {
-		createdAt = time.Now()
+		createdAt = r.clock.Now()
 	}
 	for mi := range r.cfg.Adoption.Milestones {
 		achievedAt := time.New(createdAt.Add(stdlibtime.Duration(mi) * r.cfg.Adoption.DurationBetweenMilestones))
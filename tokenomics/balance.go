@@ -56,25 +56,73 @@ func (r *repository) GetBalanceSummary( //nolint:lll // .
 	if r.isAdvancedTeamDisabled(res[0].LatestDevice) {
 		res[0].BalanceT2 = 0
 	}
+	referralTiers, err := GetReferralTiers(ctx, r.db, r.cfg.DeeperReferralTiers(), id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to GetReferralTiers for id:%v", id)
+	}
 	t1Standard, t1PreStaking := ApplyPreStaking(res[0].BalanceT0+res[0].BalanceT1, res[0].PreStakingAllocation, res[0].PreStakingBonus)
 	t2Standard, t2PreStaking := ApplyPreStaking(res[0].BalanceT2, res[0].PreStakingAllocation, res[0].PreStakingBonus)
 	soloStandard, soloPreStaking := ApplyPreStaking(res[0].BalanceSolo, res[0].PreStakingAllocation, res[0].PreStakingBonus)
+	var (
+		deeper                           []string
+		deeperStandard, deeperPreStaking float64
+	)
+	for _, tier := range referralTiers[id] {
+		standard, preStaking := ApplyPreStaking(tier.Balance, res[0].PreStakingAllocation, res[0].PreStakingBonus)
+		deeperStandard, deeperPreStaking = deeperStandard+standard, deeperPreStaking+preStaking
+		deeper = append(deeper, fmt.Sprintf(floatToStringFormatter, standard+preStaking))
+	}
 
 	return &BalanceSummary{
 		Balances: Balances[string]{
-			Total:                              fmt.Sprintf(floatToStringFormatter, soloStandard+soloPreStaking+t1Standard+t1PreStaking+t2Standard+t2PreStaking),
-			TotalNoPreStakingBonus:             fmt.Sprintf(floatToStringFormatter, res[0].BalanceSolo+res[0].BalanceT0+res[0].BalanceT1+res[0].BalanceT2),
-			Standard:                           fmt.Sprintf(floatToStringFormatter, soloStandard+t1Standard+t2Standard),
-			PreStaking:                         fmt.Sprintf(floatToStringFormatter, soloPreStaking+t1PreStaking+t2PreStaking),
+			Total:                              fmt.Sprintf(floatToStringFormatter, soloStandard+soloPreStaking+t1Standard+t1PreStaking+t2Standard+t2PreStaking+deeperStandard+deeperPreStaking),
+			TotalNoPreStakingBonus:             fmt.Sprintf(floatToStringFormatter, res[0].BalanceSolo+res[0].BalanceT0+res[0].BalanceT1+res[0].BalanceT2+referralTiers[id].Balance()),
+			Standard:                           fmt.Sprintf(floatToStringFormatter, soloStandard+t1Standard+t2Standard+deeperStandard),
+			PreStaking:                         fmt.Sprintf(floatToStringFormatter, soloPreStaking+t1PreStaking+t2PreStaking+deeperPreStaking),
 			T1:                                 fmt.Sprintf(floatToStringFormatter, t1Standard+t1PreStaking),
 			T2:                                 fmt.Sprintf(floatToStringFormatter, t2Standard+t2PreStaking),
-			TotalReferrals:                     fmt.Sprintf(floatToStringFormatter, t1Standard+t1PreStaking+t2Standard+t2PreStaking),
+			Deeper:                             deeper,
+			TotalReferrals:                     fmt.Sprintf(floatToStringFormatter, t1Standard+t1PreStaking+t2Standard+t2PreStaking+deeperStandard+deeperPreStaking),
 			TotalMiningBlockchain:              fmt.Sprintf(floatToStringFormatter, res[0].BalanceSoloEthereum+res[0].BalanceT0Ethereum+res[0].BalanceT1Ethereum+res[0].BalanceT2Ethereum),                                                                                                                     //nolint:lll // .
 			TotalMainnetRewardPoolContribution: fmt.Sprintf(floatToStringFormatter, res[0].BalanceSoloEthereumMainnetRewardPoolContribution+res[0].BalanceT0EthereumMainnetRewardPoolContribution+res[0].BalanceT1EthereumMainnetRewardPoolContribution+res[0].BalanceT2EthereumMainnetRewardPoolContribution), //nolint:lll // .
 		},
 	}, nil
 }
 
+func (r *repository) GetPendingCoinDistributionBalance(ctx context.Context, userID string) (float64, error) {
+	id, err := GetOrInitInternalID(ctx, r.db, userID)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to getOrInitInternalID for userID:%v", userID)
+	}
+	res, err := storage.Get[struct {
+		model.UserIDField
+		model.BalanceSoloEthereumPendingField
+		model.BalanceT0EthereumPendingField
+		model.BalanceT1EthereumPendingField
+		model.BalanceT2EthereumPendingField
+	}](ctx, r.db, model.SerializedUsersKey(id))
+	if err != nil || len(res) == 0 {
+		if err == nil {
+			err = errors.Wrapf(ErrRelationNotFound, "missing state for id:%v", id)
+		}
+
+		return 0, errors.Wrapf(err, "failed to get pending coin distribution balance for id:%v", id)
+	}
+	var pending float64
+	for _, balance := range []*model.FlexibleFloat64{
+		res[0].BalanceSoloEthereumPending,
+		res[0].BalanceT0EthereumPending,
+		res[0].BalanceT1EthereumPending,
+		res[0].BalanceT2EthereumPending,
+	} {
+		if balance != nil {
+			pending += float64(*balance)
+		}
+	}
+
+	return pending, nil
+}
+
 func (r *repository) GetBalanceHistory( //nolint:funlen,gocognit,revive,gocyclo,cyclop,revive // Better to be grouped together.
 	ctx context.Context, userID string, start, end *time.Time, _ stdlibtime.Duration, limit, offset uint64,
 ) ([]*BalanceHistoryEntry, error) {
@@ -101,6 +149,33 @@ func (r *repository) GetBalanceHistory( //nolint:funlen,gocognit,revive,gocyclo,
 	return r.processBalanceHistory(balanceHistory, factor > 0, notBeforeTime, notAfterTime), nil
 }
 
+func (r *repository) GetLedger(ctx context.Context, userID string, limit, offset uint64) ([]*LedgerEntry, error) {
+	id, err := GetOrInitInternalID(ctx, r.db, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to getOrInitInternalID for userID:%v", userID)
+	}
+	ledger, err := r.dwh.SelectLedger(ctx, id, limit, offset)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to SelectLedger for id:%v,limit:%v,offset:%v", id, limit, offset)
+	}
+
+	return processLedger(ledger), nil
+}
+
+func processLedger(ledger []*dwh.LedgerEntry) []*LedgerEntry {
+	entries := make([]*LedgerEntry, 0, len(ledger))
+	for _, entry := range ledger {
+		entries = append(entries, &LedgerEntry{
+			CreatedAt: entry.CreatedAt,
+			Reason:    entry.Reason,
+			Amount:    fmt.Sprintf(floatToStringFormatter, math.Abs(entry.Amount)),
+			Negative:  entry.Amount < 0,
+		})
+	}
+
+	return entries
+}
+
 func (r *repository) calculateDates(limit, offset uint64, start, end *time.Time, factor stdlibtime.Duration) (dates []stdlibtime.Time, notBeforeTime, notAfterTime *time.Time) {
 	const (
 		hoursInADay = 24
@@ -319,7 +394,7 @@ func (s *completedTasksSource) Process(ctx context.Context, message *messagebrok
 
 		return errors.Wrapf(err, "failed to get GetAdoptionSummary for id:%v", id)
 	}
-	prize := s.cfg.BaseMiningRate(time.Now(), res[0].CreatedAt) * adoptionMultiplicationFactor
+	prize := s.cfg.BaseMiningRate(s.clock.Now(), res[0].CreatedAt) * adoptionMultiplicationFactor
 
 	return errors.Wrapf(s.db.HIncrByFloat(ctx, model.SerializedUsersKey(id), "balance_solo_pending", prize).Err(),
 		"failed to incr balance_solo_pending for userID:%v by %v", val.UserID, prize)
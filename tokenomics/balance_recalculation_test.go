@@ -23,9 +23,10 @@ func TestRepository_CalculateMiningRateSummaries(t *testing.T) { //nolint:funlen
 			Child:  stdlibtime.Hour,
 		},
 		ReferralBonusMiningRates: struct {
-			T0 uint16 `yaml:"t0"`
-			T1 uint32 `yaml:"t1"`
-			T2 uint32 `yaml:"t2"`
+			Deeper []uint32 `yaml:"deeper"`
+			T0     uint16   `yaml:"t0"`
+			T1     uint32   `yaml:"t1"`
+			T2     uint32   `yaml:"t2"`
 		}{
 			T0: 25,
 			T1: 25,
@@ -45,7 +46,7 @@ func TestRepository_CalculateMiningRateSummaries(t *testing.T) { //nolint:funlen
 		now                  = time.Now()
 		endedAt              = time.New(now.Add(stdlibtime.Second))
 	)
-	actual := rep.calculateMiningRateSummaries(t0, extraBonus, preStakingAllocation, preStakingBonus, t1, t2, baseMiningRate, negativeMiningRate, totalBalance, now, endedAt, false)
+	actual := rep.calculateMiningRateSummaries(t0, extraBonus, preStakingAllocation, preStakingBonus, t1, t2, nil, baseMiningRate, negativeMiningRate, totalBalance, now, endedAt, false)
 	assert.EqualValues(t, &MiningRates[*MiningRateSummary[string]]{ //nolint:dupl // Intended.
 		Type: PositiveMiningRateType,
 		Base: &MiningRateSummary[string]{
@@ -104,7 +105,7 @@ func TestRepository_CalculateMiningRateSummaries(t *testing.T) { //nolint:funlen
 	}, actual)
 	preStakingBonus = 500
 	preStakingAllocation = 10
-	actual = rep.calculateMiningRateSummaries(t0, extraBonus, preStakingAllocation, preStakingBonus, t1, t2, baseMiningRate, negativeMiningRate, totalBalance, now, endedAt, false)
+	actual = rep.calculateMiningRateSummaries(t0, extraBonus, preStakingAllocation, preStakingBonus, t1, t2, nil, baseMiningRate, negativeMiningRate, totalBalance, now, endedAt, false)
 	assert.EqualValues(t, &MiningRates[*MiningRateSummary[string]]{ //nolint:dupl // Intended.
 		Type: PositiveMiningRateType,
 		Base: &MiningRateSummary[string]{
@@ -163,7 +164,7 @@ func TestRepository_CalculateMiningRateSummaries(t *testing.T) { //nolint:funlen
 	}, actual)
 	preStakingBonus = 100
 	preStakingAllocation = 100
-	actual = rep.calculateMiningRateSummaries(t0, extraBonus, preStakingAllocation, preStakingBonus, t1, t2, baseMiningRate, negativeMiningRate, totalBalance, now, endedAt, false)
+	actual = rep.calculateMiningRateSummaries(t0, extraBonus, preStakingAllocation, preStakingBonus, t1, t2, nil, baseMiningRate, negativeMiningRate, totalBalance, now, endedAt, false)
 	assert.EqualValues(t, &MiningRates[*MiningRateSummary[string]]{ //nolint:dupl // Wrong.
 		Type: PositiveMiningRateType,
 		Base: &MiningRateSummary[string]{
@@ -212,7 +213,7 @@ func TestRepository_CalculateMiningRateSummaries(t *testing.T) { //nolint:funlen
 	}, actual)
 	preStakingBonus = 0
 	preStakingAllocation = 0
-	actual = rep.calculateMiningRateSummaries(t0, extraBonus, preStakingAllocation, preStakingBonus, t1, t2, baseMiningRate, negativeMiningRate, totalBalance, now, endedAt, false)
+	actual = rep.calculateMiningRateSummaries(t0, extraBonus, preStakingAllocation, preStakingBonus, t1, t2, nil, baseMiningRate, negativeMiningRate, totalBalance, now, endedAt, false)
 	assert.EqualValues(t, &MiningRates[*MiningRateSummary[string]]{ //nolint:dupl // Wrong.
 		Type: PositiveMiningRateType,
 		Base: &MiningRateSummary[string]{
@@ -263,7 +264,7 @@ func TestRepository_CalculateMiningRateSummaries(t *testing.T) { //nolint:funlen
 	preStakingBonus = 500
 	preStakingAllocation = 10
 	endedAt = now
-	actual = rep.calculateMiningRateSummaries(t0, extraBonus, preStakingAllocation, preStakingBonus, t1, t2, baseMiningRate, negativeMiningRate, totalBalance, now, endedAt, false)
+	actual = rep.calculateMiningRateSummaries(t0, extraBonus, preStakingAllocation, preStakingBonus, t1, t2, nil, baseMiningRate, negativeMiningRate, totalBalance, now, endedAt, false)
 	assert.EqualValues(t, &MiningRates[*MiningRateSummary[string]]{ //nolint:dupl // Wrong.
 		Type: NoneMiningRateType,
 		Base: &MiningRateSummary[string]{
@@ -321,7 +322,7 @@ func TestRepository_CalculateMiningRateSummaries(t *testing.T) { //nolint:funlen
 		},
 	}, actual)
 	totalBalance = 1.0
-	actual = rep.calculateMiningRateSummaries(t0, extraBonus, preStakingAllocation, preStakingBonus, t1, t2, baseMiningRate, negativeMiningRate, totalBalance, now, endedAt, false)
+	actual = rep.calculateMiningRateSummaries(t0, extraBonus, preStakingAllocation, preStakingBonus, t1, t2, nil, baseMiningRate, negativeMiningRate, totalBalance, now, endedAt, false)
 	assert.EqualValues(t, &MiningRates[*MiningRateSummary[string]]{ //nolint:dupl // Wrong.
 		Type: NegativeMiningRateType,
 		Base: &MiningRateSummary[string]{
@@ -379,3 +380,27 @@ func TestRepository_CalculateMiningRateSummaries(t *testing.T) { //nolint:funlen
 		},
 	}, actual)
 }
+
+func TestRepository_CalculateMiningRateSummaries_DeeperReferralTiers(t *testing.T) {
+	t.Parallel()
+	rep := &repository{cfg: new(Config)}
+	rep.cfg.GlobalAggregationInterval.Child = stdlibtime.Hour
+	rep.cfg.ReferralBonusMiningRates.T1 = 25
+	rep.cfg.ReferralBonusMiningRates.T2 = 5
+	rep.cfg.ReferralBonusMiningRates.Deeper = []uint32{2}
+	now := time.Now()
+	endedAt := time.New(now.Add(stdlibtime.Second))
+
+	actual := rep.calculateMiningRateSummaries(0, 0, 50, 100, 0, 0, []int32{10}, 16, 1000, 0, now, endedAt, false)
+	assert.Equal(t, PositiveMiningRateType, actual.Type)
+	assert.Equal(t, []float64{10}, actual.Standard.Bonuses.Deeper)
+	assert.Equal(t, []float64{10}, actual.PreStaking.Bonuses.Deeper)
+	assert.Equal(t, []float64{20}, actual.Total.Bonuses.Deeper)
+	assert.Equal(t, "9.60", actual.Standard.Amount)
+	assert.Equal(t, "19.20", actual.PreStaking.Amount)
+	assert.Equal(t, "28.80", actual.Total.Amount)
+
+	actual = rep.calculateMiningRateSummaries(0, 0, 0, 0, 0, 0, []int32{10}, 16, 1000, 0, now, endedAt, false)
+	assert.Equal(t, "19.20", actual.Total.Amount)
+	assert.Equal(t, []float64{20}, actual.Total.Bonuses.Deeper)
+}
@@ -1097,3 +1097,17 @@ func TestProcessBalanceHistory_ChildIsEqualToParent24H(t *testing.T) {
 
 	assert.EqualValues(t, expected, entries)
 }
+
+func TestProcessLedger(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	ledger := processLedger([]*dwh.LedgerEntry{
+		{CreatedAt: now, Reason: dwh.SlashingLedgerReason, Amount: -1.234, ID: 1},
+		{CreatedAt: now, Reason: dwh.SoloMiningLedgerReason, Amount: 16, ID: 1},
+	})
+	assert.EqualValues(t, []*LedgerEntry{
+		{CreatedAt: now, Reason: dwh.SlashingLedgerReason, Amount: "1.23", Negative: true},
+		{CreatedAt: now, Reason: dwh.SoloMiningLedgerReason, Amount: "16.00"},
+	}, ledger)
+	require.Empty(t, processLedger(nil))
+}
@@ -29,7 +29,7 @@ func (r *repository) GetTotalCoinsSummary(ctx context.Context, days uint64, utcO
 	var (
 		dates    []stdlibtime.Time
 		res      = new(TotalCoinsSummary)
-		now      = time.Now()
+		now      = r.clock.Now()
 		location = stdlibtime.FixedZone(utcOffset.String(), int(utcOffset.Seconds()))
 	)
 
@@ -152,7 +152,7 @@ func (r *repository) updateCachedBlockchainDetails(ctx context.Context) error {
 		return err
 	}
 
-	now := time.Now()
+	now := r.clock.Now()
 	if value != nil && now.Sub(*value.Timestamp.Time) < r.cfg.DetailedCoinMetrics.RefreshInterval {
 		return nil
 	}
@@ -215,7 +215,7 @@ func (r *repository) keepTotalCoinsCacheUpdated(ctx context.Context) {
 		select {
 		case <-ticker.C:
 			var (
-				now                    = time.Now()
+				now                    = r.clock.Now()
 				newDate                = now.Truncate(r.cfg.GlobalAggregationInterval.Parent)
 				historyGenerationDelta = stdlibtime.Duration(float64(r.cfg.GlobalAggregationInterval.Child) * 0.75) //nolint:gomnd // .
 			)
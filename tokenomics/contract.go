@@ -15,6 +15,7 @@ import (
 	"github.com/ice-blockchain/eskimo/users"
 	dwh "github.com/ice-blockchain/freezer/bookkeeper/storage"
 	extrabonusnotifier "github.com/ice-blockchain/freezer/extra-bonus-notifier"
+	"github.com/ice-blockchain/freezer/model"
 	detailedCoinMetrics "github.com/ice-blockchain/freezer/tokenomics/detailed_coin_metrics"
 	messagebroker "github.com/ice-blockchain/wintr/connectors/message_broker"
 	storagev2 "github.com/ice-blockchain/wintr/connectors/storage/v2"
@@ -44,8 +45,15 @@ const (
 	EthereumBlockchainNetworkType BlockchainNetworkType = "ethereum"
 )
 
+const (
+	LifetimeContributionTeamOrderBy   TeamOrderBy = "lifetimeContribution"
+	T2LifetimeContributionTeamOrderBy TeamOrderBy = "t2LifetimeContribution"
+	MiningTeamOrderBy                 TeamOrderBy = "mining"
+)
+
 var (
 	ErrInvalidMiningBoostUpgradeTX                     = errors.New("transaction for upgrading mining boost tier is invalid")
+	ErrInvalidBlockchainNetwork                        = errors.New("invalid blockchain network")
 	ErrNotFound                                        = errors.New("not found")
 	ErrRelationNotFound                                = errors.New("relationship not found")
 	ErrDuplicate                                       = errors.New("duplicate")
@@ -104,17 +112,18 @@ type (
 		Balances[string]
 	}
 	Balances[DENOM ~float64 | ~string] struct {
-		Total                              DENOM  `json:"total,omitempty" swaggertype:"string" example:"1,243.02"`
-		BaseFactor                         DENOM  `json:"baseFactor,omitempty" swaggerignore:"true" swaggertype:"string" example:"1,243.02"`
-		Standard                           DENOM  `json:"standard,omitempty" swaggertype:"string" example:"1,243.02"`
-		PreStaking                         DENOM  `json:"preStaking,omitempty" swaggertype:"string" example:"1,243.02"`
-		TotalNoPreStakingBonus             DENOM  `json:"totalNoPreStakingBonus,omitempty" swaggertype:"string" example:"1,243.02"`
-		T1                                 DENOM  `json:"t1,omitempty" swaggertype:"string" example:"1,243.02"`
-		T2                                 DENOM  `json:"t2,omitempty" swaggertype:"string" example:"1,243.02"`
-		TotalReferrals                     DENOM  `json:"totalReferrals,omitempty" swaggertype:"string" example:"1,243.02"`
-		TotalMiningBlockchain              DENOM  `json:"totalMiningBlockchain,omitempty" swaggertype:"string" example:"1,243.02"`
-		TotalMainnetRewardPoolContribution DENOM  `json:"totalMainnetRewardPoolContribution,omitempty" swaggertype:"string" example:"1,243.02"`
-		UserID                             string `json:"userId,omitempty" swaggerignore:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		Total                              DENOM   `json:"total,omitempty" swaggertype:"string" example:"1,243.02"`
+		BaseFactor                         DENOM   `json:"baseFactor,omitempty" swaggerignore:"true" swaggertype:"string" example:"1,243.02"`
+		Standard                           DENOM   `json:"standard,omitempty" swaggertype:"string" example:"1,243.02"`
+		PreStaking                         DENOM   `json:"preStaking,omitempty" swaggertype:"string" example:"1,243.02"`
+		TotalNoPreStakingBonus             DENOM   `json:"totalNoPreStakingBonus,omitempty" swaggertype:"string" example:"1,243.02"`
+		T1                                 DENOM   `json:"t1,omitempty" swaggertype:"string" example:"1,243.02"`
+		T2                                 DENOM   `json:"t2,omitempty" swaggertype:"string" example:"1,243.02"`
+		Deeper                             []DENOM `json:"deeper,omitempty" swaggertype:"array,string" example:"1,243.02"`
+		TotalReferrals                     DENOM   `json:"totalReferrals,omitempty" swaggertype:"string" example:"1,243.02"`
+		TotalMiningBlockchain              DENOM   `json:"totalMiningBlockchain,omitempty" swaggertype:"string" example:"1,243.02"`
+		TotalMainnetRewardPoolContribution DENOM   `json:"totalMainnetRewardPoolContribution,omitempty" swaggertype:"string" example:"1,243.02"`
+		UserID                             string  `json:"userId,omitempty" swaggerignore:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
 		miningBlockchainAccountAddress     string
 	}
 	BalanceHistoryBalanceDiff struct {
@@ -128,6 +137,30 @@ type (
 		Balance    *BalanceHistoryBalanceDiff `json:"balance"`
 		TimeSeries []*BalanceHistoryEntry     `json:"timeSeries"`
 	}
+	LedgerEntry struct {
+		CreatedAt *time.Time       `json:"createdAt" swaggertype:"string" example:"2022-01-03T16:20:52.156534Z"`
+		Reason    dwh.LedgerReason `json:"reason" swaggertype:"string" enums:"solo_mining,t0_referral,t1_referral,t2_referral,extra_bonus,resurrection,slashing,welcome_bonus,completed_tasks_prize,pre_staking_bonus,boost" example:"solo_mining"` //nolint:lll // .
+		Amount    string           `json:"amount" example:"1,243.02"`
+		Negative  bool             `json:"negative" example:"false"`
+	}
+	TeamOrderBy  string
+	TeamEarnings struct {
+		// The T2 referrals of the user that were invited by this T1 referral, aggregated.
+		T2       *TeamEarningsT2 `json:"t2"`
+		UserID   string          `json:"userId" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		Username string          `json:"username,omitempty" example:"jdoe"`
+		// What it adds to the user's mining rate right now, in the same unit as the mining rates.
+		CurrentContribution string `json:"currentContribution" example:"4.00"`
+		// What it has added to the user's balance so far.
+		LifetimeContribution string `json:"lifetimeContribution" example:"1,243.02"`
+		Mining               bool   `json:"mining" example:"true"`
+	}
+	TeamEarningsT2 struct {
+		CurrentContribution  string `json:"currentContribution" example:"0.80"`
+		LifetimeContribution string `json:"lifetimeContribution" example:"1,243.02"`
+		Referrals            uint64 `json:"referrals" example:"11"`
+		ActiveReferrals      int32  `json:"activeReferrals" example:"1"`
+	}
 	TotalCoins struct {
 		Total      float64 `json:"total" example:"111111.2423"`
 		Blockchain float64 `json:"blockchain" example:"111111.2423"`
@@ -172,11 +205,12 @@ type (
 		Allocation float64 `json:"allocation" example:"100.00"`
 	}
 	MiningRateBonuses struct {
-		T1         float64 `json:"t1,omitempty" example:"100.00"`
-		T2         float64 `json:"t2,omitempty" example:"200.00"`
-		PreStaking float64 `json:"preStaking,omitempty" example:"300.00"`
-		Extra      float64 `json:"extra,omitempty" example:"300.00"`
-		Total      float64 `json:"total,omitempty" example:"300.00"`
+		T1         float64   `json:"t1,omitempty" example:"100.00"`
+		T2         float64   `json:"t2,omitempty" example:"200.00"`
+		Deeper     []float64 `json:"deeper,omitempty" example:"10.00"`
+		PreStaking float64   `json:"preStaking,omitempty" example:"300.00"`
+		Extra      float64   `json:"extra,omitempty" example:"300.00"`
+		Total      float64   `json:"total,omitempty" example:"300.00"`
 	}
 	MiningRateSummary[DENOM ~string | ~float64] struct {
 		Bonuses *MiningRateBonuses `json:"bonuses,omitempty"`
@@ -239,6 +273,12 @@ type (
 		GetPreStakingSummary(ctx context.Context, userID string) (*PreStakingSummary, error)
 		GetBalanceHistory(ctx context.Context, userID string, start, end *time.Time, utcOffset stdlibtime.Duration, limit, offset uint64) ([]*BalanceHistoryEntry, error) //nolint:lll // .
 		GetAdoptionSummary(ctx context.Context, userID string) (*AdoptionSummary, error)
+		// GetLedger returns what changed the user's balance and by how much, from the newest change to the oldest one.
+		GetLedger(ctx context.Context, userID string, limit, offset uint64) ([]*LedgerEntry, error)
+		// GetTeamEarnings returns the T1 referrals of the user, with what each of them, and their own referrals, contribute to the user.
+		GetTeamEarnings(ctx context.Context, userID string, orderBy TeamOrderBy, limit, offset uint64) ([]*TeamEarnings, error)
+		// GetPendingCoinDistributionBalance returns the coins mined for the blockchain which are not yet collected for coin distribution.
+		GetPendingCoinDistributionBalance(ctx context.Context, userID string) (float64, error)
 	}
 	WriteRepository interface {
 		StartNewMiningSession(ctx context.Context, ms *MiningSummary, rollbackNegativeMiningProgress *bool, skipKYCSteps []users.KYCStep) error
@@ -247,6 +287,9 @@ type (
 
 		InitializeMiningBoostUpgrade(ctx context.Context, miningBoostLevelIndex uint8, userID string) (*PendingMiningBoostUpgrade, error)
 		FinalizeMiningBoostUpgrade(ctx context.Context, network BlockchainNetworkType, txHash, userID string) (*PendingMiningBoostUpgrade, error)
+
+		// SetMiningBlockchainNetwork sets the network the user wants to receive their coin distributions on.
+		SetMiningBlockchainNetwork(ctx context.Context, network BlockchainNetworkType, userID string) error
 	}
 	Repository interface {
 		io.Closer
@@ -309,6 +352,7 @@ type (
 
 	repository struct {
 		cfg                               *Config
+		clock                             model.Clock
 		extraBonusStartDate               *time.Time
 		livenessLoadDistributionStartDate *time.Time
 		extraBonusIndicesDistribution     map[uint16]map[uint16]uint16
@@ -425,9 +469,11 @@ type (
 			RefreshInterval stdlibtime.Duration `yaml:"refresh-interval" mapstructure:"refresh-interval"`
 		} `yaml:"detailed-coin-metrics" mapstructure:"detailed-coin-metrics"`
 		ReferralBonusMiningRates struct {
-			T0 uint16 `yaml:"t0"`
-			T1 uint32 `yaml:"t1"`
-			T2 uint32 `yaml:"t2"`
+			// Deeper are the rates of the optional tiers after T2: T3's first, then T4's, etc.
+			Deeper []uint32 `yaml:"deeper"`
+			T0     uint16   `yaml:"t0"`
+			T1     uint32   `yaml:"t1"`
+			T2     uint32   `yaml:"t2"`
 		} `yaml:"referralBonusMiningRates"`
 		Tenant              string  `yaml:"tenant"`
 		DefaultReferralName string  `yaml:"defaultReferralName"`
@@ -15,7 +15,6 @@ import (
 	"github.com/ice-blockchain/freezer/model"
 	messagebroker "github.com/ice-blockchain/wintr/connectors/message_broker"
 	"github.com/ice-blockchain/wintr/connectors/storage/v3"
-	"github.com/ice-blockchain/wintr/time"
 )
 
 type (
@@ -34,7 +33,7 @@ func (r *repository) ClaimExtraBonus(ctx context.Context, ebs *ExtraBonusSummary
 	if err != nil {
 		return errors.Wrapf(err, "failed to getOrInitInternalID for userID:%v", ebs.UserID)
 	}
-	now := time.Now()
+	now := r.clock.Now()
 	if r.cfg.ExtraBonuses.KycPassedExtraBonus == 0 {
 		return ErrNotFound
 	}
@@ -123,8 +123,9 @@ func (r *repository) validateKYC(ctx context.Context, userID string, state *getC
 
 func (r *repository) checkNextKYCStep(ctx context.Context, state *getCurrentMiningSession, faceKycAvailable bool) error {
 	var (
-		isAfterFirstWindow = time.Now().Sub(*r.livenessLoadDistributionStartDate.Time) > r.cfg.KYC.FaceRecognitionDelay
-		isReservedForToday = r.cfg.KYC.FaceRecognitionDelay <= r.cfg.MiningSessionDuration.Max || isAfterFirstWindow || int64((time.Now().Sub(*r.livenessLoadDistributionStartDate.Time)%r.cfg.KYC.FaceRecognitionDelay)/r.cfg.MiningSessionDuration.Max) >= state.ID%int64(r.cfg.KYC.FaceRecognitionDelay/r.cfg.MiningSessionDuration.Max) //nolint:lll // .
+		now                = r.clock.Now()
+		isAfterFirstWindow = now.Sub(*r.livenessLoadDistributionStartDate.Time) > r.cfg.KYC.FaceRecognitionDelay
+		isReservedForToday = r.cfg.KYC.FaceRecognitionDelay <= r.cfg.MiningSessionDuration.Max || isAfterFirstWindow || int64((now.Sub(*r.livenessLoadDistributionStartDate.Time)%r.cfg.KYC.FaceRecognitionDelay)/r.cfg.MiningSessionDuration.Max) >= state.ID%int64(r.cfg.KYC.FaceRecognitionDelay/r.cfg.MiningSessionDuration.Max) //nolint:lll // .
 	)
 	if r.isKYCStepForced(users.FacialRecognitionKYCStep, state.UserID) || (isReservedForToday && r.isKYCEnabled(ctx, state.LatestDevice, users.FacialRecognitionKYCStep) && faceKycAvailable && state.KYCStepNotAttempted(users.FacialRecognitionKYCStep)) { //nolint:lll // .
 		return terror.New(ErrKYCRequired, map[string]any{
@@ -133,7 +134,7 @@ func (r *repository) checkNextKYCStep(ctx context.Context, state *getCurrentMini
 	}
 	switch state.KYCStepPassed {
 	case users.NoneKYCStep:
-		social1Required := state.KYCStepNotAttempted(users.Social1KYCStep) || state.DelayPassedSinceLastKYCStepAttempt(users.Social1KYCStep, r.cfg.KYC.Social1Delay) //nolint:lll
+		social1Required := state.KYCStepNotAttempted(users.Social1KYCStep) || state.DelayPassedSinceLastKYCStepAttempt(now, users.Social1KYCStep, r.cfg.KYC.Social1Delay) //nolint:lll
 
 		if r.isKYCStepForced(users.Social1KYCStep, state.UserID) || (!state.MiningSessionSoloLastStartedAt.IsNil() && social1Required && r.isKYCEnabled(ctx, state.LatestDevice, users.Social1KYCStep)) { //nolint:lll // .
 			return terror.New(ErrKYCRequired, map[string]any{
@@ -143,8 +144,8 @@ func (r *repository) checkNextKYCStep(ctx context.Context, state *getCurrentMini
 	case users.FacialRecognitionKYCStep:
 	case users.LivenessDetectionKYCStep:
 		social1Required := (state.KYCStepAttempted(users.Social1KYCStep-1) && state.KYCStepNotAttempted(users.Social1KYCStep)) || //nolint:lll // .
-			state.DelayPassedSinceLastKYCStepAttempt(users.Social1KYCStep, r.cfg.KYC.Social1Delay)
-		minDelaySinceLastLiveness := state.DelayPassedSinceLastKYCStepAttempt(users.LivenessDetectionKYCStep, r.cfg.MiningSessionDuration.Min)
+			state.DelayPassedSinceLastKYCStepAttempt(now, users.Social1KYCStep, r.cfg.KYC.Social1Delay)
+		minDelaySinceLastLiveness := state.DelayPassedSinceLastKYCStepAttempt(now, users.LivenessDetectionKYCStep, r.cfg.MiningSessionDuration.Min)
 
 		if r.isKYCStepForced(users.Social1KYCStep, state.UserID) || (!state.MiningSessionSoloLastStartedAt.IsNil() && social1Required && minDelaySinceLastLiveness && r.isKYCEnabled(ctx, state.LatestDevice, users.Social1KYCStep)) { //nolint:lll // .
 			return terror.New(ErrKYCRequired, map[string]any{
@@ -154,8 +155,8 @@ func (r *repository) checkNextKYCStep(ctx context.Context, state *getCurrentMini
 	case users.Social1KYCStep:
 	case users.QuizKYCStep:
 		social2Required := (state.KYCStepAttempted(users.Social2KYCStep-1) && state.KYCStepNotAttempted(users.Social2KYCStep)) ||
-			state.DelayPassedSinceLastKYCStepAttempt(users.Social2KYCStep, r.cfg.KYC.Social2Delay)
-		minDelaySinceLastKYCStep := state.DelayPassedSinceLastKYCStepAttempt(users.Social2KYCStep-1, r.cfg.MiningSessionDuration.Min)
+			state.DelayPassedSinceLastKYCStepAttempt(now, users.Social2KYCStep, r.cfg.KYC.Social2Delay)
+		minDelaySinceLastKYCStep := state.DelayPassedSinceLastKYCStepAttempt(now, users.Social2KYCStep-1, r.cfg.MiningSessionDuration.Min)
 
 		if r.isKYCStepForced(users.Social2KYCStep, state.UserID) || (!state.MiningSessionSoloLastStartedAt.IsNil() && social2Required && minDelaySinceLastKYCStep && r.isKYCEnabled(ctx, state.LatestDevice, users.Social2KYCStep)) { //nolint:lll // .
 			return terror.New(ErrKYCRequired, map[string]any{
@@ -165,8 +166,8 @@ func (r *repository) checkNextKYCStep(ctx context.Context, state *getCurrentMini
 	default:
 		nextKYCStep := state.KYCStepPassed + 1
 		dynamicSocialXRequired := (state.KYCStepAttempted(state.KYCStepPassed) && state.KYCStepNotAttempted(nextKYCStep)) ||
-			state.DelayPassedSinceLastKYCStepAttempt(nextKYCStep, r.cfg.KYC.DynamicSocialDelay)
-		minDelaySinceLastLiveness := state.DelayPassedSinceLastKYCStepAttempt(state.KYCStepPassed, r.cfg.KYC.DynamicSocialDelay)
+			state.DelayPassedSinceLastKYCStepAttempt(now, nextKYCStep, r.cfg.KYC.DynamicSocialDelay)
+		minDelaySinceLastLiveness := state.DelayPassedSinceLastKYCStepAttempt(now, state.KYCStepPassed, r.cfg.KYC.DynamicSocialDelay)
 
 		if r.isKYCStepForced(nextKYCStep, state.UserID) || (!state.MiningSessionSoloLastStartedAt.IsNil() && dynamicSocialXRequired && minDelaySinceLastLiveness && r.isKYCEnabled(ctx, state.LatestDevice, nextKYCStep)) { //nolint:lll // .
 			return terror.New(ErrKYCRequired, map[string]any{
@@ -192,11 +193,12 @@ func (r *repository) isLastKYCStep(kycStep users.KYCStep) bool {
 }
 
 func (r *repository) isQuizRequired(state *getCurrentMiningSession) bool {
-	requireQuiz := (state.KYCStepAttempted(users.QuizKYCStep-1) && state.KYCStepNotAttempted(users.QuizKYCStep)) || state.DelayPassedSinceLastKYCStepAttempt(users.QuizKYCStep, r.cfg.KYC.QuizDelay) //nolint:lll // .
+	now := r.clock.Now()
+	requireQuiz := (state.KYCStepAttempted(users.QuizKYCStep-1) && state.KYCStepNotAttempted(users.QuizKYCStep)) || state.DelayPassedSinceLastKYCStepAttempt(now, users.QuizKYCStep, r.cfg.KYC.QuizDelay) //nolint:lll // .
 	if r.cfg.KYC.RequireQuizOnlyOnSpecificDayOfWeek != nil {
 		offset := stdlibtime.Duration(state.UTCOffset) * stdlibtime.Minute
-		requireQuiz = ((state.KYCStepAttempted(users.QuizKYCStep-1) && state.KYCStepNotAttempted(users.QuizKYCStep)) || state.DelayPassedSinceLastKYCStepAttempt(users.QuizKYCStep, 2*r.cfg.MiningSessionDuration.Max)) && //nolint:lll // .
-			int(time.Now().In(stdlibtime.FixedZone(offset.String(), int(offset.Seconds()))).Weekday()) == *r.cfg.KYC.RequireQuizOnlyOnSpecificDayOfWeek
+		requireQuiz = ((state.KYCStepAttempted(users.QuizKYCStep-1) && state.KYCStepNotAttempted(users.QuizKYCStep)) || state.DelayPassedSinceLastKYCStepAttempt(now, users.QuizKYCStep, 2*r.cfg.MiningSessionDuration.Max)) && //nolint:lll // .
+			int(now.In(stdlibtime.FixedZone(offset.String(), int(offset.Seconds()))).Weekday()) == *r.cfg.KYC.RequireQuizOnlyOnSpecificDayOfWeek
 	}
 
 	return requireQuiz
@@ -164,7 +164,7 @@ func (r *repository) GetMiningSummary(ctx context.Context, userID string) (*Mini
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to getOrInitInternalID for userID:%v", userID)
 	}
-	now := time.Now()
+	now := r.clock.Now()
 	ms, err := storage.Get[struct {
 		model.CreatedAtField
 		model.MiningSessionSoloPreviouslyEndedAtField
@@ -213,33 +213,46 @@ func (r *repository) GetMiningSummary(ctx context.Context, userID string) (*Mini
 	if !ms[0].ExtraBonusStartedAt.IsNil() && ms[0].ExtraBonusStartedAt.Add(r.cfg.ExtraBonuses.Duration).After(*now.Time) {
 		extraBonus = ms[0].ExtraBonus
 	}
-	negativeMiningRate := ms[0].SlashingRateSolo + ms[0].SlashingRateT0 + ms[0].SlashingRateT1 + ms[0].SlashingRateT2
+	referralTiers, err := GetReferralTiers(ctx, r.db, r.cfg.DeeperReferralTiers(), id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to GetReferralTiers for id:%v", id)
+	}
+	deeper := make([]int32, 0, len(referralTiers[id]))
+	for _, tier := range referralTiers[id] {
+		deeper = append(deeper, tier.ActiveReferrals)
+	}
+	negativeMiningRate := ms[0].SlashingRateSolo + ms[0].SlashingRateT0 + ms[0].SlashingRateT1 + ms[0].SlashingRateT2 + referralTiers[id].SlashingRate()
 	var t2 int32
 	if r.isAdvancedTeamEnabled(ms[0].LatestDevice) {
 		t2 = ms[0].ActiveT2Referrals
 	}
-	slashingIsOff := (ms[0].BalanceSolo+ms[0].BalanceT0+ms[0].BalanceT1+ms[0].BalanceT2) <= r.cfg.SlashingFloor || (ms[0].MiningBoostLevelIndex != nil && (*r.cfg.MiningBoost.levels.Load())[*ms[0].MiningBoostLevelIndex].SlashingDisabled)
+	slashingIsOff := (ms[0].BalanceSolo+ms[0].BalanceT0+ms[0].BalanceT1+ms[0].BalanceT2+referralTiers[id].Balance()) <= r.cfg.SlashingFloor || (ms[0].MiningBoostLevelIndex != nil && (*r.cfg.MiningBoost.levels.Load())[*ms[0].MiningBoostLevelIndex].SlashingDisabled)
 	maxMiningSessionDuration := r.cfg.maxMiningSessionDuration(ms[0].MiningBoostLevelIndexField)
-	activeT1Referrals := int32(0)
-	if ms[0].MiningBoostLevelIndex != nil {
-		if ms[0].IsVerified() && ms[0].VerifiedT1Referrals >= 25 {
-			activeT1Referrals = int32((*r.cfg.MiningBoost.levels.Load())[int(*ms[0].MiningBoostLevelIndex)].MaxT1Referrals)
-		} else {
-			activeT1Referrals = int32(math.Min(float64((*r.cfg.MiningBoost.levels.Load())[int(*ms[0].MiningBoostLevelIndex)].MaxT1Referrals), float64(ms[0].ActiveT1Referrals)))
-		}
-	}
+	activeT1Referrals := r.minedActiveT1Referrals(ms[0].MiningBoostLevelIndex, ms[0].IsVerified(), ms[0].VerifiedT1Referrals, ms[0].ActiveT1Referrals)
 
 	return &MiningSummary{
 		MiningStreak:                r.calculateMiningStreak(now, ms[0].MiningSessionSoloStartedAt, ms[0].MiningSessionSoloEndedAt),
 		MiningSession:               r.calculateMiningSession(now, ms[0].MiningSessionSoloLastStartedAt, ms[0].MiningSessionSoloEndedAt, maxMiningSessionDuration),
 		RemainingFreeMiningSessions: r.calculateRemainingFreeMiningSessions(now, ms[0].MiningSessionSoloLastStartedAt, ms[0].MiningSessionSoloEndedAt, maxMiningSessionDuration),
-		MiningRates:                 r.calculateMiningRateSummaries(t0, extraBonus, ms[0].PreStakingAllocation, ms[0].PreStakingBonus, activeT1Referrals, t2, r.cfg.BaseMiningRate(now, ms[0].CreatedAt), negativeMiningRate, ms[0].BalanceTotalStandard+ms[0].BalanceTotalPreStaking, now, ms[0].MiningSessionSoloEndedAt, slashingIsOff), //nolint:lll // .
+		MiningRates:                 r.calculateMiningRateSummaries(t0, extraBonus, ms[0].PreStakingAllocation, ms[0].PreStakingBonus, activeT1Referrals, t2, deeper, r.cfg.BaseMiningRate(now, ms[0].CreatedAt), negativeMiningRate, ms[0].BalanceTotalStandard+ms[0].BalanceTotalPreStaking, now, ms[0].MiningSessionSoloEndedAt, slashingIsOff), //nolint:lll // .
 		ExtraBonusSummary:           ExtraBonusSummary{AvailableExtraBonus: extraBonus},
 		MiningStarted:               !ms[0].MiningSessionSoloStartedAt.IsNil(),
 		KYCStepBlocked:              ms[0].KYCStepBlocked,
 	}, nil
 }
 
+// The active T1 referrals that the user actually mines for, which depend on its mining boost level.
+func (r *repository) minedActiveT1Referrals(miningBoostLevelIndex *model.FlexibleUint64, verified bool, verifiedT1Referrals uint64, activeT1Referrals int32) int32 {
+	if miningBoostLevelIndex == nil {
+		return 0
+	}
+	if verified && verifiedT1Referrals >= 25 {
+		return int32((*r.cfg.MiningBoost.levels.Load())[int(*miningBoostLevelIndex)].MaxT1Referrals)
+	}
+
+	return int32(math.Min(float64((*r.cfg.MiningBoost.levels.Load())[int(*miningBoostLevelIndex)].MaxT1Referrals), float64(activeT1Referrals)))
+}
+
 func (r *repository) isT0Online(ctx context.Context, idT0 int64, now *time.Time) (uint16, error) {
 	if idT0 == 0 {
 		return 0, nil
@@ -286,7 +299,7 @@ func CalculateMiningSession(now, start, end *time.Time, miningSessionDuration st
 //nolint:funlen,gomnd,lll // A lot of calculations.
 func (r *repository) calculateMiningRateSummaries(
 	t0 uint16, extraBonus, preStakingAllocation, preStakingBonus float64,
-	t1, t2 int32,
+	t1, t2 int32, deeper []int32,
 	baseMiningRate, negativeMiningRate, totalBalance float64,
 	now, miningSessionSoloEndedAt *time.Time,
 	slashingIsOff bool,
@@ -309,7 +322,7 @@ func (r *repository) calculateMiningRateSummaries(
 	if t2 < 0 {
 		t2 = 0
 	}
-	positiveTotalNoPreStakingBonus := r.calculateMintedStandardCoins(t0, extraBonus, 0, uint32(t1), uint32(t2), baseMiningRate, r.cfg.GlobalAggregationInterval.Child, false)
+	positiveTotalNoPreStakingBonus := r.calculateMintedStandardCoins(t0, extraBonus, 0, uint32(t1), uint32(t2), deeper, baseMiningRate, r.cfg.GlobalAggregationInterval.Child, false)
 	if positiveTotalNoPreStakingBonus > baseMiningRate && baseMiningRate > 0 {
 		positiveTotalNoPreStakingBonusVal = ((positiveTotalNoPreStakingBonus - baseMiningRate) * 100) / baseMiningRate
 	}
@@ -318,6 +331,7 @@ func (r *repository) calculateMiningRateSummaries(
 		Bonuses: &MiningRateBonuses{
 			T1:         float64(t0*r.cfg.ReferralBonusMiningRates.T0) + float64(uint32(t1)*r.cfg.ReferralBonusMiningRates.T1),
 			T2:         float64(uint32(t2) * r.cfg.ReferralBonusMiningRates.T2),
+			Deeper:     r.deeperReferralBonuses(deeper, 1),
 			Extra:      float64(extraBonus),
 			PreStaking: 0,
 			Total:      positiveTotalNoPreStakingBonusVal,
@@ -330,7 +344,7 @@ func (r *repository) calculateMiningRateSummaries(
 	} else if totalBalance <= 0.0 || slashingIsOff {
 		miningRates.Type = NoneMiningRateType
 	} else {
-		extraBonus, t0, t1, t2 = 0, 0, 0, 0
+		extraBonus, t0, t1, t2, deeper = 0, 0, 0, 0, nil
 		miningRates.Type = NegativeMiningRateType
 	}
 	miningRates.Base = &MiningRateSummary[string]{
@@ -340,7 +354,7 @@ func (r *repository) calculateMiningRateSummaries(
 		var localTotalBonus float64
 		switch miningRates.Type {
 		case PositiveMiningRateType:
-			standardMiningRate = r.calculateMintedStandardCoins(t0, extraBonus, preStakingAllocation, uint32(t1), uint32(t2), baseMiningRate, r.cfg.GlobalAggregationInterval.Child, false)
+			standardMiningRate = r.calculateMintedStandardCoins(t0, extraBonus, preStakingAllocation, uint32(t1), uint32(t2), deeper, baseMiningRate, r.cfg.GlobalAggregationInterval.Child, false)
 			if standardMiningRate > baseMiningRate && baseMiningRate > 0 {
 				localTotalBonus = ((standardMiningRate - baseMiningRate) * 100) / baseMiningRate
 			}
@@ -351,10 +365,11 @@ func (r *repository) calculateMiningRateSummaries(
 		miningRates.Standard = &MiningRateSummary[string]{
 			Amount: fmt.Sprintf(floatToStringFormatter, roundFloat64(standardMiningRate)),
 			Bonuses: &MiningRateBonuses{
-				T1:    ((float64(t0*r.cfg.ReferralBonusMiningRates.T0) + float64(uint32(t1)*r.cfg.ReferralBonusMiningRates.T1)) * (100 - preStakingAllocation)) / 100,
-				T2:    float64(uint32(t2)*r.cfg.ReferralBonusMiningRates.T2) * (100 - preStakingAllocation) / 100,
-				Extra: extraBonus * (100 - preStakingAllocation) / 100,
-				Total: localTotalBonus,
+				T1:     ((float64(t0*r.cfg.ReferralBonusMiningRates.T0) + float64(uint32(t1)*r.cfg.ReferralBonusMiningRates.T1)) * (100 - preStakingAllocation)) / 100,
+				T2:     float64(uint32(t2)*r.cfg.ReferralBonusMiningRates.T2) * (100 - preStakingAllocation) / 100,
+				Deeper: r.deeperReferralBonuses(deeper, (100-preStakingAllocation)/100),
+				Extra:  extraBonus * (100 - preStakingAllocation) / 100,
+				Total:  localTotalBonus,
 			},
 		}
 	}
@@ -362,7 +377,7 @@ func (r *repository) calculateMiningRateSummaries(
 		var localTotalBonus float64
 		switch miningRates.Type {
 		case PositiveMiningRateType:
-			preStakingMiningRate = r.calculateMintedPreStakingCoins(t0, extraBonus, preStakingAllocation, preStakingBonus, uint32(t1), uint32(t2), baseMiningRate, r.cfg.GlobalAggregationInterval.Child, false)
+			preStakingMiningRate = r.calculateMintedPreStakingCoins(t0, extraBonus, preStakingAllocation, preStakingBonus, uint32(t1), uint32(t2), deeper, baseMiningRate, r.cfg.GlobalAggregationInterval.Child, false)
 			if preStakingMiningRate > baseMiningRate && baseMiningRate > 0 {
 				localTotalBonus = ((preStakingMiningRate - baseMiningRate) * 100) / baseMiningRate
 			}
@@ -379,6 +394,7 @@ func (r *repository) calculateMiningRateSummaries(
 			Bonuses: &MiningRateBonuses{
 				T1:         t1Bonus,
 				T2:         t2Bonus,
+				Deeper:     r.deeperReferralBonuses(deeper, preStakingAllocation/100),
 				Extra:      extraBonusVal,
 				PreStaking: preStakingBonusVal,
 				Total:      localTotalBonus,
@@ -401,6 +417,7 @@ func (r *repository) calculateMiningRateSummaries(
 		Bonuses: &MiningRateBonuses{
 			T1:         float64(t0*r.cfg.ReferralBonusMiningRates.T0) + float64(uint32(t1)*r.cfg.ReferralBonusMiningRates.T1),
 			T2:         float64(uint32(t2) * r.cfg.ReferralBonusMiningRates.T2),
+			Deeper:     r.deeperReferralBonuses(deeper, 1),
 			Extra:      extraBonus,
 			PreStaking: preStakingBonusVal,
 			Total:      totalBonusVal,
@@ -411,6 +428,7 @@ func (r *repository) calculateMiningRateSummaries(
 		Bonuses: &MiningRateBonuses{
 			T1:         float64(t0*r.cfg.ReferralBonusMiningRates.T0) + float64(uint32(t1)*r.cfg.ReferralBonusMiningRates.T1),
 			T2:         float64(uint32(t2) * r.cfg.ReferralBonusMiningRates.T2),
+			Deeper:     r.deeperReferralBonuses(deeper, 1),
 			Extra:      extraBonus,
 			PreStaking: 0,
 			Total:      totalNoPreStakingBonusVal,
@@ -428,7 +446,7 @@ func roundFloat64(val float64) float64 {
 
 func (r *repository) calculateMintedStandardCoins(
 	t0 uint16, extraBonus, preStakingAllocation float64,
-	t1, t2 uint32,
+	t1, t2 uint32, deeper []int32,
 	baseMiningRate float64,
 	elapsedNanos stdlibtime.Duration,
 	excludeBaseRate bool,
@@ -443,7 +461,8 @@ func (r *repository) calculateMintedStandardCoins(
 	mintedBase := includeBaseMiningRate +
 		float64(t0*r.cfg.ReferralBonusMiningRates.T0) +
 		float64(t1*r.cfg.ReferralBonusMiningRates.T1) +
-		float64(t2*r.cfg.ReferralBonusMiningRates.T2)
+		float64(t2*r.cfg.ReferralBonusMiningRates.T2) +
+		r.deeperReferralsBonus(deeper)
 	if mintedBase == 0 {
 		return 0
 	}
@@ -457,7 +476,7 @@ func (r *repository) calculateMintedStandardCoins(
 
 func (r *repository) calculateMintedPreStakingCoins(
 	t0 uint16, extraBonus, preStakingAllocation, preStakingBonus float64,
-	t1, t2 uint32,
+	t1, t2 uint32, deeper []int32,
 	baseMiningRate float64,
 	elapsedNanos stdlibtime.Duration,
 	excludeBaseRate bool,
@@ -472,7 +491,8 @@ func (r *repository) calculateMintedPreStakingCoins(
 	mintedBase := includeBaseMiningRate +
 		float64(t0*r.cfg.ReferralBonusMiningRates.T0) +
 		float64(t1*r.cfg.ReferralBonusMiningRates.T1) +
-		float64(t2*r.cfg.ReferralBonusMiningRates.T2)
+		float64(t2*r.cfg.ReferralBonusMiningRates.T2) +
+		r.deeperReferralsBonus(deeper)
 	if mintedBase == 0 {
 		return 0
 	}
@@ -0,0 +1,34 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package tokenomics
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/freezer/model"
+	"github.com/ice-blockchain/wintr/connectors/storage/v3"
+)
+
+func (r *repository) SetMiningBlockchainNetwork(ctx context.Context, network BlockchainNetworkType, userID string) error {
+	if network != BNBBlockchainNetworkType && network != EthereumBlockchainNetworkType && network != ArbitrumBlockchainNetworkType {
+		return errors.Wrapf(ErrInvalidBlockchainNetwork, "network %v", network)
+	}
+	id, err := GetInternalID(ctx, r.db, userID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			err = ErrRelationNotFound
+		}
+
+		return errors.Wrapf(err, "failed to GetInternalID for userID:%v", userID)
+	}
+
+	return errors.Wrapf(storage.Set(ctx, r.db, &struct {
+		model.DeserializedUsersKey
+		model.MiningBlockchainNetworkField
+	}{
+		DeserializedUsersKey:         model.DeserializedUsersKey{ID: id},
+		MiningBlockchainNetworkField: model.MiningBlockchainNetworkField{MiningBlockchainNetwork: string(network)},
+	}), "failed to set the mining blockchain network to %v for userID:%v", network, userID)
+}
@@ -114,7 +114,7 @@ func (r *repository) InitializeMiningBoostUpgrade(ctx context.Context, miningBoo
 	}
 	icePrice := strconv.FormatFloat(upgradePrice*(1+(float64(r.cfg.MiningBoost.PriceDelta)/100)), 'f', miningBoostPricePrecision, 64)
 	return &PendingMiningBoostUpgrade{
-		ExpiresAt:      time.New(stdlibtime.Now().Add(r.cfg.MiningBoost.SessionLength)),
+		ExpiresAt:      time.New(r.clock.Now().Add(r.cfg.MiningBoost.SessionLength)),
 		ICEPrice:       icePrice,
 		PaymentAddress: generateMiningBoostPaymentAddress(id),
 	}, nil
@@ -141,7 +141,7 @@ func (r *repository) FinalizeMiningBoostUpgrade(ctx context.Context, network Blo
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to get TTL for mining_boost_upgrades for user_id %v", userID)
 	}
-	expireAt := time.Now().Add(ttl.Abs())
+	expireAt := r.clock.Now().Add(ttl.Abs())
 
 	rawMiningBoostLevelIndex, rawICEPrice := parts[0], parts[1]
 	miningBoostLevelIndex, err := strconv.ParseUint(rawMiningBoostLevelIndex, 10, 64)
@@ -285,7 +285,7 @@ func (r *repository) checkTxHashUniqueness(ctx context.Context, userID, txHash,
 	if _, err := storagev2.Exec(ctx, r.globalDB,
 		`INSERT INTO mining_boost_accepted_transactions (created_at, mining_boost_level, tenant, tx_hash, ice_amount, payment_address, sender_address, user_id)
             VALUES($1, $2, $3, $4, $5, $6, $7, $8);`,
-		*time.Now().Time, miningBoostLevelIndex, r.cfg.Tenant, txHash, strconv.FormatFloat(burntAmount, 'f', 15, 64), paymentAddress, senderAddress, userID); err != nil {
+		*r.clock.Now().Time, miningBoostLevelIndex, r.cfg.Tenant, txHash, strconv.FormatFloat(burntAmount, 'f', 15, 64), paymentAddress, senderAddress, userID); err != nil {
 		if storagev2.IsErr(err, storagev2.ErrDuplicate) { //nolint:nestif // .
 			if storagev2.IsErr(err, storagev2.ErrDuplicate, "txhash") || storagev2.IsErr(err, storagev2.ErrDuplicate, "pk") { //nolint:gocritic // .
 				return ErrDuplicate
@@ -62,7 +62,7 @@ func (r *repository) StartNewMiningSession( //nolint:funlen,gocognit // A lot of
 	if err != nil {
 		return errors.Wrapf(err, "failed to getOrInitInternalID for userID:%v", userID)
 	}
-	now := time.Now()
+	now := r.clock.Now()
 	old, err := storage.Get[getCurrentMiningSession](ctx, r.db, model.SerializedUsersKey(id))
 	if err != nil || len(old) == 0 {
 		if err == nil {
@@ -221,13 +221,14 @@ func (r *repository) validateRollbackNegativeMiningProgress(
 }
 
 func (r *repository) isExtraBonusAvailable(state *getCurrentMiningSession) bool {
+	now := r.clock.Now()
 	// This is just a hack so that we can differentiate between a failed/skipped Social 2 and a successful one:
 	// Social2KYCStep is a failed/skipped Social 2 outcome
 	// Social3KYCStep is a completed Social 2 outcome
 	// And, in actuality, there is no Social 3
 	if (users.QuizKYCStep == state.KYCStepPassed || users.Social3KYCStep == state.KYCStepPassed) &&
-		!state.DelayPassedSinceLastKYCStepAttempt(state.KYCStepPassed, r.cfg.MiningSessionDuration.Min) &&
-		(state.ExtraBonusStartedAt.IsNil() || state.ExtraBonusStartedAt.Add(r.cfg.ExtraBonuses.Duration).Before(*time.Now().Time)) {
+		!state.DelayPassedSinceLastKYCStepAttempt(now, state.KYCStepPassed, r.cfg.MiningSessionDuration.Min) &&
+		(state.ExtraBonusStartedAt.IsNil() || state.ExtraBonusStartedAt.Add(r.cfg.ExtraBonuses.Duration).Before(*now.Time)) {
 		return true
 	}
 
@@ -354,10 +355,15 @@ func (s *miningSessionsTableSource) incrementActiveReferralCountForT0AndTMinus1(
 			err = s.db.HIncrBy(ctx, model.SerializedUsersKey(referees[0].IDTMinus1), "active_t2_referrals", 1).Err()
 		}
 	} else {
+		deeperIncrements, dErr := s.deeperActiveReferralsIncrements(ctx, referees[0].IDT0, referees[0].IDTMinus1, nil, true, 1)
+		if dErr != nil {
+			return errors.Wrapf(dErr, "failed to get the deeper active referrals increments for id:%v, userID:%v", id, *ms.UserID)
+		}
 		responses, txErr := s.db.TxPipelined(ctx, func(pipeliner redis.Pipeliner) error {
 			return multierror.Append( //nolint:wrapcheck // .
 				pipeliner.HIncrBy(ctx, model.SerializedUsersKey(referees[0].IDT0), "active_t1_referrals", 1).Err(),
 				pipeliner.HIncrBy(ctx, model.SerializedUsersKey(referees[0].IDTMinus1), "active_t2_referrals", 1).Err(),
+				IncrementActiveReferrals(ctx, pipeliner, deeperIncrements),
 			).ErrorOrNil()
 		})
 		if txErr == nil {
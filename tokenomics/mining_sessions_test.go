@@ -8,6 +8,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/ice-blockchain/eskimo/users"
 	"github.com/ice-blockchain/freezer/model"
 	appCfg "github.com/ice-blockchain/wintr/config"
 	"github.com/ice-blockchain/wintr/time"
@@ -511,3 +512,27 @@ func TestRepositoryNewStartOrExtendMiningSession_CloseToMax(t *testing.T) { //no
 	}, actual[ix])
 	assert.EqualValues(t, repo.cfg.MiningSessionDuration.Max, extensions[ix])
 }
+
+func TestRepositoryIsExtraBonusAvailable(t *testing.T) {
+	t.Parallel()
+	var cfg Config
+	cfg.MiningSessionDuration.Min = 12 * stdlibtime.Hour
+	cfg.ExtraBonuses.Duration = 6 * stdlibtime.Hour
+	clock := model.NewFakeClock(time.New(stdlibtime.Date(2024, 1, 1, 0, 0, 0, 0, stdlibtime.UTC)))
+	repo := &repository{cfg: &cfg, clock: clock}
+	state := new(getCurrentMiningSession)
+	assert.False(t, repo.isExtraBonusAvailable(state))
+
+	state.KYCStepPassed = users.QuizKYCStep
+	kycStepsLastUpdatedAt := make(model.TimeSlice, users.QuizKYCStep)
+	kycStepsLastUpdatedAt[users.QuizKYCStep-1] = clock.Now()
+	state.KYCStepsLastUpdatedAt = &kycStepsLastUpdatedAt
+	assert.True(t, repo.isExtraBonusAvailable(state))
+
+	state.ExtraBonusStartedAt = clock.Advance(stdlibtime.Hour)
+	assert.False(t, repo.isExtraBonusAvailable(state))
+	clock.Advance(cfg.ExtraBonuses.Duration + stdlibtime.Minute)
+	assert.True(t, repo.isExtraBonusAvailable(state))
+	clock.Advance(cfg.MiningSessionDuration.Min)
+	assert.False(t, repo.isExtraBonusAvailable(state))
+}
@@ -0,0 +1,196 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package tokenomics
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ice-blockchain/freezer/model"
+	"github.com/ice-blockchain/wintr/connectors/storage/v3"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+// DeeperReferralTiers is how many tiers, after T2, are configured.
+func (c *Config) DeeperReferralTiers() int {
+	return len(c.ReferralBonusMiningRates.Deeper)
+}
+
+// GetReferralTiers fetches the first `tiers` deeper referral tiers of the users with the provided ids.
+func GetReferralTiers(ctx context.Context, db storage.DB, tiers int, ids ...int64) (map[int64]model.ReferralTiers, error) {
+	if tiers <= 0 || len(ids) == 0 {
+		return nil, nil //nolint:nilnil // Nothing to fetch.
+	}
+	fields := model.ReferralTiersFields(tiers)
+	responses, err := db.Pipelined(ctx, func(pipeliner redis.Pipeliner) error {
+		for _, id := range ids {
+			if err := pipeliner.HMGet(ctx, model.SerializedUsersKey(id), fields...).Err(); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to HMGET the deeper referral tiers of %v users", len(ids))
+	}
+	referralTiers := make(map[int64]model.ReferralTiers, len(ids))
+	for ix, response := range responses {
+		values, rErr := response.(*redis.SliceCmd).Result() //nolint:errcheck,forcetypeassert // They're all HMGETs.
+		if rErr != nil {
+			return nil, errors.Wrapf(rErr, "failed to HMGET the deeper referral tiers of id:%v", ids[ix])
+		}
+		if referralTiers[ids[ix]], rErr = model.ParseReferralTiers(values); rErr != nil {
+			return nil, errors.Wrapf(rErr, "failed to ParseReferralTiers of id:%v", ids[ix])
+		}
+	}
+
+	return referralTiers, nil
+}
+
+// GetReferralAncestors fetches, for each of the provided ids, the chain of referrals above it, up to `depth` of them:
+// the T0 of the id first, then the T0 of that T0, etc. The chains stop early, at the first user with no T0.
+func GetReferralAncestors(ctx context.Context, db storage.DB, depth int, ids ...int64) (map[int64][]int64, error) {
+	if depth <= 0 || len(ids) == 0 {
+		return nil, nil //nolint:nilnil // Nothing to fetch.
+	}
+	ancestors, lastAncestors := make(map[int64][]int64, len(ids)), make(map[int64]int64, len(ids))
+	for _, id := range ids {
+		if id < 0 {
+			id *= -1
+		}
+		if id != 0 {
+			ancestors[id], lastAncestors[id] = make([]int64, 0, depth), id
+		}
+	}
+	for level := 0; level < depth && len(lastAncestors) > 0; level++ {
+		descendants := make([]int64, 0, len(lastAncestors))
+		responses, err := db.Pipelined(ctx, func(pipeliner redis.Pipeliner) error {
+			for id, lastAncestor := range lastAncestors {
+				descendants = append(descendants, id)
+				if err := pipeliner.HGet(ctx, model.SerializedUsersKey(lastAncestor), "id_t0").Err(); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, errors.Wrapf(err, "failed to HGET the level %v referral ancestors of %v users", level+1, len(lastAncestors))
+		}
+		for ix, response := range responses {
+			id := descendants[ix]
+			val, rErr := response.(*redis.StringCmd).Result() //nolint:errcheck,forcetypeassert // They're all HGETs.
+			if rErr != nil && !errors.Is(rErr, redis.Nil) {
+				return nil, errors.Wrapf(rErr, "failed to HGET the T0 of id:%v", lastAncestors[id])
+			}
+			var ancestor int64
+			if val != "" {
+				if ancestor, rErr = strconv.ParseInt(val, 10, 64); rErr != nil {
+					return nil, errors.Wrapf(rErr, "failed to ParseInt the T0 `%v` of id:%v", val, lastAncestors[id])
+				}
+			}
+			if ancestor < 0 {
+				ancestor *= -1
+			}
+			if ancestor == 0 {
+				delete(lastAncestors, id)
+
+				continue
+			}
+			ancestors[id], lastAncestors[id] = append(ancestors[id], ancestor), ancestor
+		}
+	}
+
+	return ancestors, nil
+}
+
+// IncrementActiveReferrals applies, in the pipeline, the increments computed by model.ActiveReferralsIncrements.
+func IncrementActiveReferrals(ctx context.Context, pipeliner redis.Pipeliner, increments []map[int64]int64) error {
+	for ix, values := range increments {
+		field := model.ActiveReferralsField(model.FirstDeeperReferralTier + ix)
+		for id, value := range values {
+			if value == 0 {
+				continue
+			}
+			if err := pipeliner.HIncrBy(ctx, model.SerializedUsersKey(id), field, value).Err(); err != nil {
+				return errors.Wrapf(err, "failed to HINCRBY %v of id:%v by %v", field, id, value)
+			}
+		}
+	}
+
+	return nil
+}
+
+// deeperActiveReferralsIncrements computes the increments of the active referrals of the deeper tiers of the ancestors of a user,
+// when that user starts (sign 1) or stops (sign -1) counting for them. See model.ActiveReferralsIncrements.
+func (r *repository) deeperActiveReferralsIncrements(
+	ctx context.Context, idT0, idTMinus1 int64, activeReferrals []int32, active bool, sign int64,
+) ([]map[int64]int64, error) {
+	tiers := r.cfg.DeeperReferralTiers()
+	if tiers == 0 || idT0 == 0 {
+		return nil, nil //nolint:nilnil // Nothing to increment.
+	}
+	if idTMinus1 < 0 {
+		idTMinus1 *= -1
+	}
+	ancestors := append(make([]int64, 0, 2+tiers), idT0, idTMinus1) //nolint:gomnd,mnd // T0 & T-1.
+	if idTMinus1 != 0 {
+		deeper, err := GetReferralAncestors(ctx, r.db, tiers, idTMinus1)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to GetReferralAncestors of idTMinus1:%v", idTMinus1)
+		}
+		ancestors = append(ancestors, deeper[idTMinus1]...)
+	}
+
+	return model.ActiveReferralsIncrements(ancestors, activeReferrals, active, sign, tiers), nil
+}
+
+// The deleted user stops counting for the deeper tiers of its ancestors, and so do its own active referrals.
+func (r *repository) deeperActiveReferralsIncrementsForDeletedUser(
+	ctx context.Context, id int64, miningSessionSoloEndedAt *time.Time, idT0, idTMinus1 int64, activeT1Referrals, activeT2Referrals int32,
+) ([]map[int64]int64, error) {
+	tiers := r.cfg.DeeperReferralTiers()
+	if tiers == 0 || idT0 == 0 {
+		return nil, nil //nolint:nilnil // Nothing to increment.
+	}
+	referralTiers, err := GetReferralTiers(ctx, r.db, tiers, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to GetReferralTiers for id:%v", id)
+	}
+	activeReferrals := append(make([]int32, 0, 2+tiers), activeT1Referrals, activeT2Referrals) //nolint:gomnd,mnd // T1 & T2.
+	for _, tier := range referralTiers[id] {
+		activeReferrals = append(activeReferrals, tier.ActiveReferrals)
+	}
+	wasMining := !miningSessionSoloEndedAt.IsNil() && miningSessionSoloEndedAt.After(*r.clock.Now().Time)
+
+	return r.deeperActiveReferralsIncrements(ctx, idT0, idTMinus1, activeReferrals, wasMining, -1)
+}
+
+// The sum of the bonuses, in percents of the base mining rate, that the active referrals of the deeper tiers bring.
+func (r *repository) deeperReferralsBonus(deeper []int32) (bonus float64) {
+	for _, tierBonus := range r.deeperReferralBonuses(deeper, 1) {
+		bonus += tierBonus
+	}
+
+	return bonus
+}
+
+// The bonuses, in percents of the base mining rate, that the active referrals of each of the deeper tiers bring, multiplied by factor.
+func (r *repository) deeperReferralBonuses(deeper []int32, factor float64) []float64 {
+	if len(deeper) == 0 {
+		return nil
+	}
+	bonuses := make([]float64, 0, len(deeper))
+	for ix, activeReferrals := range deeper {
+		if ix >= len(r.cfg.ReferralBonusMiningRates.Deeper) {
+			break
+		}
+		bonuses = append(bonuses, float64(max(0, activeReferrals))*float64(r.cfg.ReferralBonusMiningRates.Deeper[ix])*factor)
+	}
+
+	return bonuses
+}
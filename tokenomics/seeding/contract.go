@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package seeding
+
+import (
+	"math/rand"
+	stdlibtime "time"
+
+	"github.com/ice-blockchain/freezer/model"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+// Private API.
+
+const (
+	applicationYamlKey       = "tokenomics/seeding"
+	parentApplicationYamlKey = "tokenomics"
+	requestDeadline          = 30 * stdlibtime.Second
+
+	// The users sign up evenly during this period, the oldest ones first, so that the referrals always sign up after their referrers.
+	signupPeriod = 365 * 24 * stdlibtime.Hour
+	// The users that stopped mining did it at most this long ago.
+	maxInactivityPeriod = 30 * 24 * stdlibtime.Hour
+
+	activeMinersRatio   = 0.5
+	inactiveMinersRatio = 0.3
+	preStakingRatio     = 0.2
+	miningBoostRatio    = 0.1
+	kycBlockedRatio     = 0.05
+	hiddenRankingRatio  = 0.05
+	maxSlashedRatio     = 0.05
+
+	maxMiningBoostLevels = 5
+	maxUTCOffset         = 12 * 60
+)
+
+//nolint:gochecknoglobals // They're constants.
+var (
+	countries             = []string{"US", "GB", "DE", "FR", "IN", "NG", "VN", "BR", "PH", "ID", "RO", "TR"}
+	preStakingAllocations = []float64{25, 50, 75, 100}
+)
+
+type (
+	// seededUser is the `users:` hash of a user, the way it's written by all the services, together.
+	seededUser struct {
+		model.User
+		model.CreatedAtField
+		model.VerifiedT1ReferralsField
+		model.WelcomeBonusV2AppliedField
+		model.MiningBoostLevelIndexField
+		model.MiningBoostAmountBurntField
+	}
+	generator struct {
+		rnd *rand.Rand
+		now *time.Time
+		cfg *config
+	}
+	config struct {
+		// How many users to generate.
+		Users uint64 `yaml:"users"`
+		// How many users to write at once.
+		BatchSize uint64 `yaml:"batchSize"`
+		// How many days of daily history to backfill in ClickHouse for each user.
+		HistoryDays uint64 `yaml:"historyDays"`
+		// The same seed generates the same users.
+		Seed int64 `yaml:"seed"`
+		// The probability of a user to have a T0 referral.
+		ReferralProbability float64 `yaml:"referralProbability"`
+		// Loaded from the `tokenomics` config.
+		MiningSessionDuration struct {
+			Max stdlibtime.Duration `yaml:"max"`
+		} `yaml:"miningSessionDuration"`
+		ReferralBonusMiningRates struct {
+			T0 uint16 `yaml:"t0"`
+			T1 uint32 `yaml:"t1"`
+			T2 uint32 `yaml:"t2"`
+		} `yaml:"referralBonusMiningRates"`
+		Adoption struct {
+			StartingBaseMiningRate float64 `yaml:"startingBaseMiningRate"`
+		} `yaml:"adoption"`
+	}
+)
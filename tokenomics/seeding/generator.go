@@ -0,0 +1,205 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package seeding
+
+import (
+	"fmt"
+	"math/rand"
+	stdlibtime "time"
+
+	"github.com/ice-blockchain/eskimo/users"
+	"github.com/ice-blockchain/freezer/model"
+	"github.com/ice-blockchain/freezer/tokenomics"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+func newGenerator(cfg *config, now *time.Time) *generator {
+	return &generator{
+		rnd: rand.New(rand.NewSource(cfg.Seed)), //nolint:gosec // It has to be deterministic.
+		now: now,
+		cfg: cfg,
+	}
+}
+
+// generate generates cfg.Users users, with the internal ids starting from firstID.
+// The referrers are always generated before their referrals, so the referral tree and the referral balances are consistent.
+func (g *generator) generate(firstID int64) []*seededUser {
+	usrs := make([]*seededUser, 0, g.cfg.Users)
+	for ix := uint64(0); ix < g.cfg.Users; ix++ {
+		usr := g.user(firstID+int64(ix), ix)
+		if len(usrs) != 0 && g.rnd.Float64() < g.cfg.ReferralProbability {
+			g.refer(usr, usrs, firstID)
+		}
+		usrs = append(usrs, usr)
+	}
+	for _, usr := range usrs {
+		g.distributeReferralBalances(usr, usrs, firstID)
+	}
+	for _, usr := range usrs {
+		g.calculateTotalBalances(usr)
+	}
+
+	return usrs
+}
+
+func (g *generator) user(id int64, ix uint64) *seededUser {
+	usr := new(seededUser)
+	usr.ID = id
+	usr.UserID = fmt.Sprintf("seeded-%016x", g.rnd.Uint64())
+	usr.Username = fmt.Sprintf("seeded.user%v", id)
+	usr.ProfilePictureName = "default-profile-picture-1.png"
+	usr.Country = countries[g.rnd.Intn(len(countries))]
+	usr.UTCOffset = int64(g.rnd.Intn(2*maxUTCOffset/60+1)*60 - maxUTCOffset) //nolint:gomnd,mnd // Whole hours.
+	usr.HideRanking = g.rnd.Float64() < hiddenRankingRatio
+	welcomeBonusApplied := model.FlexibleBool(true)
+	usr.WelcomeBonusV2Applied = &welcomeBonusApplied
+	signupAgo := stdlibtime.Duration(float64(signupPeriod) * float64(g.cfg.Users-ix) / float64(g.cfg.Users))
+	usr.CreatedAt = time.New(g.now.Add(-signupAgo))
+	g.mine(usr)
+	g.preStake(usr)
+	g.boost(usr)
+	g.kyc(usr)
+
+	return usr
+}
+
+// refer picks the T0 of the user among the users generated before it.
+// Half of the time the referrer of the picked user is used instead, so the ones with more referrals get even more, like in reality.
+func (g *generator) refer(usr *seededUser, before []*seededUser, firstID int64) {
+	t0 := before[g.rnd.Intn(len(before))]
+	if t0.IDT0 != 0 && g.rnd.Intn(2) == 0 { //nolint:gomnd,mnd // Half of the time.
+		t0 = before[t0.IDT0-firstID]
+	}
+	usr.IDT0 = t0.ID
+	usr.IDTMinus1 = t0.IDT0
+}
+
+func (g *generator) mine(usr *seededUser) {
+	duration := g.cfg.MiningSessionDuration.Max
+	var startedAt stdlibtime.Time
+	switch ratio := g.rnd.Float64(); {
+	case ratio < activeMinersRatio:
+		startedAt = g.now.Add(-stdlibtime.Duration(g.rnd.Int63n(int64(duration))))
+	case ratio < activeMinersRatio+inactiveMinersRatio:
+		startedAt = g.now.Add(-duration - stdlibtime.Duration(g.rnd.Int63n(int64(maxInactivityPeriod))))
+	default:
+		return
+	}
+	if startedAt.Before(*usr.CreatedAt.Time) {
+		startedAt = *usr.CreatedAt.Time
+	}
+	endedAt := startedAt.Add(duration)
+	usr.MiningSessionSoloLastStartedAt = time.New(startedAt)
+	usr.MiningSessionSoloStartedAt = time.New(startedAt)
+	usr.MiningSessionSoloEndedAt = time.New(endedAt)
+	if previouslyEndedAt := startedAt.Add(-stdlibtime.Duration(g.rnd.Int63n(int64(duration)))); previouslyEndedAt.After(*usr.CreatedAt.Time) {
+		usr.MiningSessionSoloPreviouslyEndedAt = time.New(previouslyEndedAt)
+	}
+	usr.BalanceLastUpdatedAt = g.now
+	if endedAt.Before(*g.now.Time) {
+		usr.BalanceLastUpdatedAt = time.New(endedAt)
+	}
+	minedFor := usr.BalanceLastUpdatedAt.Sub(*usr.CreatedAt.Time) * stdlibtime.Duration(g.rnd.Intn(100)+1) / 100 //nolint:gomnd,mnd // Percentage.
+	usr.BalanceSolo = g.cfg.Adoption.StartingBaseMiningRate * minedFor.Hours()
+}
+
+func (g *generator) preStake(usr *seededUser) {
+	if g.rnd.Float64() >= preStakingRatio {
+		return
+	}
+	usr.PreStakingAllocation = preStakingAllocations[g.rnd.Intn(len(preStakingAllocations))]
+	usr.PreStakingBonus = tokenomics.PreStakingBonusesPerYear[uint8(g.rnd.Intn(5)+1)] //nolint:gomnd,mnd // 1 to 5 years.
+}
+
+func (g *generator) boost(usr *seededUser) {
+	if g.rnd.Float64() >= miningBoostRatio {
+		return
+	}
+	levelIndex := model.FlexibleUint64(g.rnd.Intn(maxMiningBoostLevels))
+	amountBurnt := model.FlexibleFloat64(float64(levelIndex+1) * 1000 * (1 + g.rnd.Float64())) //nolint:gomnd,mnd // .
+	usr.MiningBoostLevelIndex = &levelIndex
+	usr.MiningBoostAmountBurnt = &amountBurnt
+}
+
+func (g *generator) kyc(usr *seededUser) {
+	usr.KYCStepPassed = users.KYCStep(g.rnd.Intn(int(users.Social3KYCStep) + 1))
+	attempted := usr.KYCStepPassed
+	if attempted < users.Social3KYCStep && g.rnd.Float64() < kycBlockedRatio {
+		attempted++
+		usr.KYCStepBlocked = attempted
+	}
+	if attempted == users.NoneKYCStep {
+		return
+	}
+	createdAt, lastUpdatedAt := make(model.TimeSlice, 0, attempted), make(model.TimeSlice, 0, attempted)
+	step := g.now.Sub(*usr.CreatedAt.Time) / stdlibtime.Duration(attempted+1)
+	for ix := users.KYCStep(1); ix <= attempted; ix++ {
+		attemptedAt := usr.CreatedAt.Add(step * stdlibtime.Duration(ix))
+		createdAt = append(createdAt, time.New(attemptedAt))
+		lastUpdatedAt = append(lastUpdatedAt, time.New(attemptedAt.Add(step/2))) //nolint:gomnd,mnd // Halfway to the next one.
+	}
+	usr.KYCStepsCreatedAt, usr.KYCStepsLastUpdatedAt = &createdAt, &lastUpdatedAt
+	usr.KYCQuizCompleted = usr.KYCStepPassed >= users.QuizKYCStep
+}
+
+// distributeReferralBalances credits the T0 & T-1 referrers of the user with their share of the user's mining, the way the miner does.
+func (g *generator) distributeReferralBalances(usr *seededUser, usrs []*seededUser, firstID int64) {
+	if usr.IDT0 == 0 {
+		return
+	}
+	t0 := usrs[usr.IDT0-firstID]
+	active := !usr.MiningSessionSoloEndedAt.IsNil() && usr.MiningSessionSoloEndedAt.After(*g.now.Time)
+	usr.BalanceT0 = t0.BalanceSolo * float64(g.cfg.ReferralBonusMiningRates.T0) / 100     //nolint:gomnd,mnd // Percentage.
+	usr.BalanceForT0 = usr.BalanceSolo * float64(g.cfg.ReferralBonusMiningRates.T1) / 100 //nolint:gomnd,mnd // Percentage.
+	t0.BalanceT1 += usr.BalanceForT0
+	if usr.IsVerified() {
+		t0.VerifiedT1Referrals++
+	}
+	if active {
+		t0.ActiveT1Referrals++
+	}
+	if usr.IDTMinus1 == 0 {
+		return
+	}
+	tMinus1 := usrs[usr.IDTMinus1-firstID]
+	usr.BalanceForTMinus1 = usr.BalanceSolo * float64(g.cfg.ReferralBonusMiningRates.T2) / 100 //nolint:gomnd,mnd // Percentage.
+	tMinus1.BalanceT2 += usr.BalanceForTMinus1
+	if active {
+		tMinus1.ActiveT2Referrals++
+	}
+}
+
+func (g *generator) calculateTotalBalances(usr *seededUser) {
+	total := usr.BalanceSolo + usr.BalanceT0 + usr.BalanceT1 + usr.BalanceT2
+	usr.BalanceTotalStandard = total * (100 - usr.PreStakingAllocation) / 100                               //nolint:gomnd,mnd // Percentage.
+	usr.BalanceTotalPreStaking = total * usr.PreStakingAllocation / 100 * (100 + usr.PreStakingBonus) / 100 //nolint:gomnd,mnd // Percentage.
+	usr.BalanceTotalMinted = total
+	if !usr.MiningSessionSoloEndedAt.IsNil() && usr.MiningSessionSoloEndedAt.Before(*g.now.Time) {
+		usr.BalanceTotalSlashed = total * maxSlashedRatio * g.rnd.Float64()
+	}
+}
+
+// history returns the state of the users at the start of the day daysAgo days ago, 1 to cfg.HistoryDays,
+// with the balances growing linearly towards the current ones. The users that signed up later aren't part of it.
+func (g *generator) history(usrs []*seededUser, daysAgo uint64) (createdAt stdlibtime.Time, history []*model.User) {
+	createdAt = g.now.Truncate(24 * stdlibtime.Hour).Add(-stdlibtime.Duration(daysAgo) * 24 * stdlibtime.Hour) //nolint:gomnd,mnd // A day.
+	history = make([]*model.User, 0, len(usrs))
+	for _, usr := range usrs {
+		if usr.CreatedAt.After(createdAt) {
+			continue
+		}
+		progress := 1 - float64(daysAgo)/float64(g.cfg.HistoryDays+1)
+		snapshot := usr.User
+		snapshot.BalanceSolo *= progress
+		snapshot.BalanceT0 *= progress
+		snapshot.BalanceT1 *= progress
+		snapshot.BalanceT2 *= progress
+		snapshot.BalanceTotalStandard *= progress
+		snapshot.BalanceTotalPreStaking *= progress
+		snapshot.BalanceTotalMinted *= progress
+		snapshot.BalanceTotalSlashed *= progress
+		history = append(history, &snapshot)
+	}
+
+	return createdAt, history
+}
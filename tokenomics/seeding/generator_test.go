@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package seeding
+
+import (
+	"testing"
+	stdlibtime "time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ice-blockchain/eskimo/users"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+func testConfig() *config {
+	cfg := &config{Users: 1000, BatchSize: 100, HistoryDays: 3, Seed: 1, ReferralProbability: 0.85}
+	cfg.MiningSessionDuration.Max = 24 * stdlibtime.Hour
+	cfg.ReferralBonusMiningRates.T0 = 25
+	cfg.ReferralBonusMiningRates.T1 = 25
+	cfg.ReferralBonusMiningRates.T2 = 5
+	cfg.Adoption.StartingBaseMiningRate = 16
+
+	return cfg
+}
+
+func TestGenerate(t *testing.T) { //nolint:funlen // .
+	t.Parallel()
+
+	const firstID = 101
+	now := time.New(stdlibtime.Date(2024, 3, 1, 12, 0, 0, 0, stdlibtime.UTC))
+	usrs := newGenerator(testConfig(), now).generate(firstID)
+	require.Len(t, usrs, 1000)
+	require.Equal(t, usrs, newGenerator(testConfig(), now).generate(firstID))
+
+	activeT1Referrals, activeT2Referrals := make(map[int64]int32), make(map[int64]int32)
+	var referred, mining, kycPassed int
+	for ix, usr := range usrs {
+		require.EqualValues(t, firstID+ix, usr.ID)
+		require.NotEmpty(t, usr.UserID)
+		require.False(t, usr.CreatedAt.After(*now.Time))
+		if usr.IDT0 != 0 {
+			referred++
+			t0 := usrs[usr.IDT0-firstID]
+			require.Less(t, usr.IDT0, usr.ID)
+			require.Equal(t, t0.IDT0, usr.IDTMinus1)
+			require.False(t, usr.CreatedAt.Before(*t0.CreatedAt.Time))
+		} else {
+			require.Zero(t, usr.IDTMinus1)
+			require.Zero(t, usr.BalanceT0)
+		}
+		if !usr.MiningSessionSoloEndedAt.IsNil() {
+			mining++
+			require.Equal(t, 24*stdlibtime.Hour, usr.MiningSessionSoloEndedAt.Sub(*usr.MiningSessionSoloStartedAt.Time))
+			require.False(t, usr.MiningSessionSoloStartedAt.Before(*usr.CreatedAt.Time))
+			if usr.MiningSessionSoloEndedAt.After(*now.Time) && usr.IDT0 != 0 {
+				activeT1Referrals[usr.IDT0]++
+				activeT2Referrals[usr.IDTMinus1]++
+			}
+		} else {
+			require.Zero(t, usr.BalanceSolo)
+		}
+		if usr.KYCStepPassed >= users.LivenessDetectionKYCStep {
+			kycPassed++
+		}
+		if usr.KYCStepPassed != users.NoneKYCStep {
+			require.Len(t, *usr.KYCStepsCreatedAt, int(max(usr.KYCStepPassed, usr.KYCStepBlocked)))
+		}
+		total := usr.BalanceSolo + usr.BalanceT0 + usr.BalanceT1 + usr.BalanceT2
+		assert.InDelta(t, total, usr.BalanceTotalMinted, 0.000001)
+		assert.InDelta(t, total*(100-usr.PreStakingAllocation)/100, usr.BalanceTotalStandard, 0.000001)
+	}
+	for _, usr := range usrs {
+		require.Equal(t, activeT1Referrals[usr.ID], usr.ActiveT1Referrals)
+		require.Equal(t, activeT2Referrals[usr.ID], usr.ActiveT2Referrals)
+	}
+	assert.InDelta(t, 850, referred, 50)
+	assert.InDelta(t, 800, mining, 50)
+	assert.Positive(t, kycPassed)
+}
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	now := time.New(stdlibtime.Date(2024, 3, 1, 12, 0, 0, 0, stdlibtime.UTC))
+	g := newGenerator(testConfig(), now)
+	usrs := g.generate(1)
+
+	createdAt, history := g.history(usrs, 1)
+	require.Equal(t, stdlibtime.Date(2024, 2, 29, 0, 0, 0, 0, stdlibtime.UTC), createdAt)
+	require.NotEmpty(t, history)
+	require.Less(t, len(history), len(usrs))
+	for _, snapshot := range history {
+		usr := usrs[snapshot.ID-1]
+		require.False(t, usr.CreatedAt.After(createdAt))
+		assert.InDelta(t, usr.BalanceTotalMinted*3/4, snapshot.BalanceTotalMinted, 0.000001)
+	}
+	_, olderHistory := g.history(usrs, 3)
+	require.LessOrEqual(t, len(olderHistory), len(history))
+}
@@ -5,56 +5,96 @@
 package seeding
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"strings"
 	stdlibtime "time"
 
-	"github.com/ice-blockchain/go-tarantool-client"
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+
+	balancesynchronizer "github.com/ice-blockchain/freezer/balance-synchronizer"
+	dwh "github.com/ice-blockchain/freezer/bookkeeper/storage"
+	"github.com/ice-blockchain/freezer/model"
+	appCfg "github.com/ice-blockchain/wintr/config"
+	"github.com/ice-blockchain/wintr/connectors/storage/v3"
 	"github.com/ice-blockchain/wintr/log"
+	"github.com/ice-blockchain/wintr/time"
 )
 
+// StartSeeding generates synthetic users, with their referral trees, mining sessions, pre-staking, mining boosts, KYC states and balances,
+// appends them to the ones in the `users:` hashes and backfills their daily history in ClickHouse.
 func StartSeeding() {
 	before := stdlibtime.Now()
-	db := dbConnector()
+	var cfg config
+	appCfg.MustLoadFromKey(parentApplicationYamlKey, &cfg)
+	appCfg.MustLoadFromKey(applicationYamlKey, &cfg)
+	db := storage.MustConnect(context.Background(), parentApplicationYamlKey, 1)
+	dwhClient := dwh.MustConnect(context.Background(), parentApplicationYamlKey)
 	defer func() {
-		log.Panic(db.Close()) //nolint:revive // It doesnt really matter.
+		log.Panic(multierror.Append(db.Close(), dwhClient.Close()).ErrorOrNil()) //nolint:revive // It doesnt really matter.
 		log.Info(fmt.Sprintf("seeding finalized in %v", stdlibtime.Since(before).String()))
 	}()
-	log.Info("TODO: implement seeding")
-}
 
-func cleanUpWorkerSpaces(db tarantool.Connector) { //nolint:deadcode,unused // .
-	tables := []string{
-		"balance_recalculation_worker_",
-		"extra_bonus_processing_worker_",
-		"blockchain_balance_synchronization_worker_",
-		"mining_rates_recalculation_worker_",
-		"balance_recalculation_worker_",
-		"pre_stakings_",
-		"mining_sessions_dlq_",
+	reqCtx, reqCancel := context.WithTimeout(context.Background(), requestDeadline)
+	lastID, err := db.IncrBy(reqCtx, "users_serial", int64(cfg.Users)).Result()
+	reqCancel()
+	log.Panic(errors.Wrapf(err, "failed to reserve %v internal ids", cfg.Users)) //nolint:revive // It doesnt really matter.
+
+	generator := newGenerator(&cfg, time.Now())
+	usrs := generator.generate(lastID - int64(cfg.Users) + 1)
+	log.Info(fmt.Sprintf("generated %v users with the internal ids [%v, %v]", len(usrs), lastID-int64(cfg.Users)+1, lastID))
+	for from := 0; from < len(usrs); from += int(cfg.BatchSize) {
+		to := min(from+int(cfg.BatchSize), len(usrs))
+		log.Panic(errors.Wrapf(seedUsers(db, usrs[from:to]), "failed to seed users [%v, %v)", from, to)) //nolint:revive // It doesnt really matter.
 	}
-	for _, table := range tables {
-		for i := 0; i < 1000; i++ {
-			sql := fmt.Sprintf(`delete from %[1]v%[2]v where 1=1`, table, i)
-			_, err := db.PrepareExecute(sql, map[string]any{})
-			log.Panic(err)
+	log.Info(fmt.Sprintf("seeded %v users", len(usrs)))
+
+	historyColumns, historyInsertMetadata := dwh.InsertDDL(int(cfg.BatchSize))
+	for daysAgo := uint64(1); daysAgo <= cfg.HistoryDays; daysAgo++ {
+		createdAt, history := generator.history(usrs, daysAgo)
+		for from := 0; from < len(history); from += int(cfg.BatchSize) {
+			to := min(from+int(cfg.BatchSize), len(history))
+			reqCtx, reqCancel = context.WithTimeout(context.Background(), requestDeadline)
+			err = dwhClient.InsertAt(reqCtx, historyColumns, historyInsertMetadata, createdAt, history[from:to])
+			reqCancel()
+			log.Panic(errors.Wrapf(err, "failed to backfill the history at %v for users [%v, %v)", createdAt, from, to)) //nolint:revive // .
 		}
+		log.Info(fmt.Sprintf("backfilled the history at %v for %v users", createdAt, len(history)))
 	}
 }
 
-func dbConnector() tarantool.Connector {
-	parts := strings.Split(os.Getenv("MASTER_DB_INSTANCE_ADDRESS"), "@")
-	userAndPass := strings.Split(parts[0], ":")
-	opts := tarantool.Opts{
-		Timeout:       20 * stdlibtime.Second, //nolint:gomnd // It doesnt matter here.
-		Reconnect:     stdlibtime.Millisecond,
-		MaxReconnects: 10, //nolint:gomnd // It doesnt matter here.
-		User:          userAndPass[0],
-		Pass:          userAndPass[1],
+func seedUsers(db storage.DB, usrs []*seededUser) error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestDeadline)
+	defer cancel()
+	globalRanks := make([]redis.Z, 0, len(usrs))
+	results, err := db.TxPipelined(ctx, func(pipeliner redis.Pipeliner) error {
+		for _, usr := range usrs {
+			if err := pipeliner.Set(ctx, model.SerializedUsersKey(usr.UserID), usr.ID, 0).Err(); err != nil {
+				return err
+			}
+			if err := pipeliner.HSet(ctx, usr.Key(), storage.SerializeValue(usr)...).Err(); err != nil {
+				return err
+			}
+			if !usr.HideRanking {
+				globalRanks = append(globalRanks, balancesynchronizer.GlobalRank(usr.ID, usr.BalanceTotalStandard+usr.BalanceTotalPreStaking))
+			}
+		}
+		if len(globalRanks) == 0 {
+			return nil
+		}
+
+		return pipeliner.ZAdd(ctx, "top_miners", globalRanks...).Err()
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to seed %v users", len(usrs))
+	}
+	errs := make([]error, 0, len(results))
+	for _, result := range results {
+		if err = result.Err(); err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to run `%#v`", result.FullName()))
+		}
 	}
-	db, err := tarantool.Connect(parts[1], opts)
-	log.Panic(err)
 
-	return db
+	return multierror.Append(nil, errs...).ErrorOrNil()
 }
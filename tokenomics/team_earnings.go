@@ -0,0 +1,196 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package tokenomics
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ice-blockchain/freezer/model"
+	"github.com/ice-blockchain/wintr/connectors/storage/v3"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+type (
+	teamOwner struct {
+		model.CreatedAtField
+		model.MiningSessionSoloEndedAtField
+		model.MiningBoostLevelIndexField
+		model.KYCState
+		model.PreStakingAllocationField
+		model.PreStakingBonusField
+		model.VerifiedT1ReferralsField
+		model.ActiveT1ReferralsField
+		model.LatestDeviceField
+	}
+	teamMember struct {
+		model.MiningSessionSoloEndedAtField
+		model.UserIDField
+		model.UsernameField
+		model.DeserializedUsersKey
+		model.BalanceForT0Field
+		model.ActiveT1ReferralsField
+	}
+	// The T2 referrals of the user that were invited by one of its T1 referrals, aggregated.
+	teamT2 struct {
+		LifetimeContribution float64
+		Referrals            uint64
+	}
+)
+
+// GetTeamEarnings pages the T1 referrals of the user from the team that the miner keeps sorted on what's requested,
+// see model.SerializedT1ReferralsKey, model.SerializedMiningT1ReferralsKey and model.SerializedT2ReferralsKey.
+func (r *repository) GetTeamEarnings(ctx context.Context, userID string, orderBy TeamOrderBy, limit, offset uint64) ([]*TeamEarnings, error) {
+	id, err := GetOrInitInternalID(ctx, r.db, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to getOrInitInternalID for userID:%v", userID)
+	}
+	owner, err := storage.Get[teamOwner](ctx, r.db, model.SerializedUsersKey(id))
+	if err != nil || len(owner) == 0 {
+		if err == nil {
+			err = errors.Wrapf(ErrRelationNotFound, "missing state for id:%v", id)
+		}
+
+		return nil, errors.Wrapf(err, "failed to get the team owner state for id:%v", id)
+	}
+	team, err := r.getTeam(ctx, teamKey(id, orderBy), limit, offset)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the T1 referrals of id:%v", id)
+	}
+	if len(team) == 0 {
+		return []*TeamEarnings{}, nil
+	}
+	t2, err := r.getTeamT2(ctx, id, team)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the T2 referrals of id:%v", id)
+	}
+
+	return r.calculateTeamEarnings(r.clock.Now(), owner[0], team, t2), nil
+}
+
+func teamKey(id int64, orderBy TeamOrderBy) string {
+	switch orderBy { //nolint:exhaustive // The default one.
+	case T2LifetimeContributionTeamOrderBy:
+		return model.SerializedT2ReferralsKey(id)
+	case MiningTeamOrderBy:
+		return model.SerializedMiningT1ReferralsKey(id)
+	default:
+		return model.SerializedT1ReferralsKey(id)
+	}
+}
+
+// Gets the page of the team, from the highest score to the lowest one.
+func (r *repository) getTeam(ctx context.Context, key string, limit, offset uint64) ([]*teamMember, error) {
+	keys, err := r.db.ZRevRange(ctx, key, int64(offset), int64(offset+limit)-1).Result()
+	if err != nil || len(keys) == 0 {
+		return nil, errors.Wrapf(err, "failed to ZRevRange `%v`", key)
+	}
+	members, err := storage.Get[teamMember](ctx, r.db, keys...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the state of the team members:%v", keys)
+	}
+	byKey := make(map[string]*teamMember, len(members))
+	for _, member := range members {
+		if member.UserID != "" { // Otherwise it was deleted.
+			byKey[member.Key()] = member
+		}
+	}
+	team := make([]*teamMember, 0, len(byKey))
+	for _, memberKey := range keys {
+		if member := byKey[memberKey]; member != nil {
+			team = append(team, member)
+		}
+	}
+
+	return team, nil
+}
+
+// Gets, for each member of the team of the user, what its own T1 referrals contributed to the user and how many they are.
+func (r *repository) getTeamT2(ctx context.Context, id int64, team []*teamMember) (map[int64]*teamT2, error) {
+	t2Key := model.SerializedT2ReferralsKey(id)
+	responses, err := r.db.Pipelined(ctx, func(pipeliner redis.Pipeliner) error {
+		for _, member := range team {
+			if err := pipeliner.ZScore(ctx, t2Key, member.Key()).Err(); err != nil {
+				return err
+			}
+			if err := pipeliner.ZCard(ctx, model.SerializedT1ReferralsKey(member.ID)).Err(); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, errors.Wrapf(err, "failed to get the T2 referrals of the team of id:%v", id)
+	}
+	t2 := make(map[int64]*teamT2, len(team))
+	for ix, member := range team {
+		lifetimeContribution, sErr := responses[2*ix].(*redis.FloatCmd).Result()
+		if sErr != nil && !errors.Is(sErr, redis.Nil) {
+			return nil, errors.Wrapf(sErr, "failed to `%v`", responses[2*ix].FullName())
+		}
+		referrals, cErr := responses[2*ix+1].(*redis.IntCmd).Result()
+		if cErr != nil {
+			return nil, errors.Wrapf(cErr, "failed to `%v`", responses[2*ix+1].FullName())
+		}
+		t2[member.ID] = &teamT2{LifetimeContribution: max(0, lifetimeContribution), Referrals: uint64(referrals)}
+	}
+
+	return t2, nil
+}
+
+func (m *teamMember) isMining(now *time.Time) bool {
+	return !m.MiningSessionSoloEndedAt.IsNil() && m.MiningSessionSoloEndedAt.After(*now.Time)
+}
+
+func (r *repository) calculateTeamEarnings(now *time.Time, owner *teamOwner, team []*teamMember, t2 map[int64]*teamT2) []*TeamEarnings {
+	var t1Share float64
+	ownerMining := !owner.MiningSessionSoloEndedAt.IsNil() && owner.MiningSessionSoloEndedAt.After(*now.Time)
+	if minedT1Referrals := r.minedActiveT1Referrals(owner.MiningBoostLevelIndex, owner.IsVerified(), owner.VerifiedT1Referrals, owner.ActiveT1Referrals); owner.ActiveT1Referrals > 0 { //nolint:lll // .
+		t1Share = min(1, float64(minedT1Referrals)/float64(owner.ActiveT1Referrals))
+	}
+	baseMiningRate := r.cfg.BaseMiningRate(now, owner.CreatedAt)
+	minted := func(t1, t2 uint32) float64 {
+		if !ownerMining {
+			return 0
+		}
+
+		return r.calculateMintedStandardCoins(0, 0, owner.PreStakingAllocation, t1, t2, nil, baseMiningRate, r.cfg.GlobalAggregationInterval.Child, true) +
+			r.calculateMintedPreStakingCoins(0, 0, owner.PreStakingAllocation, owner.PreStakingBonus, t1, t2, nil, baseMiningRate, r.cfg.GlobalAggregationInterval.Child, true) //nolint:lll // .
+	}
+	earnings := make([]*TeamEarnings, 0, len(team))
+	for _, member := range team {
+		mining := member.isMining(now)
+		var currentContribution float64
+		if mining {
+			currentContribution = minted(1, 0) * t1Share
+		}
+		activeT2Referrals := max(0, member.ActiveT1Referrals)
+		var t2CurrentContribution float64
+		if r.isAdvancedTeamEnabled(owner.LatestDevice) {
+			t2CurrentContribution = minted(0, uint32(activeT2Referrals))
+		}
+		memberT2 := t2[member.ID]
+		if memberT2 == nil {
+			memberT2 = new(teamT2)
+		}
+		earnings = append(earnings, &TeamEarnings{
+			T2: &TeamEarningsT2{
+				CurrentContribution:  fmt.Sprintf(floatToStringFormatter, t2CurrentContribution),
+				LifetimeContribution: fmt.Sprintf(floatToStringFormatter, memberT2.LifetimeContribution),
+				Referrals:            memberT2.Referrals,
+				ActiveReferrals:      activeT2Referrals,
+			},
+			UserID:               member.UserID,
+			Username:             member.Username,
+			CurrentContribution:  fmt.Sprintf(floatToStringFormatter, currentContribution),
+			LifetimeContribution: fmt.Sprintf(floatToStringFormatter, member.BalanceForT0),
+			Mining:               mining,
+		})
+	}
+
+	return earnings
+}
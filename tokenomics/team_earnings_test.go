@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package tokenomics
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	stdlibtime "time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ice-blockchain/freezer/model"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+func TestCalculateTeamEarnings(t *testing.T) {
+	t.Parallel()
+	repo := &repository{cfg: new(Config)}
+	repo.cfg.GlobalAggregationInterval.Child = stdlibtime.Hour
+	repo.cfg.Adoption.StartingBaseMiningRate = 16
+	repo.cfg.ReferralBonusMiningRates.T1 = 25
+	repo.cfg.ReferralBonusMiningRates.T2 = 5
+	repo.cfg.MiningBoost.levels = new(atomic.Pointer[[]*MiningBoostLevel])
+	repo.cfg.MiningBoost.levels.Store(&[]*MiningBoostLevel{{MaxT1Referrals: 1}})
+	now := time.Now()
+	miningBoostLevelIndex := model.FlexibleUint64(0)
+	owner := new(teamOwner)
+	owner.MiningSessionSoloEndedAt = time.New(now.Add(stdlibtime.Hour))
+	owner.MiningBoostLevelIndex = &miningBoostLevelIndex
+	owner.ActiveT1Referrals = 2
+	member := func(id int64, mining bool, activeT1Referrals int32) *teamMember {
+		m := new(teamMember)
+		m.ID, m.UserID, m.Username = id, fmt.Sprint("u", id), fmt.Sprint("n", id)
+		m.MiningSessionSoloEndedAt = time.New(now.Add(-stdlibtime.Hour))
+		if mining {
+			m.MiningSessionSoloEndedAt = time.New(now.Add(stdlibtime.Hour))
+		}
+		m.BalanceForT0, m.ActiveT1Referrals = float64(id), activeT1Referrals
+
+		return m
+	}
+	team := []*teamMember{member(2, false, 3), member(1, true, 0)}
+	earnings := repo.calculateTeamEarnings(now, owner, team, map[int64]*teamT2{2: {LifetimeContribution: 1.234, Referrals: 5}})
+	assert.EqualValues(t, []*TeamEarnings{
+		{
+			T2:                   &TeamEarningsT2{CurrentContribution: "0.00", LifetimeContribution: "1.23", Referrals: 5, ActiveReferrals: 3}, // The advanced team is disabled.
+			UserID:               "u2",
+			Username:             "n2",
+			CurrentContribution:  "0.00",
+			LifetimeContribution: "2.00",
+		},
+		{
+			T2:                   &TeamEarningsT2{CurrentContribution: "0.00", LifetimeContribution: "0.00"},
+			UserID:               "u1",
+			Username:             "n1",
+			CurrentContribution:  "2.00",
+			LifetimeContribution: "1.00",
+			Mining:               true,
+		},
+	}, earnings)
+
+	owner.MiningSessionSoloEndedAt = time.New(now.Add(-stdlibtime.Hour))
+	earnings = repo.calculateTeamEarnings(now, owner, team, nil)
+	require.Len(t, earnings, 2)
+	assert.Equal(t, "0.00", earnings[0].T2.CurrentContribution)
+	assert.Equal(t, "0.00", earnings[1].CurrentContribution)
+	assert.True(t, earnings[1].Mining)
+}
+
+func TestTeamKey(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "t1_referrals:42", teamKey(42, LifetimeContributionTeamOrderBy))
+	assert.Equal(t, "t1_referrals_mining:42", teamKey(42, MiningTeamOrderBy))
+	assert.Equal(t, "t2_referrals:42", teamKey(42, T2LifetimeContributionTeamOrderBy))
+}
@@ -18,6 +18,7 @@ import (
 
 	dwh "github.com/ice-blockchain/freezer/bookkeeper/storage"
 	extrabonusnotifier "github.com/ice-blockchain/freezer/extra-bonus-notifier"
+	"github.com/ice-blockchain/freezer/model"
 	appCfg "github.com/ice-blockchain/wintr/config"
 	messagebroker "github.com/ice-blockchain/wintr/connectors/message_broker"
 	storagev2 "github.com/ice-blockchain/wintr/connectors/storage/v2"
@@ -35,6 +36,7 @@ func New(ctx context.Context, _ context.CancelFunc) Repository {
 	dwhClient := dwh.MustConnect(ctx, applicationYamlKey)
 	repo := &repository{
 		cfg:                           &cfg,
+		clock:                         model.ClockFromContext(ctx),
 		extraBonusStartDate:           extrabonusnotifier.MustGetExtraBonusStartDate(ctx, db),
 		extraBonusIndicesDistribution: extrabonusnotifier.MustGetExtraBonusIndicesDistribution(ctx, db),
 		shutdown: func() error {
@@ -48,7 +50,7 @@ func New(ctx context.Context, _ context.CancelFunc) Repository {
 	go repo.startDisableAdvancedTeamCfgSyncer(ctx)
 	go repo.startBlockchainCoinStatsJSONSyncer(ctx)
 
-	now := time.Now()
+	now := repo.clock.Now()
 	repo.mustInitTotalCoinsCache(ctx, now)
 
 	go repo.keepTotalCoinsCacheUpdated(ctx)
@@ -63,6 +65,7 @@ func StartProcessor(ctx context.Context, cancel context.CancelFunc) Processor {
 	dwhClient := dwh.MustConnect(ctx, applicationYamlKey)
 	prc := &processor{repository: &repository{
 		cfg:           &cfg,
+		clock:         model.ClockFromContext(ctx),
 		db:            storage.MustConnect(context.Background(), applicationYamlKey),
 		globalDB:      storagev2.MustConnect(context.Background(), globalDDL, applicationYamlKey),
 		mb:            messagebroker.MustConnect(context.Background(), applicationYamlKey),
@@ -85,7 +88,7 @@ func StartProcessor(ctx context.Context, cancel context.CancelFunc) Processor {
 	go prc.startDisableAdvancedTeamCfgSyncer(ctx)
 	go prc.startKYCConfigJSONSyncer(ctx)
 	go prc.startBlockchainCoinStatsJSONSyncer(ctx)
-	now := time.Now()
+	now := prc.clock.Now()
 	prc.mustInitTotalCoinsCache(ctx, now)
 
 	go prc.keepTotalCoinsCacheUpdated(ctx)
@@ -149,7 +152,7 @@ func (p *processor) CheckHealth(ctx context.Context) error {
 	type ts struct {
 		TS *time.Time `json:"ts"`
 	}
-	now := ts{TS: time.Now()}
+	now := ts{TS: p.clock.Now()}
 	bytes, err := json.MarshalContext(ctx, now)
 	if err != nil {
 		return errors.Wrapf(err, "[health-check] failed to marshal %#v", now)
@@ -72,7 +72,7 @@ func (s *usersTableSource) deleteUser(ctx context.Context, usr *users.User) erro
 	}{
 		MiningSessionSoloStartedAtField:         model.MiningSessionSoloStartedAtField{MiningSessionSoloStartedAt: new(time.Time)},
 		MiningSessionSoloEndedAtField:           model.MiningSessionSoloEndedAtField{MiningSessionSoloEndedAt: new(time.Time)},
-		MiningSessionSoloPreviouslyEndedAtField: model.MiningSessionSoloPreviouslyEndedAtField{MiningSessionSoloPreviouslyEndedAt: time.Now()},
+		MiningSessionSoloPreviouslyEndedAtField: model.MiningSessionSoloPreviouslyEndedAtField{MiningSessionSoloPreviouslyEndedAt: s.clock.Now()},
 		DeserializedUsersKey:                    model.DeserializedUsersKey{ID: id},
 	}); err != nil {
 		return errors.Wrapf(err, "failed to manually stop mining due to user deletion message for user:%#v", usr)
@@ -85,6 +85,7 @@ func (s *usersTableSource) deleteUser(ctx context.Context, usr *users.User) erro
 		model.BalanceForT0Field
 		model.BalanceForTMinus1Field
 		model.ActiveT1ReferralsField
+		model.ActiveT2ReferralsField
 	}](ctx, s.db, model.SerializedUsersKey(id))
 	if err != nil || len(dbUserAfterMiningStopped) == 0 {
 		if err == nil && len(dbUserAfterMiningStopped) == 0 {
@@ -93,6 +94,12 @@ func (s *usersTableSource) deleteUser(ctx context.Context, usr *users.User) erro
 
 		return errors.Wrapf(err, "[2]failed to get current state for user:%#v", usr)
 	}
+	deeperIncrements, err := s.deeperActiveReferralsIncrementsForDeletedUser(ctx, id, dbUserBeforeMiningStopped[0].MiningSessionSoloEndedAt,
+		dbUserAfterMiningStopped[0].IDT0, dbUserAfterMiningStopped[0].IDTMinus1,
+		dbUserAfterMiningStopped[0].ActiveT1Referrals, dbUserAfterMiningStopped[0].ActiveT2Referrals)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the deeper active referrals increments for user:%#v", usr)
+	}
 	results, err := s.db.TxPipelined(ctx, func(pipeliner redis.Pipeliner) error {
 		if dbUserAfterMiningStopped[0].IDT0 < 0 {
 			dbUserAfterMiningStopped[0].IDT0 *= -1
@@ -101,8 +108,16 @@ func (s *usersTableSource) deleteUser(ctx context.Context, usr *users.User) erro
 			dbUserAfterMiningStopped[0].IDTMinus1 *= -1
 		}
 		if idT0Key := model.SerializedUsersKey(dbUserAfterMiningStopped[0].IDT0); idT0Key != "" {
+			if err = removeFromTeams(ctx, pipeliner, id, dbUserAfterMiningStopped[0].IDT0); err != nil {
+				return err
+			}
+			if amount := dbUserAfterMiningStopped[0].BalanceForTMinus1; amount > 0.0 && dbUserAfterMiningStopped[0].IDTMinus1 != 0 {
+				if err = pipeliner.ZIncrBy(ctx, model.SerializedT2ReferralsKey(dbUserAfterMiningStopped[0].IDTMinus1), -amount, idT0Key).Err(); err != nil {
+					return err
+				}
+			}
 			if !dbUserBeforeMiningStopped[0].MiningSessionSoloEndedAt.IsNil() &&
-				dbUserBeforeMiningStopped[0].MiningSessionSoloEndedAt.After(*time.Now().Time) {
+				dbUserBeforeMiningStopped[0].MiningSessionSoloEndedAt.After(*s.clock.Now().Time) {
 				if err = pipeliner.HIncrBy(ctx, idT0Key, "active_t1_referrals", -1).Err(); err != nil {
 					return err
 				}
@@ -120,12 +135,15 @@ func (s *usersTableSource) deleteUser(ctx context.Context, usr *users.User) erro
 				}
 			}
 			if !dbUserBeforeMiningStopped[0].MiningSessionSoloEndedAt.IsNil() &&
-				dbUserBeforeMiningStopped[0].MiningSessionSoloEndedAt.After(*time.Now().Time) {
+				dbUserBeforeMiningStopped[0].MiningSessionSoloEndedAt.After(*s.clock.Now().Time) {
 				if err = pipeliner.HIncrBy(ctx, idTMinus1Key, "active_t2_referrals", -1).Err(); err != nil {
 					return err
 				}
 			}
 		}
+		if err = IncrementActiveReferrals(ctx, pipeliner, deeperIncrements); err != nil {
+			return err
+		}
 		toRemove, _ := s.usernameKeywords(usr.Username, "")
 		for _, usernameKeyword := range toRemove {
 			if err = pipeliner.SRem(ctx, "lookup:"+usernameKeyword, model.SerializedUsersKey(id)).Err(); err != nil {
@@ -135,7 +153,8 @@ func (s *usersTableSource) deleteUser(ctx context.Context, usr *users.User) erro
 		if err = pipeliner.ZRem(ctx, "top_miners", model.SerializedUsersKey(id)).Err(); err != nil {
 			return err
 		}
-		if err = pipeliner.Del(ctx, model.SerializedUsersKey(id), model.SerializedUsersKey(usr.ID)).Err(); err != nil {
+		if err = pipeliner.Del(ctx, model.SerializedUsersKey(id), model.SerializedUsersKey(usr.ID),
+			model.SerializedT1ReferralsKey(id), model.SerializedMiningT1ReferralsKey(id), model.SerializedT2ReferralsKey(id)).Err(); err != nil {
 			return err
 		}
 
@@ -319,6 +338,20 @@ func (r *repository) updateReferredBy(ctx context.Context, id int64, oldIDT0, ol
 			if innerErr := pipeliner.HIncrBy(ctx, model.SerializedUsersKey(localIDT0), "balance_t1_welcome_bonus_pending", WelcomeBonusV2Amount).Err(); innerErr != nil {
 				return innerErr
 			}
+			if innerErr := removeFromTeams(ctx, pipeliner, id, *oldIDT0); innerErr != nil {
+				return innerErr
+			}
+			if *oldIDT0 > 0 && *oldTMinus1 > 0 && balanceForTMinus1 > 0.0 {
+				if innerErr := pipeliner.ZIncrBy(ctx, model.SerializedT2ReferralsKey(*oldTMinus1), -balanceForTMinus1, model.SerializedUsersKey(*oldIDT0)).Err(); innerErr != nil {
+					return innerErr
+				}
+			}
+			member := redis.Z{Member: model.SerializedUsersKey(id)}
+			for _, key := range []string{model.SerializedT1ReferralsKey(localIDT0), model.SerializedMiningT1ReferralsKey(localIDT0), model.SerializedT2ReferralsKey(localIDT0)} {
+				if innerErr := pipeliner.ZAdd(ctx, key, member).Err(); innerErr != nil {
+					return innerErr
+				}
+			}
 
 			return pipeliner.HSet(ctx, newPartialState.Key(), storage.SerializeValue(newPartialState)...).Err()
 		})
@@ -508,3 +541,17 @@ func GetInternalID(ctx context.Context, db storage.DB, userID string) (int64, er
 
 	return id, nil
 }
+
+// Removes the user from the teams of its T0, its balance_for_t0 & balance_for_tminus1 are reset anyway when it gets a new one.
+func removeFromTeams(ctx context.Context, pipeliner redis.Pipeliner, id, idT0 int64) error {
+	if idT0 == 0 {
+		return nil
+	}
+	for _, key := range []string{model.SerializedT1ReferralsKey(idT0), model.SerializedMiningT1ReferralsKey(idT0), model.SerializedT2ReferralsKey(idT0)} {
+		if err := pipeliner.ZRem(ctx, key, model.SerializedUsersKey(id)).Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}